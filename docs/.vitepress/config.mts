@@ -64,6 +64,7 @@ export default defineConfig({
           items: [
             { text: "User Management", link: "/config/user-management" },
             { text: "OAuth Setup", link: "/config/oauth-setup" },
+            { text: "LDAP Setup", link: "/config/ldap-setup" },
             { text: "RBAC Configuration", link: "/config/rbac-config" },
             { text: "Prometheus Setup", link: "/config/prometheus-setup" },
             { text: "Managed K8s Auth", link: "/config/managed-k8s-auth" },
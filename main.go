@@ -17,6 +17,8 @@ import (
 	"github.com/gin-contrib/gzip"
 	"github.com/gin-gonic/gin"
 	"github.com/pixelvide/kube-sentinel/internal"
+	"github.com/pixelvide/kube-sentinel/pkg/analyzer"
+	"github.com/pixelvide/kube-sentinel/pkg/audit"
 	"github.com/pixelvide/kube-sentinel/pkg/auth"
 	"github.com/pixelvide/kube-sentinel/pkg/cluster"
 	"github.com/pixelvide/kube-sentinel/pkg/common"
@@ -26,6 +28,7 @@ import (
 	"github.com/pixelvide/kube-sentinel/pkg/middleware"
 	"github.com/pixelvide/kube-sentinel/pkg/model"
 	"github.com/pixelvide/kube-sentinel/pkg/rbac"
+	"github.com/pixelvide/kube-sentinel/pkg/scim"
 	"github.com/pixelvide/kube-sentinel/pkg/utils"
 	"github.com/pixelvide/kube-sentinel/pkg/version"
 	"github.com/prometheus/client_golang/prometheus"
@@ -80,6 +83,7 @@ func setupAPIRouter(r *gin.RouterGroup, cm *cluster.ClusterManager, authHandler
 			"status": "ok",
 		})
 	})
+	r.GET("/.well-known/jwks.json", authHandler.JWKS)
 	r.GET("/api/v1/init_check", handlers.InitCheck)
 	r.GET("/api/v1/version", version.GetVersion)
 
@@ -87,7 +91,8 @@ func setupAPIRouter(r *gin.RouterGroup, cm *cluster.ClusterManager, authHandler
 	authGroup := r.Group("/api/auth")
 	{
 		authGroup.GET("/providers", authHandler.GetProviders)
-		authGroup.POST("/login/password", authHandler.PasswordLogin)
+		authGroup.POST("/login/password", middleware.LoginRateLimit(), authHandler.PasswordLogin)
+		authGroup.POST("/login/ldap", middleware.LoginRateLimit(), authHandler.LDAPLogin)
 		authGroup.GET("/login", authHandler.Login)
 		authGroup.GET("/callback", authHandler.Callback)
 		authGroup.POST("/logout", authHandler.Logout)
@@ -95,6 +100,9 @@ func setupAPIRouter(r *gin.RouterGroup, cm *cluster.ClusterManager, authHandler
 		authGroup.GET("/user", authHandler.RequireAuth(), authHandler.GetUser)
 	}
 
+	// SCIM provisioning, authenticated with SCIM_TOKEN
+	scim.RegisterRoutes(r)
+
 	userGroup := r.Group("/api/users")
 	{
 		userGroup.POST("/sidebar_preference", authHandler.RequireAuth(), handlers.UpdateSidebarPreference)
@@ -109,6 +117,7 @@ func setupAPIRouter(r *gin.RouterGroup, cm *cluster.ClusterManager, authHandler
 	adminAPI.Use(authHandler.RequireAuth(), authHandler.RequireAdmin())
 	{
 		adminAPI.GET("/audit-logs", handlers.ListAuditLogs)
+		adminAPI.GET("/audit-logs/export", handlers.ExportAuditLogs)
 		oauthProviderAPI := adminAPI.Group("/oauth-providers")
 		{
 			oauthProviderAPI.GET("/", authHandler.ListOAuthProviders)
@@ -118,6 +127,21 @@ func setupAPIRouter(r *gin.RouterGroup, cm *cluster.ClusterManager, authHandler
 			oauthProviderAPI.DELETE("/:id", authHandler.DeleteOAuthProvider)
 		}
 
+		ldapProviderAPI := adminAPI.Group("/ldap-providers")
+		{
+			ldapProviderAPI.GET("/", authHandler.ListLDAPProviders)
+			ldapProviderAPI.POST("/", authHandler.CreateLDAPProvider)
+			ldapProviderAPI.GET("/:id", authHandler.GetLDAPProvider)
+			ldapProviderAPI.PUT("/:id", authHandler.UpdateLDAPProvider)
+			ldapProviderAPI.DELETE("/:id", authHandler.DeleteLDAPProvider)
+		}
+
+		signingKeyAPI := adminAPI.Group("/signing-keys")
+		{
+			signingKeyAPI.GET("/", authHandler.ListSigningKeys)
+			signingKeyAPI.POST("/rotate", authHandler.RotateSigningKey)
+		}
+
 		clusterAPI := adminAPI.Group("/clusters")
 		{
 			clusterAPI.GET("/", cm.GetClusterList)
@@ -144,6 +168,12 @@ func setupAPIRouter(r *gin.RouterGroup, cm *cluster.ClusterManager, authHandler
 			rbacAPI.DELETE("/:id/assign", rbac.UnassignRole)
 		}
 
+		accessAPI := adminAPI.Group("/access")
+		{
+			accessAPI.POST("/review", rbac.HandleAccessReview)
+			accessAPI.POST("/who-can", rbac.HandleWhoCan)
+		}
+
 		userAPI := adminAPI.Group("/users")
 		{
 			userAPI.GET("/", handlers.ListUsers)
@@ -153,6 +183,33 @@ func setupAPIRouter(r *gin.RouterGroup, cm *cluster.ClusterManager, authHandler
 			userAPI.POST(":id/reset_password", handlers.ResetPassword)
 			userAPI.POST(":id/enable", handlers.SetUserEnabled)
 			userAPI.PUT(":id/ai-chat", handlers.ToggleUserAIChat)
+			userAPI.GET(":id/sessions", handlers.ListUserSessions)
+			userAPI.DELETE(":id/sessions", handlers.RevokeUserSessions)
+			userAPI.DELETE(":id/two-factor", handlers.ResetUserTwoFactor)
+		}
+
+		securityAPI := adminAPI.Group("/security")
+		{
+			securityAPI.GET("/", handlers.GetSecuritySettings)
+			securityAPI.PUT("/", handlers.UpdateSecuritySettings)
+		}
+
+		analyzerRuleAPI := adminAPI.Group("/analyzer-rules")
+		{
+			analyzerRuleAPI.GET("/", handlers.ListAnalyzerRules)
+			analyzerRuleAPI.POST("/", handlers.CreateAnalyzerRule)
+			analyzerRuleAPI.POST("/dry-run", handlers.DryRunAnalyzerRule)
+			analyzerRuleAPI.GET("/:id", handlers.GetAnalyzerRule)
+			analyzerRuleAPI.PUT("/:id", handlers.UpdateAnalyzerRule)
+			analyzerRuleAPI.DELETE("/:id", handlers.DeleteAnalyzerRule)
+		}
+
+		suppressionAPI := adminAPI.Group("/analysis-suppressions")
+		{
+			suppressionAPI.GET("/", handlers.ListAnalysisSuppressions)
+			suppressionAPI.POST("/", handlers.CreateAnalysisSuppression)
+			suppressionAPI.PUT("/:id", handlers.UpdateAnalysisSuppression)
+			suppressionAPI.DELETE("/:id", handlers.DeleteAnalysisSuppression)
 		}
 
 		templateAPI := adminAPI.Group("/templates")
@@ -168,6 +225,15 @@ func setupAPIRouter(r *gin.RouterGroup, cm *cluster.ClusterManager, authHandler
 			adminAIGenericAPI.DELETE("/profiles/:id", handlers.DeleteAIProfile)
 			adminAIGenericAPI.GET("/config", handlers.GetAdminAIConfig)
 			adminAIGenericAPI.POST("/governance", handlers.UpdateAIGovernance)
+			adminAIGenericAPI.GET("/prices", handlers.ListAIModelPrices)
+			adminAIGenericAPI.POST("/prices", handlers.CreateAIModelPrice)
+			adminAIGenericAPI.PUT("/prices/:id", handlers.UpdateAIModelPrice)
+			adminAIGenericAPI.DELETE("/prices/:id", handlers.DeleteAIModelPrice)
+			adminAIGenericAPI.GET("/budgets", handlers.ListAIBudgets)
+			adminAIGenericAPI.POST("/budgets", handlers.CreateAIBudget)
+			adminAIGenericAPI.PUT("/budgets/:id", handlers.UpdateAIBudget)
+			adminAIGenericAPI.DELETE("/budgets/:id", handlers.DeleteAIBudget)
+			adminAIGenericAPI.GET("/usage", handlers.GetAIUsageReport)
 		}
 	}
 
@@ -185,6 +251,21 @@ func setupAPIRouter(r *gin.RouterGroup, cm *cluster.ClusterManager, authHandler
 			apiKeyAPI.DELETE("/:id", handlers.DeleteAPIKey)
 		}
 
+		sessionAPI := api.Group("/settings/sessions")
+		{
+			sessionAPI.GET("/", handlers.ListSessions)
+			sessionAPI.DELETE("/:id", handlers.RevokeSession)
+		}
+
+		twoFactorAPI := api.Group("/settings/two-factor")
+		{
+			twoFactorAPI.GET("/", handlers.GetTwoFactorStatus)
+			twoFactorAPI.POST("/setup", handlers.SetupTwoFactor)
+			twoFactorAPI.POST("/enable", handlers.EnableTwoFactor)
+			twoFactorAPI.POST("/recovery-codes", handlers.RegenerateRecoveryCodes)
+			twoFactorAPI.POST("/disable", handlers.DisableTwoFactor)
+		}
+
 		gitlabConfigAPI := api.Group("/settings/gitlab-configs")
 		{
 			gitlabConfigAPI.GET("/", handlers.ListUserGitlabConfigs)
@@ -212,9 +293,10 @@ func setupAPIRouter(r *gin.RouterGroup, cm *cluster.ClusterManager, authHandler
 			aiGroup.GET("/sessions", handlers.ListAIChatSessions)
 			aiGroup.GET("/sessions/:id", handlers.GetAIChatSession)
 			aiGroup.DELETE("/sessions/:id", handlers.DeleteAIChatSession)
+			aiGroup.POST("/sessions/:id/tool-calls/:toolCallID/approval", handlers.DecideAIToolApproval)
 		}
 
-		mcpGroup := api.Group("/mcp")
+		mcpGroup := api.Group("/mcp", mcp.UserContext())
 		{
 			baseURL := common.Base
 			if baseURL == "" {
@@ -232,6 +314,10 @@ func setupAPIRouter(r *gin.RouterGroup, cm *cluster.ClusterManager, authHandler
 
 		api.POST("/ai/chat", handlers.AIChat)
 
+		api.GET("/analysis/findings", handlers.ListAnalysisFindings)
+		api.GET("/analysis/trend", handlers.GetAnalysisTrend)
+		api.GET("/analysis/scores", handlers.GetAnalysisScores)
+
 		promHandler := handlers.NewPromHandler()
 		api.GET("/prometheus/resource-usage-history", promHandler.GetResourceUsageHistory)
 		api.GET("/prometheus/pods/:namespace/:podName/metrics", promHandler.GetPodMetrics)
@@ -283,8 +369,12 @@ func main() {
 	r.Use(middleware.Logger())
 	r.Use(middleware.CORS())
 	model.InitDB()
+	audit.Init(audit.SinksFromEnv()...)
 	model.StartAppConfigRefresher()
+	auth.InitSigningKeys()
 	rbac.InitRBAC()
+	analyzer.InitCustomRules()
+	analyzer.InitSuppressions()
 	handlers.InitTemplates()
 	internal.LoadConfigFromEnv()
 	handlers.RestoreGitlabConfigs()
@@ -326,4 +416,5 @@ func main() {
 	if err := srv.Shutdown(ctx); err != nil {
 		klog.Fatalf("Failed to shutdown server: %v", err)
 	}
+	audit.Close()
 }
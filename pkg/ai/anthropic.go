@@ -0,0 +1,445 @@
+package ai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	openai "github.com/sashabaranov/go-openai"
+	"k8s.io/klog/v2"
+)
+
+const (
+	defaultAnthropicBaseURL   = "https://api.anthropic.com"
+	defaultAnthropicModel     = "claude-sonnet-4-5"
+	defaultAnthropicMaxTokens = 4096
+	anthropicAPIVersion       = "2023-06-01"
+)
+
+// AnthropicAdapter talks to the Anthropic Messages API and translates its
+// messages, tool use blocks and stream events to and from the OpenAI types
+// the rest of the AI package uses.
+type AnthropicAdapter struct {
+	httpClient *http.Client
+	baseURL    string
+	apiKey     string
+	model      string
+	maxTokens  int
+}
+
+func NewAnthropicAdapter(config *AIConfig) (*AnthropicAdapter, error) {
+	baseURL := strings.TrimRight(config.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = defaultAnthropicBaseURL
+	}
+	// Accept base URLs with or without the version path
+	baseURL = strings.TrimSuffix(baseURL, "/v1")
+
+	modelName := config.Model
+	if modelName == "" {
+		modelName = config.DefaultModel
+	}
+	if modelName == "" {
+		modelName = defaultAnthropicModel
+	}
+
+	return &AnthropicAdapter{
+		httpClient: &http.Client{},
+		baseURL:    baseURL,
+		apiKey:     config.APIKey,
+		model:      modelName,
+		maxTokens:  defaultAnthropicMaxTokens,
+	}, nil
+}
+
+type anthropicRequest struct {
+	Model     string             `json:"model"`
+	MaxTokens int                `json:"max_tokens"`
+	System    string             `json:"system,omitempty"`
+	Messages  []anthropicMessage `json:"messages"`
+	Tools     []anthropicTool    `json:"tools,omitempty"`
+	Stream    bool               `json:"stream,omitempty"`
+}
+
+type anthropicMessage struct {
+	Role    string                  `json:"role"`
+	Content []anthropicContentBlock `json:"content"`
+}
+
+type anthropicContentBlock struct {
+	Type string `json:"type"`
+	Text string `json:"text,omitempty"`
+	// tool_use
+	ID    string          `json:"id,omitempty"`
+	Name  string          `json:"name,omitempty"`
+	Input json.RawMessage `json:"input,omitempty"`
+	// tool_result
+	ToolUseID string `json:"tool_use_id,omitempty"`
+	Content   string `json:"content,omitempty"`
+}
+
+type anthropicTool struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	InputSchema any    `json:"input_schema"`
+}
+
+type anthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+type anthropicResponse struct {
+	ID         string                  `json:"id"`
+	Model      string                  `json:"model"`
+	Content    []anthropicContentBlock `json:"content"`
+	StopReason string                  `json:"stop_reason"`
+	Usage      anthropicUsage          `json:"usage"`
+}
+
+type anthropicError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// anthropicStreamEvent covers the fields of all server-sent events of a
+// streamed message
+type anthropicStreamEvent struct {
+	Type         string                `json:"type"`
+	Index        int                   `json:"index"`
+	Message      anthropicResponse     `json:"message"`
+	ContentBlock anthropicContentBlock `json:"content_block"`
+	Delta        struct {
+		Type        string `json:"type"`
+		Text        string `json:"text"`
+		PartialJSON string `json:"partial_json"`
+		StopReason  string `json:"stop_reason"`
+	} `json:"delta"`
+	Usage anthropicUsage `json:"usage"`
+	Error anthropicError `json:"error"`
+}
+
+func (a *AnthropicAdapter) ChatCompletion(ctx context.Context, messages []openai.ChatCompletionMessage, tools []openai.Tool) (openai.ChatCompletionResponse, error) {
+	klog.Infof("Anthropic: ChatCompletion items: %d, tools: %d", len(messages), len(tools))
+
+	resp, err := a.send(ctx, a.buildRequest(messages, tools, false))
+	if err != nil {
+		return openai.ChatCompletionResponse{}, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	var msg anthropicResponse
+	if err := json.NewDecoder(resp.Body).Decode(&msg); err != nil {
+		return openai.ChatCompletionResponse{}, fmt.Errorf("failed to decode anthropic response: %w", err)
+	}
+
+	converted := convertAnthropicResponseToOpenAI(msg)
+	if len(converted.Choices[0].Message.ToolCalls) > 0 {
+		klog.Infof("Anthropic: AI returned %d tool calls", len(converted.Choices[0].Message.ToolCalls))
+	}
+	return converted, nil
+}
+
+func (a *AnthropicAdapter) ChatCompletionStream(ctx context.Context, messages []openai.ChatCompletionMessage, tools []openai.Tool) (chan openai.ChatCompletionStreamResponse, error) {
+	klog.Infof("Anthropic Stream: ChatCompletion items: %d, tools: %d", len(messages), len(tools))
+
+	resp, err := a.send(ctx, a.buildRequest(messages, tools, true))
+	if err != nil {
+		return nil, err
+	}
+
+	streamChan := make(chan openai.ChatCompletionStreamResponse, 100)
+
+	go func() {
+		defer close(streamChan)
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+
+		// Anthropic numbers content blocks including text, OpenAI numbers
+		// only the tool calls
+		toolIndexes := map[int]int{}
+		toolHasInput := map[int]bool{}
+		id, modelName := "", a.model
+		inputTokens := 0
+
+		chunk := func(delta openai.ChatCompletionStreamChoiceDelta, finishReason openai.FinishReason) openai.ChatCompletionStreamResponse {
+			delta.Role = openai.ChatMessageRoleAssistant
+			return openai.ChatCompletionStreamResponse{
+				ID:      id,
+				Object:  "chat.completion.chunk",
+				Model:   modelName,
+				Choices: []openai.ChatCompletionStreamChoice{{Delta: delta, FinishReason: finishReason}},
+			}
+		}
+		toolCall := func(blockIndex int, tc openai.ToolCall) openai.ChatCompletionStreamChoiceDelta {
+			index := toolIndexes[blockIndex]
+			tc.Index = &index
+			return openai.ChatCompletionStreamChoiceDelta{ToolCalls: []openai.ToolCall{tc}}
+		}
+
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+		for scanner.Scan() {
+			data, ok := strings.CutPrefix(scanner.Text(), "data:")
+			if !ok {
+				continue
+			}
+			var event anthropicStreamEvent
+			if err := json.Unmarshal([]byte(strings.TrimSpace(data)), &event); err != nil {
+				klog.Errorf("Anthropic Stream: failed to decode event: %v", err)
+				streamChan <- StreamErrorChunk(fmt.Errorf("failed to decode anthropic stream event: %w", err))
+				return
+			}
+
+			switch event.Type {
+			case "message_start":
+				id = event.Message.ID
+				if event.Message.Model != "" {
+					modelName = event.Message.Model
+				}
+				inputTokens = event.Message.Usage.InputTokens
+			case "content_block_start":
+				if event.ContentBlock.Type != "tool_use" {
+					continue
+				}
+				toolIndexes[event.Index] = len(toolIndexes)
+				streamChan <- chunk(toolCall(event.Index, openai.ToolCall{
+					ID:       event.ContentBlock.ID,
+					Type:     openai.ToolTypeFunction,
+					Function: openai.FunctionCall{Name: event.ContentBlock.Name},
+				}), "")
+			case "content_block_delta":
+				switch event.Delta.Type {
+				case "text_delta":
+					streamChan <- chunk(openai.ChatCompletionStreamChoiceDelta{Content: event.Delta.Text}, "")
+				case "input_json_delta":
+					if event.Delta.PartialJSON == "" {
+						continue
+					}
+					toolHasInput[event.Index] = true
+					streamChan <- chunk(toolCall(event.Index, openai.ToolCall{
+						Function: openai.FunctionCall{Arguments: event.Delta.PartialJSON},
+					}), "")
+				}
+			case "content_block_stop":
+				// Tools without parameters stream no input, send the empty
+				// object OpenAI would send
+				if _, isTool := toolIndexes[event.Index]; isTool && !toolHasInput[event.Index] {
+					streamChan <- chunk(toolCall(event.Index, openai.ToolCall{
+						Function: openai.FunctionCall{Arguments: "{}"},
+					}), "")
+				}
+			case "message_delta":
+				resp := chunk(openai.ChatCompletionStreamChoiceDelta{}, convertAnthropicStopReason(event.Delta.StopReason))
+				resp.Usage = &openai.Usage{
+					PromptTokens:     inputTokens,
+					CompletionTokens: event.Usage.OutputTokens,
+					TotalTokens:      inputTokens + event.Usage.OutputTokens,
+				}
+				streamChan <- resp
+			case "message_stop":
+				return
+			case "error":
+				klog.Errorf("Anthropic Stream error: %s: %s", event.Error.Type, event.Error.Message)
+				streamChan <- StreamErrorChunk(fmt.Errorf("anthropic stream failed: %s: %s", event.Error.Type, event.Error.Message))
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil && ctx.Err() == nil {
+			klog.Errorf("Anthropic Stream error: %v", err)
+			streamChan <- StreamErrorChunk(fmt.Errorf("anthropic stream failed: %w", err))
+		}
+	}()
+
+	return streamChan, nil
+}
+
+func (a *AnthropicAdapter) buildRequest(messages []openai.ChatCompletionMessage, tools []openai.Tool, stream bool) anthropicRequest {
+	system, history := buildAnthropicMessages(messages)
+	return anthropicRequest{
+		Model:     a.model,
+		MaxTokens: a.maxTokens,
+		System:    system,
+		Messages:  history,
+		Tools:     convertToolsToAnthropic(tools),
+		Stream:    stream,
+	}
+}
+
+// send posts a request to the messages endpoint and returns the response
+// if it succeeded
+func (a *AnthropicAdapter) send(ctx context.Context, req anthropicRequest) (*http.Response, error) {
+	if len(req.Messages) == 0 {
+		return nil, fmt.Errorf("anthropic: no messages to send")
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal anthropic request: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, a.baseURL+"/v1/messages", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("X-Api-Key", a.apiKey)
+	httpReq.Header.Set("Anthropic-Version", anthropicAPIVersion)
+
+	resp, err := a.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("anthropic request failed: %w", err)
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+		raw, _ := io.ReadAll(resp.Body)
+		var errResp struct {
+			Error anthropicError `json:"error"`
+		}
+		if json.Unmarshal(raw, &errResp) == nil && errResp.Error.Message != "" {
+			return nil, fmt.Errorf("anthropic request failed with status %d: %s: %s", resp.StatusCode, errResp.Error.Type, errResp.Error.Message)
+		}
+		return nil, fmt.Errorf("anthropic request failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(raw)))
+	}
+	return resp, nil
+}
+
+// buildAnthropicMessages splits the system prompt from the conversation.
+// Tool results are sent as user messages, and consecutive messages of the
+// same role are merged because the Messages API requires alternating roles.
+func buildAnthropicMessages(messages []openai.ChatCompletionMessage) (string, []anthropicMessage) {
+	var system []string
+	var history []anthropicMessage
+
+	appendBlocks := func(role string, blocks ...anthropicContentBlock) {
+		if len(blocks) == 0 {
+			return
+		}
+		if n := len(history); n > 0 && history[n-1].Role == role {
+			history[n-1].Content = append(history[n-1].Content, blocks...)
+			return
+		}
+		history = append(history, anthropicMessage{Role: role, Content: blocks})
+	}
+
+	for _, m := range messages {
+		switch m.Role {
+		case openai.ChatMessageRoleSystem:
+			if m.Content != "" {
+				system = append(system, m.Content)
+			}
+		case openai.ChatMessageRoleAssistant:
+			var blocks []anthropicContentBlock
+			if m.Content != "" {
+				blocks = append(blocks, anthropicContentBlock{Type: "text", Text: m.Content})
+			}
+			for _, tc := range m.ToolCalls {
+				input := json.RawMessage(tc.Function.Arguments)
+				if !json.Valid(input) {
+					input = json.RawMessage("{}")
+				}
+				blocks = append(blocks, anthropicContentBlock{
+					Type:  "tool_use",
+					ID:    tc.ID,
+					Name:  tc.Function.Name,
+					Input: input,
+				})
+			}
+			appendBlocks("assistant", blocks...)
+		case openai.ChatMessageRoleTool:
+			appendBlocks("user", anthropicContentBlock{
+				Type:      "tool_result",
+				ToolUseID: m.ToolCallID,
+				Content:   m.Content,
+			})
+		default:
+			if m.Content != "" {
+				appendBlocks("user", anthropicContentBlock{Type: "text", Text: m.Content})
+			}
+		}
+	}
+
+	return strings.Join(system, "\n\n"), history
+}
+
+func convertToolsToAnthropic(tools []openai.Tool) []anthropicTool {
+	var result []anthropicTool
+	for _, t := range tools {
+		if t.Type != openai.ToolTypeFunction || t.Function == nil {
+			continue
+		}
+		schema := t.Function.Parameters
+		if schema == nil {
+			schema = map[string]any{"type": "object", "properties": map[string]any{}}
+		}
+		result = append(result, anthropicTool{
+			Name:        t.Function.Name,
+			Description: t.Function.Description,
+			InputSchema: schema,
+		})
+	}
+	return result
+}
+
+func convertAnthropicResponseToOpenAI(resp anthropicResponse) openai.ChatCompletionResponse {
+	msg := openai.ChatCompletionMessage{
+		Role: openai.ChatMessageRoleAssistant,
+	}
+
+	var contentBuilder strings.Builder
+	for _, block := range resp.Content {
+		switch block.Type {
+		case "text":
+			contentBuilder.WriteString(block.Text)
+		case "tool_use":
+			args := string(block.Input)
+			if args == "" || args == "null" {
+				args = "{}"
+			}
+			msg.ToolCalls = append(msg.ToolCalls, openai.ToolCall{
+				ID:   block.ID,
+				Type: openai.ToolTypeFunction,
+				Function: openai.FunctionCall{
+					Name:      block.Name,
+					Arguments: args,
+				},
+			})
+		}
+	}
+	msg.Content = contentBuilder.String()
+
+	return openai.ChatCompletionResponse{
+		ID:     resp.ID,
+		Object: "chat.completion",
+		Model:  resp.Model,
+		Choices: []openai.ChatCompletionChoice{{
+			Message:      msg,
+			FinishReason: convertAnthropicStopReason(resp.StopReason),
+		}},
+		Usage: openai.Usage{
+			PromptTokens:     resp.Usage.InputTokens,
+			CompletionTokens: resp.Usage.OutputTokens,
+			TotalTokens:      resp.Usage.InputTokens + resp.Usage.OutputTokens,
+		},
+	}
+}
+
+func convertAnthropicStopReason(reason string) openai.FinishReason {
+	switch reason {
+	case "tool_use":
+		return openai.FinishReasonToolCalls
+	case "max_tokens":
+		return openai.FinishReasonLength
+	default:
+		return openai.FinishReasonStop
+	}
+}
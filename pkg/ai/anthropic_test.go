@@ -0,0 +1,229 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testTools = []openai.Tool{{
+	Type: openai.ToolTypeFunction,
+	Function: &openai.FunctionDefinition{
+		Name:        "get_pod_logs",
+		Description: "Get the logs of a pod",
+		Parameters: map[string]any{
+			"type":       "object",
+			"properties": map[string]any{"name": map[string]any{"type": "string"}},
+			"required":   []string{"name"},
+		},
+	},
+}}
+
+var testConversation = []openai.ChatCompletionMessage{
+	{Role: openai.ChatMessageRoleSystem, Content: "You are a Kubernetes assistant."},
+	{Role: openai.ChatMessageRoleUser, Content: "Why is web-1 crashing?"},
+	{Role: openai.ChatMessageRoleAssistant, Content: "Let me check.", ToolCalls: []openai.ToolCall{
+		{ID: "toolu_1", Type: openai.ToolTypeFunction, Function: openai.FunctionCall{Name: "get_pod_logs", Arguments: `{"name":"web-1"}`}},
+		{ID: "toolu_2", Type: openai.ToolTypeFunction, Function: openai.FunctionCall{Name: "get_pod_logs", Arguments: `{"name":"web-2"}`}},
+	}},
+	{Role: openai.ChatMessageRoleTool, ToolCallID: "toolu_1", Content: "panic: out of memory"},
+	{Role: openai.ChatMessageRoleTool, ToolCallID: "toolu_2", Content: "ok"},
+}
+
+// newAnthropicServer returns a stand-in for the Messages API that records
+// the request and answers with respond
+func newAnthropicServer(t *testing.T, received *anthropicRequest, respond func(w http.ResponseWriter)) *AnthropicAdapter {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/messages", r.URL.Path)
+		assert.Equal(t, "test-key", r.Header.Get("x-api-key"))
+		assert.Equal(t, anthropicAPIVersion, r.Header.Get("anthropic-version"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(received))
+		respond(w)
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(&AIConfig{Provider: "anthropic", APIKey: "test-key", BaseURL: server.URL + "/v1", DefaultModel: "claude-test"})
+	require.NoError(t, err)
+	require.IsType(t, &AnthropicAdapter{}, client)
+	return client.(*AnthropicAdapter)
+}
+
+func TestAnthropicChatCompletion(t *testing.T) {
+	var received anthropicRequest
+	adapter := newAnthropicServer(t, &received, func(w http.ResponseWriter) {
+		_, _ = w.Write([]byte(`{
+			"id": "msg_1",
+			"model": "claude-test",
+			"content": [
+				{"type": "text", "text": "The pod runs out of memory."},
+				{"type": "tool_use", "id": "toolu_3", "name": "get_pod_logs", "input": {"name": "web-3"}}
+			],
+			"stop_reason": "tool_use",
+			"usage": {"input_tokens": 120, "output_tokens": 30}
+		}`))
+	})
+
+	resp, err := adapter.ChatCompletion(context.Background(), testConversation, testTools)
+	require.NoError(t, err)
+
+	assert.Equal(t, "claude-test", received.Model)
+	assert.Equal(t, defaultAnthropicMaxTokens, received.MaxTokens)
+	assert.Equal(t, "You are a Kubernetes assistant.", received.System)
+	assert.False(t, received.Stream)
+	require.Len(t, received.Tools, 1)
+	assert.Equal(t, "get_pod_logs", received.Tools[0].Name)
+	assert.Equal(t, "object", received.Tools[0].InputSchema.(map[string]any)["type"])
+
+	// Both tool results are sent in a single user message after the tool use
+	require.Len(t, received.Messages, 3)
+	assert.Equal(t, "user", received.Messages[0].Role)
+	assistant := received.Messages[1]
+	assert.Equal(t, "assistant", assistant.Role)
+	require.Len(t, assistant.Content, 3)
+	assert.Equal(t, "text", assistant.Content[0].Type)
+	assert.Equal(t, "tool_use", assistant.Content[1].Type)
+	assert.Equal(t, "toolu_1", assistant.Content[1].ID)
+	assert.JSONEq(t, `{"name":"web-1"}`, string(assistant.Content[1].Input))
+	results := received.Messages[2]
+	assert.Equal(t, "user", results.Role)
+	require.Len(t, results.Content, 2)
+	assert.Equal(t, "tool_result", results.Content[0].Type)
+	assert.Equal(t, "toolu_1", results.Content[0].ToolUseID)
+	assert.Equal(t, "panic: out of memory", results.Content[0].Content)
+
+	require.Len(t, resp.Choices, 1)
+	choice := resp.Choices[0]
+	assert.Equal(t, openai.FinishReasonToolCalls, choice.FinishReason)
+	assert.Equal(t, "The pod runs out of memory.", choice.Message.Content)
+	require.Len(t, choice.Message.ToolCalls, 1)
+	assert.Equal(t, "toolu_3", choice.Message.ToolCalls[0].ID)
+	assert.Equal(t, "get_pod_logs", choice.Message.ToolCalls[0].Function.Name)
+	assert.JSONEq(t, `{"name":"web-3"}`, choice.Message.ToolCalls[0].Function.Arguments)
+	assert.Equal(t, 150, resp.Usage.TotalTokens)
+}
+
+func TestAnthropicChatCompletionError(t *testing.T) {
+	var received anthropicRequest
+	adapter := newAnthropicServer(t, &received, func(w http.ResponseWriter) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`))
+	})
+
+	_, err := adapter.ChatCompletion(context.Background(), testConversation[:2], nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid x-api-key")
+}
+
+func TestAnthropicChatCompletionStream(t *testing.T) {
+	events := []string{
+		`{"type":"message_start","message":{"id":"msg_2","model":"claude-test","usage":{"input_tokens":10}}}`,
+		`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Checking "}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"the logs."}}`,
+		`{"type":"content_block_stop","index":0}`,
+		`{"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_4","name":"get_pod_logs","input":{}}}`,
+		`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":""}}`,
+		`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"name\":"}}`,
+		`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"\"web-1\"}"}}`,
+		`{"type":"content_block_stop","index":1}`,
+		`{"type":"content_block_start","index":2,"content_block":{"type":"tool_use","id":"toolu_5","name":"list_pods","input":{}}}`,
+		`{"type":"content_block_stop","index":2}`,
+		`{"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":25}}`,
+		`{"type":"message_stop"}`,
+	}
+	var received anthropicRequest
+	adapter := newAnthropicServer(t, &received, func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, event := range events {
+			var e struct {
+				Type string `json:"type"`
+			}
+			_ = json.Unmarshal([]byte(event), &e)
+			_, _ = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, event)
+		}
+	})
+
+	stream, err := adapter.ChatCompletionStream(context.Background(), testConversation[:2], testTools)
+	require.NoError(t, err)
+	assert.True(t, received.Stream)
+
+	// Merge the chunks the way the chat handler does
+	var content string
+	var toolCalls []openai.ToolCall
+	var finishReason openai.FinishReason
+	var usage *openai.Usage
+	for chunk := range stream {
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+		require.Len(t, chunk.Choices, 1)
+		assert.Equal(t, "msg_2", chunk.ID)
+		delta := chunk.Choices[0].Delta
+		content += delta.Content
+		for _, tc := range delta.ToolCalls {
+			require.NotNil(t, tc.Index)
+			for len(toolCalls) <= *tc.Index {
+				toolCalls = append(toolCalls, openai.ToolCall{})
+			}
+			if tc.ID != "" {
+				toolCalls[*tc.Index].ID = tc.ID
+			}
+			toolCalls[*tc.Index].Function.Name += tc.Function.Name
+			toolCalls[*tc.Index].Function.Arguments += tc.Function.Arguments
+		}
+		if chunk.Choices[0].FinishReason != "" {
+			finishReason = chunk.Choices[0].FinishReason
+		}
+	}
+
+	assert.Equal(t, "Checking the logs.", content)
+	assert.Equal(t, openai.FinishReasonToolCalls, finishReason)
+	require.NotNil(t, usage)
+	assert.Equal(t, openai.Usage{PromptTokens: 10, CompletionTokens: 25, TotalTokens: 35}, *usage)
+	require.Len(t, toolCalls, 2)
+	assert.Equal(t, "toolu_4", toolCalls[0].ID)
+	assert.Equal(t, "get_pod_logs", toolCalls[0].Function.Name)
+	assert.JSONEq(t, `{"name":"web-1"}`, toolCalls[0].Function.Arguments)
+	assert.Equal(t, "toolu_5", toolCalls[1].ID)
+	assert.Equal(t, "list_pods", toolCalls[1].Function.Name)
+	assert.Equal(t, "{}", toolCalls[1].Function.Arguments)
+}
+
+func TestAnthropicChatCompletionStreamError(t *testing.T) {
+	events := []string{
+		`{"type":"message_start","message":{"id":"msg_3","model":"claude-test","usage":{"input_tokens":10}}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Checking "}}`,
+		`{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`,
+	}
+	var received anthropicRequest
+	adapter := newAnthropicServer(t, &received, func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, event := range events {
+			_, _ = fmt.Fprintf(w, "data: %s\n\n", event)
+		}
+	})
+
+	stream, err := adapter.ChatCompletionStream(context.Background(), testConversation[:2], testTools)
+	require.NoError(t, err)
+
+	var content string
+	var streamErr error
+	for chunk := range stream {
+		if err := StreamError(chunk); err != nil {
+			streamErr = err
+			continue
+		}
+		require.Nil(t, streamErr, "the error is the last chunk")
+		content += chunk.Choices[0].Delta.Content
+	}
+	assert.Equal(t, "Checking ", content)
+	require.Error(t, streamErr)
+	assert.Contains(t, streamErr.Error(), "overloaded_error: Overloaded")
+}
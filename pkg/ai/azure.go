@@ -0,0 +1,53 @@
+package ai
+
+import (
+	"fmt"
+	"strings"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// defaultAzureAPIVersion is the api-version used when a profile does not set one
+const defaultAzureAPIVersion = "2024-10-21"
+
+// AzureOpenAIAdapter talks to an Azure OpenAI resource. Azure addresses
+// models by deployment name and every request needs an api-version, the
+// chat completion and streaming logic is the same as for OpenAI.
+type AzureOpenAIAdapter struct {
+	OpenAIAdapter
+}
+
+// NewAzureOpenAIAdapter returns an adapter for the Azure OpenAI resource in
+// config.BaseURL (e.g. https://my-resource.openai.azure.com). The model of
+// the config is the deployment name.
+func NewAzureOpenAIAdapter(config *AIConfig) (*AzureOpenAIAdapter, error) {
+	if config.BaseURL == "" {
+		return nil, fmt.Errorf("azure openai requires the resource endpoint as base URL")
+	}
+
+	deployment := config.Model
+	if deployment == "" {
+		deployment = config.DefaultModel
+	}
+	if deployment == "" {
+		return nil, fmt.Errorf("azure openai requires a deployment name as model")
+	}
+
+	oaConfig := openai.DefaultAzureConfig(config.APIKey, strings.TrimRight(config.BaseURL, "/"))
+	oaConfig.APIVersion = config.APIVersion
+	if oaConfig.APIVersion == "" {
+		oaConfig.APIVersion = defaultAzureAPIVersion
+	}
+	// The default mapper strips dots and colons from the model name, which
+	// would break deployment names that contain them
+	oaConfig.AzureModelMapperFunc = func(model string) string {
+		return model
+	}
+
+	return &AzureOpenAIAdapter{
+		OpenAIAdapter: OpenAIAdapter{
+			client: openai.NewClientWithConfig(oaConfig),
+			model:  deployment,
+		},
+	}, nil
+}
@@ -0,0 +1,93 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAzureOpenAIChatCompletion(t *testing.T) {
+	var received openai.ChatCompletionRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/openai/deployments/gpt-4.1-prod/chat/completions", r.URL.Path)
+		assert.Equal(t, "2025-01-01-preview", r.URL.Query().Get("api-version"))
+		assert.Equal(t, "test-key", r.Header.Get("api-key"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{
+			"id": "chatcmpl-1",
+			"object": "chat.completion",
+			"choices": [{
+				"index": 0,
+				"finish_reason": "tool_calls",
+				"message": {
+					"role": "assistant",
+					"tool_calls": [{"id": "call_1", "type": "function", "function": {"name": "get_pod_logs", "arguments": "{\"name\":\"web-1\"}"}}]
+				}
+			}]
+		}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(&AIConfig{
+		Provider:     "azure",
+		APIKey:       "test-key",
+		BaseURL:      server.URL + "/",
+		DefaultModel: "gpt-4.1-prod",
+		APIVersion:   "2025-01-01-preview",
+	})
+	require.NoError(t, err)
+	require.IsType(t, &AzureOpenAIAdapter{}, client)
+
+	resp, err := client.ChatCompletion(context.Background(), testConversation, testTools)
+	require.NoError(t, err)
+	assert.Len(t, received.Messages, len(testConversation))
+	require.Len(t, received.Tools, 1)
+	assert.Equal(t, "get_pod_logs", received.Tools[0].Function.Name)
+
+	require.Len(t, resp.Choices, 1)
+	require.Len(t, resp.Choices[0].Message.ToolCalls, 1)
+	assert.Equal(t, "call_1", resp.Choices[0].Message.ToolCalls[0].ID)
+	assert.JSONEq(t, `{"name":"web-1"}`, resp.Choices[0].Message.ToolCalls[0].Function.Arguments)
+}
+
+func TestAzureOpenAIChatCompletionStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/openai/deployments/chat/chat/completions", r.URL.Path)
+		assert.Equal(t, defaultAzureAPIVersion, r.URL.Query().Get("api-version"))
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, content := range []string{"Hello", " there"} {
+			_, _ = fmt.Fprintf(w, "data: {\"id\":\"chatcmpl-2\",\"choices\":[{\"index\":0,\"delta\":{\"content\":%q}}]}\n\n", content)
+		}
+		_, _ = fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	client, err := NewClient(&AIConfig{Provider: "azure", APIKey: "test-key", BaseURL: server.URL, Model: "chat"})
+	require.NoError(t, err)
+
+	stream, err := client.ChatCompletionStream(context.Background(), testConversation[:2], nil)
+	require.NoError(t, err)
+	var content string
+	for chunk := range stream {
+		content += chunk.Choices[0].Delta.Content
+	}
+	assert.Equal(t, "Hello there", content)
+}
+
+func TestNewAzureOpenAIAdapterValidation(t *testing.T) {
+	_, err := NewClient(&AIConfig{Provider: "azure", APIKey: "test-key", DefaultModel: "chat"})
+	assert.Error(t, err)
+
+	_, err = NewClient(&AIConfig{Provider: "azure", APIKey: "test-key", BaseURL: "https://example.openai.azure.com"})
+	assert.Error(t, err)
+}
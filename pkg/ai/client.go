@@ -2,7 +2,9 @@ package ai
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 
 	openai "github.com/sashabaranov/go-openai"
 )
@@ -14,12 +16,21 @@ type OpenAIAdapter struct {
 
 // NewClient returns an AIClient based on the provider in config
 func NewClient(config *AIConfig) (AIClient, error) {
+	if config.Provider == "ollama" {
+		return NewOllamaAdapter(config)
+	}
+
 	if config.APIKey == "" {
 		return nil, fmt.Errorf("API key is required")
 	}
 
-	if config.Provider == "google" || config.Provider == "gemini" {
+	switch config.Provider {
+	case "google", "gemini":
 		return NewGeminiAdapter(config)
+	case "anthropic", "claude":
+		return NewAnthropicAdapter(config)
+	case "azure":
+		return NewAzureOpenAIAdapter(config)
 	}
 
 	// Default to OpenAI / Custom OpenAI-compatible
@@ -56,6 +67,8 @@ func (c *OpenAIAdapter) ChatCompletionStream(ctx context.Context, messages []ope
 		Messages: messages,
 		Tools:    tools,
 		Stream:   true,
+		// Report the token usage in the last chunk
+		StreamOptions: &openai.StreamOptions{IncludeUsage: true},
 	}
 
 	stream, err := c.client.CreateChatCompletionStream(ctx, req)
@@ -73,7 +86,13 @@ func (c *OpenAIAdapter) ChatCompletionStream(ctx context.Context, messages []ope
 
 		for {
 			resp, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				return
+			}
 			if err != nil {
+				if ctx.Err() == nil {
+					streamChan <- StreamErrorChunk(err)
+				}
 				return
 			}
 			streamChan <- resp
@@ -124,6 +124,7 @@ func convertGeminiStreamResponseToOpenAI(resp *genai.GenerateContentResponse) op
 			Created: 0,
 			Model:   "gemini",
 			Choices: choices,
+			Usage:   convertGeminiUsage(resp),
 		}
 	}
 
@@ -173,6 +174,20 @@ func convertGeminiStreamResponseToOpenAI(resp *genai.GenerateContentResponse) op
 		Created: 0,
 		Model:   "gemini",
 		Choices: choices,
+		Usage:   convertGeminiUsage(resp),
+	}
+}
+
+// convertGeminiUsage returns the token usage of a response. Streamed
+// responses report the usage so far in every chunk.
+func convertGeminiUsage(resp *genai.GenerateContentResponse) *openai.Usage {
+	if resp == nil || resp.UsageMetadata == nil {
+		return nil
+	}
+	return &openai.Usage{
+		PromptTokens:     int(resp.UsageMetadata.PromptTokenCount),
+		CompletionTokens: int(resp.UsageMetadata.CandidatesTokenCount),
+		TotalTokens:      int(resp.UsageMetadata.TotalTokenCount),
 	}
 }
 
@@ -378,13 +393,17 @@ func convertGeminiResponseToOpenAI(resp *genai.GenerateContentResponse) openai.C
 		})
 	}
 
-	return openai.ChatCompletionResponse{
+	converted := openai.ChatCompletionResponse{
 		ID:      "gemini-resp",
 		Object:  "chat.completion",
 		Created: 0,
 		Model:   "gemini",
 		Choices: choices,
 	}
+	if usage := convertGeminiUsage(resp); usage != nil {
+		converted.Usage = *usage
+	}
+	return converted
 }
 
 func buildGeminiHistory(messages []openai.ChatCompletionMessage) ([]*genai.Content, *genai.Content) {
@@ -2,6 +2,7 @@ package ai
 
 import (
 	"context"
+	"errors"
 
 	openai "github.com/sashabaranov/go-openai"
 )
@@ -11,3 +12,28 @@ type AIClient interface {
 	ChatCompletion(ctx context.Context, messages []openai.ChatCompletionMessage, tools []openai.Tool) (openai.ChatCompletionResponse, error)
 	ChatCompletionStream(ctx context.Context, messages []openai.ChatCompletionMessage, tools []openai.Tool) (chan openai.ChatCompletionStreamResponse, error)
 }
+
+// ModelLister is implemented by AI clients that can discover the models
+// their server offers
+type ModelLister interface {
+	ListModels(ctx context.Context) ([]string, error)
+}
+
+// streamErrorObject is the Object of the chunk that ends a stream which
+// failed after it started. The chunk carries the error message in ID.
+const streamErrorObject = "error"
+
+// StreamErrorChunk returns the last chunk a stream sends when the provider
+// fails after the stream started
+func StreamErrorChunk(err error) openai.ChatCompletionStreamResponse {
+	return openai.ChatCompletionStreamResponse{Object: streamErrorObject, ID: err.Error()}
+}
+
+// StreamError returns the error of a chunk made by StreamErrorChunk, or nil
+// for other chunks
+func StreamError(resp openai.ChatCompletionStreamResponse) error {
+	if resp.Object != streamErrorObject {
+		return nil
+	}
+	return errors.New(resp.ID)
+}
@@ -0,0 +1,231 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+
+	openai "github.com/sashabaranov/go-openai"
+	"k8s.io/klog/v2"
+)
+
+// JSON tool call protocol for models without native tool calling. The
+// tools are described in the system prompt and the model answers with a
+// JSON object instead of text when it wants to call them.
+
+const jsonToolsInstructions = `You can call tools to answer. To call one or more tools, reply with only a JSON object and no other text:
+{"tool_calls": [{"name": "<tool name>", "arguments": {<arguments matching the tool parameters>}}]}
+The results of the tools are sent to you in the next message. Reply with normal text when you do not need a tool.
+
+Available tools:`
+
+type jsonToolCall struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+// jsonToolCallReply accepts a list of calls and, as models sometimes
+// answer that way, a single call
+type jsonToolCallReply struct {
+	ToolCalls []jsonToolCall `json:"tool_calls"`
+	jsonToolCall
+}
+
+// buildJSONToolMessages adds the tool descriptions to the system prompt and
+// rewrites tool calls and tool results of the history as plain messages
+func buildJSONToolMessages(messages []openai.ChatCompletionMessage, tools []openai.Tool) []openai.ChatCompletionMessage {
+	var instructions strings.Builder
+	instructions.WriteString(jsonToolsInstructions)
+	for _, t := range tools {
+		if t.Type != openai.ToolTypeFunction || t.Function == nil {
+			continue
+		}
+		params, err := json.Marshal(t.Function.Parameters)
+		if err != nil || t.Function.Parameters == nil {
+			params = []byte("{}")
+		}
+		fmt.Fprintf(&instructions, "\n- %s: %s\n  parameters: %s", t.Function.Name, t.Function.Description, params)
+	}
+
+	result := make([]openai.ChatCompletionMessage, 0, len(messages)+1)
+	hasSystem := false
+	for _, m := range messages {
+		switch {
+		case m.Role == openai.ChatMessageRoleSystem && !hasSystem:
+			hasSystem = true
+			result = append(result, openai.ChatCompletionMessage{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: strings.TrimSpace(m.Content + "\n\n" + instructions.String()),
+			})
+		case m.Role == openai.ChatMessageRoleAssistant && len(m.ToolCalls) > 0:
+			var calls []jsonToolCall
+			for _, tc := range m.ToolCalls {
+				args := json.RawMessage(tc.Function.Arguments)
+				if !json.Valid(args) {
+					args = json.RawMessage("{}")
+				}
+				calls = append(calls, jsonToolCall{Name: tc.Function.Name, Arguments: args})
+			}
+			encoded, _ := json.Marshal(map[string][]jsonToolCall{"tool_calls": calls})
+			result = append(result, openai.ChatCompletionMessage{
+				Role:    openai.ChatMessageRoleAssistant,
+				Content: strings.TrimSpace(m.Content + "\n" + string(encoded)),
+			})
+		case m.Role == openai.ChatMessageRoleTool:
+			result = append(result, openai.ChatCompletionMessage{
+				Role:    openai.ChatMessageRoleUser,
+				Content: fmt.Sprintf("Result of tool %s:\n%s", findToolName(messages, m.ToolCallID), m.Content),
+			})
+		default:
+			result = append(result, m)
+		}
+	}
+
+	if !hasSystem {
+		result = append([]openai.ChatCompletionMessage{{
+			Role:    openai.ChatMessageRoleSystem,
+			Content: instructions.String(),
+		}}, result...)
+	}
+	return result
+}
+
+// parseJSONToolCalls extracts the tool calls of a JSON tool call reply. It
+// returns the text before the JSON object and the calls, or the unchanged
+// text if it does not contain tool calls.
+func parseJSONToolCalls(text string) (string, []openai.ToolCall) {
+	start := strings.Index(text, "{")
+	end := strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return text, nil
+	}
+
+	var reply jsonToolCallReply
+	if err := json.Unmarshal([]byte(text[start:end+1]), &reply); err != nil {
+		return text, nil
+	}
+	calls := reply.ToolCalls
+	if len(calls) == 0 && reply.Name != "" {
+		calls = []jsonToolCall{reply.jsonToolCall}
+	}
+
+	var toolCalls []openai.ToolCall
+	for i, call := range calls {
+		if call.Name == "" {
+			continue
+		}
+		toolCalls = append(toolCalls, openai.ToolCall{
+			ID:   fmt.Sprintf("call_%d_%s", i, call.Name),
+			Type: openai.ToolTypeFunction,
+			Function: openai.FunctionCall{
+				Name:      call.Name,
+				Arguments: jsonToolArguments(call.Arguments),
+			},
+		})
+	}
+	if len(toolCalls) == 0 {
+		return text, nil
+	}
+
+	// Drop the code fence models like to put around the JSON
+	content := strings.TrimSpace(text[:start])
+	content = strings.TrimSuffix(content, "```json")
+	content = strings.TrimSuffix(content, "```")
+	return strings.TrimSpace(content), toolCalls
+}
+
+// jsonToolArguments returns the arguments of a call as JSON object, also
+// when the model encoded them as a string
+func jsonToolArguments(raw json.RawMessage) string {
+	var encoded string
+	if err := json.Unmarshal(raw, &encoded); err == nil {
+		raw = json.RawMessage(encoded)
+	}
+	if len(raw) == 0 || string(raw) == "null" || !json.Valid(raw) {
+		return "{}"
+	}
+	return string(raw)
+}
+
+// convertJSONToolCallStream passes text answers through as they stream. An
+// answer that starts like a JSON object or code fence is held back until
+// the end and then sent as tool calls, or as text if it is none.
+func convertJSONToolCallStream(in chan openai.ChatCompletionStreamResponse) chan openai.ChatCompletionStreamResponse {
+	out := make(chan openai.ChatCompletionStreamResponse, 100)
+
+	go func() {
+		defer close(out)
+
+		var held strings.Builder
+		var last openai.ChatCompletionStreamResponse
+		var usage *openai.Usage
+		decided, holding := false, false
+
+		for chunk := range in {
+			if StreamError(chunk) != nil {
+				out <- chunk
+				return
+			}
+			last = chunk
+			if chunk.Usage != nil {
+				usage = chunk.Usage
+			}
+			if len(chunk.Choices) == 0 {
+				if !holding {
+					out <- chunk
+				}
+				continue
+			}
+			text := chunk.Choices[0].Delta.Content
+			if !decided {
+				held.WriteString(text)
+				start := strings.TrimLeftFunc(held.String(), unicode.IsSpace)
+				if start == "" {
+					continue
+				}
+				decided = true
+				holding = start[0] == '{' || start[0] == '`'
+				if holding {
+					continue
+				}
+				chunk.Choices[0].Delta.Content = held.String()
+				held.Reset()
+			} else if holding {
+				held.WriteString(text)
+				continue
+			}
+			out <- chunk
+		}
+
+		if held.Len() == 0 {
+			return
+		}
+		content, toolCalls := parseJSONToolCalls(held.String())
+		finishReason := openai.FinishReasonStop
+		if len(toolCalls) > 0 {
+			klog.Infof("JSON tool calls: AI returned %d tool calls", len(toolCalls))
+			finishReason = openai.FinishReasonToolCalls
+			for i := range toolCalls {
+				index := i
+				toolCalls[i].Index = &index
+			}
+		}
+		out <- openai.ChatCompletionStreamResponse{
+			ID:     last.ID,
+			Object: "chat.completion.chunk",
+			Model:  last.Model,
+			Choices: []openai.ChatCompletionStreamChoice{{
+				Delta: openai.ChatCompletionStreamChoiceDelta{
+					Role:      openai.ChatMessageRoleAssistant,
+					Content:   content,
+					ToolCalls: toolCalls,
+				},
+				FinishReason: finishReason,
+			}},
+			Usage: usage,
+		}
+	}()
+
+	return out
+}
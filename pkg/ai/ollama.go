@@ -0,0 +1,173 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	openai "github.com/sashabaranov/go-openai"
+	"k8s.io/klog/v2"
+)
+
+const (
+	defaultOllamaBaseURL = "http://localhost:11434"
+	defaultOllamaModel   = "llama3.1"
+)
+
+// withoutNativeTools remembers the servers and models that rejected tool
+// definitions, keyed by base URL and model, so that later requests use the
+// JSON tool call protocol right away
+var withoutNativeTools sync.Map
+
+// OllamaAdapter talks to self-hosted runtimes with an OpenAI-compatible
+// API, such as Ollama, the llama.cpp server and vLLM. Models without
+// native tool calling get the tools in the system prompt and answer with
+// JSON tool calls, which are translated to OpenAI tool calls.
+type OllamaAdapter struct {
+	OpenAIAdapter
+	baseURL    string
+	httpClient *http.Client
+}
+
+func NewOllamaAdapter(config *AIConfig) (*OllamaAdapter, error) {
+	baseURL := strings.TrimSuffix(strings.TrimRight(config.BaseURL, "/"), "/v1")
+	if baseURL == "" {
+		baseURL = defaultOllamaBaseURL
+	}
+
+	modelName := config.Model
+	if modelName == "" {
+		modelName = config.DefaultModel
+	}
+	if modelName == "" {
+		modelName = defaultOllamaModel
+	}
+
+	// Self-hosted runtimes usually do not check the key
+	oaConfig := openai.DefaultConfig(config.APIKey)
+	oaConfig.BaseURL = baseURL + "/v1"
+
+	return &OllamaAdapter{
+		OpenAIAdapter: OpenAIAdapter{
+			client: openai.NewClientWithConfig(oaConfig),
+			model:  modelName,
+		},
+		baseURL:    baseURL,
+		httpClient: &http.Client{},
+	}, nil
+}
+
+func (o *OllamaAdapter) ChatCompletion(ctx context.Context, messages []openai.ChatCompletionMessage, tools []openai.Tool) (openai.ChatCompletionResponse, error) {
+	if len(tools) == 0 || o.hasNativeTools() {
+		resp, err := o.OpenAIAdapter.ChatCompletion(ctx, messages, tools)
+		if len(tools) == 0 || !o.rejectedTools(err) {
+			return resp, err
+		}
+	}
+
+	resp, err := o.OpenAIAdapter.ChatCompletion(ctx, buildJSONToolMessages(messages, tools), nil)
+	if err != nil {
+		return resp, err
+	}
+	for i := range resp.Choices {
+		content, toolCalls := parseJSONToolCalls(resp.Choices[i].Message.Content)
+		if len(toolCalls) > 0 {
+			resp.Choices[i].Message.Content = content
+			resp.Choices[i].Message.ToolCalls = toolCalls
+			resp.Choices[i].FinishReason = openai.FinishReasonToolCalls
+		}
+	}
+	return resp, nil
+}
+
+func (o *OllamaAdapter) ChatCompletionStream(ctx context.Context, messages []openai.ChatCompletionMessage, tools []openai.Tool) (chan openai.ChatCompletionStreamResponse, error) {
+	if len(tools) == 0 || o.hasNativeTools() {
+		stream, err := o.OpenAIAdapter.ChatCompletionStream(ctx, messages, tools)
+		if len(tools) == 0 || !o.rejectedTools(err) {
+			return stream, err
+		}
+	}
+
+	stream, err := o.OpenAIAdapter.ChatCompletionStream(ctx, buildJSONToolMessages(messages, tools), nil)
+	if err != nil {
+		return nil, err
+	}
+	return convertJSONToolCallStream(stream), nil
+}
+
+// ListModels returns the models the server serves, from the
+// OpenAI-compatible model list or the Ollama tags endpoint
+func (o *OllamaAdapter) ListModels(ctx context.Context) ([]string, error) {
+	var models []string
+	list, err := o.client.ListModels(ctx)
+	if err == nil {
+		for _, m := range list.Models {
+			models = append(models, m.ID)
+		}
+	} else {
+		// Older Ollama versions only list models on their own API
+		req, reqErr := http.NewRequestWithContext(ctx, http.MethodGet, o.baseURL+"/api/tags", nil)
+		if reqErr != nil {
+			return nil, reqErr
+		}
+		resp, tagsErr := o.httpClient.Do(req)
+		if tagsErr != nil {
+			return nil, fmt.Errorf("failed to list models: %w", tagsErr)
+		}
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("failed to list models: /api/tags returned status %d", resp.StatusCode)
+		}
+		var tags struct {
+			Models []struct {
+				Name string `json:"name"`
+			} `json:"models"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&tags); err != nil {
+			return nil, fmt.Errorf("failed to decode model list: %w", err)
+		}
+		for _, m := range tags.Models {
+			models = append(models, m.Name)
+		}
+	}
+	sort.Strings(models)
+	return models, nil
+}
+
+func (o *OllamaAdapter) toolsKey() string {
+	return o.baseURL + "|" + o.model
+}
+
+func (o *OllamaAdapter) hasNativeTools() bool {
+	_, without := withoutNativeTools.Load(o.toolsKey())
+	return !without
+}
+
+// rejectedTools reports whether err is the server refusing tool
+// definitions for the model, and if so remembers it
+func (o *OllamaAdapter) rejectedTools(err error) bool {
+	var apiErr *openai.APIError
+	var reqErr *openai.RequestError
+	status, message := 0, ""
+	switch {
+	case errors.As(err, &apiErr):
+		status, message = apiErr.HTTPStatusCode, apiErr.Message
+	case errors.As(err, &reqErr):
+		status, message = reqErr.HTTPStatusCode, string(reqErr.Body)
+	default:
+		return false
+	}
+	if status != http.StatusBadRequest || !strings.Contains(strings.ToLower(message), "tool") {
+		return false
+	}
+	klog.Infof("Model %s on %s does not support tool calling, using JSON tool calls: %s", o.model, o.baseURL, message)
+	withoutNativeTools.Store(o.toolsKey(), true)
+	return true
+}
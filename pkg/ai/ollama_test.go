@@ -0,0 +1,233 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newOllamaServer returns a stand-in for a self-hosted runtime that serves
+// model and records the chat requests it receives
+func newOllamaServer(t *testing.T, model string, chat func(w http.ResponseWriter, req openai.ChatCompletionRequest)) (*OllamaAdapter, *[]openai.ChatCompletionRequest) {
+	var requests []openai.ChatCompletionRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v1/chat/completions", r.URL.Path)
+		var req openai.ChatCompletionRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		requests = append(requests, req)
+		chat(w, req)
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(&AIConfig{Provider: "ollama", BaseURL: server.URL, DefaultModel: model})
+	require.NoError(t, err)
+	require.IsType(t, &OllamaAdapter{}, client)
+	return client.(*OllamaAdapter), &requests
+}
+
+func writeChatResponse(w http.ResponseWriter, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(openai.ChatCompletionResponse{
+		ID: "chatcmpl-1",
+		Choices: []openai.ChatCompletionChoice{{
+			Message:      openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: message},
+			FinishReason: openai.FinishReasonStop,
+		}},
+	})
+}
+
+func rejectTools(w http.ResponseWriter, req openai.ChatCompletionRequest) bool {
+	if len(req.Tools) == 0 {
+		return false
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	_, _ = fmt.Fprintf(w, `{"error":{"message":"registry.ollama.ai/library/%s does not support tools","type":"api_error"}}`, req.Model)
+	return true
+}
+
+func TestOllamaNativeToolCalls(t *testing.T) {
+	adapter, requests := newOllamaServer(t, "qwen3:8b", func(w http.ResponseWriter, req openai.ChatCompletionRequest) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"chatcmpl-1","choices":[{"finish_reason":"tool_calls","message":{"role":"assistant","tool_calls":[
+			{"id":"call_abc","type":"function","function":{"name":"get_pod_logs","arguments":"{\"name\":\"web-1\"}"}}]}}]}`))
+	})
+
+	resp, err := adapter.ChatCompletion(context.Background(), testConversation[:2], testTools)
+	require.NoError(t, err)
+	require.Len(t, *requests, 1)
+	assert.Equal(t, "qwen3:8b", (*requests)[0].Model)
+	assert.Len(t, (*requests)[0].Tools, 1)
+	require.Len(t, resp.Choices[0].Message.ToolCalls, 1)
+	assert.Equal(t, "call_abc", resp.Choices[0].Message.ToolCalls[0].ID)
+}
+
+func TestOllamaJSONToolCallFallback(t *testing.T) {
+	adapter, requests := newOllamaServer(t, "gemma:2b", func(w http.ResponseWriter, req openai.ChatCompletionRequest) {
+		if rejectTools(w, req) {
+			return
+		}
+		writeChatResponse(w, "I will check.\n```json\n{\"tool_calls\": [{\"name\": \"get_pod_logs\", \"arguments\": {\"name\": \"web-1\"}}]}\n```")
+	})
+
+	resp, err := adapter.ChatCompletion(context.Background(), testConversation, testTools)
+	require.NoError(t, err)
+
+	// The rejected request is retried without tools, which are described
+	// in the system prompt instead
+	require.Len(t, *requests, 2)
+	retry := (*requests)[1]
+	assert.Empty(t, retry.Tools)
+	require.Len(t, retry.Messages, len(testConversation))
+	assert.Equal(t, openai.ChatMessageRoleSystem, retry.Messages[0].Role)
+	assert.True(t, strings.HasPrefix(retry.Messages[0].Content, "You are a Kubernetes assistant."))
+	assert.Contains(t, retry.Messages[0].Content, "- get_pod_logs: Get the logs of a pod")
+	assert.Equal(t, openai.ChatMessageRoleAssistant, retry.Messages[2].Role)
+	assert.Empty(t, retry.Messages[2].ToolCalls)
+	assert.Contains(t, retry.Messages[2].Content, `{"tool_calls":[{"name":"get_pod_logs","arguments":{"name":"web-1"}}`)
+	assert.Equal(t, openai.ChatMessageRoleUser, retry.Messages[3].Role)
+	assert.Equal(t, "Result of tool get_pod_logs:\npanic: out of memory", retry.Messages[3].Content)
+
+	choice := resp.Choices[0]
+	assert.Equal(t, openai.FinishReasonToolCalls, choice.FinishReason)
+	assert.Equal(t, "I will check.", choice.Message.Content)
+	require.Len(t, choice.Message.ToolCalls, 1)
+	assert.Equal(t, "get_pod_logs", choice.Message.ToolCalls[0].Function.Name)
+	assert.JSONEq(t, `{"name":"web-1"}`, choice.Message.ToolCalls[0].Function.Arguments)
+
+	// The model is remembered as one without tool calling
+	_, err = adapter.ChatCompletion(context.Background(), testConversation[:2], testTools)
+	require.NoError(t, err)
+	require.Len(t, *requests, 3)
+	assert.Empty(t, (*requests)[2].Tools)
+}
+
+func TestOllamaJSONToolCallStream(t *testing.T) {
+	answer := []string{"Checking the pod."}
+	adapter, _ := newOllamaServer(t, "phi3:mini", func(w http.ResponseWriter, req openai.ChatCompletionRequest) {
+		if rejectTools(w, req) {
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, content := range answer {
+			chunk, _ := json.Marshal(openai.ChatCompletionStreamResponse{
+				ID:      "chatcmpl-2",
+				Choices: []openai.ChatCompletionStreamChoice{{Delta: openai.ChatCompletionStreamChoiceDelta{Content: content}}},
+			})
+			_, _ = fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+		_, _ = fmt.Fprint(w, "data: [DONE]\n\n")
+	})
+
+	collect := func() (string, []openai.ToolCall, int) {
+		stream, err := adapter.ChatCompletionStream(context.Background(), testConversation[:2], testTools)
+		require.NoError(t, err)
+		var content string
+		var toolCalls []openai.ToolCall
+		chunks := 0
+		for chunk := range stream {
+			chunks++
+			content += chunk.Choices[0].Delta.Content
+			toolCalls = append(toolCalls, chunk.Choices[0].Delta.ToolCalls...)
+		}
+		return content, toolCalls, chunks
+	}
+
+	// Text answers are streamed as they arrive
+	answer = []string{"\n", "The pod ", "is healthy."}
+	content, toolCalls, chunks := collect()
+	assert.Equal(t, "\nThe pod is healthy.", content)
+	assert.Empty(t, toolCalls)
+	assert.Equal(t, 2, chunks)
+
+	// JSON answers are sent as tool calls
+	answer = []string{" {\"tool_calls\": [", "{\"name\": \"get_pod_logs\", \"arguments\": \"{\\\"name\\\": \\\"web-1\\\"}\"},", "{\"name\": \"list_pods\"}]}"}
+	content, toolCalls, _ = collect()
+	assert.Empty(t, content)
+	require.Len(t, toolCalls, 2)
+	require.NotNil(t, toolCalls[1].Index)
+	assert.Equal(t, 1, *toolCalls[1].Index)
+	assert.Equal(t, "get_pod_logs", toolCalls[0].Function.Name)
+	assert.JSONEq(t, `{"name":"web-1"}`, toolCalls[0].Function.Arguments)
+	assert.Equal(t, "list_pods", toolCalls[1].Function.Name)
+	assert.Equal(t, "{}", toolCalls[1].Function.Arguments)
+
+	// Code blocks that are not tool calls are sent as text
+	answer = []string{"```yaml\n", "kind: Pod\n```"}
+	content, toolCalls, _ = collect()
+	assert.Equal(t, "```yaml\nkind: Pod\n```", content)
+	assert.Empty(t, toolCalls)
+}
+
+func TestOllamaListModels(t *testing.T) {
+	openAIModels, tags := true, true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/v1/models" && openAIModels:
+			_, _ = w.Write([]byte(`{"object":"list","data":[{"id":"qwen3:8b"},{"id":"llama3.1:latest"}]}`))
+		case r.URL.Path == "/api/tags" && !tags:
+			w.WriteHeader(http.StatusBadGateway)
+		case r.URL.Path == "/api/tags":
+			_, _ = w.Write([]byte(`{"models":[{"name":"mistral:7b"}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(&AIConfig{Provider: "ollama", BaseURL: server.URL + "/v1/"})
+	require.NoError(t, err)
+	lister, ok := client.(ModelLister)
+	require.True(t, ok)
+
+	models, err := lister.ListModels(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"llama3.1:latest", "qwen3:8b"}, models)
+
+	openAIModels = false
+	models, err = lister.ListModels(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"mistral:7b"}, models)
+
+	// The error reports why the fallback failed
+	tags = false
+	_, err = lister.ListModels(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "/api/tags returned status 502")
+}
+
+func TestParseJSONToolCalls(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		content string
+		calls   []string
+	}{
+		{name: "plain text", text: "The pod is healthy.", content: "The pod is healthy."},
+		{name: "json without calls", text: `{"status": "ok"}`, content: `{"status": "ok"}`},
+		{name: "invalid json", text: `{"tool_calls": [`, content: `{"tool_calls": [`},
+		{name: "list", text: `{"tool_calls": [{"name": "a"}, {"name": "b", "arguments": {"x": 1}}]}`, calls: []string{"a:{}", `b:{"x": 1}`}},
+		{name: "single call", text: `{"name": "a", "arguments": {"x": 1}}`, calls: []string{`a:{"x": 1}`}},
+		{name: "fenced with text", text: "Let me look.\n```\n{\"name\": \"a\"}\n```", content: "Let me look.", calls: []string{"a:{}"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, toolCalls := parseJSONToolCalls(tt.text)
+			assert.Equal(t, tt.content, content)
+			var calls []string
+			for _, tc := range toolCalls {
+				calls = append(calls, tc.Function.Name+":"+tc.Function.Arguments)
+			}
+			assert.Equal(t, tt.calls, calls)
+		})
+	}
+}
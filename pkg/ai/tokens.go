@@ -0,0 +1,116 @@
+package ai
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+const (
+	// defaultContextWindow is used for models of unknown size
+	defaultContextWindow = 16385
+	// ollamaContextWindow is the context self-hosted runtimes are usually
+	// started with, their models often support more than the server serves
+	ollamaContextWindow = 8192
+	// messageOverheadTokens is the role and separators of a message
+	messageOverheadTokens = 4
+)
+
+// modelContextWindows are the context sizes of model families, matched by
+// prefix of the model name in order
+var modelContextWindows = []struct {
+	prefix string
+	tokens int
+}{
+	{"gpt-4.1", 1047576},
+	{"gpt-4o", 128000},
+	{"gpt-4-turbo", 128000},
+	{"gpt-4", 8192},
+	{"gpt-5", 400000},
+	{"gpt-3.5-turbo", 16385},
+	{"o1", 200000},
+	{"o3", 200000},
+	{"o4", 200000},
+	{"claude", 200000},
+	{"gemini-1.5-pro", 2097152},
+	{"gemini", 1048576},
+}
+
+// providerContextWindows are the context sizes of providers whose model is
+// not known
+var providerContextWindows = map[string]int{
+	"google":    1048576,
+	"gemini":    1048576,
+	"anthropic": 200000,
+	"claude":    200000,
+	"ollama":    ollamaContextWindow,
+}
+
+// ContextWindow returns the number of tokens the model of config accepts.
+// A context window set in the profile takes precedence.
+func ContextWindow(config *AIConfig) int {
+	if config.ContextWindow > 0 {
+		return config.ContextWindow
+	}
+	if config.Provider == "ollama" {
+		return ollamaContextWindow
+	}
+
+	modelName := strings.ToLower(config.Model)
+	if modelName == "" {
+		modelName = strings.ToLower(config.DefaultModel)
+	}
+	for _, w := range modelContextWindows {
+		if strings.HasPrefix(modelName, w.prefix) {
+			return w.tokens
+		}
+	}
+	if tokens, ok := providerContextWindows[config.Provider]; ok {
+		return tokens
+	}
+	return defaultContextWindow
+}
+
+// EstimateTokens estimates the tokens of text. Tokenizers differ between
+// providers, about four characters per token is close for English text,
+// code and JSON alike.
+func EstimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + 3) / 4
+}
+
+// EstimateMessageTokens estimates the tokens of messages including their
+// tool calls
+func EstimateMessageTokens(messages []openai.ChatCompletionMessage) int {
+	total := 0
+	for _, m := range messages {
+		total += messageOverheadTokens + EstimateTokens(m.Content)
+		for _, tc := range m.ToolCalls {
+			total += EstimateTokens(tc.ID) + EstimateTokens(tc.Function.Name) + EstimateTokens(tc.Function.Arguments)
+		}
+	}
+	return total
+}
+
+// ToolResultBudget returns the tokens a single tool result may take of a
+// context window
+func ToolResultBudget(contextWindow int) int {
+	return min(max(contextWindow/8, 1000), 16000)
+}
+
+// TruncateToolResult shortens content to about maxTokens by keeping its
+// beginning and end, which hold the headers and the latest lines of logs
+// and lists
+func TruncateToolResult(content string, maxTokens int) string {
+	if EstimateTokens(content) <= maxTokens {
+		return content
+	}
+
+	runes := []rune(content)
+	keep := maxTokens * 4
+	head := runes[:keep*2/3]
+	tail := runes[len(runes)-(keep-len(head)):]
+	return fmt.Sprintf("%s\n\n... [%d characters truncated to fit the context window, ask for a narrower query to see them] ...\n\n%s",
+		string(head), len(runes)-keep, string(tail))
+}
@@ -0,0 +1,50 @@
+package ai
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextWindow(t *testing.T) {
+	tests := []struct {
+		config AIConfig
+		want   int
+	}{
+		{AIConfig{Provider: "openai", Model: "gpt-4o-mini"}, 128000},
+		{AIConfig{Provider: "openai", DefaultModel: "gpt-4"}, 8192},
+		{AIConfig{Provider: "openai", Model: "GPT-4.1", DefaultModel: "gpt-4"}, 1047576},
+		{AIConfig{Provider: "anthropic", Model: "claude-sonnet-4-5"}, 200000},
+		{AIConfig{Provider: "gemini", Model: "my-tuned-model"}, 1048576},
+		{AIConfig{Provider: "ollama", Model: "llama3.1:70b"}, ollamaContextWindow},
+		{AIConfig{Provider: "azure", Model: "prod-deployment"}, defaultContextWindow},
+		{AIConfig{Provider: "ollama", Model: "llama3.1:70b", ContextWindow: 32768}, 32768},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, ContextWindow(&tt.config), "%+v", tt.config)
+	}
+}
+
+func TestTruncateToolResult(t *testing.T) {
+	short := "pod web-1 is running"
+	assert.Equal(t, short, TruncateToolResult(short, 100))
+
+	var logs strings.Builder
+	for i := 0; i < 2000; i++ {
+		logs.WriteString("2026-01-01T00:00:00Z INFO request handled\n")
+	}
+	logs.WriteString("panic: out of memory")
+
+	truncated := TruncateToolResult(logs.String(), 1000)
+	assert.True(t, strings.HasPrefix(truncated, "2026-01-01T00:00:00Z INFO"))
+	assert.True(t, strings.HasSuffix(truncated, "panic: out of memory"))
+	assert.Contains(t, truncated, "characters truncated")
+	assert.InDelta(t, 1000, EstimateTokens(truncated), 50)
+}
+
+func TestToolResultBudget(t *testing.T) {
+	assert.Equal(t, 1000, ToolResultBudget(4096))
+	assert.Equal(t, 16000, ToolResultBudget(128000))
+	assert.Equal(t, 16000, ToolResultBudget(1048576))
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/pixelvide/kube-sentinel/pkg/common"
 	openai "github.com/sashabaranov/go-openai"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -46,6 +47,17 @@ func (t *AnalyzeSecurityTool) Definition() openai.Tool {
 	}
 }
 
+func (t *AnalyzeSecurityTool) Permissions(args string) ([]Permission, error) {
+	var params struct {
+		Namespace string `json:"namespace"`
+		Kind      string `json:"kind"`
+	}
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return nil, err
+	}
+	return []Permission{{Resource: kindResource(params.Kind), Verb: common.VerbGet, Namespace: params.Namespace}}, nil
+}
+
 func (t *AnalyzeSecurityTool) Execute(ctx context.Context, args string) (string, error) {
 	var params struct {
 		Namespace string `json:"namespace"`
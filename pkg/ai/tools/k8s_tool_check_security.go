@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/pixelvide/kube-sentinel/pkg/common"
 	"github.com/pixelvide/kube-sentinel/pkg/model"
 	openai "github.com/sashabaranov/go-openai"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -55,6 +56,17 @@ func (t *CheckImageSecurityTool) Definition() openai.Tool {
 	}
 }
 
+func (t *CheckImageSecurityTool) Permissions(args string) ([]Permission, error) {
+	var params struct {
+		Namespace string `json:"namespace"`
+		Kind      string `json:"kind"`
+	}
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return nil, err
+	}
+	return []Permission{{Resource: kindResource(params.Kind), Verb: common.VerbGet, Namespace: params.Namespace}}, nil
+}
+
 func (t *CheckImageSecurityTool) Execute(ctx context.Context, args string) (string, error) {
 	var params struct {
 		Namespace string `json:"namespace"`
@@ -10,6 +10,7 @@ import (
 	"strings"
 
 	"github.com/pixelvide/kube-sentinel/pkg/cluster"
+	"github.com/pixelvide/kube-sentinel/pkg/common"
 	openai "github.com/sashabaranov/go-openai"
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
@@ -44,6 +45,29 @@ func (t *DebugAppConnectionTool) Definition() openai.Tool {
 	}
 }
 
+func (t *DebugAppConnectionTool) Permissions(args string) ([]Permission, error) {
+	var params struct {
+		Query     string `json:"query"`
+		Namespace string `json:"namespace"`
+	}
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return nil, err
+	}
+	// The target namespace is only known once the query is resolved, so
+	// everything the report reads is checked in the requested namespace
+	perms := []Permission{
+		{Resource: "services", Verb: common.VerbGet, Namespace: params.Namespace},
+		{Resource: "endpoints", Verb: common.VerbGet, Namespace: params.Namespace},
+		{Resource: "pods", Verb: common.VerbGet, Namespace: params.Namespace},
+		{Resource: "pods/log", Verb: common.VerbLog, Namespace: params.Namespace},
+	}
+	if strings.Contains(params.Query, ".") {
+		// URLs are resolved through the ingresses of all namespaces
+		perms = append(perms, Permission{Resource: "ingresses", Verb: common.VerbGet})
+	}
+	return perms, nil
+}
+
 func (t *DebugAppConnectionTool) Execute(ctx context.Context, args string) (string, error) {
 	var params struct {
 		Query     string `json:"query"`
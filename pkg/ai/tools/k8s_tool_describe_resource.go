@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/pixelvide/kube-sentinel/pkg/common"
 	openai "github.com/sashabaranov/go-openai"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -42,6 +43,17 @@ func (t *DescribeResourceTool) Definition() openai.Tool {
 	}
 }
 
+func (t *DescribeResourceTool) Permissions(args string) ([]Permission, error) {
+	var params struct {
+		Namespace string `json:"namespace"`
+		Kind      string `json:"kind"`
+	}
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return nil, err
+	}
+	return []Permission{{Resource: kindResource(params.Kind), Verb: common.VerbGet, Namespace: params.Namespace}}, nil
+}
+
 func (t *DescribeResourceTool) Execute(ctx context.Context, args string) (string, error) {
 	var params struct {
 		Namespace string `json:"namespace"`
@@ -0,0 +1,106 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/pixelvide/kube-sentinel/pkg/analyzer"
+	"github.com/pixelvide/kube-sentinel/pkg/model"
+	"github.com/pixelvide/kube-sentinel/pkg/rbac"
+	openai "github.com/sashabaranov/go-openai"
+	"gorm.io/gorm"
+)
+
+type GetAnalysisScoresTool struct{}
+
+func (t *GetAnalysisScoresTool) Name() string { return "get_analysis_scores" }
+
+// Permissions is empty, the scores come from the stored analysis and Execute
+// leaves out the namespaces the user cannot access
+func (t *GetAnalysisScoresTool) Permissions(args string) ([]Permission, error) {
+	return []Permission{}, nil
+}
+
+func (t *GetAnalysisScoresTool) Definition() openai.Tool {
+	return openai.Tool{
+		Type: openai.ToolTypeFunction,
+		Function: &openai.FunctionDefinition{
+			Name:        "get_analysis_scores",
+			Description: "Get the health score (0-100) of the cluster and its namespaces from the latest background analysis, with the sub-score of each category (security, reliability, resources, topology, hygiene) and the weakest area.",
+			Parameters: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"namespace": {
+						"type": "string",
+						"description": "Only report this namespace. If empty, reports the cluster and all namespaces."
+					}
+				}
+			}`),
+		},
+	}
+}
+
+func formatRollup(sb *strings.Builder, subject string, r analyzer.ScoreRollup) {
+	sb.WriteString(fmt.Sprintf("- %s (%d resources)\n", r.Describe(subject), r.Resources))
+	parts := make([]string, 0, len(r.Categories))
+	for _, c := range r.Categories {
+		parts = append(parts, fmt.Sprintf("%s %d", c.Category, c.Score))
+	}
+	sb.WriteString(fmt.Sprintf("  categories: %s\n", strings.Join(parts, ", ")))
+}
+
+func (t *GetAnalysisScoresTool) Execute(ctx context.Context, args string) (string, error) {
+	var params struct {
+		Namespace string `json:"namespace"`
+	}
+	if args != "" {
+		if err := json.Unmarshal([]byte(args), &params); err != nil {
+			return "", err
+		}
+	}
+
+	cs, err := GetClientSet(ctx)
+	if err != nil {
+		return "", err
+	}
+	user, err := GetUser(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	scan, err := model.LatestAnalysisScan(cs.Name)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Sprintf("No analysis scan has completed for cluster %s yet.", cs.Name), nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to load analysis scan: %w", err)
+	}
+	scores, err := model.ListNamespaceScores(cs.Name)
+	if err != nil {
+		return "", fmt.Errorf("failed to load namespace scores: %w", err)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Analysis scores from the scan at %s:\n", scan.ScannedAt.Format("2006-01-02 15:04 MST")))
+	if params.Namespace == "" {
+		formatRollup(&sb, "cluster "+cs.Name, analyzer.RollupFromModel(scan.Score, scan.Resources, scan.CategoryScores))
+	}
+	found := false
+	for _, s := range scores {
+		if params.Namespace != "" && s.Namespace != params.Namespace {
+			continue
+		}
+		if !rbac.CanAccessNamespace(*user, cs.Name, s.Namespace) {
+			continue
+		}
+		found = true
+		formatRollup(&sb, "namespace "+s.Namespace, analyzer.RollupFromModel(s.Score, s.Resources, s.CategoryScores))
+	}
+	if params.Namespace != "" && !found {
+		return fmt.Sprintf("No analysis score found for namespace %s.", params.Namespace), nil
+	}
+	return sb.String(), nil
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/pixelvide/kube-sentinel/pkg/common"
 	openai "github.com/sashabaranov/go-openai"
 	corev1 "k8s.io/api/core/v1"
 )
@@ -25,6 +26,13 @@ func (t *GetClusterInfoTool) Definition() openai.Tool {
 	}
 }
 
+func (t *GetClusterInfoTool) Permissions(args string) ([]Permission, error) {
+	return []Permission{
+		{Resource: "nodes", Verb: common.VerbGet},
+		{Resource: "pods", Verb: common.VerbGet},
+	}, nil
+}
+
 func (t *GetClusterInfoTool) Execute(ctx context.Context, args string) (string, error) {
 	cs, err := GetClientSet(ctx)
 	if err != nil {
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/pixelvide/kube-sentinel/pkg/common"
 	openai "github.com/sashabaranov/go-openai"
 	corev1 "k8s.io/api/core/v1"
 )
@@ -45,6 +46,16 @@ func (t *GetPodLogsTool) Definition() openai.Tool {
 	}
 }
 
+func (t *GetPodLogsTool) Permissions(args string) ([]Permission, error) {
+	var params struct {
+		Namespace string `json:"namespace"`
+	}
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return nil, err
+	}
+	return []Permission{{Resource: "pods/log", Verb: common.VerbLog, Namespace: params.Namespace}}, nil
+}
+
 func (t *GetPodLogsTool) Execute(ctx context.Context, args string) (string, error) {
 	var params struct {
 		Namespace string `json:"namespace"`
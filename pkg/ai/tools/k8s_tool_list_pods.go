@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/pixelvide/kube-sentinel/pkg/common"
 	openai "github.com/sashabaranov/go-openai"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -44,6 +45,16 @@ func (t *ListPodsTool) Definition() openai.Tool {
 	}
 }
 
+func (t *ListPodsTool) Permissions(args string) ([]Permission, error) {
+	var params struct {
+		Namespace string `json:"namespace"`
+	}
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return nil, err
+	}
+	return []Permission{{Resource: "pods", Verb: common.VerbGet, Namespace: params.Namespace}}, nil
+}
+
 func (t *ListPodsTool) Execute(ctx context.Context, args string) (string, error) {
 	var params struct {
 		Namespace    string `json:"namespace"`
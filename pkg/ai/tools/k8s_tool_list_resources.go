@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/pixelvide/kube-sentinel/pkg/cluster"
+	"github.com/pixelvide/kube-sentinel/pkg/common"
 	"github.com/pixelvide/kube-sentinel/pkg/helm"
 	openai "github.com/sashabaranov/go-openai"
 	appsv1 "k8s.io/api/apps/v1"
@@ -62,6 +63,28 @@ func (t *ListResourcesTool) Definition() openai.Tool {
 	}
 }
 
+// defaultListKinds are searched when no kind is given
+var defaultListKinds = []string{"pod", "service", "deployment", "ingress"}
+
+func (t *ListResourcesTool) Permissions(args string) ([]Permission, error) {
+	var params struct {
+		Kind      string `json:"kind"`
+		Namespace string `json:"namespace"`
+	}
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return nil, err
+	}
+	kinds := []string{params.Kind}
+	if params.Kind == "" {
+		kinds = defaultListKinds
+	}
+	perms := make([]Permission, 0, len(kinds))
+	for _, k := range kinds {
+		perms = append(perms, Permission{Resource: kindResource(k), Verb: common.VerbGet, Namespace: params.Namespace})
+	}
+	return perms, nil
+}
+
 func (t *ListResourcesTool) Execute(ctx context.Context, args string) (string, error) {
 	var params struct {
 		Kind          string `json:"kind"`
@@ -87,9 +110,8 @@ func (t *ListResourcesTool) Execute(ctx context.Context, args string) (string, e
 
 	if kind == "" {
 		// Default search across common kinds
-		commonKinds := []string{"pod", "service", "deployment", "ingress"}
 		var combinedResults []string
-		for _, k := range commonKinds {
+		for _, k := range defaultListKinds {
 			res, err := t.listByKind(ctx, cs, k, params.Namespace, params.NameFilter, opts)
 			if err == nil && len(res) > 0 {
 				combinedResults = append(combinedResults, fmt.Sprintf("--- %s ---", strings.ToUpper(k)))
@@ -5,6 +5,9 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/pixelvide/kube-sentinel/pkg/audit"
+	"github.com/pixelvide/kube-sentinel/pkg/cluster"
+	"github.com/pixelvide/kube-sentinel/pkg/common"
 	"github.com/pixelvide/kube-sentinel/pkg/model"
 	openai "github.com/sashabaranov/go-openai"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -22,7 +25,7 @@ func (t *ScaleDeploymentTool) Definition() openai.Tool {
 		Type: openai.ToolTypeFunction,
 		Function: &openai.FunctionDefinition{
 			Name:        "scale_deployment",
-			Description: "Scale a deployment to a specified number of replicas. Requires confirmation.",
+			Description: "Scale a deployment to a specified number of replicas. The user is asked to approve the change before it is made.",
 			Parameters: json.RawMessage(`{
 				"type": "object",
 				"properties": {
@@ -37,10 +40,6 @@ func (t *ScaleDeploymentTool) Definition() openai.Tool {
 					"replicas": {
 						"type": "integer",
 						"description": "The target number of replicas."
-					},
-					"confirm": {
-						"type": "boolean",
-						"description": "Set to true to actually execute the scaling. Defaults to false (dry-run)."
 					}
 				},
 				"required": ["namespace", "name", "replicas"]
@@ -49,41 +48,80 @@ func (t *ScaleDeploymentTool) Definition() openai.Tool {
 	}
 }
 
-func (t *ScaleDeploymentTool) Execute(ctx context.Context, args string) (string, error) {
+func (t *ScaleDeploymentTool) Permissions(args string) ([]Permission, error) {
 	var params struct {
 		Namespace string `json:"namespace"`
-		Name      string `json:"name"`
-		Replicas  int32  `json:"replicas"`
-		Confirm   bool   `json:"confirm"`
 	}
 	if err := json.Unmarshal([]byte(args), &params); err != nil {
-		return "", err
+		return nil, err
+	}
+	return []Permission{{Resource: "deployments", Verb: common.VerbUpdate, Namespace: params.Namespace}}, nil
+}
+
+type scaleDeploymentParams struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Replicas  int32  `json:"replicas"`
+}
+
+func parseScaleDeploymentParams(args string) (scaleDeploymentParams, error) {
+	var params scaleDeploymentParams
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return params, err
 	}
 	if params.Replicas < 0 {
-		return "", fmt.Errorf("replicas cannot be negative")
+		return params, fmt.Errorf("replicas cannot be negative")
 	}
+	return params, nil
+}
+
+// deploymentReplicas returns the replicas a deployment is scaled to
+func deploymentReplicas(ctx context.Context, cs *cluster.ClientSet, namespace, name string) (int32, error) {
+	deploy, err := cs.K8sClient.ClientSet.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get deployment: %w", err)
+	}
+	if deploy.Spec.Replicas == nil {
+		return 0, nil
+	}
+	return *deploy.Spec.Replicas, nil
+}
 
+func (t *ScaleDeploymentTool) Preview(ctx context.Context, args string) (string, error) {
+	params, err := parseScaleDeploymentParams(args)
+	if err != nil {
+		return "", err
+	}
 	cs, err := GetClientSet(ctx)
 	if err != nil {
 		return "", err
 	}
+	current, err := deploymentReplicas(ctx, cs, params.Namespace, params.Name)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Deployment %s/%s on cluster %s\n- replicas: %d\n+ replicas: %d",
+		params.Namespace, params.Name, cs.Name, current, params.Replicas), nil
+}
 
-	deployClient := cs.K8sClient.ClientSet.AppsV1().Deployments(params.Namespace)
-
-	// Always get first to check existence
-	deploy, err := deployClient.Get(ctx, params.Name, metav1.GetOptions{})
+// Execute scales the deployment. The chat only calls it once the user
+// approved the call.
+func (t *ScaleDeploymentTool) Execute(ctx context.Context, args string) (string, error) {
+	params, err := parseScaleDeploymentParams(args)
 	if err != nil {
-		return "", fmt.Errorf("failed to get deployment: %w", err)
+		return "", err
 	}
 
-	currentReplicas := int32(0)
-	if deploy.Spec.Replicas != nil {
-		currentReplicas = *deploy.Spec.Replicas
+	cs, err := GetClientSet(ctx)
+	if err != nil {
+		return "", err
 	}
 
-	if !params.Confirm {
-		return fmt.Sprintf("Dry run: Deployment '%s/%s' currently has %d replicas. Would scale to %d. To execute, call this tool again with 'confirm' set to true.",
-			params.Namespace, params.Name, currentReplicas, params.Replicas), nil
+	deployClient := cs.K8sClient.ClientSet.AppsV1().Deployments(params.Namespace)
+
+	currentReplicas, err := deploymentReplicas(ctx, cs, params.Namespace, params.Name)
+	if err != nil {
+		return "", err
 	}
 
 	var finalErr error
@@ -91,42 +129,23 @@ func (t *ScaleDeploymentTool) Execute(ctx context.Context, args string) (string,
 		// Audit Log
 		user, err := GetUser(ctx)
 		if err == nil && user != nil {
-			errMsg := ""
-			if finalErr != nil {
-				errMsg = finalErr.Error()
-			}
-			payload := map[string]interface{}{
-				"clusterName":      cs.Name,
-				"resourceType":     "deployments",
-				"resourceName":     params.Name,
-				"namespace":        params.Namespace,
-				"action":           "scale",
-				"previousReplicas": currentReplicas,
-				"targetReplicas":   params.Replicas,
-			}
-
-			// Add AI context if available
-			sessionID := GetSessionID(ctx)
-			if sessionID != "" {
-				payload["source"] = "ai"
-				payload["chatSessionId"] = sessionID
-			}
-
-			payloadBytes, err := json.Marshal(payload)
-			if err != nil {
-				payloadBytes = []byte("{}")
-			}
-
-			model.DB.Create(&model.AuditLog{
-				AppID:        model.CurrentApp.ID,
-				Action:       "scale",
-				ActorID:      user.ID,
-				Payload:      string(payloadBytes),
-				Success:      finalErr == nil,
-				ErrorMessage: errMsg,
+			audit.Emit(audit.Entry{
+				Action:        "scale",
+				ActorID:       user.ID,
+				ClusterName:   cs.Name,
+				ResourceType:  "deployments",
+				ResourceName:  params.Name,
+				Namespace:     params.Namespace,
+				Source:        model.AuditSourceAI,
+				ChatSessionID: GetSessionID(ctx),
+				Payload: map[string]interface{}{
+					"action":           "scale",
+					"previousReplicas": currentReplicas,
+					"targetReplicas":   params.Replicas,
+				},
 				// IP and UserAgent are not easily available here without threading more context,
 				// but ActorID is the most important.
-			})
+			}.WithError(finalErr))
 		}
 	}()
 
@@ -15,6 +15,12 @@ type KnowledgeTool struct{}
 
 func (t *KnowledgeTool) Name() string { return "manage_knowledge" }
 
+// Permissions is empty, the knowledge base is stored by kube-sentinel and
+// not in the cluster
+func (t *KnowledgeTool) Permissions(args string) ([]Permission, error) {
+	return []Permission{}, nil
+}
+
 func (t *KnowledgeTool) Definition() openai.Tool {
 	return openai.Tool{
 		Type: openai.ToolTypeFunction,
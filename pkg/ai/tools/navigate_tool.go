@@ -14,6 +14,12 @@ type NavigateToTool struct{}
 
 func (t *NavigateToTool) Name() string { return "navigate_to" }
 
+// Permissions is empty, navigating only changes the page in the browser of
+// the user, where the page itself checks access
+func (t *NavigateToTool) Permissions(args string) ([]Permission, error) {
+	return []Permission{}, nil
+}
+
 func (t *NavigateToTool) Definition() openai.Tool {
 	return openai.Tool{
 		Type: openai.ToolTypeFunction,
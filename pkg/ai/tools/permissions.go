@@ -0,0 +1,109 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pixelvide/kube-sentinel/pkg/common"
+	"github.com/pixelvide/kube-sentinel/pkg/kube"
+	"github.com/pixelvide/kube-sentinel/pkg/rbac"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+)
+
+// Permission is a kube-sentinel RBAC check a tool call has to pass. An empty
+// namespace means all namespaces. Resources backed by a subresource, such as
+// logs, are named "pods/log" so roles on the subresource apply.
+type Permission struct {
+	Resource  string
+	Verb      common.Verb
+	Namespace string
+}
+
+// Authorizer declares the permissions of a tool call. Registry.Execute
+// checks them for the calling user before the tool runs, and denies calls of
+// tools that do not implement it. Tools that touch no cluster resources
+// return an empty list.
+type Authorizer interface {
+	Permissions(args string) ([]Permission, error)
+}
+
+// authorize returns a tool result explaining why the call is denied, or an
+// empty string when the caller may run it
+func authorize(ctx context.Context, tool Tool, args string) (string, error) {
+	a, ok := tool.(Authorizer)
+	if !ok {
+		klog.Errorf("AI tool %s declares no permissions, denying the call", tool.Name())
+		return fmt.Sprintf("Permission denied: the %s tool declares no permissions and was not executed.", tool.Name()), nil
+	}
+	perms, err := a.Permissions(args)
+	if err != nil {
+		return "", err
+	}
+	if len(perms) == 0 {
+		return "", nil
+	}
+
+	user, err := GetUser(ctx)
+	if err != nil {
+		return fmt.Sprintf("Permission denied: %s requires an authenticated user.", tool.Name()), nil
+	}
+	cs, err := GetClientSet(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, p := range perms {
+		if err := rbac.CheckAccess(*user, p.Resource, string(p.Verb), cs.Name, p.Namespace); err != nil {
+			klog.Infof("AI tool %s denied for user %s: %v", tool.Name(), user.Key(), err)
+			return fmt.Sprintf("Permission denied: %v. The %s tool was not executed; tell the user they need a role granting this access instead of retrying.", err, tool.Name()), nil
+		}
+	}
+	return "", nil
+}
+
+// kindAliases maps kubectl short names to resource names
+var kindAliases = map[string]string{
+	"po":     "pods",
+	"svc":    "services",
+	"deploy": "deployments",
+	"sts":    "statefulsets",
+	"ds":     "daemonsets",
+	"rs":     "replicasets",
+	"cj":     "cronjobs",
+	"ing":    "ingresses",
+	"netpol": "networkpolicies",
+	"cm":     "configmaps",
+	"ns":     "namespaces",
+	"no":     "nodes",
+	"ep":     "endpoints",
+	"ev":     "events",
+	"sa":     "serviceaccounts",
+	"pv":     "persistentvolumes",
+	"pvc":    "persistentvolumeclaims",
+	"sc":     "storageclasses",
+	"hpa":    "horizontalpodautoscalers",
+	"pdb":    "poddisruptionbudgets",
+	"crd":    "customresourcedefinitions",
+}
+
+// kindResource converts a kind as the model passes it (Deployment,
+// deployments, deploy) to the plural resource name RBAC roles use
+func kindResource(kind string) string {
+	k := strings.ToLower(kind)
+	if r, ok := kindAliases[k]; ok {
+		return r
+	}
+	for gvk := range kube.GetScheme().AllKnownTypes() {
+		plural, _ := meta.UnsafeGuessKindToResource(gvk)
+		if strings.ToLower(gvk.Kind) == k || plural.Resource == k {
+			return plural.Resource
+		}
+	}
+	if strings.HasSuffix(k, "s") {
+		return k
+	}
+	plural, _ := meta.UnsafeGuessKindToResource(schema.GroupVersionKind{Kind: k})
+	return plural.Resource
+}
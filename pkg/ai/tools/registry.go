@@ -13,6 +13,22 @@ type Tool interface {
 	Name() string
 }
 
+// Mutator is implemented by tools that change resources. The chat runs
+// their calls only after the user approved them, showing the preview of the
+// change.
+type Mutator interface {
+	// Preview describes the change a call would make without making it
+	Preview(ctx context.Context, args string) (string, error)
+}
+
+// DeniedError is returned by Registry.Preview when the caller may not make
+// a call. Reason is meant as the tool result.
+type DeniedError struct {
+	Reason string
+}
+
+func (e *DeniedError) Error() string { return e.Reason }
+
 type Registry struct {
 	tools map[string]Tool
 }
@@ -35,10 +51,46 @@ func (r *Registry) GetDefinitions() []openai.Tool {
 	return defs
 }
 
+// RequiresApproval reports whether the calls of a tool change resources
+// and have to be approved by the user before they run
+func (r *Registry) RequiresApproval(name string) bool {
+	_, ok := r.tools[name].(Mutator)
+	return ok
+}
+
+// Preview describes the change a call of a mutating tool would make. The
+// permissions are checked first, a DeniedError is returned when the caller
+// may not make the call.
+func (r *Registry) Preview(ctx context.Context, name string, args string) (string, error) {
+	tool, ok := r.tools[name]
+	if !ok {
+		return "", fmt.Errorf("tool %s not found", name)
+	}
+	m, ok := tool.(Mutator)
+	if !ok {
+		return "", fmt.Errorf("tool %s does not change resources", name)
+	}
+	denied, err := authorize(ctx, tool, args)
+	if err != nil {
+		return "", err
+	}
+	if denied != "" {
+		return "", &DeniedError{Reason: denied}
+	}
+	return m.Preview(ctx, args)
+}
+
 func (r *Registry) Execute(ctx context.Context, name string, args string) (string, error) {
 	tool, ok := r.tools[name]
 	if !ok {
 		return "", fmt.Errorf("tool %s not found", name)
 	}
+	denied, err := authorize(ctx, tool, args)
+	if err != nil {
+		return "", err
+	}
+	if denied != "" {
+		return denied, nil
+	}
 	return tool.Execute(ctx, args)
 }
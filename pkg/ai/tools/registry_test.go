@@ -4,6 +4,9 @@ import (
 	"context"
 	"testing"
 
+	"github.com/pixelvide/kube-sentinel/pkg/cluster"
+	"github.com/pixelvide/kube-sentinel/pkg/common"
+	"github.com/pixelvide/kube-sentinel/pkg/model"
 	openai "github.com/sashabaranov/go-openai"
 	"github.com/stretchr/testify/assert"
 )
@@ -22,6 +25,9 @@ func (t *MockTool) Definition() openai.Tool {
 func (t *MockTool) Execute(ctx context.Context, args string) (string, error) {
 	return "executed " + args, nil
 }
+func (t *MockTool) Permissions(args string) ([]Permission, error) {
+	return []Permission{}, nil
+}
 
 func TestRegistry(t *testing.T) {
 	r := NewRegistry()
@@ -39,3 +45,132 @@ func TestRegistry(t *testing.T) {
 	_, err = r.Execute(context.Background(), "unknown", "test")
 	assert.Error(t, err)
 }
+
+// undeclaredTool declares no permissions
+type undeclaredTool struct{}
+
+func (t *undeclaredTool) Name() string            { return "undeclared" }
+func (t *undeclaredTool) Definition() openai.Tool { return openai.Tool{} }
+func (t *undeclaredTool) Execute(ctx context.Context, args string) (string, error) {
+	return "executed", nil
+}
+
+func TestRegistryDeniesToolsWithoutPermissions(t *testing.T) {
+	r := NewRegistry()
+	r.Register(&undeclaredTool{})
+
+	res, err := r.Execute(context.Background(), "undeclared", "")
+	assert.NoError(t, err)
+	assert.Contains(t, res, "Permission denied")
+}
+
+type scaleMockTool struct{ MockTool }
+
+func (t *scaleMockTool) Name() string { return "scale_mock" }
+func (t *scaleMockTool) Permissions(args string) ([]Permission, error) {
+	return []Permission{{Resource: "deployments", Verb: common.VerbUpdate, Namespace: args}}, nil
+}
+
+func TestRegistryAuthorization(t *testing.T) {
+	r := NewRegistry()
+	r.Register(&scaleMockTool{})
+
+	user := &model.User{Username: "dev", Roles: []common.Role{{
+		Name:       "dev-editor",
+		Clusters:   []string{"dev-cluster"},
+		Namespaces: []string{"dev"},
+		Resources:  []string{"deployments"},
+		Verbs:      []string{"get", "update"},
+	}}}
+	ctx := context.WithValue(context.Background(), ClientSetKey{}, &cluster.ClientSet{Name: "dev-cluster"})
+
+	res, err := r.Execute(ctx, "scale_mock", "dev")
+	assert.NoError(t, err)
+	assert.Contains(t, res, "Permission denied", "calls without a user are denied")
+
+	ctx = context.WithValue(ctx, UserKey{}, user)
+	res, err = r.Execute(ctx, "scale_mock", "dev")
+	assert.NoError(t, err)
+	assert.Equal(t, "executed dev", res)
+
+	res, err = r.Execute(ctx, "scale_mock", "prod")
+	assert.NoError(t, err)
+	assert.Contains(t, res, "user dev does not have permission to update deployments in namespace prod on cluster dev-cluster")
+
+	res, err = r.Execute(ctx, "scale_mock", "")
+	assert.NoError(t, err)
+	assert.Contains(t, res, "in namespace All", "an empty namespace needs access to all namespaces")
+}
+
+type mutatingMockTool struct{ scaleMockTool }
+
+func (t *mutatingMockTool) Name() string { return "mutating_mock" }
+func (t *mutatingMockTool) Preview(ctx context.Context, args string) (string, error) {
+	return "would change " + args, nil
+}
+
+func TestRegistryPreview(t *testing.T) {
+	r := NewRegistry()
+	r.Register(&MockTool{})
+	r.Register(&mutatingMockTool{})
+	assert.False(t, r.RequiresApproval("mock_tool"))
+	assert.True(t, r.RequiresApproval("mutating_mock"))
+	assert.False(t, r.RequiresApproval("unknown"))
+	r.Register(&ScaleDeploymentTool{})
+	assert.True(t, r.RequiresApproval("scale_deployment"))
+
+	user := &model.User{Username: "dev", Roles: []common.Role{{
+		Name:       "dev-editor",
+		Clusters:   []string{"dev-cluster"},
+		Namespaces: []string{"dev"},
+		Resources:  []string{"deployments"},
+		Verbs:      []string{"update"},
+	}}}
+	ctx := context.WithValue(context.Background(), ClientSetKey{}, &cluster.ClientSet{Name: "dev-cluster"})
+	ctx = context.WithValue(ctx, UserKey{}, user)
+
+	preview, err := r.Preview(ctx, "mutating_mock", "dev")
+	assert.NoError(t, err)
+	assert.Equal(t, "would change dev", preview)
+
+	_, err = r.Preview(ctx, "mutating_mock", "prod")
+	var denied *DeniedError
+	if assert.ErrorAs(t, err, &denied) {
+		assert.Contains(t, denied.Reason, "does not have permission to update deployments in namespace prod")
+	}
+
+	_, err = r.Preview(ctx, "mock_tool", "dev")
+	assert.Error(t, err, "tools that do not change resources have no preview")
+}
+
+func TestKindResource(t *testing.T) {
+	for kind, want := range map[string]string{
+		"Pod":           "pods",
+		"pods":          "pods",
+		"deploy":        "deployments",
+		"Ingress":       "ingresses",
+		"NetworkPolicy": "networkpolicies",
+		"HTTPRoute":     "httproutes",
+		"Certificate":   "certificates",
+	} {
+		assert.Equal(t, want, kindResource(kind), kind)
+	}
+}
+
+func TestPodLogsPermission(t *testing.T) {
+	viewer := common.Role{Name: "viewer", Clusters: []string{"*"}, Namespaces: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"}}
+	noLogs := common.Role{Name: "no-logs", Effect: common.EffectDeny, Clusters: []string{"*"}, Namespaces: []string{"*"}, Resources: []string{"pods/log"}, Verbs: []string{"*"}}
+	logsOnly := common.Role{Name: "logs-only", Clusters: []string{"*"}, Namespaces: []string{"dev"}, Resources: []string{"pods/log"}, Verbs: []string{"log"}}
+	args := `{"namespace":"dev","pod_name":"web-1"}`
+	ctx := context.WithValue(context.Background(), ClientSetKey{}, &cluster.ClientSet{Name: "dev-cluster"})
+
+	for _, tool := range []Tool{&GetPodLogsTool{}, &DebugAppConnectionTool{}} {
+		denied, err := authorize(context.WithValue(ctx, UserKey{}, &model.User{Username: "dev", Roles: []common.Role{viewer, noLogs}}), tool, args)
+		assert.NoError(t, err)
+		assert.Contains(t, denied, "Permission denied", "%s: a deny role on pods/log blocks the logs", tool.Name())
+	}
+
+	denied, err := authorize(context.WithValue(ctx, UserKey{}, &model.User{Username: "dev", Roles: []common.Role{logsOnly}}), &GetPodLogsTool{}, args)
+	assert.NoError(t, err)
+	assert.Empty(t, denied, "a role granting pods/log allows the logs")
+}
@@ -1,9 +1,12 @@
 package ai
 
 type AIConfig struct {
-	Provider     string
-	APIKey       string
-	BaseURL      string
-	Model        string
-	DefaultModel string
+	ProfileID     uint // AIProviderProfile the config was resolved from
+	Provider      string
+	APIKey        string
+	BaseURL       string
+	Model         string
+	DefaultModel  string
+	APIVersion    string // Azure OpenAI api-version
+	ContextWindow int    // Tokens the model accepts, 0 to look up by model
 }
@@ -0,0 +1,284 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pixelvide/kube-sentinel/pkg/kube"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gatewayapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+// internalLoadBalancerAnnotations mark cloud load balancers that are only
+// reachable from the private network
+var internalLoadBalancerAnnotations = map[string]string{
+	"service.beta.kubernetes.io/aws-load-balancer-internal":          "true",
+	"service.beta.kubernetes.io/aws-load-balancer-scheme":            "internal",
+	"service.beta.kubernetes.io/azure-load-balancer-internal":        "true",
+	"networking.gke.io/load-balancer-type":                           "Internal",
+	"cloud.google.com/load-balancer-type":                            "Internal",
+	"service.beta.kubernetes.io/oci-load-balancer-internal":          "true",
+	"service.kubernetes.io/ibm-load-balancer-cloud-provider-ip-type": "private",
+}
+
+// systemNamespaces are not expected to carry NetworkPolicies
+var systemNamespaces = map[string]bool{
+	"kube-system":     true,
+	"kube-public":     true,
+	"kube-node-lease": true,
+}
+
+type ServiceExposureAnalyzer struct{}
+
+func (a *ServiceExposureAnalyzer) Name() string { return "ServiceExposure" }
+
+func (a *ServiceExposureAnalyzer) Analyze(ctx context.Context, c client.Client, obj client.Object) ([]Anomaly, error) {
+	svc, ok := obj.(*corev1.Service)
+	if !ok {
+		return nil, nil
+	}
+
+	switch svc.Spec.Type {
+	case corev1.ServiceTypeLoadBalancer:
+		for k, v := range internalLoadBalancerAnnotations {
+			if strings.EqualFold(svc.Annotations[k], v) {
+				return nil, nil
+			}
+		}
+		if len(svc.Spec.LoadBalancerSourceRanges) > 0 {
+			return nil, nil
+		}
+		return []Anomaly{
+			{
+				Severity:    SeverityHigh,
+				Title:       "Unrestricted LoadBalancer Service",
+				Message:     fmt.Sprintf("LoadBalancer Service %s accepts traffic from any source address.", svc.Name),
+				Remediation: "Set spec.loadBalancerSourceRanges to the client CIDRs that need access, or make the load balancer internal.",
+				RuleID:      "NET-001",
+			},
+		}, nil
+	case corev1.ServiceTypeNodePort:
+		ports := make([]string, 0, len(svc.Spec.Ports))
+		for _, p := range svc.Spec.Ports {
+			if p.NodePort != 0 {
+				ports = append(ports, fmt.Sprintf("%d", p.NodePort))
+			}
+		}
+		return []Anomaly{
+			{
+				Severity:    SeverityMedium,
+				Title:       "NodePort Service Exposed on Every Node",
+				Message:     fmt.Sprintf("NodePort Service %s opens port(s) %s on every node without source restrictions.", svc.Name, strings.Join(ports, ", ")),
+				Remediation: "Prefer a ClusterIP Service behind an Ingress or Gateway, or restrict the node ports with firewall rules or a NetworkPolicy.",
+				RuleID:      "NET-001",
+			},
+		}, nil
+	}
+	return nil, nil
+}
+
+type IngressTLSAnalyzer struct{}
+
+func (a *IngressTLSAnalyzer) Name() string { return "IngressTLS" }
+
+func (a *IngressTLSAnalyzer) Analyze(ctx context.Context, c client.Client, obj client.Object) ([]Anomaly, error) {
+	ing, ok := obj.(*networkingv1.Ingress)
+	if !ok {
+		return nil, nil
+	}
+
+	if len(ing.Spec.TLS) == 0 {
+		services := kube.IngressServices(ing.Namespace, ing)
+		names := make([]string, 0, len(services))
+		for _, s := range services {
+			names = append(names, s.Name)
+		}
+		return []Anomaly{
+			{
+				Severity:    SeverityMedium,
+				Title:       "Ingress Without TLS",
+				Message:     fmt.Sprintf("Ingress %s serves plain HTTP to service(s) %s.", ing.Name, strings.Join(names, ", ")),
+				Remediation: "Add a spec.tls section with a certificate Secret (for example issued by cert-manager) for every host.",
+				RuleID:      "NET-002",
+			},
+		}, nil
+	}
+
+	covered := map[string]bool{}
+	for _, tls := range ing.Spec.TLS {
+		for _, h := range tls.Hosts {
+			covered[h] = true
+		}
+	}
+	var plain []string
+	for _, rule := range ing.Spec.Rules {
+		if rule.Host != "" && !covered[rule.Host] {
+			plain = append(plain, rule.Host)
+		}
+	}
+	if len(plain) > 0 {
+		return []Anomaly{
+			{
+				Severity:    SeverityMedium,
+				Title:       "Ingress Without TLS",
+				Message:     fmt.Sprintf("Ingress %s has no TLS for host(s) %s.", ing.Name, strings.Join(plain, ", ")),
+				Remediation: "List every host of the Ingress under spec.tls.hosts.",
+				RuleID:      "NET-002",
+			},
+		}, nil
+	}
+	return nil, nil
+}
+
+// isDefaultDenyIngress reports whether a policy selects every pod of its
+// namespace and allows no ingress traffic
+func isDefaultDenyIngress(np networkingv1.NetworkPolicy) bool {
+	if len(np.Spec.PodSelector.MatchLabels) > 0 || len(np.Spec.PodSelector.MatchExpressions) > 0 {
+		return false
+	}
+	appliesToIngress := len(np.Spec.PolicyTypes) == 0
+	for _, t := range np.Spec.PolicyTypes {
+		if t == networkingv1.PolicyTypeIngress {
+			appliesToIngress = true
+		}
+	}
+	return appliesToIngress && len(np.Spec.Ingress) == 0
+}
+
+type NetworkPolicyCoverageAnalyzer struct{}
+
+func (a *NetworkPolicyCoverageAnalyzer) Name() string { return "NetworkPolicyCoverage" }
+
+func (a *NetworkPolicyCoverageAnalyzer) Analyze(ctx context.Context, c client.Client, obj client.Object) ([]Anomaly, error) {
+	var podLabels map[string]string
+	var kind string
+	switch o := obj.(type) {
+	case *corev1.Pod:
+		podLabels, kind = o.Labels, "Pod"
+	case *appsv1.Deployment:
+		podLabels, kind = o.Spec.Template.Labels, "Deployment"
+	case *appsv1.StatefulSet:
+		podLabels, kind = o.Spec.Template.Labels, "StatefulSet"
+	case *appsv1.DaemonSet:
+		podLabels, kind = o.Spec.Template.Labels, "DaemonSet"
+	default:
+		return nil, nil
+	}
+
+	var npList networkingv1.NetworkPolicyList
+	if err := c.List(ctx, &npList, client.InNamespace(obj.GetNamespace())); err != nil {
+		return nil, err
+	}
+
+	var defaultDeny string
+	for _, np := range npList.Items {
+		if isDefaultDenyIngress(np) {
+			defaultDeny = np.Name
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(&np.Spec.PodSelector)
+		if err != nil {
+			continue
+		}
+		if selector.Matches(labels.Set(podLabels)) {
+			return nil, nil
+		}
+	}
+	if defaultDeny == "" {
+		return nil, nil
+	}
+
+	return []Anomaly{
+		{
+			Severity:    SeverityLow,
+			Title:       "Pods Not Selected by Any NetworkPolicy",
+			Message:     fmt.Sprintf("The pods of this %s are only covered by the default-deny policy %s, so all ingress traffic to them is blocked.", kind, defaultDeny),
+			Remediation: "Add a NetworkPolicy that selects these pods and allows the traffic they need, or remove the workload if it should not receive traffic.",
+			RuleID:      "NET-003",
+		},
+	}, nil
+}
+
+type NamespaceNetworkPolicyAnalyzer struct{}
+
+func (a *NamespaceNetworkPolicyAnalyzer) Name() string { return "NamespaceNetworkPolicy" }
+
+func (a *NamespaceNetworkPolicyAnalyzer) Analyze(ctx context.Context, c client.Client, obj client.Object) ([]Anomaly, error) {
+	ns, ok := obj.(*corev1.Namespace)
+	if !ok || systemNamespaces[ns.Name] {
+		return nil, nil
+	}
+
+	var npList networkingv1.NetworkPolicyList
+	if err := c.List(ctx, &npList, client.InNamespace(ns.Name), client.Limit(1)); err != nil {
+		return nil, err
+	}
+	if len(npList.Items) > 0 {
+		return nil, nil
+	}
+	return []Anomaly{
+		{
+			Severity:    SeverityMedium,
+			Title:       "Namespace Without NetworkPolicies",
+			Message:     fmt.Sprintf("Namespace %s has no NetworkPolicies, so every pod accepts traffic from anywhere in the cluster.", ns.Name),
+			Remediation: "Add a default-deny NetworkPolicy to the namespace and explicit allow policies for the traffic its workloads need.",
+			RuleID:      "NET-004",
+		},
+	}, nil
+}
+
+type HTTPRouteBackendAnalyzer struct{}
+
+func (a *HTTPRouteBackendAnalyzer) Name() string { return "HTTPRouteBackend" }
+
+func (a *HTTPRouteBackendAnalyzer) Analyze(ctx context.Context, c client.Client, obj client.Object) ([]Anomaly, error) {
+	route, ok := obj.(*gatewayapiv1.HTTPRoute)
+	if !ok {
+		return nil, nil
+	}
+
+	var missing []string
+	seen := map[string]bool{}
+	for _, ref := range kube.HTTPRouteRelatedResources(route, route.Namespace) {
+		key := ref.Namespace + "/" + ref.Name
+		if ref.Type != "services" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		var svc corev1.Service
+		err := c.Get(ctx, types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}, &svc)
+		if errors.IsNotFound(err) {
+			missing = append(missing, key)
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	if len(missing) == 0 {
+		return nil, nil
+	}
+	return []Anomaly{
+		{
+			Severity:    SeverityHigh,
+			Title:       "HTTPRoute Backend Service Missing",
+			Message:     fmt.Sprintf("HTTPRoute %s routes traffic to Service(s) that do not exist: %s.", route.Name, strings.Join(missing, ", ")),
+			Remediation: "Create the missing Services or fix the backendRefs; requests matched by these rules fail with 500 errors.",
+			RuleID:      "NET-005",
+		},
+	}, nil
+}
+
+func init() {
+	Register(&ServiceExposureAnalyzer{})
+	Register(&IngressTLSAnalyzer{})
+	Register(&NetworkPolicyCoverageAnalyzer{})
+	Register(&NamespaceNetworkPolicyAnalyzer{})
+	Register(&HTTPRouteBackendAnalyzer{})
+}
@@ -0,0 +1,183 @@
+package analyzer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pixelvide/kube-sentinel/pkg/kube"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	gatewayapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+func newExposureClient(objs ...client.Object) client.Client {
+	return fake.NewClientBuilder().WithScheme(kube.GetScheme()).WithObjects(objs...).Build()
+}
+
+func TestServiceExposureAnalyzer(t *testing.T) {
+	a := &ServiceExposureAnalyzer{}
+	tests := []struct {
+		name     string
+		svc      *corev1.Service
+		severity AnomalySeverity
+	}{
+		{
+			name: "open load balancer",
+			svc: &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "web"},
+				Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+			},
+			severity: SeverityHigh,
+		},
+		{
+			name: "restricted load balancer",
+			svc: &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "web"},
+				Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer, LoadBalancerSourceRanges: []string{"10.0.0.0/8"}},
+			},
+		},
+		{
+			name: "internal load balancer",
+			svc: &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Annotations: map[string]string{"networking.gke.io/load-balancer-type": "Internal"}},
+				Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+			},
+		},
+		{
+			name: "node port",
+			svc: &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "web"},
+				Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeNodePort, Ports: []corev1.ServicePort{{NodePort: 30080}}},
+			},
+			severity: SeverityMedium,
+		},
+		{
+			name: "cluster ip",
+			svc:  &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "web"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			anomalies, err := a.Analyze(context.Background(), nil, tt.svc)
+			require.NoError(t, err)
+			if tt.severity == "" {
+				assert.Empty(t, anomalies)
+				return
+			}
+			require.Len(t, anomalies, 1)
+			assert.Equal(t, tt.severity, anomalies[0].Severity)
+			assert.Equal(t, "NET-001", anomalies[0].RuleID)
+		})
+	}
+}
+
+func TestIngressTLSAnalyzer(t *testing.T) {
+	a := &IngressTLSAnalyzer{}
+	ing := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec: networkingv1.IngressSpec{
+			Rules: []networkingv1.IngressRule{
+				{Host: "a.example.com"},
+				{Host: "b.example.com"},
+			},
+			DefaultBackend: &networkingv1.IngressBackend{Service: &networkingv1.IngressServiceBackend{Name: "frontend"}},
+		},
+	}
+
+	anomalies, err := a.Analyze(context.Background(), nil, ing)
+	require.NoError(t, err)
+	require.Len(t, anomalies, 1)
+	assert.Contains(t, anomalies[0].Message, "plain HTTP to service(s) frontend")
+
+	ing.Spec.TLS = []networkingv1.IngressTLS{{Hosts: []string{"a.example.com"}}}
+	anomalies, err = a.Analyze(context.Background(), nil, ing)
+	require.NoError(t, err)
+	require.Len(t, anomalies, 1)
+	assert.Contains(t, anomalies[0].Message, "no TLS for host(s) b.example.com")
+
+	ing.Spec.TLS[0].Hosts = append(ing.Spec.TLS[0].Hosts, "b.example.com")
+	anomalies, err = a.Analyze(context.Background(), nil, ing)
+	require.NoError(t, err)
+	assert.Empty(t, anomalies)
+}
+
+func TestNetworkPolicyAnalyzers(t *testing.T) {
+	denyAll := &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "default-deny", Namespace: "shop"},
+		Spec:       networkingv1.NetworkPolicySpec{PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress}},
+	}
+	allowWeb := &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "allow-web", Namespace: "shop"},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+			Ingress:     []networkingv1.NetworkPolicyIngressRule{{}},
+		},
+	}
+	web := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop"},
+		Spec:       appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "web"}}}},
+	}
+	worker := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "worker", Namespace: "shop", Labels: map[string]string{"app": "worker"}}}
+	c := newExposureClient(denyAll, allowWeb)
+
+	coverage := &NetworkPolicyCoverageAnalyzer{}
+	anomalies, err := coverage.Analyze(context.Background(), c, web)
+	require.NoError(t, err)
+	assert.Empty(t, anomalies)
+
+	anomalies, err = coverage.Analyze(context.Background(), c, worker)
+	require.NoError(t, err)
+	require.Len(t, anomalies, 1)
+	assert.Equal(t, "NET-003", anomalies[0].RuleID)
+	assert.Contains(t, anomalies[0].Message, "default-deny policy default-deny")
+
+	// Without a default-deny every pod is reachable, which NET-004 covers
+	c = newExposureClient(allowWeb)
+	anomalies, err = coverage.Analyze(context.Background(), c, worker)
+	require.NoError(t, err)
+	assert.Empty(t, anomalies)
+
+	namespaces := &NamespaceNetworkPolicyAnalyzer{}
+	anomalies, err = namespaces.Analyze(context.Background(), c, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shop"}})
+	require.NoError(t, err)
+	assert.Empty(t, anomalies)
+
+	anomalies, err = namespaces.Analyze(context.Background(), c, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "billing"}})
+	require.NoError(t, err)
+	require.Len(t, anomalies, 1)
+	assert.Equal(t, "NET-004", anomalies[0].RuleID)
+
+	anomalies, err = namespaces.Analyze(context.Background(), c, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}})
+	require.NoError(t, err)
+	assert.Empty(t, anomalies)
+}
+
+func TestHTTPRouteBackendAnalyzer(t *testing.T) {
+	otherNS := gatewayapiv1.Namespace("payments")
+	route := &gatewayapiv1.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "shop"},
+		Spec: gatewayapiv1.HTTPRouteSpec{
+			CommonRouteSpec: gatewayapiv1.CommonRouteSpec{ParentRefs: []gatewayapiv1.ParentReference{{Name: "public"}}},
+			Rules: []gatewayapiv1.HTTPRouteRule{
+				{BackendRefs: []gatewayapiv1.HTTPBackendRef{
+					{BackendRef: gatewayapiv1.BackendRef{BackendObjectReference: gatewayapiv1.BackendObjectReference{Name: "frontend"}}},
+					{BackendRef: gatewayapiv1.BackendRef{BackendObjectReference: gatewayapiv1.BackendObjectReference{Name: "checkout", Namespace: &otherNS}}},
+				}},
+			},
+		},
+	}
+	c := newExposureClient(&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "frontend", Namespace: "shop"}})
+
+	anomalies, err := (&HTTPRouteBackendAnalyzer{}).Analyze(context.Background(), c, route)
+	require.NoError(t, err)
+	require.Len(t, anomalies, 1)
+	assert.Equal(t, SeverityHigh, anomalies[0].Severity)
+	assert.Contains(t, anomalies[0].Message, "do not exist: payments/checkout")
+	assert.NotContains(t, anomalies[0].Message, "frontend")
+}
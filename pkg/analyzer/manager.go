@@ -2,8 +2,12 @@ package analyzer
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"sync"
+	"time"
 
+	"github.com/pixelvide/kube-sentinel/pkg/common"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -19,42 +23,35 @@ func Register(a Analyzer) {
 	analyzers = append(analyzers, a)
 }
 
-func Analyze(ctx context.Context, k8sClient client.Client, obj client.Object) *ResourceAnalysis {
+// Analyze runs all registered analyzers concurrently against an object of
+// the given cluster. Analyzers that fail or exceed common.AnalyzerTimeout are
+// reported in Errors. Anomalies silenced by a suppression or the ignore-rules
+// annotation are reported in Suppressed and do not affect the score.
+func Analyze(ctx context.Context, clusterName string, k8sClient client.Client, obj client.Object) *ResourceAnalysis {
 	mu.RLock()
-	defer mu.RUnlock()
+	registered := make([]Analyzer, len(analyzers))
+	copy(registered, analyzers)
+	mu.RUnlock()
 
-	var anomalies []Anomaly
-	for _, a := range analyzers {
-		results, err := a.Analyze(ctx, k8sClient, obj)
-		if err != nil {
-			klog.Errorf("Analyzer %s failed: %v", a.Name(), err)
-			continue
+	anomalies, errs := runAnalyzers(ctx, registered, common.AnalyzerTimeout, k8sClient, obj)
+	for i := range anomalies {
+		if anomalies[i].Category == "" {
+			anomalies[i].Category = RuleCategory(anomalies[i].RuleID)
 		}
-		anomalies = append(anomalies, results...)
 	}
-
+	anomalies, suppressed := applySuppressions(clusterName, obj, anomalies)
 	analysis := &ResourceAnalysis{
-		Anomalies: anomalies,
-		Score:     100, // Placeholder
+		Anomalies:  anomalies,
+		Suppressed: suppressed,
+		Errors:     errs,
 	}
+	analysis.Score, analysis.Categories, analysis.Explanation = scoreAnomalies(anomalies)
 
 	if len(anomalies) > 0 {
-		analysis.Summary = "Anomalies detected"
-		// Simple score reduction logic
-		for _, anomaly := range anomalies {
-			switch anomaly.Severity {
-			case SeverityCritical:
-				analysis.Score -= 20
-			case SeverityHigh:
-				analysis.Score -= 10
-			case SeverityMedium:
-				analysis.Score -= 5
-			case SeverityLow:
-				analysis.Score -= 2
-			}
-		}
-		if analysis.Score < 0 {
-			analysis.Score = 0
+		if weakest := WeakestCategory(analysis.Categories); weakest != "" {
+			analysis.Summary = fmt.Sprintf("Anomalies detected, %s is the weakest area", weakest)
+		} else {
+			analysis.Summary = "Anomalies detected"
 		}
 	} else {
 		analysis.Summary = "No anomalies detected"
@@ -62,3 +59,77 @@ func Analyze(ctx context.Context, k8sClient client.Client, obj client.Object) *R
 
 	return analysis
 }
+
+type analyzerResult struct {
+	anomalies []Anomaly
+	err       error
+}
+
+// runAnalyzers runs the analyzers on their own goroutines, each with its own
+// deadline, and returns their anomalies in registration order. An analyzer
+// that ignores its context is abandoned once the deadline passes.
+func runAnalyzers(ctx context.Context, list []Analyzer, timeout time.Duration, k8sClient client.Client, obj client.Object) ([]Anomaly, []AnalyzerError) {
+	results := make([][]Anomaly, len(list))
+	errs := make([]*AnalyzerError, len(list))
+
+	var wg sync.WaitGroup
+	for i, a := range list {
+		wg.Add(1)
+		go func(i int, a Analyzer) {
+			defer wg.Done()
+			actx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			start := time.Now()
+			done := make(chan analyzerResult, 1)
+			go func() {
+				defer func() {
+					if r := recover(); r != nil {
+						done <- analyzerResult{err: fmt.Errorf("panic: %v", r)}
+					}
+				}()
+				anomalies, err := a.Analyze(actx, k8sClient, obj)
+				done <- analyzerResult{anomalies: anomalies, err: err}
+			}()
+
+			var res analyzerResult
+			select {
+			case res = <-done:
+			case <-actx.Done():
+				res.err = actx.Err()
+			}
+
+			outcome := outcomeOK
+			switch {
+			case errors.Is(res.err, context.DeadlineExceeded):
+				outcome = outcomeTimeout
+				errs[i] = &AnalyzerError{Analyzer: a.Name(), Error: fmt.Sprintf("timed out after %s", timeout), TimedOut: true}
+			case res.err != nil:
+				outcome = outcomeError
+				errs[i] = &AnalyzerError{Analyzer: a.Name(), Error: res.err.Error()}
+			default:
+				results[i] = res.anomalies
+			}
+			analyzerDurationSeconds.WithLabelValues(a.Name(), outcome).Observe(time.Since(start).Seconds())
+			if res.err != nil {
+				klog.Warningf("Analyzer %s failed on %s/%s: %v", a.Name(), obj.GetNamespace(), obj.GetName(), res.err)
+			}
+		}(i, a)
+	}
+	wg.Wait()
+
+	var anomalies []Anomaly
+	for _, r := range results {
+		anomalies = append(anomalies, r...)
+	}
+	for _, a := range anomalies {
+		analyzerFindingsTotal.WithLabelValues(a.RuleID, string(a.Severity)).Inc()
+	}
+	var failed []AnalyzerError
+	for _, e := range errs {
+		if e != nil {
+			failed = append(failed, *e)
+		}
+	}
+	return anomalies, failed
+}
@@ -0,0 +1,71 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type fakeAnalyzer struct {
+	name    string
+	delay   time.Duration
+	err     error
+	ruleIDs []string
+	// ignoreContext makes the analyzer sleep through its deadline
+	ignoreContext bool
+}
+
+func (f *fakeAnalyzer) Name() string { return f.name }
+
+func (f *fakeAnalyzer) Analyze(ctx context.Context, c client.Client, obj client.Object) ([]Anomaly, error) {
+	if f.ignoreContext {
+		time.Sleep(f.delay)
+	} else {
+		select {
+		case <-time.After(f.delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	var anomalies []Anomaly
+	for _, id := range f.ruleIDs {
+		anomalies = append(anomalies, Anomaly{RuleID: id, Severity: SeverityLow})
+	}
+	return anomalies, nil
+}
+
+func TestRunAnalyzers(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"}}
+	list := []Analyzer{
+		&fakeAnalyzer{name: "slow-ok", delay: 50 * time.Millisecond, ruleIDs: []string{"A-001"}},
+		&fakeAnalyzer{name: "fast-ok", ruleIDs: []string{"B-001", "B-002"}},
+		&fakeAnalyzer{name: "failing", err: errors.New("list failed")},
+		&fakeAnalyzer{name: "hanging", delay: time.Hour},
+		&fakeAnalyzer{name: "stubborn", delay: time.Second, ignoreContext: true},
+	}
+
+	start := time.Now()
+	anomalies, errs := runAnalyzers(context.Background(), list, 200*time.Millisecond, nil, pod)
+	assert.Less(t, time.Since(start), time.Second, "analyzers run concurrently and are abandoned at the deadline")
+
+	ids := make([]string, 0, len(anomalies))
+	for _, a := range anomalies {
+		ids = append(ids, a.RuleID)
+	}
+	assert.Equal(t, []string{"A-001", "B-001", "B-002"}, ids, "anomalies keep registration order")
+
+	assert.Equal(t, []AnalyzerError{
+		{Analyzer: "failing", Error: "list failed"},
+		{Analyzer: "hanging", Error: "timed out after 200ms", TimedOut: true},
+		{Analyzer: "stubborn", Error: "timed out after 200ms", TimedOut: true},
+	}, errs)
+}
@@ -0,0 +1,34 @@
+package analyzer
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	analyzerDurationSeconds = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "analyzer_duration_seconds",
+			Help:    "Time a single analyzer took to analyze one object.",
+			Buckets: []float64{.001, .005, .01, .05, .1, .25, .5, 1, 2.5, 5, 10},
+		},
+		[]string{"analyzer", "outcome"},
+	)
+
+	analyzerFindingsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "analyzer_findings_total",
+			Help: "Total number of anomalies reported by analyzers, by rule.",
+		},
+		[]string{"rule_id", "severity"},
+	)
+)
+
+// Analyzer outcomes used as metric label values
+const (
+	outcomeOK      = "ok"
+	outcomeError   = "error"
+	outcomeTimeout = "timeout"
+)
+
+func init() {
+	_ = prometheus.Register(analyzerDurationSeconds)
+	_ = prometheus.Register(analyzerFindingsTotal)
+}
@@ -0,0 +1,414 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// statusEventWindow is how far back events are counted for status findings
+const statusEventWindow = time.Hour
+
+// statusHit is one container or pod affected by a status check
+type statusHit struct {
+	pod         string
+	detail      string
+	remediation string
+}
+
+// podStatusAnalyzer is one member of the pod status family. It runs check on
+// the pod itself, or on every pod selected by a Deployment, StatefulSet or
+// DaemonSet, and reports at most one anomaly per object.
+type podStatusAnalyzer struct {
+	name     string
+	ruleID   string
+	title    string
+	severity AnomalySeverity
+	check    func(ctx context.Context, c client.Client, pod *corev1.Pod) ([]statusHit, error)
+}
+
+func (a *podStatusAnalyzer) Name() string { return a.name }
+
+func (a *podStatusAnalyzer) Analyze(ctx context.Context, c client.Client, obj client.Object) ([]Anomaly, error) {
+	pods, err := statusPods(ctx, c, obj)
+	if err != nil || len(pods) == 0 {
+		return nil, err
+	}
+
+	var hits []statusHit
+	affected := map[string]bool{}
+	for i := range pods {
+		podHits, err := a.check(ctx, c, &pods[i])
+		if err != nil {
+			return nil, err
+		}
+		for _, h := range podHits {
+			affected[h.pod] = true
+		}
+		hits = append(hits, podHits...)
+	}
+	if len(hits) == 0 {
+		return nil, nil
+	}
+
+	details := make([]string, 0, len(hits))
+	for _, h := range hits {
+		details = append(details, h.detail)
+	}
+	message := strings.Join(details, "; ")
+	if _, isPod := obj.(*corev1.Pod); !isPod {
+		message = fmt.Sprintf("%d of %d pods affected: %s", len(affected), len(pods), details[0])
+		if len(hits) > 1 {
+			message += fmt.Sprintf(" (and %d more)", len(hits)-1)
+		}
+	}
+	return []Anomaly{
+		{
+			Severity:    a.severity,
+			Title:       a.title,
+			Message:     message,
+			Remediation: hits[0].remediation,
+			RuleID:      a.ruleID,
+		},
+	}, nil
+}
+
+// statusPods returns the pods the status of an object depends on
+func statusPods(ctx context.Context, c client.Client, obj client.Object) ([]corev1.Pod, error) {
+	var selector *metav1.LabelSelector
+	switch o := obj.(type) {
+	case *corev1.Pod:
+		return []corev1.Pod{*o}, nil
+	case *appsv1.Deployment:
+		selector = o.Spec.Selector
+	case *appsv1.StatefulSet:
+		selector = o.Spec.Selector
+	case *appsv1.DaemonSet:
+		selector = o.Spec.Selector
+	default:
+		return nil, nil
+	}
+	if selector == nil {
+		return nil, nil
+	}
+	s, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil || s.Empty() {
+		return nil, nil
+	}
+	var podList corev1.PodList
+	if err := c.List(ctx, &podList, client.InNamespace(obj.GetNamespace()), client.MatchingLabelsSelector{Selector: s}); err != nil {
+		return nil, err
+	}
+	return podList.Items, nil
+}
+
+// eventsGetter is implemented by clients that list events from the API
+// server, such as kube.K8sClient
+type eventsGetter interface {
+	Events(namespace string) typedcorev1.EventInterface
+}
+
+// podEvents returns the events of a pod, newest first. They are listed from
+// the API server with an involvedObject field selector, as the events API
+// does. Without such a client the checks rely on the pod status alone.
+func podEvents(ctx context.Context, c client.Client, pod *corev1.Pod) ([]corev1.Event, error) {
+	getter, ok := c.(eventsGetter)
+	if !ok {
+		return nil, nil
+	}
+	eventList, err := getter.Events(pod.Namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: "involvedObject.kind=Pod,involvedObject.name=" + pod.Name,
+	})
+	if err != nil {
+		return nil, err
+	}
+	events := make([]corev1.Event, 0, len(eventList.Items))
+	for _, e := range eventList.Items {
+		if e.InvolvedObject.Kind == "Pod" && e.InvolvedObject.Name == pod.Name && (e.InvolvedObject.UID == "" || e.InvolvedObject.UID == pod.UID) {
+			events = append(events, e)
+		}
+	}
+	sort.Slice(events, func(i, j int) bool { return eventTime(events[i]).After(eventTime(events[j])) })
+	return events, nil
+}
+
+func eventTime(e corev1.Event) time.Time {
+	switch {
+	case !e.LastTimestamp.IsZero():
+		return e.LastTimestamp.Time
+	case e.Series != nil && !e.Series.LastObservedTime.IsZero():
+		return e.Series.LastObservedTime.Time
+	case !e.EventTime.IsZero():
+		return e.EventTime.Time
+	case !e.FirstTimestamp.IsZero():
+		return e.FirstTimestamp.Time
+	}
+	return e.CreationTimestamp.Time
+}
+
+func eventCount(e corev1.Event) int32 {
+	if e.Series != nil && e.Series.Count > 0 {
+		return e.Series.Count
+	}
+	return max(e.Count, 1)
+}
+
+// recentEvents counts the occurrences of events with the given reason in the
+// status event window and returns the newest one
+func recentEvents(events []corev1.Event, reason string) (int32, *corev1.Event) {
+	since := time.Now().Add(-statusEventWindow)
+	var count int32
+	var latest *corev1.Event
+	for i := range events {
+		e := &events[i]
+		if e.Reason != reason || eventTime(*e).Before(since) {
+			continue
+		}
+		count += eventCount(*e)
+		if latest == nil {
+			latest = e
+		}
+	}
+	return count, latest
+}
+
+func allContainerStatuses(pod *corev1.Pod) []corev1.ContainerStatus {
+	statuses := make([]corev1.ContainerStatus, 0, len(pod.Status.InitContainerStatuses)+len(pod.Status.ContainerStatuses))
+	statuses = append(statuses, pod.Status.InitContainerStatuses...)
+	return append(statuses, pod.Status.ContainerStatuses...)
+}
+
+func containerSpec(pod *corev1.Pod, name string) *corev1.Container {
+	for i := range pod.Spec.InitContainers {
+		if pod.Spec.InitContainers[i].Name == name {
+			return &pod.Spec.InitContainers[i]
+		}
+	}
+	for i := range pod.Spec.Containers {
+		if pod.Spec.Containers[i].Name == name {
+			return &pod.Spec.Containers[i]
+		}
+	}
+	return nil
+}
+
+func waitingReason(cs corev1.ContainerStatus, reasons ...string) (*corev1.ContainerStateWaiting, bool) {
+	if cs.State.Waiting == nil {
+		return nil, false
+	}
+	for _, r := range reasons {
+		if cs.State.Waiting.Reason == r {
+			return cs.State.Waiting, true
+		}
+	}
+	return nil, false
+}
+
+func checkCrashLoop(ctx context.Context, c client.Client, pod *corev1.Pod) ([]statusHit, error) {
+	var hits []statusHit
+	var events []corev1.Event
+	for _, cs := range allContainerStatuses(pod) {
+		if _, ok := waitingReason(cs, "CrashLoopBackOff"); !ok {
+			continue
+		}
+		if events == nil {
+			var err error
+			if events, err = podEvents(ctx, c, pod); err != nil {
+				return nil, err
+			}
+		}
+
+		detail := fmt.Sprintf("container %s of pod %s is in CrashLoopBackOff after %d restarts", cs.Name, pod.Name, cs.RestartCount)
+		if backoffs, _ := recentEvents(events, "BackOff"); backoffs > 0 {
+			detail += fmt.Sprintf(", back-off restarting reported %d times in the last hour", backoffs)
+		}
+		remediation := fmt.Sprintf("Inspect the logs of the previous run (kubectl logs %s -c %s --previous) to find why the process exits.", pod.Name, cs.Name)
+		if last := cs.LastTerminationState.Terminated; last != nil {
+			detail += fmt.Sprintf("; last exit code %d (%s)", last.ExitCode, last.Reason)
+			switch {
+			case last.Reason == "OOMKilled":
+				remediation = "The container is killed for exceeding its memory limit; raise the limit or reduce its memory usage."
+			case last.ExitCode == 0:
+				remediation = "The process exits successfully but the pod restarts it; make sure the main process keeps running in the foreground, or use a Job for run-to-completion work."
+			default:
+				remediation = fmt.Sprintf("The process exits with code %d. %s Check the command, arguments and the configuration it needs at startup.", last.ExitCode, remediation)
+			}
+		}
+		hits = append(hits, statusHit{pod: pod.Name, detail: detail, remediation: remediation})
+	}
+	return hits, nil
+}
+
+func checkOOMKilled(_ context.Context, _ client.Client, pod *corev1.Pod) ([]statusHit, error) {
+	since := time.Now().Add(-statusEventWindow)
+	var hits []statusHit
+	for _, cs := range allContainerStatuses(pod) {
+		// The status keeps only the current and the previous termination of
+		// a container, so the latest OOM kill among them within the window is
+		// reported together with the restart count rather than a kill count
+		var term *corev1.ContainerStateTerminated
+		for _, t := range []*corev1.ContainerStateTerminated{cs.State.Terminated, cs.LastTerminationState.Terminated} {
+			if t == nil || t.Reason != "OOMKilled" || t.FinishedAt.Time.Before(since) {
+				continue
+			}
+			if term == nil || t.FinishedAt.After(term.FinishedAt.Time) {
+				term = t
+			}
+		}
+		if term == nil {
+			continue
+		}
+
+		var limit string
+		if spec := containerSpec(pod, cs.Name); spec != nil {
+			if l, ok := spec.Resources.Limits[corev1.ResourceMemory]; ok {
+				limit = l.String()
+			}
+		}
+		when := term.FinishedAt.UTC().Format("2006-01-02 15:04 MST")
+		if limit == "" {
+			hits = append(hits, statusHit{
+				pod:         pod.Name,
+				detail:      fmt.Sprintf("container %s of pod %s without a memory limit was last OOM killed at %s (%d restarts)", cs.Name, pod.Name, when, cs.RestartCount),
+				remediation: "The node ran out of memory and killed the container. Set memory requests that match its real usage so the scheduler places it on a node with enough memory.",
+			})
+			continue
+		}
+		hits = append(hits, statusHit{
+			pod:         pod.Name,
+			detail:      fmt.Sprintf("memory limit %s of container %s in pod %s was exceeded, last OOM killed at %s (%d restarts)", limit, cs.Name, pod.Name, when, cs.RestartCount),
+			remediation: fmt.Sprintf("Raise the memory limit of container %s above %s, or reduce its memory usage; compare against the usage graph of the pod.", cs.Name, limit),
+		})
+	}
+	return hits, nil
+}
+
+func checkImagePull(ctx context.Context, c client.Client, pod *corev1.Pod) ([]statusHit, error) {
+	var hits []statusHit
+	var events []corev1.Event
+	for _, cs := range allContainerStatuses(pod) {
+		waiting, ok := waitingReason(cs, "ImagePullBackOff", "ErrImagePull", "InvalidImageName", "ErrImageNeverPull")
+		if !ok {
+			continue
+		}
+		if events == nil {
+			var err error
+			if events, err = podEvents(ctx, c, pod); err != nil {
+				return nil, err
+			}
+		}
+
+		reason := waiting.Message
+		if failures, latest := recentEvents(events, "Failed"); latest != nil {
+			reason = fmt.Sprintf("%s (failed %d times in the last hour)", latest.Message, failures)
+		}
+		detail := fmt.Sprintf("container %s of pod %s cannot pull image %s (%s)", cs.Name, pod.Name, cs.Image, waiting.Reason)
+		if reason != "" {
+			detail += ": " + reason
+		}
+
+		lower := strings.ToLower(reason)
+		var remediation string
+		switch {
+		case waiting.Reason == "InvalidImageName":
+			remediation = fmt.Sprintf("The image reference %s is malformed; fix the image name in the pod spec.", cs.Image)
+		case strings.Contains(lower, "not found") || strings.Contains(lower, "manifest unknown"):
+			remediation = fmt.Sprintf("The image %s does not exist in the registry; check the repository name and tag.", cs.Image)
+		case strings.Contains(lower, "unauthorized") || strings.Contains(lower, "denied") || strings.Contains(lower, "authentication"):
+			secrets := make([]string, 0, len(pod.Spec.ImagePullSecrets))
+			for _, s := range pod.Spec.ImagePullSecrets {
+				secrets = append(secrets, s.Name)
+			}
+			remediation = fmt.Sprintf("The registry rejected the pull; make sure imagePullSecrets (currently [%s]) hold valid credentials for it.", strings.Join(secrets, ", "))
+		case strings.Contains(lower, "no such host") || strings.Contains(lower, "timeout") || strings.Contains(lower, "i/o"):
+			remediation = "The registry is unreachable from the node; check DNS, proxy settings and egress firewall rules."
+		default:
+			remediation = "Check the image name and tag, and that imagePullSecrets grant access to the registry."
+		}
+		hits = append(hits, statusHit{pod: pod.Name, detail: detail, remediation: remediation})
+	}
+	return hits, nil
+}
+
+func checkContainerConfig(_ context.Context, _ client.Client, pod *corev1.Pod) ([]statusHit, error) {
+	var hits []statusHit
+	for _, cs := range allContainerStatuses(pod) {
+		waiting, ok := waitingReason(cs, "CreateContainerConfigError", "CreateContainerError", "RunContainerError")
+		if !ok {
+			continue
+		}
+		detail := fmt.Sprintf("container %s of pod %s cannot start (%s)", cs.Name, pod.Name, waiting.Reason)
+		if waiting.Message != "" {
+			detail += ": " + waiting.Message
+		}
+		remediation := "Fix the container configuration reported in the message (command, env, volume mounts or security context)."
+		if strings.Contains(strings.ToLower(waiting.Message), "not found") {
+			remediation = fmt.Sprintf("Create the ConfigMap or Secret named in the message in namespace %s, or fix the reference in env, envFrom or volumes.", pod.Namespace)
+		}
+		hits = append(hits, statusHit{pod: pod.Name, detail: detail, remediation: remediation})
+	}
+	return hits, nil
+}
+
+func checkFailedScheduling(ctx context.Context, c client.Client, pod *corev1.Pod) ([]statusHit, error) {
+	if pod.Status.Phase != corev1.PodPending {
+		return nil, nil
+	}
+	var condition *corev1.PodCondition
+	for i := range pod.Status.Conditions {
+		cond := &pod.Status.Conditions[i]
+		if cond.Type == corev1.PodScheduled && cond.Status == corev1.ConditionFalse {
+			condition = cond
+		}
+	}
+	if condition == nil {
+		return nil, nil
+	}
+
+	events, err := podEvents(ctx, c, pod)
+	if err != nil {
+		return nil, err
+	}
+	message := condition.Message
+	if _, latest := recentEvents(events, "FailedScheduling"); latest != nil {
+		message = latest.Message
+	}
+	detail := fmt.Sprintf("pod %s has been pending for %s and cannot be scheduled", pod.Name, time.Since(pod.CreationTimestamp.Time).Round(time.Minute))
+	if message != "" {
+		detail += ": " + message
+	}
+
+	lower := strings.ToLower(message)
+	var remediation string
+	switch {
+	case strings.Contains(lower, "insufficient"):
+		remediation = "No node has enough free capacity for the pod's resource requests; lower the requests or add nodes."
+	case strings.Contains(lower, "affinity") || strings.Contains(lower, "selector"):
+		remediation = "No node matches the pod's nodeSelector or affinity rules; check them against the node labels."
+	case strings.Contains(lower, "taint"):
+		remediation = "The nodes have taints the pod does not tolerate; add matching tolerations or schedule onto other nodes."
+	case strings.Contains(lower, "persistentvolumeclaim") || strings.Contains(lower, "volume"):
+		remediation = "A volume of the pod cannot be bound or attached; check the PVC status and its StorageClass."
+	case strings.Contains(lower, "too many pods"):
+		remediation = "The nodes reached their maximum pod count; add nodes or raise the kubelet maxPods setting."
+	default:
+		remediation = "Check the FailedScheduling events of the pod for the scheduler's reasoning."
+	}
+	return []statusHit{{pod: pod.Name, detail: detail, remediation: remediation}}, nil
+}
+
+func init() {
+	Register(&podStatusAnalyzer{name: "CrashLoopBackOff", ruleID: "POD-001", title: "Container in CrashLoopBackOff", severity: SeverityHigh, check: checkCrashLoop})
+	Register(&podStatusAnalyzer{name: "OOMKilled", ruleID: "POD-002", title: "Container OOM Killed", severity: SeverityHigh, check: checkOOMKilled})
+	Register(&podStatusAnalyzer{name: "ImagePullFailure", ruleID: "POD-003", title: "Image Pull Failure", severity: SeverityHigh, check: checkImagePull})
+	Register(&podStatusAnalyzer{name: "ContainerConfigError", ruleID: "POD-004", title: "Container Configuration Error", severity: SeverityHigh, check: checkContainerConfig})
+	Register(&podStatusAnalyzer{name: "FailedScheduling", ruleID: "POD-005", title: "Pod Cannot Be Scheduled", severity: SeverityHigh, check: checkFailedScheduling})
+}
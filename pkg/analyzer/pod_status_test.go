@@ -0,0 +1,171 @@
+package analyzer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// statusClient serves objects from a fake cached client and events from a
+// fake clientset, as kube.K8sClient does
+type statusClient struct {
+	client.Client
+	clientset *k8sfake.Clientset
+}
+
+func (c statusClient) Events(namespace string) typedcorev1.EventInterface {
+	return c.clientset.CoreV1().Events(namespace)
+}
+
+func newStatusClient(objs ...client.Object) client.Client {
+	var events []runtime.Object
+	var others []client.Object
+	for _, o := range objs {
+		if e, ok := o.(*corev1.Event); ok {
+			events = append(events, e)
+		} else {
+			others = append(others, o)
+		}
+	}
+	return statusClient{
+		Client:    fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(others...).Build(),
+		clientset: k8sfake.NewClientset(events...),
+	}
+}
+
+func statusAnalyzer(t *testing.T, ruleID string) Analyzer {
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, a := range analyzers {
+		if s, ok := a.(*podStatusAnalyzer); ok && s.ruleID == ruleID {
+			return s
+		}
+	}
+	t.Fatalf("analyzer for %s not registered", ruleID)
+	return nil
+}
+
+func TestOOMKilledAnalyzer(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "worker-1", Namespace: "jobs", Labels: map[string]string{"app": "worker"}},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name:      "app",
+			Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("128Mi")}},
+		}}},
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+			Name:                 "app",
+			RestartCount:         6,
+			LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "OOMKilled", ExitCode: 137, FinishedAt: metav1.NewTime(time.Now().Add(-10 * time.Minute))}},
+		}}},
+	}
+	healthy := pod.DeepCopy()
+	healthy.Name = "worker-2"
+	healthy.Status = corev1.PodStatus{}
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "worker", Namespace: "jobs"},
+		Spec:       appsv1.DeploymentSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "worker"}}},
+	}
+	c := newStatusClient(pod, healthy)
+	a := statusAnalyzer(t, "POD-002")
+
+	anomalies, err := a.Analyze(context.Background(), c, pod)
+	require.NoError(t, err)
+	require.Len(t, anomalies, 1)
+	assert.Contains(t, anomalies[0].Message, "memory limit 128Mi of container app in pod worker-1 was exceeded")
+	assert.Contains(t, anomalies[0].Message, "6 restarts")
+	assert.Contains(t, anomalies[0].Remediation, "above 128Mi")
+
+	anomalies, err = a.Analyze(context.Background(), c, deployment)
+	require.NoError(t, err)
+	require.Len(t, anomalies, 1)
+	assert.Contains(t, anomalies[0].Message, "1 of 2 pods affected")
+
+	// The latest of the terminations in the status is reported, without
+	// claiming how often the container was killed
+	killed := pod.DeepCopy()
+	now := time.Now()
+	killed.Status.ContainerStatuses[0].State = corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "OOMKilled", ExitCode: 137, FinishedAt: metav1.NewTime(now)}}
+	anomalies, err = a.Analyze(context.Background(), c, killed)
+	require.NoError(t, err)
+	require.Len(t, anomalies, 1)
+	assert.Contains(t, anomalies[0].Message, "last OOM killed at "+now.UTC().Format("2006-01-02 15:04 MST"))
+	assert.NotContains(t, anomalies[0].Message, "times")
+
+	// An OOM kill before the status event window is not reported
+	old := pod.DeepCopy()
+	old.Status.ContainerStatuses[0].LastTerminationState.Terminated.FinishedAt = metav1.NewTime(time.Now().Add(-2 * time.Hour))
+	anomalies, err = a.Analyze(context.Background(), c, old)
+	require.NoError(t, err)
+	assert.Empty(t, anomalies)
+}
+
+func TestFailedSchedulingAnalyzer(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "default", UID: "pod-uid", CreationTimestamp: metav1.NewTime(time.Now().Add(-10 * time.Minute))},
+		Status: corev1.PodStatus{
+			Phase:      corev1.PodPending,
+			Conditions: []corev1.PodCondition{{Type: corev1.PodScheduled, Status: corev1.ConditionFalse, Reason: "Unschedulable"}},
+		},
+	}
+	event := &corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: "web-1.1", Namespace: "default"},
+		InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "web-1", UID: "pod-uid"},
+		Reason:         "FailedScheduling",
+		Message:        "0/3 nodes are available: 3 Insufficient memory.",
+		LastTimestamp:  metav1.NewTime(time.Now().Add(-time.Minute)),
+		Count:          4,
+	}
+	c := newStatusClient(pod, event)
+
+	anomalies, err := statusAnalyzer(t, "POD-005").Analyze(context.Background(), c, pod)
+	require.NoError(t, err)
+	require.Len(t, anomalies, 1)
+	assert.Contains(t, anomalies[0].Message, "3 Insufficient memory")
+	assert.Contains(t, anomalies[0].Remediation, "lower the requests or add nodes")
+}
+
+func TestCrashLoopAnalyzer(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "api-1", Namespace: "default"},
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+			Name:                 "api",
+			RestartCount:         9,
+			State:                corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+			LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "Error", ExitCode: 2}},
+		}}},
+	}
+	event := &corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: "api-1.1", Namespace: "default"},
+		InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "api-1"},
+		Reason:         "BackOff",
+		LastTimestamp:  metav1.NewTime(time.Now()),
+		Count:          5,
+	}
+	c := newStatusClient(pod, event)
+
+	anomalies, err := statusAnalyzer(t, "POD-001").Analyze(context.Background(), c, pod)
+	require.NoError(t, err)
+	require.Len(t, anomalies, 1)
+	assert.Contains(t, anomalies[0].Message, "after 9 restarts")
+	assert.Contains(t, anomalies[0].Message, "reported 5 times in the last hour")
+	assert.Contains(t, anomalies[0].Message, "last exit code 2")
+	assert.Contains(t, anomalies[0].Remediation, "kubectl logs api-1 -c api --previous")
+
+	// Image pull and config checks do not fire on a crash looping container
+	anomalies, err = statusAnalyzer(t, "POD-003").Analyze(context.Background(), c, pod)
+	require.NoError(t, err)
+	assert.Empty(t, anomalies)
+}
@@ -0,0 +1,265 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+	"github.com/pixelvide/kube-sentinel/pkg/kube"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// ruleCostLimit bounds the work a single rule evaluation may do, so a badly
+// written expression cannot stall a scan
+const ruleCostLimit = 1000000
+
+// RuleSpec is a custom analyzer rule. Match selects the objects the rule
+// applies to and may be empty; Expression must evaluate to true for an
+// object to pass, otherwise an anomaly is reported. Both are CEL expressions
+// over `object` (the resource as a map) and `kind`.
+type RuleSpec struct {
+	RuleID      string   `json:"ruleId"`
+	Title       string   `json:"title"`
+	Description string   `json:"description,omitempty"`
+	Severity    string   `json:"severity"`
+	Category    string   `json:"category,omitempty"`
+	Kinds       []string `json:"kinds,omitempty"`
+	Match       string   `json:"match,omitempty"`
+	Expression  string   `json:"expression"`
+	Message     string   `json:"message,omitempty"`
+	Remediation string   `json:"remediation,omitempty"`
+	DocURL      string   `json:"docUrl,omitempty"`
+}
+
+// CompiledRule is a RuleSpec with its CEL programs ready for evaluation
+type CompiledRule struct {
+	Spec       RuleSpec
+	match      cel.Program
+	expression cel.Program
+}
+
+var (
+	ruleEnvOnce sync.Once
+	ruleEnv     *cel.Env
+	ruleEnvErr  error
+)
+
+func celEnv() (*cel.Env, error) {
+	ruleEnvOnce.Do(func() {
+		ruleEnv, ruleEnvErr = cel.NewEnv(
+			cel.Variable("object", cel.DynType),
+			cel.Variable("kind", cel.StringType),
+			ext.Strings(),
+		)
+	})
+	return ruleEnv, ruleEnvErr
+}
+
+func compileExpression(env *cel.Env, field, expr string) (cel.Program, error) {
+	ast, iss := env.Compile(expr)
+	if iss != nil && iss.Err() != nil {
+		return nil, fmt.Errorf("invalid %s: %w", field, iss.Err())
+	}
+	if t := ast.OutputType(); t != cel.BoolType && t != cel.DynType {
+		return nil, fmt.Errorf("invalid %s: must evaluate to bool, got %s", field, t)
+	}
+	prg, err := env.Program(ast, cel.CostLimit(ruleCostLimit), cel.InterruptCheckFrequency(100))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", field, err)
+	}
+	return prg, nil
+}
+
+// CompileRule validates a rule and compiles its expressions
+func CompileRule(spec RuleSpec) (*CompiledRule, error) {
+	if spec.RuleID == "" {
+		return nil, fmt.Errorf("ruleId is required")
+	}
+	if spec.Title == "" {
+		return nil, fmt.Errorf("title is required")
+	}
+	switch AnomalySeverity(spec.Severity) {
+	case SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow, SeverityInfo:
+	default:
+		return nil, fmt.Errorf("invalid severity %q", spec.Severity)
+	}
+	if spec.Category != "" && !IsValidCategory(Category(spec.Category)) {
+		return nil, fmt.Errorf("invalid category %q", spec.Category)
+	}
+	if strings.TrimSpace(spec.Expression) == "" {
+		return nil, fmt.Errorf("expression is required")
+	}
+
+	env, err := celEnv()
+	if err != nil {
+		return nil, err
+	}
+	rule := &CompiledRule{Spec: spec}
+	if strings.TrimSpace(spec.Match) != "" {
+		if rule.match, err = compileExpression(env, "match", spec.Match); err != nil {
+			return nil, err
+		}
+	}
+	if rule.expression, err = compileExpression(env, "expression", spec.Expression); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func evalBool(ctx context.Context, prg cel.Program, vars map[string]interface{}) (bool, error) {
+	out, _, err := prg.ContextEval(ctx, vars)
+	if err != nil {
+		return false, err
+	}
+	b, ok := out.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("expression returned %T, expected bool", out.Value())
+	}
+	return b, nil
+}
+
+// Evaluate runs the rule against an object. It returns nil when the rule
+// does not apply to the object or the object passes.
+func (r *CompiledRule) Evaluate(ctx context.Context, obj client.Object) (*Anomaly, error) {
+	kind, data, err := objectToMap(obj)
+	if err != nil {
+		return nil, err
+	}
+	if len(r.Spec.Kinds) > 0 && !containsFold(r.Spec.Kinds, kind) {
+		return nil, nil
+	}
+
+	vars := map[string]interface{}{"object": data, "kind": kind}
+	if r.match != nil {
+		matched, err := evalBool(ctx, r.match, vars)
+		if err != nil {
+			return nil, fmt.Errorf("match: %w", err)
+		}
+		if !matched {
+			return nil, nil
+		}
+	}
+	passed, err := evalBool(ctx, r.expression, vars)
+	if err != nil {
+		return nil, fmt.Errorf("expression: %w", err)
+	}
+	if passed {
+		return nil, nil
+	}
+
+	message := r.Spec.Message
+	if message == "" {
+		message = fmt.Sprintf("%s %s does not satisfy rule %s", kind, obj.GetName(), r.Spec.RuleID)
+	}
+	return &Anomaly{
+		Severity:    AnomalySeverity(r.Spec.Severity),
+		Title:       r.Spec.Title,
+		Message:     message,
+		Remediation: r.Spec.Remediation,
+		RuleID:      r.Spec.RuleID,
+		DocURL:      r.Spec.DocURL,
+		Category:    Category(r.Spec.Category),
+	}, nil
+}
+
+// objectToMap returns the kind of an object and its unstructured content
+func objectToMap(obj client.Object) (string, map[string]interface{}, error) {
+	var data map[string]interface{}
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		data = u.Object
+	} else {
+		var err error
+		if data, err = runtime.DefaultUnstructuredConverter.ToUnstructured(obj); err != nil {
+			return "", nil, err
+		}
+	}
+
+	gvk := objectGVK(obj)
+	if gvk.Kind != "" {
+		data["apiVersion"] = gvk.GroupVersion().String()
+		data["kind"] = gvk.Kind
+	}
+	return gvk.Kind, data, nil
+}
+
+// objectGVK returns the GVK of an object, looking typed objects without
+// TypeMeta up in the scheme of the cluster clients
+func objectGVK(obj client.Object) schema.GroupVersionKind {
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	if gvk.Kind == "" {
+		if found, err := apiutil.GVKForObject(obj, kube.GetScheme()); err == nil {
+			gvk = found
+		}
+	}
+	return gvk
+}
+
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// CustomRulesAnalyzer evaluates the custom rules loaded from the database
+// and the rules file
+type CustomRulesAnalyzer struct {
+	mu    sync.RWMutex
+	rules []*CompiledRule
+}
+
+var customRules = &CustomRulesAnalyzer{}
+
+func (a *CustomRulesAnalyzer) Name() string { return "CustomRules" }
+
+// SetRules replaces the active rules
+func (a *CustomRulesAnalyzer) SetRules(rules []*CompiledRule) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.rules = rules
+}
+
+// Rule returns the active rule with the given ID
+func (a *CustomRulesAnalyzer) Rule(ruleID string) *CompiledRule {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	for _, r := range a.rules {
+		if r.Spec.RuleID == ruleID {
+			return r
+		}
+	}
+	return nil
+}
+
+func (a *CustomRulesAnalyzer) Analyze(ctx context.Context, c client.Client, obj client.Object) ([]Anomaly, error) {
+	a.mu.RLock()
+	rules := a.rules
+	a.mu.RUnlock()
+
+	var anomalies []Anomaly
+	for _, r := range rules {
+		anomaly, err := r.Evaluate(ctx, obj)
+		if err != nil {
+			klog.Warningf("Custom rule %s failed on %s/%s: %v", r.Spec.RuleID, obj.GetNamespace(), obj.GetName(), err)
+			continue
+		}
+		if anomaly != nil {
+			anomalies = append(anomalies, *anomaly)
+		}
+	}
+	return anomalies, nil
+}
+
+func init() {
+	Register(customRules)
+}
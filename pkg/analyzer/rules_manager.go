@@ -0,0 +1,125 @@
+package analyzer
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pixelvide/kube-sentinel/pkg/common"
+	"github.com/pixelvide/kube-sentinel/pkg/model"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+)
+
+// RulesFile is the layout of the YAML file referenced by ANALYZER_RULES_FILE
+type RulesFile struct {
+	Rules []RuleSpec `json:"rules"`
+}
+
+var (
+	rulesOnce sync.Once
+
+	// SyncRulesNow triggers an immediate reload of the custom rules
+	SyncRulesNow = make(chan struct{}, 1)
+)
+
+// InitCustomRules starts keeping the custom rules analyzer in sync with the
+// database and the rules file
+func InitCustomRules() {
+	rulesOnce.Do(func() {
+		go SyncCustomRules()
+	})
+}
+
+// SpecFromModel converts a stored rule to a RuleSpec
+func SpecFromModel(r model.AnalyzerRule) RuleSpec {
+	return RuleSpec{
+		RuleID:      r.RuleID,
+		Title:       r.Title,
+		Description: r.Description,
+		Severity:    r.Severity,
+		Category:    r.Category,
+		Kinds:       r.Kinds,
+		Match:       r.Match,
+		Expression:  r.Expression,
+		Message:     r.Message,
+		Remediation: r.Remediation,
+		DocURL:      r.DocURL,
+	}
+}
+
+// LoadRulesFile reads the rules defined in the rules file, if one is configured
+func LoadRulesFile() ([]RuleSpec, error) {
+	if common.AnalyzerRulesFile == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(common.AnalyzerRulesFile)
+	if err != nil {
+		return nil, err
+	}
+	var f RulesFile
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", common.AnalyzerRulesFile, err)
+	}
+	return f.Rules, nil
+}
+
+// loadCustomRules compiles the rules file and the enabled DB rules. Rules
+// that fail to compile are logged and skipped; file rules win over DB rules
+// with the same ID.
+func loadCustomRules() error {
+	specs, err := LoadRulesFile()
+	if err != nil {
+		klog.Errorf("failed to load analyzer rules file: %v", err)
+	}
+
+	var dbRules []model.AnalyzerRule
+	if err := model.DB.Where("enabled = ?", true).Order("id").Find(&dbRules).Error; err != nil {
+		return err
+	}
+	for _, r := range dbRules {
+		specs = append(specs, SpecFromModel(r))
+	}
+
+	seen := make(map[string]bool, len(specs))
+	compiled := make([]*CompiledRule, 0, len(specs))
+	for _, spec := range specs {
+		if seen[spec.RuleID] {
+			klog.Warningf("Duplicate analyzer rule %s ignored", spec.RuleID)
+			continue
+		}
+		rule, err := CompileRule(spec)
+		if err != nil {
+			klog.Errorf("Analyzer rule %s is invalid: %v", spec.RuleID, err)
+			continue
+		}
+		seen[spec.RuleID] = true
+		compiled = append(compiled, rule)
+	}
+	customRules.SetRules(compiled)
+	return nil
+}
+
+// ActiveRule returns the loaded custom rule with the given ID
+func ActiveRule(ruleID string) *CompiledRule {
+	return customRules.Rule(ruleID)
+}
+
+func SyncCustomRules() {
+	ticker := time.NewTicker(1 * time.Minute)
+	defer ticker.Stop()
+	SyncRulesNow <- struct{}{}
+	for {
+		select {
+		case <-ticker.C:
+			if err := loadCustomRules(); err != nil {
+				klog.Errorf("failed to sync analyzer rules: %v", err)
+			}
+		case <-SyncRulesNow:
+			if err := loadCustomRules(); err != nil {
+				klog.Errorf("failed to sync analyzer rules: %v", err)
+			}
+		}
+	}
+}
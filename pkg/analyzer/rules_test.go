@@ -0,0 +1,71 @@
+package analyzer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCompileRuleValidation(t *testing.T) {
+	valid := RuleSpec{RuleID: "ORG-001", Title: "t", Severity: "high", Expression: "true"}
+
+	_, err := CompileRule(valid)
+	assert.NoError(t, err)
+
+	bad := valid
+	bad.Severity = "urgent"
+	_, err = CompileRule(bad)
+	assert.ErrorContains(t, err, "invalid severity")
+
+	bad = valid
+	bad.Expression = "object.metadata.name +"
+	_, err = CompileRule(bad)
+	assert.ErrorContains(t, err, "invalid expression")
+
+	bad = valid
+	bad.Expression = "'name'"
+	_, err = CompileRule(bad)
+	assert.ErrorContains(t, err, "must evaluate to bool")
+}
+
+func TestCompiledRuleEvaluate(t *testing.T) {
+	rule, err := CompileRule(RuleSpec{
+		RuleID:      "ORG-001",
+		Title:       "Missing cost-center label",
+		Severity:    "medium",
+		Kinds:       []string{"deployment"},
+		Match:       `object.metadata.namespace.startsWith("team-")`,
+		Expression:  `has(object.metadata.labels) && "cost-center" in object.metadata.labels`,
+		Remediation: "Add a cost-center label.",
+	})
+	require.NoError(t, err)
+
+	deployment := func(ns string, labels map[string]string) *appsv1.Deployment {
+		return &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: ns, Labels: labels}}
+	}
+	ctx := context.Background()
+
+	anomaly, err := rule.Evaluate(ctx, deployment("team-a", nil))
+	require.NoError(t, err)
+	require.NotNil(t, anomaly)
+	assert.Equal(t, "ORG-001", anomaly.RuleID)
+	assert.Equal(t, SeverityMedium, anomaly.Severity)
+	assert.Contains(t, anomaly.Message, "Deployment web")
+
+	anomaly, err = rule.Evaluate(ctx, deployment("team-a", map[string]string{"cost-center": "42"}))
+	require.NoError(t, err)
+	assert.Nil(t, anomaly)
+
+	anomaly, err = rule.Evaluate(ctx, deployment("platform", nil))
+	require.NoError(t, err)
+	assert.Nil(t, anomaly, "match excludes other namespaces")
+
+	anomaly, err = rule.Evaluate(ctx, &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "team-a"}})
+	require.NoError(t, err)
+	assert.Nil(t, anomaly, "kinds excludes services")
+}
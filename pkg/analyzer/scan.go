@@ -0,0 +1,98 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	netv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gatewayapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+// ScanResult is the analysis of a single object found by ScanCluster
+type ScanResult struct {
+	Kind         string
+	ResourceType string
+	Namespace    string
+	Name         string
+	Analysis     *ResourceAnalysis
+}
+
+// scanKind describes a kind of object covered by cluster scans
+type scanKind struct {
+	kind         string
+	resourceType string
+	newList      func() client.ObjectList
+	// skip filters objects that are already covered through another kind
+	skip func(obj client.Object) bool
+}
+
+var scanKinds = []scanKind{
+	{kind: "Deployment", resourceType: "deployments", newList: func() client.ObjectList { return &appsv1.DeploymentList{} }},
+	{kind: "StatefulSet", resourceType: "statefulsets", newList: func() client.ObjectList { return &appsv1.StatefulSetList{} }},
+	{kind: "DaemonSet", resourceType: "daemonsets", newList: func() client.ObjectList { return &appsv1.DaemonSetList{} }},
+	{
+		kind:         "Pod",
+		resourceType: "pods",
+		newList:      func() client.ObjectList { return &corev1.PodList{} },
+		// Pods owned by a controller are analyzed through their workload
+		skip: func(obj client.Object) bool { return metav1.GetControllerOf(obj) != nil },
+	},
+	{kind: "Service", resourceType: "services", newList: func() client.ObjectList { return &corev1.ServiceList{} }},
+	{kind: "Ingress", resourceType: "ingresses", newList: func() client.ObjectList { return &netv1.IngressList{} }},
+	{kind: "Namespace", resourceType: "namespaces", newList: func() client.ObjectList { return &corev1.NamespaceList{} }},
+	{kind: "HTTPRoute", resourceType: "httproutes", newList: func() client.ObjectList { return &gatewayapiv1.HTTPRouteList{} }},
+}
+
+// ScanCluster runs all registered analyzers against every workload, service,
+// ingress, HTTPRoute and namespace of a cluster. Kinds that cannot be listed
+// are logged and skipped so one missing permission does not fail the whole
+// scan; kinds whose CRD is not installed are skipped silently. It also
+// returns the kinds that were listed, the results only cover those.
+func ScanCluster(ctx context.Context, clusterName string, k8sClient client.Client) ([]ScanResult, []string, error) {
+	var results []ScanResult
+	var listed []string
+	for _, sk := range scanKinds {
+		list := sk.newList()
+		if err := k8sClient.List(ctx, list); err != nil {
+			if meta.IsNoMatchError(err) {
+				continue
+			}
+			klog.Warningf("Analysis scan: failed to list %s: %v", sk.resourceType, err)
+			continue
+		}
+		listed = append(listed, sk.kind)
+		items, err := meta.ExtractList(list)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, item := range items {
+			if err := ctx.Err(); err != nil {
+				return nil, nil, err
+			}
+			obj, ok := item.(client.Object)
+			if !ok {
+				continue
+			}
+			if sk.skip != nil && sk.skip(obj) {
+				continue
+			}
+			results = append(results, ScanResult{
+				Kind:         sk.kind,
+				ResourceType: sk.resourceType,
+				Namespace:    obj.GetNamespace(),
+				Name:         obj.GetName(),
+				Analysis:     Analyze(ctx, clusterName, k8sClient, obj),
+			})
+		}
+	}
+	if len(listed) == 0 {
+		return nil, nil, fmt.Errorf("failed to list any resources")
+	}
+	return results, listed, nil
+}
@@ -0,0 +1,253 @@
+package analyzer
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/pixelvide/kube-sentinel/pkg/common"
+	"github.com/pixelvide/kube-sentinel/pkg/model"
+)
+
+// Category groups rules for scoring
+type Category string
+
+const (
+	CategorySecurity    Category = "security"
+	CategoryReliability Category = "reliability"
+	CategoryResources   Category = "resources"
+	CategoryTopology    Category = "topology"
+	CategoryHygiene     Category = "hygiene"
+)
+
+// Categories lists every scoring category in display order
+var Categories = []Category{CategorySecurity, CategoryReliability, CategoryResources, CategoryTopology, CategoryHygiene}
+
+// defaultCategoryWeights are the relative weights of the categories in the
+// overall score, overridable through ANALYZER_CATEGORY_WEIGHTS
+var defaultCategoryWeights = map[Category]float64{
+	CategorySecurity:    30,
+	CategoryReliability: 25,
+	CategoryResources:   20,
+	CategoryTopology:    15,
+	CategoryHygiene:     10,
+}
+
+// severityPoints is what one anomaly costs its category sub-score
+var severityPoints = map[AnomalySeverity]int{
+	SeverityCritical: 40,
+	SeverityHigh:     20,
+	SeverityMedium:   10,
+	SeverityLow:      5,
+	SeverityInfo:     0,
+}
+
+// rulePrefixCategories maps built-in rule ID prefixes to their category
+var rulePrefixCategories = []struct {
+	prefix   string
+	category Category
+}{
+	{"SEC-", CategorySecurity},
+	{"REL-", CategoryReliability},
+	{"POD-", CategoryReliability},
+	{"TOP-", CategoryTopology},
+	{"NET-", CategorySecurity},
+	{"R-", CategoryResources},
+	{"H-", CategoryHygiene},
+	{"G-", CategoryHygiene},
+	{"I-", CategoryHygiene},
+}
+
+// IsValidCategory reports whether c is a known category
+func IsValidCategory(c Category) bool {
+	_, ok := defaultCategoryWeights[c]
+	return ok
+}
+
+// RuleCategory returns the category of a built-in rule ID. Unknown rules
+// count as hygiene.
+func RuleCategory(ruleID string) Category {
+	for _, p := range rulePrefixCategories {
+		if strings.HasPrefix(ruleID, p.prefix) {
+			return p.category
+		}
+	}
+	return CategoryHygiene
+}
+
+// CategoryWeights returns the configured weight of every category
+func CategoryWeights() map[Category]float64 {
+	weights := make(map[Category]float64, len(defaultCategoryWeights))
+	for c, w := range defaultCategoryWeights {
+		weights[c] = w
+		if override, ok := common.AnalyzerCategoryWeights[string(c)]; ok {
+			weights[c] = override
+		}
+	}
+	return weights
+}
+
+// CategoryScore is the sub-score of one category
+type CategoryScore struct {
+	Category Category `json:"category"`
+	Score    int      `json:"score"`
+	Weight   float64  `json:"weight"`
+}
+
+// ScoreDeduction explains the points one anomaly cost. Points are taken from
+// the category sub-score, Impact is the resulting drop of the overall score.
+type ScoreDeduction struct {
+	RuleID   string          `json:"ruleId"`
+	Title    string          `json:"title"`
+	Category Category        `json:"category"`
+	Severity AnomalySeverity `json:"severity"`
+	Points   int             `json:"points"`
+	Impact   float64         `json:"impact"`
+}
+
+// weightedScore combines category sub-scores using their weights
+func weightedScore(categories []CategoryScore) int {
+	var total, weights float64
+	for _, c := range categories {
+		total += float64(c.Score) * c.Weight
+		weights += c.Weight
+	}
+	if weights == 0 {
+		return 100
+	}
+	return int(math.Round(total / weights))
+}
+
+// scoreAnomalies computes the overall score, the category sub-scores and
+// the deductions behind them
+func scoreAnomalies(anomalies []Anomaly) (int, []CategoryScore, []ScoreDeduction) {
+	weights := CategoryWeights()
+	var totalWeight float64
+	for _, w := range weights {
+		totalWeight += w
+	}
+
+	remaining := make(map[Category]int, len(Categories))
+	for _, c := range Categories {
+		remaining[c] = 100
+	}
+	var explanation []ScoreDeduction
+	for _, a := range anomalies {
+		points := min(severityPoints[a.Severity], remaining[a.Category])
+		if points == 0 {
+			continue
+		}
+		remaining[a.Category] -= points
+		impact := 0.0
+		if totalWeight > 0 {
+			impact = math.Round(float64(points)*weights[a.Category]/totalWeight*10) / 10
+		}
+		explanation = append(explanation, ScoreDeduction{
+			RuleID:   a.RuleID,
+			Title:    a.Title,
+			Category: a.Category,
+			Severity: a.Severity,
+			Points:   points,
+			Impact:   impact,
+		})
+	}
+
+	categories := make([]CategoryScore, 0, len(Categories))
+	for _, c := range Categories {
+		categories = append(categories, CategoryScore{Category: c, Score: remaining[c], Weight: weights[c]})
+	}
+	return weightedScore(categories), categories, explanation
+}
+
+// WeakestCategory returns the category with the lowest sub-score, or an
+// empty category when every category has a perfect score
+func WeakestCategory(categories []CategoryScore) Category {
+	var weakest Category
+	lowest := 100
+	for _, c := range categories {
+		if c.Weight > 0 && c.Score < lowest {
+			weakest, lowest = c.Category, c.Score
+		}
+	}
+	return weakest
+}
+
+// ScoreRollup aggregates the scores of many resources, e.g. a namespace or
+// a cluster. Category sub-scores are averaged over the resources.
+type ScoreRollup struct {
+	Score      int             `json:"score"`
+	Resources  int             `json:"resources"`
+	Categories []CategoryScore `json:"categories"`
+	Weakest    Category        `json:"weakest,omitempty"`
+}
+
+// RollupScores aggregates resource analyses into one score
+func RollupScores(analyses []*ResourceAnalysis) ScoreRollup {
+	weights := CategoryWeights()
+	sums := make(map[Category]int, len(Categories))
+	for _, a := range analyses {
+		for _, c := range a.Categories {
+			sums[c.Category] += c.Score
+		}
+	}
+
+	rollup := ScoreRollup{Resources: len(analyses), Categories: make([]CategoryScore, 0, len(Categories))}
+	for _, c := range Categories {
+		score := 100
+		if len(analyses) > 0 {
+			score = int(math.Round(float64(sums[c]) / float64(len(analyses))))
+		}
+		rollup.Categories = append(rollup.Categories, CategoryScore{Category: c, Score: score, Weight: weights[c]})
+	}
+	rollup.Score = weightedScore(rollup.Categories)
+	rollup.Weakest = WeakestCategory(rollup.Categories)
+	return rollup
+}
+
+// Describe summarizes a score for humans and AI tools, e.g.
+// "namespace payments: 72/100, reliability is the weakest area"
+func (r ScoreRollup) Describe(subject string) string {
+	if r.Weakest == "" {
+		return fmt.Sprintf("%s: %d/100", subject, r.Score)
+	}
+	return fmt.Sprintf("%s: %d/100, %s is the weakest area", subject, r.Score, r.Weakest)
+}
+
+// ToModelCategoryScores converts category sub-scores to their persisted form
+func ToModelCategoryScores(categories []CategoryScore) model.CategoryScores {
+	var m model.CategoryScores
+	for _, c := range categories {
+		switch c.Category {
+		case CategorySecurity:
+			m.SecurityScore = c.Score
+		case CategoryReliability:
+			m.ReliabilityScore = c.Score
+		case CategoryResources:
+			m.ResourcesScore = c.Score
+		case CategoryTopology:
+			m.TopologyScore = c.Score
+		case CategoryHygiene:
+			m.HygieneScore = c.Score
+		}
+	}
+	return m
+}
+
+// RollupFromModel rebuilds a rollup from a persisted score and its category
+// sub-scores, using the current weights
+func RollupFromModel(score, resources int, m model.CategoryScores) ScoreRollup {
+	weights := CategoryWeights()
+	scores := map[Category]int{
+		CategorySecurity:    m.SecurityScore,
+		CategoryReliability: m.ReliabilityScore,
+		CategoryResources:   m.ResourcesScore,
+		CategoryTopology:    m.TopologyScore,
+		CategoryHygiene:     m.HygieneScore,
+	}
+	rollup := ScoreRollup{Score: score, Resources: resources, Categories: make([]CategoryScore, 0, len(Categories))}
+	for _, c := range Categories {
+		rollup.Categories = append(rollup.Categories, CategoryScore{Category: c, Score: scores[c], Weight: weights[c]})
+	}
+	rollup.Weakest = WeakestCategory(rollup.Categories)
+	return rollup
+}
@@ -0,0 +1,80 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/pixelvide/kube-sentinel/pkg/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScoreAnomalies(t *testing.T) {
+	score, categories, explanation := scoreAnomalies(nil)
+	assert.Equal(t, 100, score)
+	assert.Len(t, categories, len(Categories))
+	assert.Empty(t, explanation)
+
+	score, categories, explanation = scoreAnomalies([]Anomaly{
+		{RuleID: "REL-001", Severity: SeverityHigh, Category: CategoryReliability},
+		{RuleID: "REL-002", Severity: SeverityMedium, Category: CategoryReliability},
+		{RuleID: "SEC-001", Severity: SeverityCritical, Category: CategorySecurity},
+		{RuleID: "SEC-002", Severity: SeverityCritical, Category: CategorySecurity},
+		{RuleID: "SEC-003", Severity: SeverityCritical, Category: CategorySecurity},
+		{RuleID: "G-001", Severity: SeverityInfo, Category: CategoryHygiene},
+	})
+
+	byCategory := map[Category]int{}
+	for _, c := range categories {
+		byCategory[c.Category] = c.Score
+	}
+	assert.Equal(t, 70, byCategory[CategoryReliability])
+	assert.Equal(t, 0, byCategory[CategorySecurity], "sub-scores do not go below zero")
+	assert.Equal(t, 100, byCategory[CategoryHygiene])
+	// (0*30 + 70*25 + 100*20 + 100*15 + 100*10) / 100
+	assert.Equal(t, 63, score)
+
+	// The third critical finding only had 20 points left to take, and info
+	// findings cost nothing
+	assert.Len(t, explanation, 5)
+	assert.Equal(t, "SEC-003", explanation[4].RuleID)
+	assert.Equal(t, 20, explanation[4].Points)
+	assert.Equal(t, 6.0, explanation[4].Impact)
+	assert.Equal(t, CategorySecurity, WeakestCategory(categories))
+}
+
+func TestCategoryWeightOverride(t *testing.T) {
+	common.AnalyzerCategoryWeights = map[string]float64{"security": 0, "reliability": 0, "resources": 0, "topology": 0}
+	t.Cleanup(func() { common.AnalyzerCategoryWeights = map[string]float64{} })
+
+	score, _, _ := scoreAnomalies([]Anomaly{
+		{RuleID: "SEC-001", Severity: SeverityCritical, Category: CategorySecurity},
+		{RuleID: "H-001", Severity: SeverityMedium, Category: CategoryHygiene},
+	})
+	assert.Equal(t, 90, score, "only hygiene carries weight")
+}
+
+func TestRollupScores(t *testing.T) {
+	analysis := func(anomalies ...Anomaly) *ResourceAnalysis {
+		a := &ResourceAnalysis{Anomalies: anomalies}
+		a.Score, a.Categories, a.Explanation = scoreAnomalies(anomalies)
+		return a
+	}
+	rollup := RollupScores([]*ResourceAnalysis{
+		analysis(),
+		analysis(Anomaly{RuleID: "REL-001", Severity: SeverityCritical, Category: CategoryReliability}),
+	})
+	assert.Equal(t, 2, rollup.Resources)
+	assert.Equal(t, CategoryReliability, rollup.Weakest)
+	assert.Equal(t, 95, rollup.Score)
+	assert.Equal(t, "namespace payments: 95/100, reliability is the weakest area", rollup.Describe("namespace payments"))
+
+	empty := RollupScores(nil)
+	assert.Equal(t, 100, empty.Score)
+	assert.Equal(t, "cluster prod: 100/100", empty.Describe("cluster prod"))
+}
+
+func TestRuleCategory(t *testing.T) {
+	assert.Equal(t, CategorySecurity, RuleCategory("SEC-004"))
+	assert.Equal(t, CategoryResources, RuleCategory("R-002"))
+	assert.Equal(t, CategoryReliability, RuleCategory("REL-001"))
+	assert.Equal(t, CategoryHygiene, RuleCategory("ORG-001"))
+}
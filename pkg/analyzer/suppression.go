@@ -0,0 +1,165 @@
+package analyzer
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pixelvide/kube-sentinel/pkg/model"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// IgnoreRulesAnnotation lists rule IDs, comma separated, that are suppressed
+// for the annotated resource
+const IgnoreRulesAnnotation = "kube-sentinel.io/ignore-rules"
+
+// suppression is a stored suppression with its label selector parsed
+type suppression struct {
+	model.AnalysisSuppression
+	selector labels.Selector
+}
+
+var (
+	suppressionsOnce sync.Once
+	suppressionsMu   sync.RWMutex
+	suppressions     []suppression
+
+	// SyncSuppressionsNow triggers an immediate reload of the suppressions
+	SyncSuppressionsNow = make(chan struct{}, 1)
+)
+
+// InitSuppressions starts keeping the in-memory suppressions in sync with
+// the database
+func InitSuppressions() {
+	suppressionsOnce.Do(func() {
+		go SyncSuppressions()
+	})
+}
+
+// SetSuppressions replaces the suppressions applied by Analyze. Entries with
+// an invalid label selector are logged and skipped.
+func SetSuppressions(list []model.AnalysisSuppression) {
+	parsed := make([]suppression, 0, len(list))
+	for _, s := range list {
+		selector := labels.Everything()
+		if s.LabelSelector != "" {
+			var err error
+			if selector, err = labels.Parse(s.LabelSelector); err != nil {
+				klog.Errorf("Suppression %d has an invalid label selector: %v", s.ID, err)
+				continue
+			}
+		}
+		parsed = append(parsed, suppression{AnalysisSuppression: s, selector: selector})
+	}
+	suppressionsMu.Lock()
+	suppressions = parsed
+	suppressionsMu.Unlock()
+}
+
+func (s suppression) matches(clusterName, kind string, obj client.Object, ruleID string, now time.Time) bool {
+	if s.RuleID != ruleID || !now.Before(s.ExpiresAt) {
+		return false
+	}
+	if s.ClusterName != "" && s.ClusterName != clusterName {
+		return false
+	}
+	if s.Namespace != "" && s.Namespace != obj.GetNamespace() {
+		return false
+	}
+	if s.Kind != "" && !strings.EqualFold(s.Kind, kind) {
+		return false
+	}
+	if s.Name != "" && s.Name != obj.GetName() {
+		return false
+	}
+	return s.selector.Matches(labels.Set(obj.GetLabels()))
+}
+
+// ignoredRules returns the rule IDs listed in the ignore-rules annotation
+func ignoredRules(obj client.Object) map[string]bool {
+	value := obj.GetAnnotations()[IgnoreRulesAnnotation]
+	if value == "" {
+		return nil
+	}
+	ignored := map[string]bool{}
+	for _, id := range strings.Split(value, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ignored[id] = true
+		}
+	}
+	return ignored
+}
+
+// applySuppressions splits anomalies into the ones still reported and the
+// ones silenced by the ignore-rules annotation or a matching suppression
+func applySuppressions(clusterName string, obj client.Object, anomalies []Anomaly) ([]Anomaly, []SuppressedAnomaly) {
+	if len(anomalies) == 0 {
+		return anomalies, nil
+	}
+	ignored := ignoredRules(obj)
+	kind := objectGVK(obj).Kind
+	now := time.Now()
+
+	suppressionsMu.RLock()
+	active := suppressions
+	suppressionsMu.RUnlock()
+
+	var kept []Anomaly
+	var suppressed []SuppressedAnomaly
+	for _, a := range anomalies {
+		if ignored[a.RuleID] {
+			suppressed = append(suppressed, SuppressedAnomaly{
+				Anomaly: a,
+				Reason:  "Ignored by the " + IgnoreRulesAnnotation + " annotation",
+			})
+			continue
+		}
+		matched := false
+		for _, s := range active {
+			if s.matches(clusterName, kind, obj, a.RuleID, now) {
+				expiresAt := s.ExpiresAt
+				suppressed = append(suppressed, SuppressedAnomaly{
+					Anomaly:       a,
+					Reason:        s.Reason,
+					SuppressionID: s.ID,
+					ExpiresAt:     &expiresAt,
+				})
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			kept = append(kept, a)
+		}
+	}
+	return kept, suppressed
+}
+
+func loadSuppressions() error {
+	list, err := model.ActiveSuppressions(time.Now())
+	if err != nil {
+		return err
+	}
+	SetSuppressions(list)
+	return nil
+}
+
+func SyncSuppressions() {
+	ticker := time.NewTicker(1 * time.Minute)
+	defer ticker.Stop()
+	SyncSuppressionsNow <- struct{}{}
+	for {
+		select {
+		case <-ticker.C:
+			if err := loadSuppressions(); err != nil {
+				klog.Errorf("failed to sync analyzer suppressions: %v", err)
+			}
+		case <-SyncSuppressionsNow:
+			if err := loadSuppressions(); err != nil {
+				klog.Errorf("failed to sync analyzer suppressions: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pixelvide/kube-sentinel/pkg/model"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestApplySuppressions(t *testing.T) {
+	t.Cleanup(func() { SetSuppressions(nil) })
+
+	future := time.Now().Add(time.Hour)
+	SetSuppressions([]model.AnalysisSuppression{
+		{RuleID: "REL-001", ClusterName: "prod", Namespace: "jobs", Kind: "Deployment", Reason: "worker is single replica by design", ExpiresAt: future},
+		{RuleID: "SEC-001", LabelSelector: "tier=legacy", Reason: "legacy", ExpiresAt: future},
+		{RuleID: "SEC-002", Namespace: "jobs", Reason: "expired", ExpiresAt: time.Now().Add(-time.Hour)},
+	})
+
+	anomalies := []Anomaly{
+		{RuleID: "REL-001", Severity: SeverityMedium},
+		{RuleID: "SEC-001", Severity: SeverityHigh},
+		{RuleID: "SEC-002", Severity: SeverityHigh},
+		{RuleID: "SEC-003", Severity: SeverityLow},
+	}
+	worker := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{
+		Name:        "worker",
+		Namespace:   "jobs",
+		Labels:      map[string]string{"tier": "legacy"},
+		Annotations: map[string]string{IgnoreRulesAnnotation: "SEC-003, OTHER"},
+	}}
+
+	kept, suppressed := applySuppressions("prod", worker, anomalies)
+	assert.Equal(t, []Anomaly{{RuleID: "SEC-002", Severity: SeverityHigh}}, kept)
+	if assert.Len(t, suppressed, 3) {
+		assert.Equal(t, "REL-001", suppressed[0].RuleID)
+		assert.Equal(t, "worker is single replica by design", suppressed[0].Reason)
+		assert.NotNil(t, suppressed[0].ExpiresAt)
+		assert.Equal(t, "SEC-001", suppressed[1].RuleID)
+		assert.Equal(t, "SEC-003", suppressed[2].RuleID)
+		assert.Contains(t, suppressed[2].Reason, IgnoreRulesAnnotation)
+	}
+
+	// Scope fields must all match
+	kept, _ = applySuppressions("staging", worker.DeepCopy(), anomalies[:1])
+	assert.Len(t, kept, 1)
+}
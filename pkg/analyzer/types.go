@@ -1,5 +1,7 @@
 package analyzer
 
+import "time"
+
 type AnomalySeverity string
 
 const (
@@ -17,10 +19,34 @@ type Anomaly struct {
 	Remediation string          `json:"remediation,omitempty"`
 	RuleID      string          `json:"ruleId"`
 	DocURL      string          `json:"docUrl,omitempty"`
+	Category    Category        `json:"category,omitempty"`
+}
+
+// SuppressedAnomaly is an anomaly silenced by a suppression or by the
+// ignore-rules annotation of the resource
+type SuppressedAnomaly struct {
+	Anomaly
+	Reason        string     `json:"reason"`
+	SuppressionID uint       `json:"suppressionId,omitempty"`
+	ExpiresAt     *time.Time `json:"expiresAt,omitempty"`
+}
+
+// AnalyzerError reports an analyzer that failed or timed out, so its checks
+// are missing from the analysis
+type AnalyzerError struct {
+	Analyzer string `json:"analyzer"`
+	Error    string `json:"error"`
+	TimedOut bool   `json:"timedOut,omitempty"`
 }
 
 type ResourceAnalysis struct {
-	Anomalies []Anomaly `json:"anomalies"`
-	Summary   string    `json:"summary,omitempty"`
-	Score     int       `json:"score,omitempty"`
+	Anomalies  []Anomaly           `json:"anomalies"`
+	Suppressed []SuppressedAnomaly `json:"suppressed,omitempty"`
+	Summary    string              `json:"summary,omitempty"`
+	Score      int                 `json:"score"`
+	// Categories are the sub-scores the overall score is weighted from, and
+	// Explanation lists the points each anomaly cost
+	Categories  []CategoryScore  `json:"categories"`
+	Explanation []ScoreDeduction `json:"explanation,omitempty"`
+	Errors      []AnalyzerError  `json:"errors,omitempty"`
 }
@@ -0,0 +1,213 @@
+package audit
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pixelvide/kube-sentinel/pkg/model"
+	"k8s.io/klog/v2"
+)
+
+// Entry describes a single audited action. The structured fields are stored
+// in their own columns and are also merged into the JSON payload.
+type Entry struct {
+	Action        string
+	ActorID       uint
+	ClusterName   string
+	ResourceType  string
+	ResourceName  string
+	Namespace     string
+	Source        string
+	ChatSessionID string
+	IPAddress     string
+	UserAgent     string
+	Payload       map[string]interface{}
+	Success       bool
+	ErrorMessage  string
+}
+
+// FromRequest returns an entry pre-filled with the actor, client address and
+// audit source of the current request
+func FromRequest(c *gin.Context, action string) Entry {
+	e := Entry{
+		Action:    action,
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		Source:    model.ResolveAuditSource(c.GetString(model.AuditSourceContextKey)),
+	}
+	if user, ok := c.Get("user"); ok {
+		if u, ok := user.(model.User); ok {
+			e.ActorID = u.ID
+		}
+	}
+	return e
+}
+
+// WithError marks the entry as failed when err is not nil
+func (e Entry) WithError(err error) Entry {
+	e.Success = err == nil
+	if err != nil {
+		e.ErrorMessage = err.Error()
+	}
+	return e
+}
+
+func (e Entry) toAuditLog() model.AuditLog {
+	payload := make(map[string]interface{}, len(e.Payload)+6)
+	for k, v := range e.Payload {
+		payload[k] = v
+	}
+	setIfNotEmpty := func(key, value string) {
+		if value != "" {
+			payload[key] = value
+		}
+	}
+	setIfNotEmpty("clusterName", e.ClusterName)
+	setIfNotEmpty("resourceType", e.ResourceType)
+	setIfNotEmpty("resourceName", e.ResourceName)
+	setIfNotEmpty("namespace", e.Namespace)
+	setIfNotEmpty("source", e.Source)
+	setIfNotEmpty("chatSessionId", e.ChatSessionID)
+
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		klog.Errorf("Failed to marshal audit payload: %v", err)
+	}
+
+	appID := uint(0)
+	if model.CurrentApp != nil {
+		appID = model.CurrentApp.ID
+	}
+	return model.AuditLog{
+		AppID:         appID,
+		Action:        e.Action,
+		ActorID:       e.ActorID,
+		IPAddress:     e.IPAddress,
+		UserAgent:     e.UserAgent,
+		Payload:       string(payloadBytes),
+		Success:       e.Success,
+		ErrorMessage:  e.ErrorMessage,
+		ClusterName:   e.ClusterName,
+		ResourceType:  e.ResourceType,
+		ResourceName:  e.ResourceName,
+		Namespace:     e.Namespace,
+		Source:        model.ResolveAuditSource(e.Source),
+		ChatSessionID: e.ChatSessionID,
+	}
+}
+
+// Emitter persists audit logs and fans them out to the configured sinks.
+// Sinks are written asynchronously, and a request only waits for them when
+// they fall a whole queue behind.
+type Emitter struct {
+	sinks []Sink
+	queue chan model.AuditLog
+	wg    sync.WaitGroup
+}
+
+const emitterQueueSize = 1000
+
+// emitterQueueTimeout bounds how long Emit waits for room in a full queue
+// before it drops the record for the sinks
+var emitterQueueTimeout = 5 * time.Second
+
+// NewEmitter creates an emitter writing to the given sinks
+func NewEmitter(sinks ...Sink) *Emitter {
+	e := &Emitter{
+		sinks: sinks,
+		queue: make(chan model.AuditLog, emitterQueueSize),
+	}
+	e.wg.Add(1)
+	go e.run()
+	return e
+}
+
+func (e *Emitter) run() {
+	defer e.wg.Done()
+	for log := range e.queue {
+		record := NewRecord(log)
+		for _, s := range e.sinks {
+			if err := s.Write(record); err != nil {
+				klog.Errorf("Audit sink %s failed: %v", s.Name(), err)
+			}
+		}
+	}
+}
+
+// Emit stores the entry in the database and forwards it to the sinks
+func (e *Emitter) Emit(entry Entry) {
+	log := entry.toAuditLog()
+	if model.DB != nil {
+		db := model.DB
+		if log.ActorID == 0 {
+			// Actions without a signed-in user, such as SCIM provisioning,
+			// store no actor rather than a dangling reference
+			db = db.Omit("ActorID")
+		}
+		if err := db.Create(&log).Error; err != nil {
+			klog.Errorf("Failed to create audit log: %v", err)
+		}
+	}
+	if len(e.sinks) == 0 {
+		return
+	}
+	select {
+	case e.queue <- log:
+		return
+	default:
+	}
+
+	// Wait for slow sinks to catch up rather than dropping the record
+	timer := time.NewTimer(emitterQueueTimeout)
+	defer timer.Stop()
+	select {
+	case e.queue <- log:
+	case <-timer.C:
+		klog.Warningf("Audit sink queue is full, dropping %s event for sinks", log.Action)
+		for _, s := range e.sinks {
+			auditSinkDroppedTotal.WithLabelValues(s.Name()).Inc()
+		}
+	}
+}
+
+// Close drains pending events and closes all sinks
+func (e *Emitter) Close() {
+	close(e.queue)
+	e.wg.Wait()
+	for _, s := range e.sinks {
+		if err := s.Close(); err != nil {
+			klog.Errorf("Failed to close audit sink %s: %v", s.Name(), err)
+		}
+	}
+}
+
+var (
+	defaultEmitter   = NewEmitter()
+	defaultEmitterMu sync.RWMutex
+)
+
+// Init replaces the default emitter with one writing to the given sinks
+func Init(sinks ...Sink) {
+	defaultEmitterMu.Lock()
+	old := defaultEmitter
+	defaultEmitter = NewEmitter(sinks...)
+	defaultEmitterMu.Unlock()
+	old.Close()
+}
+
+// Emit records an audit entry through the default emitter
+func Emit(entry Entry) {
+	defaultEmitterMu.RLock()
+	defer defaultEmitterMu.RUnlock()
+	defaultEmitter.Emit(entry)
+}
+
+// Close flushes and closes the default emitter
+func Close() {
+	defaultEmitterMu.Lock()
+	defer defaultEmitterMu.Unlock()
+	defaultEmitter.Close()
+	defaultEmitter = NewEmitter()
+}
@@ -0,0 +1,112 @@
+package audit
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/pixelvide/kube-sentinel/pkg/model"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type memorySink struct {
+	mu      sync.Mutex
+	records []Record
+}
+
+func (s *memorySink) Name() string { return "memory" }
+
+func (s *memorySink) Write(r Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, r)
+	return nil
+}
+
+func (s *memorySink) Close() error { return nil }
+
+func TestEmitterPersistsAndForwards(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:audit_emitter?mode=memory&cache=shared"), &gorm.Config{})
+	require.NoError(t, err)
+	model.DB = db
+	require.NoError(t, model.DB.AutoMigrate(&model.AuditLog{}))
+
+	sink := &memorySink{}
+	e := NewEmitter(sink)
+	e.Emit(Entry{
+		Action:       "delete",
+		ActorID:      3,
+		ClusterName:  "prod",
+		ResourceType: "pods",
+		ResourceName: "web",
+		Namespace:    "default",
+		Payload:      map[string]interface{}{"previousYaml": "kind: Pod"},
+	}.WithError(errors.New("forbidden")))
+	e.Close()
+
+	var logs []model.AuditLog
+	require.NoError(t, model.DB.Find(&logs).Error)
+	require.Len(t, logs, 1)
+	assert.Equal(t, "prod", logs[0].ClusterName)
+	assert.Equal(t, model.AuditSourceUI, logs[0].Source)
+	assert.False(t, logs[0].Success)
+	assert.Equal(t, "forbidden", logs[0].ErrorMessage)
+
+	var payload map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(logs[0].Payload), &payload))
+	assert.Equal(t, "web", payload["resourceName"])
+	assert.Equal(t, "kind: Pod", payload["previousYaml"])
+
+	require.Len(t, sink.records, 1)
+	assert.Equal(t, logs[0].ID, sink.records[0].ID)
+	assert.Equal(t, "delete", sink.records[0].Action)
+}
+
+// blockingSink holds every write until it is released
+type blockingSink struct {
+	memorySink
+	release chan struct{}
+}
+
+func (s *blockingSink) Write(r Record) error {
+	<-s.release
+	return s.memorySink.Write(r)
+}
+
+func TestEmitterWaitsForFullQueue(t *testing.T) {
+	db, original := model.DB, emitterQueueTimeout
+	model.DB = nil
+	t.Cleanup(func() {
+		model.DB, emitterQueueTimeout = db, original
+	})
+
+	sink := &blockingSink{release: make(chan struct{})}
+	e := NewEmitter(sink)
+	// One record is held by the sink and the rest fill the queue
+	for range emitterQueueSize + 1 {
+		e.Emit(Entry{Action: "update"})
+	}
+
+	// A full queue drops records once the wait times out
+	emitterQueueTimeout = 10 * time.Millisecond
+	dropped := testutil.ToFloat64(auditSinkDroppedTotal.WithLabelValues("memory"))
+	e.Emit(Entry{Action: "update"})
+	assert.Equal(t, dropped+1, testutil.ToFloat64(auditSinkDroppedTotal.WithLabelValues("memory")))
+
+	// and are kept when the sink catches up in time
+	emitterQueueTimeout = 5 * time.Second
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		close(sink.release)
+	}()
+	e.Emit(Entry{Action: "update"})
+	e.Close()
+	assert.Len(t, sink.records, emitterQueueSize+2)
+	assert.Equal(t, dropped+1, testutil.ToFloat64(auditSinkDroppedTotal.WithLabelValues("memory")))
+}
@@ -0,0 +1,106 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// FileSink appends records as JSON lines to a file and rotates it once it
+// exceeds maxSize, keeping up to maxBackups old files (path.1 is the newest).
+type FileSink struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+func NewFileSink(path string, maxSize int64, maxBackups int) (*FileSink, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	s := &FileSink{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileSink) Name() string {
+	return "file"
+}
+
+func (s *FileSink) open() error {
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	s.file = f
+	s.size = info.Size()
+	return nil
+}
+
+func (s *FileSink) rotate() error {
+	if err := s.file.Close(); err != nil {
+		return err
+	}
+	if s.maxBackups > 0 {
+		for i := s.maxBackups - 1; i >= 1; i-- {
+			src := fmt.Sprintf("%s.%d", s.path, i)
+			if _, err := os.Stat(src); err == nil {
+				if err := os.Rename(src, fmt.Sprintf("%s.%d", s.path, i+1)); err != nil {
+					return err
+				}
+			}
+		}
+		if err := os.Rename(s.path, s.path+".1"); err != nil {
+			return err
+		}
+	} else if err := os.Remove(s.path); err != nil {
+		return err
+	}
+	return s.open()
+}
+
+func (s *FileSink) Write(r Record) error {
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return fmt.Errorf("file sink is closed")
+	}
+	if s.maxSize > 0 && s.size > 0 && s.size+int64(len(line)) > s.maxSize {
+		if err := s.rotate(); err != nil {
+			return fmt.Errorf("failed to rotate audit file: %w", err)
+		}
+	}
+	n, err := s.file.Write(line)
+	s.size += int64(n)
+	return err
+}
+
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
@@ -0,0 +1,15 @@
+package audit
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var auditSinkDroppedTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "audit_sink_dropped_total",
+		Help: "Total number of audit records dropped because the sink queue stayed full.",
+	},
+	[]string{"sink"},
+)
+
+func init() {
+	_ = prometheus.Register(auditSinkDroppedTotal)
+}
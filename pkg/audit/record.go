@@ -0,0 +1,88 @@
+package audit
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/pixelvide/kube-sentinel/pkg/model"
+)
+
+// Record is the flat representation of an audit log used by sinks and exports
+type Record struct {
+	ID            uint            `json:"id"`
+	Timestamp     time.Time       `json:"timestamp"`
+	Action        string          `json:"action"`
+	ActorID       uint            `json:"actorId"`
+	Actor         string          `json:"actor,omitempty"`
+	ClusterName   string          `json:"clusterName,omitempty"`
+	ResourceType  string          `json:"resourceType,omitempty"`
+	ResourceName  string          `json:"resourceName,omitempty"`
+	Namespace     string          `json:"namespace,omitempty"`
+	Source        string          `json:"source"`
+	ChatSessionID string          `json:"chatSessionId,omitempty"`
+	IPAddress     string          `json:"ipAddress,omitempty"`
+	UserAgent     string          `json:"userAgent,omitempty"`
+	Success       bool            `json:"success"`
+	ErrorMessage  string          `json:"errorMessage,omitempty"`
+	Payload       json.RawMessage `json:"payload,omitempty"`
+}
+
+// NewRecord converts a stored audit log into a Record
+func NewRecord(l model.AuditLog) Record {
+	r := Record{
+		ID:            l.ID,
+		Timestamp:     l.CreatedAt,
+		Action:        l.Action,
+		ActorID:       l.ActorID,
+		ClusterName:   l.ClusterName,
+		ResourceType:  l.ResourceType,
+		ResourceName:  l.ResourceName,
+		Namespace:     l.Namespace,
+		Source:        l.Source,
+		ChatSessionID: l.ChatSessionID,
+		IPAddress:     l.IPAddress,
+		UserAgent:     l.UserAgent,
+		Success:       l.Success,
+		ErrorMessage:  l.ErrorMessage,
+	}
+	if r.Timestamp.IsZero() {
+		r.Timestamp = time.Now()
+	}
+	if l.Actor != nil {
+		r.Actor = l.Actor.Username
+	}
+	if json.Valid([]byte(l.Payload)) {
+		r.Payload = json.RawMessage(l.Payload)
+	}
+	return r
+}
+
+// CSVHeader is the column order used by CSVRow
+var CSVHeader = []string{
+	"id", "timestamp", "action", "actorId", "actor", "clusterName", "resourceType",
+	"resourceName", "namespace", "source", "chatSessionId", "ipAddress", "userAgent",
+	"success", "errorMessage",
+}
+
+// CSVRow returns the record as a CSV row matching CSVHeader. The payload is
+// omitted since it may contain full manifests.
+func (r Record) CSVRow() []string {
+	return []string{
+		strconv.FormatUint(uint64(r.ID), 10),
+		r.Timestamp.UTC().Format(time.RFC3339),
+		r.Action,
+		strconv.FormatUint(uint64(r.ActorID), 10),
+		r.Actor,
+		r.ClusterName,
+		r.ResourceType,
+		r.ResourceName,
+		r.Namespace,
+		r.Source,
+		r.ChatSessionID,
+		r.IPAddress,
+		r.UserAgent,
+		strconv.FormatBool(r.Success),
+		r.ErrorMessage,
+	}
+}
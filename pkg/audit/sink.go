@@ -0,0 +1,57 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/pixelvide/kube-sentinel/pkg/common"
+	"k8s.io/klog/v2"
+)
+
+// Sink receives every audit record after it has been stored
+type Sink interface {
+	Name() string
+	Write(r Record) error
+	Close() error
+}
+
+// SinksFromEnv builds the sinks enabled through environment configuration
+func SinksFromEnv() []Sink {
+	var sinks []Sink
+
+	if common.AuditFilePath != "" {
+		s, err := NewFileSink(common.AuditFilePath, common.AuditFileMaxSizeMB*1024*1024, common.AuditFileMaxBackups)
+		if err != nil {
+			klog.Errorf("Failed to create audit file sink: %v", err)
+		} else {
+			klog.Infof("Audit file sink enabled: %s", common.AuditFilePath)
+			sinks = append(sinks, s)
+		}
+	}
+
+	if common.AuditSyslogAddr != "" {
+		s, err := NewSyslogSink(common.AuditSyslogAddr)
+		if err != nil {
+			klog.Errorf("Failed to create audit syslog sink: %v", err)
+		} else {
+			klog.Infof("Audit syslog sink enabled: %s", common.AuditSyslogAddr)
+			sinks = append(sinks, s)
+		}
+	}
+
+	if common.AuditWebhookURL != "" {
+		bufferDir := common.AuditWebhookBufferDir
+		if bufferDir == "" {
+			bufferDir = filepath.Join(os.TempDir(), common.AppName+"-audit")
+		}
+		s, err := NewWebhookSink(common.AuditWebhookURL, common.AuditWebhookToken, filepath.Join(bufferDir, "webhook-buffer.jsonl"), common.AuditWebhookBufferMaxSizeMB*1024*1024)
+		if err != nil {
+			klog.Errorf("Failed to create audit webhook sink: %v", err)
+		} else {
+			klog.Infof("Audit webhook sink enabled: %s", common.AuditWebhookURL)
+			sinks = append(sinks, s)
+		}
+	}
+
+	return sinks
+}
@@ -0,0 +1,261 @@
+package audit
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testRecord(id uint) Record {
+	return Record{
+		ID:           id,
+		Timestamp:    time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		Action:       "update",
+		ActorID:      7,
+		ClusterName:  "prod",
+		ResourceType: "pods",
+		ResourceName: `web"]`,
+		Namespace:    "default",
+		Source:       "ui",
+		Success:      true,
+	}
+}
+
+func TestFileSinkRotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "audit.jsonl")
+
+	line, err := json.Marshal(testRecord(1))
+	require.NoError(t, err)
+	// Room for two records per file
+	s, err := NewFileSink(path, int64(2*(len(line)+1)), 2)
+	require.NoError(t, err)
+
+	for i := uint(1); i <= 7; i++ {
+		require.NoError(t, s.Write(testRecord(1)))
+	}
+	require.NoError(t, s.Close())
+
+	countLines := func(p string) int {
+		data, err := os.ReadFile(p)
+		require.NoError(t, err)
+		return strings.Count(string(data), "\n")
+	}
+	assert.Equal(t, 1, countLines(path))
+	assert.Equal(t, 2, countLines(path+".1"))
+	assert.Equal(t, 2, countLines(path+".2"))
+	_, err = os.Stat(path + ".3")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestFormatRFC5424(t *testing.T) {
+	msg, err := formatRFC5424(testRecord(42), "host-1")
+	require.NoError(t, err)
+
+	// local0.info
+	assert.True(t, strings.HasPrefix(msg, "<134>1 2025-01-02T03:04:05Z host-1 kube-sentinel "), msg)
+	assert.Contains(t, msg, " update [audit@32473 id=\"42\" actorId=\"7\"")
+	assert.Contains(t, msg, `resourceName="web\"\]"`)
+
+	failed := testRecord(1)
+	failed.Success = false
+	msg, err = formatRFC5424(failed, "")
+	require.NoError(t, err)
+	// local0.warning
+	assert.True(t, strings.HasPrefix(msg, "<132>1 "), msg)
+}
+
+func TestSyslogSinkUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	s, err := NewSyslogSink("udp://" + conn.LocalAddr().String())
+	require.NoError(t, err)
+	require.NoError(t, s.Write(testRecord(1)))
+	defer func() {
+		_ = s.Close()
+	}()
+
+	buf := make([]byte, 4096)
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+	assert.Contains(t, string(buf[:n]), "[audit@32473")
+}
+
+func TestWebhookSinkRetriesAndDrainsBuffer(t *testing.T) {
+	var calls atomic.Int32
+	received := make(chan []Record, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		// Fail the first delivery to exercise the retry path
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var batch []Record
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&batch))
+		received <- batch
+	}))
+	defer srv.Close()
+
+	bufferPath := filepath.Join(t.TempDir(), "buffer.jsonl")
+	s, err := NewWebhookSink(srv.URL, "secret", bufferPath, 1024*1024)
+	require.NoError(t, err)
+	defer func() {
+		_ = s.Close()
+	}()
+
+	require.NoError(t, s.Write(testRecord(1)))
+	require.NoError(t, s.Write(testRecord(2)))
+
+	var got []Record
+	deadline := time.After(10 * time.Second)
+	for len(got) < 2 {
+		select {
+		case batch := <-received:
+			got = append(got, batch...)
+		case <-deadline:
+			t.Fatalf("webhook did not receive records, got %d", len(got))
+		}
+	}
+	assert.Equal(t, uint(1), got[0].ID)
+	assert.Equal(t, uint(2), got[1].ID)
+
+	assert.Eventually(t, func() bool {
+		data, err := os.ReadFile(bufferPath)
+		return err == nil && len(strings.TrimSpace(string(data))) == 0
+	}, 5*time.Second, 50*time.Millisecond)
+}
+
+func TestWebhookSinkDropsOldestRecords(t *testing.T) {
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+
+	// Room for three records
+	line, err := json.Marshal(testRecord(10))
+	require.NoError(t, err)
+	maxSize := int64(3 * (len(line) + 1))
+
+	bufferPath := filepath.Join(t.TempDir(), "buffer.jsonl")
+	s, err := NewWebhookSink(down.URL, "", bufferPath, maxSize)
+	require.NoError(t, err)
+	for id := uint(1); id <= 10; id++ {
+		require.NoError(t, s.Write(testRecord(id)))
+	}
+	require.NoError(t, s.Close())
+
+	info, err := os.Stat(bufferPath)
+	require.NoError(t, err)
+	assert.LessOrEqual(t, info.Size(), 2*maxSize, "dropped records are cut off the buffer file")
+
+	// After a restart only the newest records are delivered
+	received := make(chan []Record, 10)
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var batch []Record
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&batch))
+		received <- batch
+	}))
+	defer up.Close()
+	s, err = NewWebhookSink(up.URL, "", bufferPath, maxSize)
+	require.NoError(t, err)
+	defer func() {
+		_ = s.Close()
+	}()
+
+	select {
+	case batch := <-received:
+		ids := make([]uint, 0, len(batch))
+		for _, r := range batch {
+			ids = append(ids, r.ID)
+		}
+		assert.Equal(t, []uint{8, 9, 10}, ids)
+	case <-time.After(10 * time.Second):
+		t.Fatal("webhook did not receive records")
+	}
+	assert.Eventually(t, func() bool {
+		info, err := os.Stat(bufferPath)
+		return err == nil && info.Size() == 0
+	}, 5*time.Second, 50*time.Millisecond)
+}
+
+func TestWebhookSinkCompactsDuringSend(t *testing.T) {
+	// Room for three records
+	line, err := json.Marshal(testRecord(10))
+	require.NoError(t, err)
+	maxSize := int64(3 * (len(line) + 1))
+
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+	bufferPath := filepath.Join(t.TempDir(), "buffer.jsonl")
+	s, err := NewWebhookSink(down.URL, "", bufferPath, maxSize)
+	require.NoError(t, err)
+	for id := uint(1); id <= 3; id++ {
+		require.NoError(t, s.Write(testRecord(id)))
+	}
+	require.NoError(t, s.Close())
+
+	// The first delivery is held until the buffer has been compacted
+	var calls atomic.Int32
+	release := make(chan struct{})
+	received := make(chan []uint, 10)
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var batch []Record
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&batch))
+		ids := make([]uint, 0, len(batch))
+		for _, r := range batch {
+			ids = append(ids, r.ID)
+		}
+		received <- ids
+		if calls.Add(1) == 1 {
+			<-release
+		}
+	}))
+	defer up.Close()
+	s, err = NewWebhookSink(up.URL, "", bufferPath, maxSize)
+	require.NoError(t, err)
+	defer func() {
+		_ = s.Close()
+	}()
+
+	select {
+	case ids := <-received:
+		assert.Equal(t, []uint{1, 2, 3}, ids)
+	case <-time.After(10 * time.Second):
+		t.Fatal("webhook did not receive records")
+	}
+	// Records 1 to 4 are dropped, which compacts the buffer file
+	for id := uint(4); id <= 7; id++ {
+		require.NoError(t, s.Write(testRecord(id)))
+	}
+	close(release)
+
+	var got []uint
+	deadline := time.After(10 * time.Second)
+	for len(got) < 3 {
+		select {
+		case ids := <-received:
+			got = append(got, ids...)
+		case <-deadline:
+			t.Fatalf("webhook did not receive the records written during the send, got %v", got)
+		}
+	}
+	assert.Equal(t, []uint{5, 6, 7}, got)
+}
@@ -0,0 +1,170 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pixelvide/kube-sentinel/pkg/common"
+)
+
+const (
+	// syslogFacilityLocal0 is used for all audit messages
+	syslogFacilityLocal0 = 16
+	syslogSeverityWarn   = 4
+	syslogSeverityInfo   = 6
+
+	// syslogEnterpriseID is the private enterprise number used for the
+	// structured data element. 32473 is reserved for documentation.
+	syslogEnterpriseID = "32473"
+)
+
+// SyslogSink sends records as RFC5424 messages over UDP or TCP. TCP messages
+// use octet-counting framing (RFC6587).
+type SyslogSink struct {
+	network  string
+	address  string
+	hostname string
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// NewSyslogSink creates a sink for an address like udp://host:514 or
+// tcp://host:601. Addresses without a scheme default to UDP.
+func NewSyslogSink(addr string) (*SyslogSink, error) {
+	network, address := "udp", addr
+	if strings.Contains(addr, "://") {
+		u, err := url.Parse(addr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid syslog address: %w", err)
+		}
+		network, address = u.Scheme, u.Host
+	}
+	if network != "udp" && network != "tcp" {
+		return nil, fmt.Errorf("unsupported syslog network %q", network)
+	}
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+	return &SyslogSink{network: network, address: address, hostname: hostname}, nil
+}
+
+func (s *SyslogSink) Name() string {
+	return "syslog"
+}
+
+// formatRFC5424 renders a record as an RFC5424 syslog message
+func formatRFC5424(r Record, hostname string) (string, error) {
+	severity := syslogSeverityInfo
+	if !r.Success {
+		severity = syslogSeverityWarn
+	}
+	msg, err := json.Marshal(r)
+	if err != nil {
+		return "", err
+	}
+
+	params := []struct{ key, value string }{
+		{"id", fmt.Sprintf("%d", r.ID)},
+		{"actorId", fmt.Sprintf("%d", r.ActorID)},
+		{"source", r.Source},
+		{"cluster", r.ClusterName},
+		{"resourceType", r.ResourceType},
+		{"namespace", r.Namespace},
+		{"resourceName", r.ResourceName},
+		{"success", fmt.Sprintf("%t", r.Success)},
+	}
+	var sd strings.Builder
+	sd.WriteString("[audit@" + syslogEnterpriseID)
+	for _, p := range params {
+		if p.value == "" {
+			continue
+		}
+		fmt.Fprintf(&sd, " %s=\"%s\"", p.key, escapeSDParam(p.value))
+	}
+	sd.WriteString("]")
+
+	msgID := syslogToken(r.Action, 32)
+	return fmt.Sprintf("<%d>1 %s %s %s %d %s %s %s",
+		syslogFacilityLocal0*8+severity,
+		r.Timestamp.UTC().Format(time.RFC3339Nano),
+		syslogToken(hostname, 255),
+		common.AppName,
+		os.Getpid(),
+		msgID,
+		sd.String(),
+		msg,
+	), nil
+}
+
+// escapeSDParam escapes the characters RFC5424 requires in param values
+func escapeSDParam(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(v)
+}
+
+// syslogToken makes a header field safe: printable ASCII without spaces,
+// truncated to max, or "-" when empty
+func syslogToken(v string, max int) string {
+	var b strings.Builder
+	for _, r := range v {
+		if r > 32 && r < 127 {
+			b.WriteRune(r)
+		}
+	}
+	out := b.String()
+	if len(out) > max {
+		out = out[:max]
+	}
+	if out == "" {
+		return "-"
+	}
+	return out
+}
+
+func (s *SyslogSink) Write(r Record) error {
+	msg, err := formatRFC5424(r, s.hostname)
+	if err != nil {
+		return err
+	}
+	if s.network == "tcp" {
+		msg = fmt.Sprintf("%d %s", len(msg), msg)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Retry once with a fresh connection in case the previous one went away
+	for attempt := 0; attempt < 2; attempt++ {
+		if s.conn == nil {
+			conn, err := net.DialTimeout(s.network, s.address, 5*time.Second)
+			if err != nil {
+				return err
+			}
+			s.conn = conn
+		}
+		_ = s.conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+		if _, err = s.conn.Write([]byte(msg)); err == nil {
+			return nil
+		}
+		_ = s.conn.Close()
+		s.conn = nil
+	}
+	return err
+}
+
+func (s *SyslogSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
@@ -0,0 +1,376 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+const (
+	webhookBatchSize  = 100
+	webhookMinBackoff = time.Second
+	webhookMaxBackoff = 5 * time.Minute
+)
+
+// WebhookSink posts records as JSON arrays to an HTTP endpoint. Records are
+// first appended to a buffer file on disk and delivered from a read offset
+// stored next to it, so nothing is lost across restarts or short outages.
+// Undelivered records are limited to maxSize bytes, beyond that the oldest
+// ones are dropped.
+type WebhookSink struct {
+	url        string
+	token      string
+	bufferPath string
+	maxSize    int64
+	client     *http.Client
+
+	mu sync.Mutex
+	// offset is where the undelivered records start in the buffer file, and
+	// size is the length of the file. base counts the bytes cut off the
+	// front of the file since the start, so batches read before a
+	// compaction are acknowledged at the right place.
+	offset  int64
+	size    int64
+	base    int64
+	notify  chan struct{}
+	cancel  context.CancelFunc
+	stopped chan struct{}
+}
+
+func NewWebhookSink(url, token, bufferPath string, maxSize int64) (*WebhookSink, error) {
+	if err := os.MkdirAll(filepath.Dir(bufferPath), 0o700); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &WebhookSink{
+		url:        url,
+		token:      token,
+		bufferPath: bufferPath,
+		maxSize:    maxSize,
+		client:     &http.Client{Timeout: 10 * time.Second},
+		notify:     make(chan struct{}, 1),
+		cancel:     cancel,
+		stopped:    make(chan struct{}),
+	}
+	if err := s.load(); err != nil {
+		cancel()
+		return nil, err
+	}
+	go s.run(ctx)
+	// Deliver anything left over from a previous run
+	s.signal()
+	return s, nil
+}
+
+func (s *WebhookSink) Name() string {
+	return "webhook"
+}
+
+func (s *WebhookSink) offsetPath() string {
+	return s.bufferPath + ".offset"
+}
+
+// load restores the size of the buffer file and the read offset of a
+// previous run
+func (s *WebhookSink) load() error {
+	info, err := os.Stat(s.bufferPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	s.size = info.Size()
+	data, err := os.ReadFile(s.offsetPath())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	offset, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil || offset < 0 || offset > s.size {
+		klog.Warningf("Invalid audit webhook buffer offset %q, delivering the whole buffer", data)
+		return nil
+	}
+	s.offset = offset
+	return nil
+}
+
+func (s *WebhookSink) saveOffset() error {
+	tmp := s.offsetPath() + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strconv.FormatInt(s.offset, 10)), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.offsetPath())
+}
+
+func (s *WebhookSink) signal() {
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (s *WebhookSink) Write(r Record) error {
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	err = s.makeRoom(int64(len(line)))
+	if err == nil {
+		err = s.appendLine(line)
+	}
+	s.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to buffer audit record: %w", err)
+	}
+	s.signal()
+	return nil
+}
+
+func (s *WebhookSink) appendLine(line []byte) error {
+	f, err := os.OpenFile(s.bufferPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	n, err := f.Write(line)
+	s.size += int64(n)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// makeRoom drops the oldest undelivered records until n more bytes fit into
+// maxSize
+func (s *WebhookSink) makeRoom(n int64) error {
+	excess := s.size - s.offset + n - s.maxSize
+	if s.maxSize <= 0 || excess <= 0 {
+		return nil
+	}
+	f, err := os.Open(s.bufferPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	if _, err := f.Seek(s.offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	reader := bufio.NewReader(f)
+	var skipped int64
+	dropped := 0
+	for skipped < excess {
+		line, err := reader.ReadBytes('\n')
+		if len(line) == 0 || line[len(line)-1] != '\n' {
+			break
+		}
+		skipped += int64(len(line))
+		if len(bytes.TrimSpace(line)) > 0 {
+			dropped++
+		}
+		if err != nil {
+			break
+		}
+	}
+	klog.Warningf("Audit webhook buffer is full (%d bytes), dropped the %d oldest undelivered records", s.maxSize, dropped)
+	return s.advance(s.offset + skipped)
+}
+
+// advance moves the read offset past delivered or dropped records. The
+// buffer file is emptied once everything is delivered, and the records
+// before the offset are cut off once they take more than maxSize, so the
+// file stays within twice that size.
+func (s *WebhookSink) advance(offset int64) error {
+	if offset <= s.offset {
+		return nil
+	}
+	s.offset = offset
+	switch {
+	case s.offset >= s.size:
+		if err := os.Truncate(s.bufferPath, 0); err != nil {
+			return err
+		}
+		s.base += s.size
+		s.offset, s.size = 0, 0
+	case s.maxSize > 0 && s.offset > s.maxSize:
+		if err := s.compact(); err != nil {
+			return err
+		}
+	}
+	return s.saveOffset()
+}
+
+// compact rewrites the buffer file without the records before the offset
+func (s *WebhookSink) compact() error {
+	src, err := os.Open(s.bufferPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = src.Close()
+	}()
+	if _, err := src.Seek(s.offset, io.SeekStart); err != nil {
+		return err
+	}
+	tmp := s.bufferPath + ".tmp"
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	n, err := io.Copy(dst, src)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.bufferPath); err != nil {
+		return err
+	}
+	s.base += s.offset
+	s.offset, s.size = 0, n
+	return nil
+}
+
+// readBatch returns up to webhookBatchSize undelivered records and the
+// position after the last of them, counted from the start of the buffer
+// before any compaction
+func (s *WebhookSink) readBatch() ([]json.RawMessage, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.offset >= s.size {
+		return nil, s.base + s.offset, nil
+	}
+	f, err := os.Open(s.bufferPath)
+	if err != nil {
+		return nil, s.base + s.offset, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	if _, err := f.Seek(s.offset, io.SeekStart); err != nil {
+		return nil, s.base + s.offset, err
+	}
+
+	var batch []json.RawMessage
+	end := s.offset
+	reader := bufio.NewReader(f)
+	for len(batch) < webhookBatchSize {
+		line, err := reader.ReadBytes('\n')
+		// A line without newline is still being written
+		if len(line) == 0 || line[len(line)-1] != '\n' {
+			break
+		}
+		end += int64(len(line))
+		if trimmed := bytes.TrimSpace(line); len(trimmed) > 0 {
+			batch = append(batch, json.RawMessage(trimmed))
+		}
+		if err != nil {
+			break
+		}
+	}
+	return batch, s.base + end, nil
+}
+
+// ack marks the records before end, a position returned by readBatch, as
+// delivered. Records written meanwhile may have dropped and cut off some of
+// them, so end is rebased onto the current buffer file.
+func (s *WebhookSink) ack(end int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.advance(end - s.base)
+}
+
+func (s *WebhookSink) send(ctx context.Context, batch []json.RawMessage) error {
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (s *WebhookSink) run(ctx context.Context) {
+	defer close(s.stopped)
+	backoff := webhookMinBackoff
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.notify:
+		}
+
+		for {
+			batch, end, err := s.readBatch()
+			if err != nil {
+				klog.Errorf("Failed to read audit webhook buffer: %v", err)
+				break
+			}
+			if len(batch) == 0 {
+				if err := s.ack(end); err != nil {
+					klog.Errorf("Failed to trim audit webhook buffer: %v", err)
+				}
+				break
+			}
+			if err := s.send(ctx, batch); err != nil {
+				klog.Warningf("Failed to deliver %d audit records to webhook, retrying in %s: %v", len(batch), backoff, err)
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(backoff):
+				}
+				backoff = min(backoff*2, webhookMaxBackoff)
+				continue
+			}
+			backoff = webhookMinBackoff
+			if err := s.ack(end); err != nil {
+				klog.Errorf("Failed to trim audit webhook buffer: %v", err)
+				break
+			}
+		}
+	}
+}
+
+// Close stops delivery. Undelivered records stay in the buffer file and are
+// sent on the next start.
+func (s *WebhookSink) Close() error {
+	s.cancel()
+	<-s.stopped
+	return nil
+}
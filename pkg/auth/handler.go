@@ -1,12 +1,14 @@
 package auth
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/pixelvide/kube-sentinel/pkg/audit"
 	"github.com/pixelvide/kube-sentinel/pkg/cluster"
 	"github.com/pixelvide/kube-sentinel/pkg/common"
 	"github.com/pixelvide/kube-sentinel/pkg/model"
@@ -29,11 +31,15 @@ func NewAuthHandler(cm *cluster.ClusterManager) *AuthHandler {
 
 func (h *AuthHandler) GetProviders(c *gin.Context) {
 	providers := h.manager.GetAvailableProviders()
+	ldapProviders := h.manager.GetAvailableLDAPProviders()
+	providers = append(providers, ldapProviders...)
 	if model.IsLocalLoginEnabled() {
 		providers = append(providers, "password")
 	}
 	c.JSON(http.StatusOK, gin.H{
 		"providers": providers,
+		// LDAP providers log in with a username and password form
+		"ldapProviders": ldapProviders,
 	})
 }
 
@@ -83,11 +89,19 @@ func (h *AuthHandler) PasswordLogin(c *gin.Context) {
 
 	user, err := model.GetUserByUsername(req.Username)
 	if err != nil {
+		recordLoginAudit(c, "login_failed", nil, req.Username, errors.New("user not found"))
 		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
 		return
 	}
 
+	if user.IsLocked(time.Now()) {
+		recordLoginAudit(c, "login_failed", user, user.Username, errors.New("account locked"))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Account is temporarily locked after too many failed logins, try again later"})
+		return
+	}
+
 	if !model.CheckPassword(user.Password, req.Password) {
+		loginFailed(c, user, errors.New("invalid credentials"))
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
 		return
 	}
@@ -108,12 +122,20 @@ func (h *AuthHandler) PasswordLogin(c *gin.Context) {
 		return
 	}
 
+	recoveryCodes, ok := checkSecondFactor(c, user, req.Code)
+	if !ok {
+		return
+	}
+
+	// A successful login clears earlier failures
+	user.FailedLogins = 0
+	user.LockedUntil = nil
 	if err := model.LoginUser(user); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed login"})
 		return
 	}
 
-	jwtToken, err := h.manager.GenerateJWT(user, "")
+	jwtToken, err := h.startSession(c, user, "")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate JWT"})
 		return
@@ -125,6 +147,10 @@ func (h *AuthHandler) PasswordLogin(c *gin.Context) {
 		h.cm.UpdateUserActivity(user.ID)
 	}
 
+	if len(recoveryCodes) > 0 {
+		c.JSON(http.StatusOK, gin.H{"recoveryCodes": recoveryCodes})
+		return
+	}
 	c.Status(http.StatusNoContent)
 }
 
@@ -223,7 +249,7 @@ func (h *AuthHandler) Callback(c *gin.Context) {
 	}
 
 	// Generate JWT with refresh token support
-	jwtToken, err := h.manager.GenerateJWT(user, tokenResp.RefreshToken)
+	jwtToken, err := h.startSession(c, user, tokenResp.RefreshToken)
 	if err != nil {
 		c.Redirect(http.StatusFound, base+"/login?error=jwt_generation_failed&reason=jwt_generation_failed&user="+user.Key()+"&provider="+provider)
 		return
@@ -239,7 +265,25 @@ func (h *AuthHandler) Callback(c *gin.Context) {
 	c.Redirect(http.StatusFound, base+"/")
 }
 
+// startSession creates a server-side session for a user who just logged in
+// and returns the session JWT for it
+func (h *AuthHandler) startSession(c *gin.Context, user *model.User, refreshToken string) (string, error) {
+	session, err := model.CreateUserSession(user.ID, user.Provider, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		klog.Errorf("Failed to create session for user %s: %v", user.Key(), err)
+		return "", err
+	}
+	return h.manager.GenerateJWT(user, refreshToken, session.SessionID)
+}
+
 func (h *AuthHandler) Logout(c *gin.Context) {
+	if tokenString, _ := c.Cookie("auth_token"); tokenString != "" {
+		if sessionID, err := h.manager.SessionID(tokenString); err == nil && sessionID != "" {
+			if err := model.RevokeSessionByID(sessionID); err != nil {
+				klog.Errorf("Failed to revoke session on logout: %v", err)
+			}
+		}
+	}
 	setCookieSecure(c, "auth_token", "", -1)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -316,8 +360,13 @@ func (h *AuthHandler) RequireAPIKeyAuth(c *gin.Context, token string) {
 		"last_used_ip": pat.LastUsedIP,
 	})
 
+	pat.User.OIDCGroups = model.WithSCIMGroups(pat.User.ID, nil)
 	pat.User.Roles = rbac.GetUserRoles(pat.User)
+	if !pat.Scope.IsEmpty() {
+		pat.User.TokenScope = &pat.Scope
+	}
 	c.Set("user", pat.User)
+	c.Set(model.AuditSourceContextKey, model.AuditSourceAPIKey)
 
 	userConfig, err := model.GetUserConfig(pat.User.ID)
 	if err != nil {
@@ -372,6 +421,15 @@ func (h *AuthHandler) RequireAuth() gin.HandlerFunc {
 				return
 			}
 		}
+		session, err := model.GetActiveUserSession(claims.ID)
+		if err != nil || session.UserID != claims.UserID {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Session has been revoked or expired",
+			})
+			setCookieSecure(c, "auth_token", "", -1)
+			c.Abort()
+			return
+		}
 		user, err := model.GetUserByID(uint64(claims.UserID))
 		if err != nil || !user.Enabled {
 			c.JSON(http.StatusUnauthorized, gin.H{
@@ -381,9 +439,13 @@ func (h *AuthHandler) RequireAuth() gin.HandlerFunc {
 			c.Abort()
 			return
 		}
-		user.OIDCGroups = claims.OIDCGroups
+		if err := model.TouchUserSession(session, c.ClientIP()); err != nil {
+			klog.Errorf("Failed to update session %d: %v", session.ID, err)
+		}
+		user.OIDCGroups = model.WithSCIMGroups(user.ID, claims.OIDCGroups)
 		user.Roles = rbac.GetUserRoles(*user)
 		c.Set("user", *user)
+		c.Set("sessionID", session.ID)
 
 		userConfig, err := model.GetUserConfig(user.ID)
 		if err != nil {
@@ -607,6 +669,65 @@ func (h *AuthHandler) GetOAuthProvider(c *gin.Context) {
 	})
 }
 
+// JWKS serves the public keys session JWTs are signed with, so other services
+// can verify kube-sentinel tokens without the signing secret
+func (h *AuthHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, gin.H{
+		"keys": h.manager.JWKS(),
+	})
+}
+
+// Signing Key Management APIs
+
+func (h *AuthHandler) ListSigningKeys(c *gin.Context) {
+	keys, err := model.ListSigningKeys()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve signing keys",
+		})
+		return
+	}
+	current := ""
+	if h.manager.algorithm != "HS256" {
+		if key, err := h.manager.keys.signingKey(); err == nil {
+			current = key.kid
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"algorithm": h.manager.algorithm,
+		"current":   current,
+		"keys":      keys,
+	})
+}
+
+// RotateSigningKey generates a new signing key right away. Tokens signed by
+// the previous keys stay valid until their sessions end.
+func (h *AuthHandler) RotateSigningKey(c *gin.Context) {
+	if h.manager.algorithm == "HS256" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Key rotation requires JWT_SIGNING_ALGORITHM RS256 or ES256",
+		})
+		return
+	}
+	err := h.manager.keys.rotate()
+	entry := audit.FromRequest(c, "rotate")
+	entry.ResourceType = "signing-keys"
+	audit.Emit(entry.WithError(err))
+	if err != nil {
+		klog.Errorf("Failed to rotate JWT signing key: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to rotate signing key",
+		})
+		return
+	}
+	key, _ := h.manager.keys.signingKey()
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"kid":     key.kid,
+	})
+}
+
 // setCookieSecure sets a cookie with SameSite=Lax and HttpOnly=true. It marks Secure=true
 // when the request is over TLS or X-Forwarded-Proto indicates https, or when
 // common.Host appears to be an https scheme.
@@ -0,0 +1,277 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pixelvide/kube-sentinel/pkg/common"
+	"github.com/pixelvide/kube-sentinel/pkg/model"
+	"github.com/pixelvide/kube-sentinel/pkg/utils"
+	"k8s.io/klog/v2"
+)
+
+// keyReloadInterval is how often keys are re-read from the database, so
+// replicas pick up keys rotated by another replica
+const keyReloadInterval = time.Minute
+
+// signingKey is a parsed model.SigningKey
+type signingKey struct {
+	kid       string
+	method    jwt.SigningMethod
+	private   crypto.Signer
+	public    crypto.PublicKey
+	createdAt time.Time
+	retired   bool
+}
+
+// keyStore caches the signing keys stored in the database
+type keyStore struct {
+	mu       sync.RWMutex
+	keys     map[string]*signingKey
+	current  *signingKey
+	loadedAt time.Time
+}
+
+var signingKeys = &keyStore{}
+
+// InitSigningKeys loads the RS256/ES256 signing keys, creates the first one
+// if needed, and starts the scheduled rotation. It does nothing for HS256.
+func InitSigningKeys() {
+	if common.JWTSigningAlgorithm == "HS256" {
+		return
+	}
+	if err := signingKeys.rotateIfDue(); err != nil {
+		klog.Fatalf("Failed to initialize JWT signing keys: %v", err)
+	}
+	go func() {
+		ticker := time.NewTicker(keyReloadInterval)
+		for range ticker.C {
+			if err := signingKeys.rotateIfDue(); err != nil {
+				klog.Errorf("Failed to rotate JWT signing keys: %v", err)
+			}
+		}
+	}()
+}
+
+func signingMethod(alg string) (jwt.SigningMethod, error) {
+	switch alg {
+	case "RS256":
+		return jwt.SigningMethodRS256, nil
+	case "ES256":
+		return jwt.SigningMethodES256, nil
+	}
+	return nil, fmt.Errorf("unsupported signing algorithm %s", alg)
+}
+
+func parseSigningKey(k model.SigningKey) (*signingKey, error) {
+	method, err := signingMethod(k.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode([]byte(k.PrivateKey))
+	if block == nil {
+		return nil, fmt.Errorf("invalid private key PEM")
+	}
+	priv, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := priv.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", priv)
+	}
+	return &signingKey{
+		kid:       k.KID,
+		method:    method,
+		private:   signer,
+		public:    signer.Public(),
+		createdAt: k.CreatedAt,
+		retired:   k.RetiredAt != nil,
+	}, nil
+}
+
+// load re-reads the keys from the database. Keys retired for longer than a
+// session can live are deleted first, as no live session can hold a token
+// signed by them.
+func (s *keyStore) load() error {
+	if err := model.DeleteSigningKeysRetiredBefore(time.Now().Add(-common.SessionMaxAge)); err != nil {
+		return err
+	}
+	stored, err := model.ListSigningKeys()
+	if err != nil {
+		return err
+	}
+	keys := make(map[string]*signingKey, len(stored))
+	var current *signingKey
+	for _, k := range stored {
+		key, err := parseSigningKey(k)
+		if err != nil {
+			klog.Warningf("Skipping JWT signing key %s: %v", k.KID, err)
+			continue
+		}
+		keys[key.kid] = key
+		if current == nil && !key.retired && key.method.Alg() == common.JWTSigningAlgorithm {
+			current = key
+		}
+	}
+
+	s.mu.Lock()
+	s.keys = keys
+	s.current = current
+	s.loadedAt = time.Now()
+	s.mu.Unlock()
+	return nil
+}
+
+// rotate generates a new key for the configured algorithm and makes it the
+// signing key
+func (s *keyStore) rotate() error {
+	var priv crypto.Signer
+	var err error
+	switch common.JWTSigningAlgorithm {
+	case "RS256":
+		priv, err = rsa.GenerateKey(rand.Reader, 2048)
+	case "ES256":
+		priv, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	default:
+		return fmt.Errorf("unsupported signing algorithm %s", common.JWTSigningAlgorithm)
+	}
+	if err != nil {
+		return err
+	}
+	privDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return err
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(priv.Public())
+	if err != nil {
+		return err
+	}
+
+	key := &model.SigningKey{
+		KID:        utils.RandomString(16),
+		Algorithm:  common.JWTSigningAlgorithm,
+		PrivateKey: model.SecretString(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER})),
+		PublicKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER})),
+	}
+	if err := model.CreateSigningKey(key); err != nil {
+		return err
+	}
+	klog.Infof("Rotated JWT signing key, new kid %s (%s)", key.KID, key.Algorithm)
+	return s.load()
+}
+
+// rotateIfDue reloads the keys and rotates when there is no signing key for
+// the configured algorithm or it is older than the rotation interval. Two
+// replicas may rotate at the same time; both keys then verify and the newest
+// one signs.
+func (s *keyStore) rotateIfDue() error {
+	if err := s.load(); err != nil {
+		return err
+	}
+	s.mu.RLock()
+	current := s.current
+	s.mu.RUnlock()
+	if current != nil && (common.JWTKeyRotationInterval == 0 || time.Since(current.createdAt) < common.JWTKeyRotationInterval) {
+		return nil
+	}
+	return s.rotate()
+}
+
+// signingKey returns the key new tokens are signed with
+func (s *keyStore) signingKey() (*signingKey, error) {
+	s.mu.RLock()
+	current := s.current
+	s.mu.RUnlock()
+	if current != nil {
+		return current, nil
+	}
+	if err := s.rotateIfDue(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.current == nil {
+		return nil, fmt.Errorf("no JWT signing key available")
+	}
+	return s.current, nil
+}
+
+// verificationKey returns the key with the given kid. Unknown kids trigger a
+// reload, rate limited by keyReloadInterval, in case another replica rotated.
+func (s *keyStore) verificationKey(kid string) (*signingKey, error) {
+	s.mu.RLock()
+	key, ok := s.keys[kid]
+	stale := time.Since(s.loadedAt) > keyReloadInterval
+	s.mu.RUnlock()
+	if ok {
+		return key, nil
+	}
+	if stale {
+		if err := s.load(); err != nil {
+			return nil, err
+		}
+		s.mu.RLock()
+		key, ok = s.keys[kid]
+		s.mu.RUnlock()
+		if ok {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// JWK is a public key in JSON Web Key format
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// jwks returns the public keys of all keys that may have signed a live token
+func (s *keyStore) jwks() []JWK {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	keys := make([]JWK, 0, len(s.keys))
+	for _, k := range s.keys {
+		jwk := JWK{Use: "sig", Alg: k.method.Alg(), Kid: k.kid}
+		switch pub := k.public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case *ecdsa.PublicKey:
+			ecdhKey, err := pub.ECDH()
+			if err != nil {
+				continue
+			}
+			// Uncompressed point: 0x04 || X || Y
+			point := ecdhKey.Bytes()[1:]
+			jwk.Kty = "EC"
+			jwk.Crv = pub.Curve.Params().Name
+			jwk.X = base64.RawURLEncoding.EncodeToString(point[:len(point)/2])
+			jwk.Y = base64.RawURLEncoding.EncodeToString(point[len(point)/2:])
+		default:
+			continue
+		}
+		keys = append(keys, jwk)
+	}
+	return keys
+}
@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pixelvide/kube-sentinel/pkg/common"
+	"github.com/pixelvide/kube-sentinel/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupSigningKeyTest(t *testing.T, alg string) {
+	if model.DB == nil {
+		common.DBType = "sqlite"
+		common.DBDSN = "file::memory:?cache=shared"
+		model.InitDB()
+	}
+	model.DB.Exec("DELETE FROM signing_keys")
+	prevAlg := common.JWTSigningAlgorithm
+	common.JWTSigningAlgorithm = alg
+	signingKeys = &keyStore{}
+	t.Cleanup(func() {
+		common.JWTSigningAlgorithm = prevAlg
+		signingKeys = &keyStore{}
+	})
+}
+
+func decodeJWKInt(t *testing.T, s string) *big.Int {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	require.NoError(t, err)
+	return new(big.Int).SetBytes(b)
+}
+
+func TestAsymmetricJWTRotation(t *testing.T) {
+	for _, alg := range []string{"RS256", "ES256"} {
+		t.Run(alg, func(t *testing.T) {
+			setupSigningKeyTest(t, alg)
+			om := NewOAuthManager()
+			user := &model.User{Model: model.Model{ID: 1}, Username: "alice", Provider: "password"}
+
+			oldToken, err := om.GenerateJWT(user, "", "session-1")
+			require.NoError(t, err)
+			claims, err := om.ValidateJWT(oldToken)
+			require.NoError(t, err)
+			assert.Equal(t, "alice", claims.Username)
+
+			oldKey, err := signingKeys.signingKey()
+			require.NoError(t, err)
+			require.NoError(t, signingKeys.rotate())
+			newKey, err := signingKeys.signingKey()
+			require.NoError(t, err)
+			assert.NotEqual(t, oldKey.kid, newKey.kid)
+
+			// Tokens of the retired key stay valid, new tokens use the new kid
+			_, err = om.ValidateJWT(oldToken)
+			require.NoError(t, err)
+			newToken, err := om.GenerateJWT(user, "", "session-1")
+			require.NoError(t, err)
+			parsed, _, err := jwt.NewParser().ParseUnverified(newToken, &Claims{})
+			require.NoError(t, err)
+			assert.Equal(t, newKey.kid, parsed.Header["kid"])
+			assert.Equal(t, alg, parsed.Header["alg"])
+
+			keys := om.JWKS()
+			require.Len(t, keys, 2)
+			for _, k := range keys {
+				assert.Equal(t, alg, k.Alg)
+				assert.Equal(t, "sig", k.Use)
+			}
+		})
+	}
+}
+
+func TestJWKSMatchesSigningKeys(t *testing.T) {
+	setupSigningKeyTest(t, "ES256")
+	om := NewOAuthManager()
+	token, err := om.GenerateJWT(&model.User{Model: model.Model{ID: 1}, Username: "alice"}, "", "s")
+	require.NoError(t, err)
+
+	// A sidecar verifying with only the published key accepts the token
+	keys := om.JWKS()
+	require.Len(t, keys, 1)
+	assert.Equal(t, "EC", keys[0].Kty)
+	assert.Equal(t, "P-256", keys[0].Crv)
+	pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: decodeJWKInt(t, keys[0].X), Y: decodeJWKInt(t, keys[0].Y)}
+	_, err = jwt.Parse(token, func(*jwt.Token) (interface{}, error) { return pub, nil })
+	assert.NoError(t, err)
+
+	setupSigningKeyTest(t, "RS256")
+	om = NewOAuthManager()
+	token, err = om.GenerateJWT(&model.User{Model: model.Model{ID: 1}, Username: "alice"}, "", "s")
+	require.NoError(t, err)
+	keys = om.JWKS()
+	require.Len(t, keys, 1)
+	rsaPub := &rsa.PublicKey{N: decodeJWKInt(t, keys[0].N), E: int(decodeJWKInt(t, keys[0].E).Int64())}
+	_, err = jwt.Parse(token, func(*jwt.Token) (interface{}, error) { return rsaPub, nil })
+	assert.NoError(t, err)
+}
+
+func TestAsymmetricJWTRejectsHMACTokens(t *testing.T) {
+	setupSigningKeyTest(t, "RS256")
+	common.JwtSecret = "testsecret"
+	hsToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"user_id": 1}).
+		SignedString([]byte(common.JwtSecret))
+	require.NoError(t, err)
+
+	_, err = NewOAuthManager().ValidateJWT(hsToken)
+	assert.Error(t, err)
+}
@@ -0,0 +1,203 @@
+package auth
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/pixelvide/kube-sentinel/pkg/model"
+)
+
+const ldapTimeout = 10 * time.Second
+
+// errInvalidLDAPCredentials is returned for unknown users and wrong
+// passwords alike, so logins do not reveal which usernames exist
+var errInvalidLDAPCredentials = errors.New("invalid credentials")
+
+// ldapConn is the part of an LDAP connection logins use
+type ldapConn interface {
+	Bind(username, password string) error
+	Search(req *ldap.SearchRequest) (*ldap.SearchResult, error)
+	Close() error
+}
+
+// dialLDAP connects to the server of an LDAP provider, upgrading the
+// connection with StartTLS when configured. Tests replace it with a stand-in
+// directory.
+var dialLDAP = func(p model.LDAPProvider) (ldapConn, error) {
+	u, err := url.Parse(p.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid LDAP URL: %w", err)
+	}
+	tlsConfig := &tls.Config{
+		ServerName:         u.Hostname(),
+		InsecureSkipVerify: p.InsecureSkipVerify, //nolint:gosec // opt-in for test directories
+	}
+	conn, err := ldap.DialURL(p.URL,
+		ldap.DialWithDialer(&net.Dialer{Timeout: ldapTimeout}),
+		ldap.DialWithTLSConfig(tlsConfig))
+	if err != nil {
+		return nil, err
+	}
+	conn.SetTimeout(ldapTimeout)
+	if p.StartTLS {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("StartTLS: %w", err)
+		}
+	}
+	return conn, nil
+}
+
+// withLDAPDefaults fills in the search settings of a provider that were left
+// empty with values for OpenLDAP style directories
+func withLDAPDefaults(p model.LDAPProvider) model.LDAPProvider {
+	if p.UserFilter == "" {
+		p.UserFilter = "(uid={username})"
+	}
+	if p.UsernameAttribute == "" {
+		p.UsernameAttribute = "uid"
+	}
+	if p.NameAttribute == "" {
+		p.NameAttribute = "cn"
+	}
+	if p.EmailAttribute == "" {
+		p.EmailAttribute = "mail"
+	}
+	if p.GroupFilter == "" {
+		p.GroupFilter = "(member={dn})"
+	}
+	if p.GroupNameAttribute == "" {
+		p.GroupNameAttribute = "cn"
+	}
+	return p
+}
+
+// authenticateLDAP checks a username and password against an LDAP provider.
+// It looks up the user entry with the service account, binds as the user to
+// check the password and reads the groups of the user. The returned user is
+// ready for model.FindWithSubOrUpsertUser, with the entry DN as subject and
+// the groups as OIDCGroups so group role assignments apply.
+func authenticateLDAP(p model.LDAPProvider, username, password string) (*model.User, error) {
+	// An empty password would make an unauthenticated bind that succeeds
+	if username == "" || password == "" {
+		return nil, errInvalidLDAPCredentials
+	}
+	p = withLDAPDefaults(p)
+
+	conn, err := dialLDAP(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to LDAP server: %w", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	if err := bindServiceAccount(conn, p); err != nil {
+		return nil, err
+	}
+
+	filter := strings.ReplaceAll(p.UserFilter, "{username}", ldap.EscapeFilter(username))
+	result, err := conn.Search(ldap.NewSearchRequest(
+		p.UserSearchBase, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 2, int(ldapTimeout.Seconds()), false,
+		filter, []string{p.UsernameAttribute, p.NameAttribute, p.EmailAttribute, "memberOf"}, nil,
+	))
+	if ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) || ldap.IsErrorWithCode(err, ldap.LDAPResultNoSuchObject) {
+		return nil, errInvalidLDAPCredentials
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to search LDAP user: %w", err)
+	}
+	if len(result.Entries) != 1 {
+		return nil, errInvalidLDAPCredentials
+	}
+	entry := result.Entries[0]
+
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, errInvalidLDAPCredentials
+		}
+		return nil, fmt.Errorf("failed to bind as LDAP user: %w", err)
+	}
+
+	groups, err := ldapGroups(conn, p, entry, username)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &model.User{
+		Provider:   string(p.Name),
+		Sub:        entry.DN,
+		Username:   entry.GetAttributeValue(p.UsernameAttribute),
+		Name:       entry.GetAttributeValue(p.NameAttribute),
+		Email:      entry.GetAttributeValue(p.EmailAttribute),
+		OIDCGroups: groups,
+	}
+	if user.Username == "" {
+		user.Username = username
+	}
+	return user, nil
+}
+
+// bindServiceAccount binds with the service account of the provider, if it
+// has one
+func bindServiceAccount(conn ldapConn, p model.LDAPProvider) error {
+	if p.BindDN == "" {
+		return nil
+	}
+	if err := conn.Bind(p.BindDN, string(p.BindPassword)); err != nil {
+		return fmt.Errorf("failed to bind LDAP service account: %w", err)
+	}
+	return nil
+}
+
+// ldapGroups returns the group names of a user, searched under the group
+// search base or read from its memberOf attribute
+func ldapGroups(conn ldapConn, p model.LDAPProvider, entry *ldap.Entry, username string) ([]string, error) {
+	if p.GroupSearchBase == "" {
+		var groups []string
+		for _, dn := range entry.GetAttributeValues("memberOf") {
+			if name := firstRDNValue(dn); name != "" {
+				groups = append(groups, name)
+			}
+		}
+		return groups, nil
+	}
+
+	// The user may not be allowed to read groups, search them as the
+	// service account again
+	if err := bindServiceAccount(conn, p); err != nil {
+		return nil, err
+	}
+	filter := strings.NewReplacer(
+		"{dn}", ldap.EscapeFilter(entry.DN),
+		"{username}", ldap.EscapeFilter(username),
+	).Replace(p.GroupFilter)
+	result, err := conn.Search(ldap.NewSearchRequest(
+		p.GroupSearchBase, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, int(ldapTimeout.Seconds()), false,
+		filter, []string{p.GroupNameAttribute}, nil,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to search LDAP groups: %w", err)
+	}
+	var groups []string
+	for _, group := range result.Entries {
+		if name := group.GetAttributeValue(p.GroupNameAttribute); name != "" {
+			groups = append(groups, name)
+		}
+	}
+	return groups, nil
+}
+
+// firstRDNValue returns the value of the first RDN of a DN, the group name
+// of a memberOf value like cn=admins,ou=groups,dc=example,dc=org
+func firstRDNValue(dn string) string {
+	parsed, err := ldap.ParseDN(dn)
+	if err != nil || len(parsed.RDNs) == 0 || len(parsed.RDNs[0].Attributes) == 0 {
+		return ""
+	}
+	return parsed.RDNs[0].Attributes[0].Value
+}
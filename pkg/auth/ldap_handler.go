@@ -0,0 +1,296 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pixelvide/kube-sentinel/pkg/common"
+	"github.com/pixelvide/kube-sentinel/pkg/model"
+	"github.com/pixelvide/kube-sentinel/pkg/rbac"
+	"k8s.io/klog/v2"
+)
+
+// errLocalAccount refuses LDAP logins as users that have a local password
+var errLocalAccount = errors.New("local account")
+
+// LDAPLogin logs a user in with their username and password of an LDAP
+// provider. Directory users are linked and updated like OAuth users, with
+// their LDAP groups as OIDC groups.
+func (h *AuthHandler) LDAPLogin(c *gin.Context) {
+	var req common.LDAPLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	provider, err := model.GetLDAPProviderByName(req.Provider)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Provider not found: " + req.Provider})
+		return
+	}
+
+	user, err := authenticateLDAP(provider, req.Username, req.Password)
+	if errors.Is(err, errInvalidLDAPCredentials) {
+		recordLoginAudit(c, "login_failed", nil, req.Username, err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+		return
+	}
+	if err != nil {
+		klog.Errorf("LDAP login of %s with provider %s failed: %v", req.Username, provider.Name, err)
+		recordLoginAudit(c, "login_failed", nil, req.Username, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to authenticate with the LDAP server"})
+		return
+	}
+
+	// Users are linked by username, so an LDAP uid that matches a local
+	// password account, such as the super user, would otherwise sign in as
+	// it without its password, second factor or lockout
+	if existing, err := model.GetUserByUsername(user.Username); err == nil && existing.Password != "" {
+		recordLoginAudit(c, "login_failed", existing, req.Username, errLocalAccount)
+		c.JSON(http.StatusForbidden, gin.H{"error": "A local account with this username exists, sign in with its password"})
+		return
+	}
+
+	if err := model.FindWithSubOrUpsertUser(user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save user"})
+		return
+	}
+	// Identities linked before local accounts were refused
+	if user.Password != "" {
+		recordLoginAudit(c, "login_failed", user, req.Username, errLocalAccount)
+		c.JSON(http.StatusForbidden, gin.H{"error": "A local account with this username exists, sign in with its password"})
+		return
+	}
+	if user.IsLocked(time.Now()) {
+		recordLoginAudit(c, "login_failed", user, user.Username, errors.New("account locked"))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Account is temporarily locked after too many failed logins, try again later"})
+		return
+	}
+	klog.V(1).Infof("LDAP Login - User details: Username=%s, Name=%s, Sub=%s, OIDCGroups=%v",
+		user.Username, user.Name, user.Sub, user.OIDCGroups)
+
+	if len(rbac.GetUserRoles(*user)) == 0 {
+		klog.Warningf("LDAP Login - Access denied for user: %s (provider: %s), OIDCGroups: %v",
+			user.Key(), provider.Name, user.OIDCGroups)
+		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions to access this application"})
+		return
+	}
+	if !user.Enabled {
+		c.JSON(http.StatusForbidden, gin.H{"error": "user disabled"})
+		return
+	}
+
+	canAccess, err := model.CheckOrInitializeUserAccess(user.ID)
+	if err != nil {
+		klog.Errorf("Failed to check user access: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check user access"})
+		return
+	}
+	if !canAccess {
+		c.JSON(http.StatusForbidden, gin.H{"error": "you don't have access to the app please contact the team for access"})
+		return
+	}
+
+	jwtToken, err := h.startSession(c, user, "")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate JWT"})
+		return
+	}
+
+	setCookieSecure(c, "auth_token", jwtToken, common.CookieExpirationSeconds)
+
+	if h.cm != nil {
+		h.cm.UpdateUserActivity(user.ID)
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// LDAP Provider Management APIs
+
+func (h *AuthHandler) ListLDAPProviders(c *gin.Context) {
+	providers, err := model.GetAllLDAPProviders()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve LDAP providers",
+		})
+		return
+	}
+
+	// Don't expose bind passwords in the response
+	for i := range providers {
+		providers[i].BindPassword = maskSecret(providers[i].BindPassword)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"providers": providers,
+	})
+}
+
+func (h *AuthHandler) GetLDAPProvider(c *gin.Context) {
+	dbID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid provider ID",
+		})
+		return
+	}
+
+	provider, err := model.GetLDAPProviderByID(uint(dbID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "LDAP provider not found",
+		})
+		return
+	}
+
+	provider.BindPassword = maskSecret(provider.BindPassword)
+	c.JSON(http.StatusOK, gin.H{
+		"provider": provider,
+	})
+}
+
+func (h *AuthHandler) CreateLDAPProvider(c *gin.Context) {
+	var provider model.LDAPProvider
+	if err := c.ShouldBindJSON(&provider); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request payload: " + err.Error(),
+		})
+		return
+	}
+
+	if !validateLDAPProvider(c, &provider) {
+		return
+	}
+
+	if err := model.CreateLDAPProvider(&provider); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create LDAP provider: " + err.Error(),
+		})
+		return
+	}
+
+	provider.BindPassword = maskSecret(provider.BindPassword)
+	c.JSON(http.StatusCreated, gin.H{
+		"provider": provider,
+	})
+}
+
+func (h *AuthHandler) UpdateLDAPProvider(c *gin.Context) {
+	var provider model.LDAPProvider
+	if err := c.ShouldBindJSON(&provider); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request payload: " + err.Error(),
+		})
+		return
+	}
+
+	dbID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid provider ID",
+		})
+		return
+	}
+	provider.ID = uint(dbID)
+
+	if !validateLDAPProvider(c, &provider) {
+		return
+	}
+
+	updates := map[string]interface{}{
+		"name":                 provider.Name,
+		"url":                  provider.URL,
+		"start_tls":            provider.StartTLS,
+		"insecure_skip_verify": provider.InsecureSkipVerify,
+		"bind_dn":              provider.BindDN,
+		"user_search_base":     provider.UserSearchBase,
+		"user_filter":          provider.UserFilter,
+		"username_attribute":   provider.UsernameAttribute,
+		"name_attribute":       provider.NameAttribute,
+		"email_attribute":      provider.EmailAttribute,
+		"group_search_base":    provider.GroupSearchBase,
+		"group_filter":         provider.GroupFilter,
+		"group_name_attribute": provider.GroupNameAttribute,
+		"enabled":              provider.Enabled,
+	}
+	if provider.BindPassword != "" {
+		updates["bind_password"] = provider.BindPassword
+	}
+
+	if err := model.UpdateLDAPProvider(&provider, updates); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update LDAP provider: " + err.Error(),
+		})
+		return
+	}
+
+	provider.BindPassword = maskSecret(provider.BindPassword)
+	c.JSON(http.StatusOK, gin.H{
+		"provider": provider,
+	})
+}
+
+func (h *AuthHandler) DeleteLDAPProvider(c *gin.Context) {
+	dbID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid provider ID",
+		})
+		return
+	}
+
+	if err := model.DeleteLDAPProvider(uint(dbID)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to delete LDAP provider: " + err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "LDAP provider deleted successfully",
+	})
+}
+
+// validateLDAPProvider checks the required fields of an LDAP provider and
+// that its name is not used by another login provider
+func validateLDAPProvider(c *gin.Context, provider *model.LDAPProvider) bool {
+	if provider.Name == "" || provider.URL == "" || provider.UserSearchBase == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Name, URL, and UserSearchBase are required",
+		})
+		return false
+	}
+	if u, err := url.Parse(provider.URL); err != nil || (u.Scheme != "ldap" && u.Scheme != "ldaps") {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "URL must start with ldap:// or ldaps://",
+		})
+		return false
+	}
+	taken, err := model.IsLoginProviderNameTaken(string(provider.Name), provider.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to check provider name: " + err.Error(),
+		})
+		return false
+	}
+	if taken {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "A login provider named " + string(provider.Name) + " already exists",
+		})
+		return false
+	}
+	return true
+}
+
+// maskSecret hides a secret in API responses while showing whether it is set
+func maskSecret(secret model.SecretString) model.SecretString {
+	if secret == "" {
+		return ""
+	}
+	return "***"
+}
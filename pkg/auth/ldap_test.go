@@ -0,0 +1,329 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/pixelvide/kube-sentinel/pkg/common"
+	"github.com/pixelvide/kube-sentinel/pkg/model"
+	"github.com/pixelvide/kube-sentinel/pkg/rbac"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDirectory is an in-memory stand-in for an LDAP server. It supports
+// the simple binds and the equality, presence, and, or and not filters
+// logins use.
+type fakeDirectory struct {
+	entries   []*ldap.Entry
+	passwords map[string]string
+	bound     string
+}
+
+func (d *fakeDirectory) Bind(username, password string) error {
+	if password == "" || d.passwords[username] != password {
+		return ldap.NewError(ldap.LDAPResultInvalidCredentials, errors.New("invalid credentials"))
+	}
+	d.bound = username
+	return nil
+}
+
+func (d *fakeDirectory) Search(req *ldap.SearchRequest) (*ldap.SearchResult, error) {
+	if d.bound == "" {
+		return nil, ldap.NewError(ldap.LDAPResultInsufficientAccessRights, errors.New("anonymous search"))
+	}
+	filter, err := ldap.CompileFilter(req.Filter)
+	if err != nil {
+		return nil, err
+	}
+	result := &ldap.SearchResult{}
+	for _, entry := range d.entries {
+		if !strings.HasSuffix(strings.ToLower(entry.DN), strings.ToLower(req.BaseDN)) || !matchFilter(filter, entry) {
+			continue
+		}
+		if req.SizeLimit > 0 && len(result.Entries) == req.SizeLimit {
+			return result, ldap.NewError(ldap.LDAPResultSizeLimitExceeded, errors.New("size limit exceeded"))
+		}
+		result.Entries = append(result.Entries, entry)
+	}
+	return result, nil
+}
+
+func (d *fakeDirectory) Close() error {
+	return nil
+}
+
+func matchFilter(filter *ber.Packet, entry *ldap.Entry) bool {
+	switch filter.Tag {
+	case ldap.FilterAnd:
+		for _, child := range filter.Children {
+			if !matchFilter(child, entry) {
+				return false
+			}
+		}
+		return true
+	case ldap.FilterOr:
+		for _, child := range filter.Children {
+			if matchFilter(child, entry) {
+				return true
+			}
+		}
+		return false
+	case ldap.FilterNot:
+		return !matchFilter(filter.Children[0], entry)
+	case ldap.FilterPresent:
+		return len(entry.GetAttributeValues(filter.Data.String())) > 0
+	case ldap.FilterEqualityMatch:
+		value := filter.Children[1].Data.String()
+		for _, v := range entry.GetAttributeValues(filter.Children[0].Data.String()) {
+			if strings.EqualFold(v, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func newFakeDirectory() *fakeDirectory {
+	return &fakeDirectory{
+		entries: []*ldap.Entry{
+			ldap.NewEntry("uid=alice,ou=people,dc=example,dc=org", map[string][]string{
+				"objectClass": {"inetOrgPerson"},
+				"uid":         {"alice"},
+				"cn":          {"Alice Liddell"},
+				"mail":        {"alice@example.org"},
+			}),
+			ldap.NewEntry("cn=Bob Builder,ou=people,dc=example,dc=org", map[string][]string{
+				"objectClass":    {"user"},
+				"sAMAccountName": {"bob"},
+				"displayName":    {"Bob Builder"},
+				"memberOf":       {"CN=k8s-viewers,OU=Groups,DC=example,DC=org"},
+			}),
+			ldap.NewEntry("cn=k8s-admins,ou=groups,dc=example,dc=org", map[string][]string{
+				"objectClass": {"groupOfNames"},
+				"cn":          {"k8s-admins"},
+				"member":      {"uid=alice,ou=people,dc=example,dc=org"},
+			}),
+			ldap.NewEntry("cn=developers,ou=groups,dc=example,dc=org", map[string][]string{
+				"objectClass": {"groupOfNames"},
+				"cn":          {"developers"},
+				"member":      {"uid=alice,ou=people,dc=example,dc=org", "uid=carol,ou=people,dc=example,dc=org"},
+			}),
+		},
+		passwords: map[string]string{
+			"cn=reader,dc=example,dc=org":                "reader-password",
+			"uid=alice,ou=people,dc=example,dc=org":      "alice-password",
+			"cn=Bob Builder,ou=people,dc=example,dc=org": "bob-password",
+		},
+	}
+}
+
+func useFakeDirectory(t *testing.T, directory *fakeDirectory) {
+	original := dialLDAP
+	dialLDAP = func(model.LDAPProvider) (ldapConn, error) {
+		directory.bound = ""
+		return directory, nil
+	}
+	t.Cleanup(func() { dialLDAP = original })
+}
+
+func testLDAPProvider() model.LDAPProvider {
+	return model.LDAPProvider{
+		Name:            "corp",
+		URL:             "ldap://ldap.example.org",
+		BindDN:          "cn=reader,dc=example,dc=org",
+		BindPassword:    "reader-password",
+		UserSearchBase:  "ou=people,dc=example,dc=org",
+		GroupSearchBase: "ou=groups,dc=example,dc=org",
+		Enabled:         true,
+	}
+}
+
+func TestAuthenticateLDAP(t *testing.T) {
+	useFakeDirectory(t, newFakeDirectory())
+
+	user, err := authenticateLDAP(testLDAPProvider(), "alice", "alice-password")
+	require.NoError(t, err)
+	assert.Equal(t, "corp", user.Provider)
+	assert.Equal(t, "uid=alice,ou=people,dc=example,dc=org", user.Sub)
+	assert.Equal(t, "alice", user.Username)
+	assert.Equal(t, "Alice Liddell", user.Name)
+	assert.Equal(t, "alice@example.org", user.Email)
+	assert.ElementsMatch(t, []string{"k8s-admins", "developers"}, user.OIDCGroups)
+
+	for name, password := range map[string]string{
+		"wrong password": "wrong",
+		"empty password": "",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := authenticateLDAP(testLDAPProvider(), "alice", password)
+			assert.ErrorIs(t, err, errInvalidLDAPCredentials)
+		})
+	}
+
+	_, err = authenticateLDAP(testLDAPProvider(), "nobody", "alice-password")
+	assert.ErrorIs(t, err, errInvalidLDAPCredentials)
+
+	// Filter injection does not match other users
+	_, err = authenticateLDAP(testLDAPProvider(), "*", "alice-password")
+	assert.ErrorIs(t, err, errInvalidLDAPCredentials)
+
+	provider := testLDAPProvider()
+	provider.BindPassword = "wrong"
+	_, err = authenticateLDAP(provider, "alice", "alice-password")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, errInvalidLDAPCredentials)
+}
+
+func TestAuthenticateLDAPActiveDirectory(t *testing.T) {
+	useFakeDirectory(t, newFakeDirectory())
+
+	provider := testLDAPProvider()
+	provider.UserFilter = "(&(objectClass=user)(sAMAccountName={username}))"
+	provider.UsernameAttribute = "sAMAccountName"
+	provider.NameAttribute = "displayName"
+	provider.GroupSearchBase = ""
+
+	user, err := authenticateLDAP(provider, "bob", "bob-password")
+	require.NoError(t, err)
+	assert.Equal(t, "bob", user.Username)
+	assert.Equal(t, "Bob Builder", user.Name)
+	assert.ElementsMatch(t, []string{"k8s-viewers"}, user.OIDCGroups)
+}
+
+func TestLDAPLogin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	if model.DB == nil {
+		common.DBType = "sqlite"
+		common.DBDSN = "file::memory:?cache=shared"
+		model.InitDB()
+	}
+	common.JwtSecret = "testsecret"
+	useFakeDirectory(t, newFakeDirectory())
+	rbac.RBACConfig = &common.RolesConfig{
+		Roles:       []common.Role{{Name: model.DefaultAdminRole.Name}},
+		RoleMapping: []common.RoleMapping{{Name: model.DefaultAdminRole.Name, OIDCGroups: []string{"k8s-admins"}}},
+	}
+	require.NoError(t, model.SetAppConfig(model.CurrentApp.ID, model.DefaultUserAccessKey, "true"))
+
+	provider := testLDAPProvider()
+	require.NoError(t, model.CreateLDAPProvider(&provider))
+	t.Cleanup(func() {
+		_ = model.DeleteLDAPProvider(provider.ID)
+		model.DB.Where("username = ?", "alice").Delete(&model.User{})
+	})
+
+	h := NewAuthHandler(nil)
+	r := gin.New()
+	r.GET("/providers", h.GetProviders)
+	r.POST("/login/ldap", h.LDAPLogin)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/providers", nil))
+	var providers struct {
+		Providers     []string `json:"providers"`
+		LDAPProviders []string `json:"ldapProviders"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &providers))
+	assert.Contains(t, providers.Providers, "corp")
+	assert.Equal(t, []string{"corp"}, providers.LDAPProviders)
+
+	login := func(username, password string) *httptest.ResponseRecorder {
+		body, err := json.Marshal(common.LDAPLoginRequest{Provider: "corp", Username: username, Password: password})
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/login/ldap", bytes.NewReader(body)))
+		return w
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, login("alice", "wrong").Code)
+
+	w = login("alice", "alice-password")
+	require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+	assert.Contains(t, w.Header().Get("Set-Cookie"), "auth_token=")
+
+	user, err := model.GetUserByUsername("alice")
+	require.NoError(t, err)
+	assert.Equal(t, "alice@example.org", user.Email)
+
+	var identity model.UserIdentity
+	require.NoError(t, model.DB.Where("user_id = ? AND provider = ?", user.ID, "corp").First(&identity).Error)
+	assert.Equal(t, "uid=alice,ou=people,dc=example,dc=org", identity.ProviderID)
+	assert.ElementsMatch(t, []string{"k8s-admins", "developers"}, identity.OIDCGroups)
+
+	// Users without a role mapped to their groups cannot log in
+	directory := newFakeDirectory()
+	directory.entries = directory.entries[:2]
+	useFakeDirectory(t, directory)
+	assert.Equal(t, http.StatusForbidden, login("alice", "alice-password").Code)
+}
+
+func TestLDAPLoginLocalAccountCollision(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	if model.DB == nil {
+		common.DBType = "sqlite"
+		common.DBDSN = "file::memory:?cache=shared"
+		model.InitDB()
+	}
+	common.JwtSecret = "testsecret"
+	// A directory user named like the local super user
+	directory := newFakeDirectory()
+	directory.entries = append(directory.entries, ldap.NewEntry("uid=root,ou=people,dc=example,dc=org", map[string][]string{
+		"objectClass": {"inetOrgPerson"},
+		"uid":         {"root"},
+		"cn":          {"Root"},
+	}))
+	directory.passwords["uid=root,ou=people,dc=example,dc=org"] = "root-password"
+	useFakeDirectory(t, directory)
+	rbac.RBACConfig = &common.RolesConfig{
+		Roles:       []common.Role{{Name: model.DefaultAdminRole.Name}},
+		RoleMapping: []common.RoleMapping{{Name: model.DefaultAdminRole.Name, Users: []string{"root"}}},
+	}
+
+	provider := testLDAPProvider()
+	require.NoError(t, model.CreateLDAPProvider(&provider))
+	model.DB.Where("username = ?", "root").Delete(&model.User{})
+	local := &model.User{Username: "root", Password: "local-password", Enabled: true}
+	require.NoError(t, model.AddUser(local))
+	t.Cleanup(func() {
+		_ = model.DeleteLDAPProvider(provider.ID)
+		model.DB.Where("user_id = ?", local.ID).Delete(&model.UserIdentity{})
+		model.DB.Delete(&model.User{}, local.ID)
+	})
+
+	r := gin.New()
+	r.POST("/login/ldap", NewAuthHandler(nil).LDAPLogin)
+	login := func() *httptest.ResponseRecorder {
+		body, err := json.Marshal(common.LDAPLoginRequest{Provider: "corp", Username: "root", Password: "root-password"})
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/login/ldap", bytes.NewReader(body)))
+		return w
+	}
+
+	// The directory user does not sign in as the local account
+	w := login()
+	assert.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+	assert.NotContains(t, w.Header().Get("Set-Cookie"), "auth_token=")
+	var count int64
+	require.NoError(t, model.DB.Model(&model.UserIdentity{}).Where("user_id = ?", local.ID).Count(&count).Error)
+	assert.Zero(t, count)
+
+	// Nor through an identity linked before
+	require.NoError(t, model.DB.Create(&model.UserIdentity{
+		UserID:     local.ID,
+		Provider:   "corp",
+		ProviderID: "uid=root,ou=people,dc=example,dc=org",
+	}).Error)
+	w = login()
+	assert.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+	assert.NotContains(t, w.Header().Get("Set-Cookie"), "auth_token=")
+}
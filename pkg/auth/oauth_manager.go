@@ -16,11 +16,15 @@ import (
 
 type OAuthManager struct {
 	jwtSecret string
+	algorithm string
+	keys      *keyStore
 }
 
 func NewOAuthManager() *OAuthManager {
 	return &OAuthManager{
 		jwtSecret: common.JwtSecret,
+		algorithm: common.JWTSigningAlgorithm,
+		keys:      signingKeys,
 	}
 }
 
@@ -66,13 +70,29 @@ func (om *OAuthManager) GetAvailableProviders() []string {
 	return providers
 }
 
+// GetAvailableLDAPProviders returns the names of the enabled LDAP providers
+func (om *OAuthManager) GetAvailableLDAPProviders() []string {
+	providers := []string{}
+	dbProviders, err := model.GetEnabledLDAPProviders()
+	if err != nil {
+		klog.Warningf("Failed to load LDAP providers from database: %v", err)
+		return providers
+	}
+	for _, provider := range dbProviders {
+		providers = append(providers, string(provider.Name))
+	}
+	return providers
+}
+
 func (om *OAuthManager) GenerateState() string {
 	b := make([]byte, 32)
 	_, _ = rand.Read(b)
 	return base64.URLEncoding.EncodeToString(b)
 }
 
-func (om *OAuthManager) GenerateJWT(user *model.User, refreshToken string) (string, error) {
+// GenerateJWT issues a session JWT for the user. sessionID is the jti of the
+// server-side session the token belongs to.
+func (om *OAuthManager) GenerateJWT(user *model.User, refreshToken, sessionID string) (string, error) {
 	now := time.Now()
 	expirationTime := now.Add(common.JWTExpirationSeconds * time.Second)
 
@@ -83,6 +103,7 @@ func (om *OAuthManager) GenerateJWT(user *model.User, refreshToken string) (stri
 		RefreshToken: refreshToken,
 		OIDCGroups:   user.OIDCGroups,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        sessionID,
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(now),
 			NotBefore: jwt.NewNumericDate(now),
@@ -90,17 +111,53 @@ func (om *OAuthManager) GenerateJWT(user *model.User, refreshToken string) (stri
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(om.jwtSecret))
+	if om.algorithm == "HS256" {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		return token.SignedString([]byte(om.jwtSecret))
+	}
+
+	key, err := om.keys.signingKey()
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.kid
+	return token.SignedString(key.private)
 }
 
-func (om *OAuthManager) ValidateJWT(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+// verificationKey resolves the key a token was signed with. Tokens must use
+// the configured algorithm family, so an HS256 token is never checked against
+// a public key or the other way round.
+func (om *OAuthManager) verificationKey(token *jwt.Token) (interface{}, error) {
+	if om.algorithm == "HS256" {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
 		return []byte(om.jwtSecret), nil
-	})
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, err := om.keys.verificationKey(kid)
+	if err != nil {
+		return nil, err
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.public, nil
+}
+
+// JWKS returns the public signing keys as a JSON Web Key Set. It is empty
+// for HS256, whose secret cannot be published.
+func (om *OAuthManager) JWKS() []JWK {
+	if om.algorithm == "HS256" {
+		return []JWK{}
+	}
+	return om.keys.jwks()
+}
+
+func (om *OAuthManager) ValidateJWT(tokenString string) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, om.verificationKey)
 
 	if err != nil {
 		return nil, err
@@ -114,24 +171,46 @@ func (om *OAuthManager) ValidateJWT(tokenString string) (*Claims, error) {
 	return claims, nil
 }
 
+// parseExpiredJWT verifies the signature of a token but accepts it after it
+// has expired
+func (om *OAuthManager) parseExpiredJWT(tokenString string) (*Claims, error) {
+	var claims Claims
+	token, err := jwt.ParseWithClaims(tokenString, &claims, om.verificationKey, jwt.WithoutClaimsValidation())
+	if err != nil {
+		return nil, err
+	}
+
+	// Ensure signature was valid
+	if token == nil || !token.Valid {
+		return nil, fmt.Errorf("invalid token signature")
+	}
+	return &claims, nil
+}
+
+// SessionID returns the jti of a session JWT signed by this server, also
+// when the token has expired
+func (om *OAuthManager) SessionID(tokenString string) (string, error) {
+	claims, err := om.parseExpiredJWT(tokenString)
+	if err != nil {
+		return "", err
+	}
+	return claims.ID, nil
+}
+
 func (om *OAuthManager) RefreshJWT(c *gin.Context, tokenString string) (string, error) {
 	// We need to accept expired tokens here so we can extract claims and refresh using
 	// the stored refresh token. Parse the token while skipping claims validation but
 	// still validate the signature.
-	var claims Claims
-	token, err := jwt.ParseWithClaims(tokenString, &claims, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(om.jwtSecret), nil
-	}, jwt.WithoutClaimsValidation())
+	claims, err := om.parseExpiredJWT(tokenString)
 	if err != nil {
 		return "", err
 	}
 
-	// Ensure signature was valid
-	if token == nil || !token.Valid {
-		return "", fmt.Errorf("invalid token signature")
+	// Only tokens of an active session can be re-issued, so revoking the
+	// session also ends refreshes
+	session, err := model.GetActiveUserSession(claims.ID)
+	if err != nil || session.UserID != claims.UserID {
+		return "", fmt.Errorf("session is revoked or expired")
 	}
 
 	// Check if token is close to expiration (within 1 hour)
@@ -164,7 +243,8 @@ func (om *OAuthManager) RefreshJWT(c *gin.Context, tokenString string) (string,
 			newRefreshToken = claims.RefreshToken // Keep the old refresh token if no new one provided
 		}
 
-		return om.GenerateJWT(user, newRefreshToken)
+		user.ID = claims.UserID
+		return om.GenerateJWT(user, newRefreshToken, claims.ID)
 	}
 
 	// If no refresh token available, just generate a new JWT with existing claims
@@ -178,5 +258,5 @@ func (om *OAuthManager) RefreshJWT(c *gin.Context, tokenString string) (string,
 		OIDCGroups: claims.OIDCGroups,
 	}
 
-	return om.GenerateJWT(user, "")
+	return om.GenerateJWT(user, "", claims.ID)
 }
@@ -22,7 +22,7 @@ func TestOIDCGroupsInJWT(t *testing.T) {
 		OIDCGroups: groups,
 	}
 
-	tokenString, err := om.GenerateJWT(user, "refresh_token")
+	tokenString, err := om.GenerateJWT(user, "refresh_token", "session-1")
 	assert.NoError(t, err)
 
 	// Parse the token without validating signature
@@ -55,9 +55,19 @@ func TestRefreshJWTPreservesGroups(t *testing.T) {
 	common.JwtSecret = "testsecret"
 	om := NewOAuthManager()
 
+	if model.DB == nil {
+		common.DBType = "sqlite"
+		common.DBDSN = "file::memory:?cache=shared"
+		model.InitDB()
+	}
+	// Only tokens of an active session are re-issued
+	session, err := model.CreateUserSession(1, "testprovider", "127.0.0.1", "")
+	assert.NoError(t, err)
+
 	// Create a token manually with groups
 	// We use the same signing method and secret as OAuthManager uses (HS256)
 	claims := jwt.MapClaims{
+		"jti":         session.SessionID,
 		"user_id":     1.0, // JWT numbers are float64 by default
 		"username":    "testuser",
 		"provider":    "testprovider",
@@ -85,4 +95,10 @@ func TestRefreshJWTPreservesGroups(t *testing.T) {
 		assert.True(t, ok, "oidc_groups is not a list in refreshed token")
 		assert.Equal(t, 2, len(groupsInterface))
 	}
+	assert.Equal(t, session.SessionID, newClaims["jti"])
+
+	// Once the session is revoked the token can no longer be refreshed
+	assert.NoError(t, model.RevokeSessionByID(session.SessionID))
+	_, err = om.RefreshJWT(nil, tokenString)
+	assert.Error(t, err)
 }
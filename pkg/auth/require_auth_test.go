@@ -29,6 +29,7 @@ func TestRequireAuthRestoresOIDCGroups(t *testing.T) {
 		// Clean tables
 		model.DB.Exec("DELETE FROM users")
 		model.DB.Exec("DELETE FROM user_configs")
+		model.DB.Exec("DELETE FROM user_sessions")
 	} else {
 		model.InitDB()
 	}
@@ -51,9 +52,11 @@ func TestRequireAuthRestoresOIDCGroups(t *testing.T) {
 	groups := []string{"dev-team", "admins"}
 	user.OIDCGroups = groups
 
-	// Generate JWT
+	// Generate JWT for a new session
+	session, err := model.CreateUserSession(user.ID, user.Provider, "127.0.0.1", "")
+	assert.NoError(t, err)
 	om := NewOAuthManager()
-	token, err := om.GenerateJWT(user, "")
+	token, err := om.GenerateJWT(user, "", session.SessionID)
 	assert.NoError(t, err)
 
 	// Setup Gin
@@ -99,4 +102,12 @@ func TestRequireAuthRestoresOIDCGroups(t *testing.T) {
 	r.ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusOK, w.Code)
+
+	// A revoked session no longer authenticates, even with a valid JWT
+	assert.NoError(t, model.RevokeSessionByID(session.SessionID))
+	req = httptest.NewRequest("GET", "/protected", nil)
+	req.AddCookie(cookie)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
 }
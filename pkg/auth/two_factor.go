@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pixelvide/kube-sentinel/pkg/audit"
+	"github.com/pixelvide/kube-sentinel/pkg/model"
+	"github.com/pixelvide/kube-sentinel/pkg/rbac"
+	"github.com/pixelvide/kube-sentinel/pkg/utils"
+	"k8s.io/klog/v2"
+)
+
+// checkSecondFactor runs the two-factor step of a password login whose
+// password was correct. Users with a second factor must send a TOTP or
+// recovery code. Users who must use two-factor authentication but have not
+// set it up get a new secret, and enable it by logging in again with a code
+// for it, which returns their recovery codes.
+//
+// It writes the response and returns false when the login cannot go on.
+func checkSecondFactor(c *gin.Context, user *model.User, code string) (recoveryCodes []string, ok bool) {
+	enabled, err := model.IsTwoFactorEnabled(user.ID)
+	if err != nil {
+		klog.Errorf("Failed to load two-factor settings of user %s: %v", user.Username, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check two-factor authentication"})
+		return nil, false
+	}
+
+	if enabled {
+		if code == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Two-factor code required", "twoFactorRequired": true})
+			return nil, false
+		}
+		usedRecoveryCode, err := model.VerifyTwoFactor(user.ID, code)
+		if err != nil && !errors.Is(err, model.ErrInvalidTwoFactorCode) {
+			klog.Errorf("Failed to verify two-factor code of user %s: %v", user.Username, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check two-factor authentication"})
+			return nil, false
+		}
+		if err != nil {
+			loginFailed(c, user, errors.New("invalid two-factor code"))
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid two-factor code", "twoFactorRequired": true})
+			return nil, false
+		}
+		if usedRecoveryCode {
+			recordLoginAudit(c, "two_factor_recovery_code_used", user, user.Username, nil)
+		}
+		return nil, true
+	}
+
+	if !rbac.TwoFactorRequired(*user) {
+		return nil, true
+	}
+	if code == "" {
+		secret, err := model.StartTwoFactorSetup(user.ID)
+		if err != nil {
+			klog.Errorf("Failed to start two-factor setup of user %s: %v", user.Username, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start two-factor setup"})
+			return nil, false
+		}
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":                  "Two-factor authentication is required for your account, set it up to continue",
+			"twoFactorSetupRequired": true,
+			"secret":                 secret,
+			"uri":                    utils.TOTPProvisioningURI(utils.TOTPIssuer, user.Username, secret),
+		})
+		return nil, false
+	}
+	recoveryCodes, err = model.EnableTwoFactor(user.ID, code)
+	if err != nil && !errors.Is(err, model.ErrInvalidTwoFactorCode) && !errors.Is(err, model.ErrTwoFactorNotStarted) {
+		klog.Errorf("Failed to enable two-factor authentication of user %s: %v", user.Username, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable two-factor authentication"})
+		return nil, false
+	}
+	if err != nil {
+		loginFailed(c, user, errors.New("invalid two-factor setup code"))
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid two-factor code", "twoFactorSetupRequired": true})
+		return nil, false
+	}
+	recordLoginAudit(c, "enable_two_factor", user, user.Username, nil)
+	return recoveryCodes, true
+}
+
+// loginFailed records a failed password login of a user and locks the
+// account when it had too many
+func loginFailed(c *gin.Context, user *model.User, reason error) {
+	recordLoginAudit(c, "login_failed", user, user.Username, reason)
+	lockedUntil, err := model.RecordFailedLogin(user.ID)
+	if err != nil {
+		klog.Errorf("Failed to record failed login of user %s: %v", user.Username, err)
+		return
+	}
+	if lockedUntil != nil {
+		klog.Warningf("Locked user %s until %s after too many failed logins", user.Username, lockedUntil)
+		recordLoginAudit(c, "login_locked", user, user.Username, nil)
+	}
+}
+
+// recordLoginAudit records a password login event. Events of unknown users
+// have no actor and name the username tried.
+func recordLoginAudit(c *gin.Context, action string, user *model.User, username string, err error) {
+	entry := audit.FromRequest(c, action)
+	entry.ResourceType = "users"
+	entry.ResourceName = username
+	if user != nil {
+		entry.ActorID = user.ID
+	}
+	audit.Emit(entry.WithError(err))
+}
@@ -0,0 +1,147 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pixelvide/kube-sentinel/pkg/common"
+	"github.com/pixelvide/kube-sentinel/pkg/model"
+	"github.com/pixelvide/kube-sentinel/pkg/rbac"
+	"github.com/pixelvide/kube-sentinel/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupPasswordLoginTest(t *testing.T, username string) (*gin.Engine, *model.User) {
+	gin.SetMode(gin.TestMode)
+	if model.DB == nil {
+		common.DBType = "sqlite"
+		common.DBDSN = "file::memory:?cache=shared"
+		model.InitDB()
+	}
+	common.JwtSecret = "testsecret"
+	rbac.RBACConfig = &common.RolesConfig{
+		Roles:       []common.Role{{Name: model.DefaultAdminRole.Name}},
+		RoleMapping: []common.RoleMapping{{Name: model.DefaultAdminRole.Name, Users: []string{"root"}}},
+	}
+	require.NoError(t, model.SetAppConfig(model.CurrentApp.ID, model.LocalLoginEnabledKey, "true"))
+	require.NoError(t, model.SetAppConfig(model.CurrentApp.ID, model.RequireAdminTwoFactorKey, "false"))
+
+	model.DB.Where("username = ?", username).Delete(&model.User{})
+	user := &model.User{Username: username, Password: "secret-password", Enabled: true}
+	require.NoError(t, model.AddUser(user))
+	t.Cleanup(func() {
+		_ = model.DisableTwoFactor(user.ID)
+		model.DB.Delete(&model.User{}, user.ID)
+	})
+
+	r := gin.New()
+	r.POST("/login", NewAuthHandler(nil).PasswordLogin)
+	return r, user
+}
+
+func passwordLogin(t *testing.T, r *gin.Engine, username, password, code string) (int, map[string]any) {
+	body, err := json.Marshal(common.PasswordLoginRequest{Username: username, Password: password, Code: code})
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader(body)))
+	var resp map[string]any
+	if w.Body.Len() > 0 {
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	}
+	return w.Code, resp
+}
+
+func currentCode(t *testing.T, secret string, offset time.Duration) string {
+	code, err := utils.TOTPCode(secret, utils.TOTPStep(time.Now().Add(offset)))
+	require.NoError(t, err)
+	return code
+}
+
+func TestPasswordLoginWithTwoFactor(t *testing.T) {
+	r, user := setupPasswordLoginTest(t, "totp-user")
+
+	secret, err := model.StartTwoFactorSetup(user.ID)
+	require.NoError(t, err)
+	recoveryCodes, err := model.EnableTwoFactor(user.ID, currentCode(t, secret, -utils.TOTPPeriod))
+	require.NoError(t, err)
+	require.Len(t, recoveryCodes, 10)
+
+	status, resp := passwordLogin(t, r, "totp-user", "secret-password", "")
+	assert.Equal(t, http.StatusUnauthorized, status)
+	assert.Equal(t, true, resp["twoFactorRequired"])
+
+	status, _ = passwordLogin(t, r, "totp-user", "secret-password", "000000")
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	code := currentCode(t, secret, 0)
+	status, _ = passwordLogin(t, r, "totp-user", "secret-password", code)
+	assert.Equal(t, http.StatusNoContent, status)
+
+	// A code cannot be replayed
+	status, _ = passwordLogin(t, r, "totp-user", "secret-password", code)
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	// Recovery codes work once
+	status, _ = passwordLogin(t, r, "totp-user", "secret-password", recoveryCodes[0])
+	assert.Equal(t, http.StatusNoContent, status)
+	status, _ = passwordLogin(t, r, "totp-user", "secret-password", recoveryCodes[0])
+	assert.Equal(t, http.StatusUnauthorized, status)
+	remaining, err := model.CountRecoveryCodes(user.ID)
+	require.NoError(t, err)
+	assert.EqualValues(t, 9, remaining)
+}
+
+func TestPasswordLoginRequiresAdminEnrollment(t *testing.T) {
+	r, user := setupPasswordLoginTest(t, "root")
+	require.NoError(t, model.SetAppConfig(model.CurrentApp.ID, model.RequireAdminTwoFactorKey, "true"))
+
+	status, resp := passwordLogin(t, r, "root", "secret-password", "")
+	require.Equal(t, http.StatusForbidden, status)
+	assert.Equal(t, true, resp["twoFactorSetupRequired"])
+	secret := resp["secret"].(string)
+	assert.Contains(t, resp["uri"], "otpauth://totp/")
+
+	status, resp = passwordLogin(t, r, "root", "secret-password", currentCode(t, secret, 0))
+	require.Equal(t, http.StatusOK, status)
+	assert.Len(t, resp["recoveryCodes"], 10)
+
+	enabled, err := model.IsTwoFactorEnabled(user.ID)
+	require.NoError(t, err)
+	assert.True(t, enabled)
+}
+
+func TestPasswordLoginLockout(t *testing.T) {
+	r, user := setupPasswordLoginTest(t, "lockout-user")
+	prevMax, prevDuration := common.LoginMaxFailures, common.LoginLockoutDuration
+	common.LoginMaxFailures, common.LoginLockoutDuration = 3, time.Minute
+	t.Cleanup(func() { common.LoginMaxFailures, common.LoginLockoutDuration = prevMax, prevDuration })
+
+	for i := 0; i < 3; i++ {
+		status, _ := passwordLogin(t, r, "lockout-user", "wrong", "")
+		assert.Equal(t, http.StatusUnauthorized, status)
+	}
+	// Locked accounts reject even the right password
+	status, _ := passwordLogin(t, r, "lockout-user", "secret-password", "")
+	assert.Equal(t, http.StatusTooManyRequests, status)
+
+	var logs []model.AuditLog
+	require.NoError(t, model.DB.Where("actor_id = ? AND action = ?", user.ID, "login_locked").Find(&logs).Error)
+	assert.Len(t, logs, 1)
+
+	// The lock expires
+	require.NoError(t, model.DB.Model(&model.User{}).Where("id = ?", user.ID).
+		Update("locked_until", time.Now().Add(-time.Second)).Error)
+	status, _ = passwordLogin(t, r, "lockout-user", "secret-password", "")
+	assert.Equal(t, http.StatusNoContent, status)
+
+	reloaded, err := model.GetUserByID(uint64(user.ID))
+	require.NoError(t, err)
+	assert.Zero(t, reloaded.FailedLogins)
+	assert.Nil(t, reloaded.LockedUntil)
+}
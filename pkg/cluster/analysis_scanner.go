@@ -0,0 +1,166 @@
+package cluster
+
+import (
+	"context"
+	"time"
+
+	"github.com/pixelvide/kube-sentinel/pkg/analyzer"
+	"github.com/pixelvide/kube-sentinel/pkg/common"
+	"github.com/pixelvide/kube-sentinel/pkg/model"
+	"k8s.io/klog/v2"
+)
+
+// analysisScanTimeout bounds a single cluster scan
+const analysisScanTimeout = 10 * time.Minute
+
+// startAnalysisScanner periodically scans every shared cluster with the
+// registered analyzers. Each cluster is scanned on its own goroutine and a
+// cluster is never scanned twice concurrently.
+func (cm *ClusterManager) startAnalysisScanner() {
+	if common.AnalysisScanInterval <= 0 {
+		klog.Info("Background analysis scan is disabled")
+		return
+	}
+	// Give the initial cluster sync time to warm up the informer caches
+	time.Sleep(time.Minute)
+	cm.scanAllClusters()
+
+	ticker := time.NewTicker(common.AnalysisScanInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		cm.scanAllClusters()
+	}
+}
+
+func (cm *ClusterManager) scanAllClusters() {
+	cm.mu.RLock()
+	targets := make([]*ClientSet, 0, len(cm.clusters))
+	for _, cs := range cm.clusters {
+		targets = append(targets, cs)
+	}
+	cm.mu.RUnlock()
+
+	for _, cs := range targets {
+		cm.scanningMu.Lock()
+		if cm.scanning[cs.Name] {
+			cm.scanningMu.Unlock()
+			klog.V(2).Infof("Analysis scan for cluster %s still running, skipping", cs.Name)
+			continue
+		}
+		cm.scanning[cs.Name] = true
+		cm.scanningMu.Unlock()
+
+		go func(cs *ClientSet) {
+			defer func() {
+				cm.scanningMu.Lock()
+				delete(cm.scanning, cs.Name)
+				cm.scanningMu.Unlock()
+			}()
+			ScanCluster(cs)
+		}(cs)
+	}
+}
+
+// ScanCluster runs a full analyzer scan of one cluster and persists the
+// findings and a trend entry
+func ScanCluster(cs *ClientSet) {
+	ctx, cancel := context.WithTimeout(context.Background(), analysisScanTimeout)
+	defer cancel()
+
+	start := time.Now()
+	klog.Infof("Starting analysis scan for cluster %s", cs.Name)
+	results, listedKinds, err := analyzer.ScanCluster(ctx, cs.Name, cs.K8sClient)
+	scan := model.AnalysisScan{
+		ClusterName: cs.Name,
+		ScannedAt:   start,
+		DurationMs:  time.Since(start).Milliseconds(),
+		Score:       100,
+	}
+	if err != nil {
+		klog.Errorf("Analysis scan for cluster %s failed: %v", cs.Name, err)
+		scan.Error = err.Error()
+		if err := model.DB.Create(&scan).Error; err != nil {
+			klog.Errorf("Failed to save analysis scan for cluster %s: %v", cs.Name, err)
+		}
+		return
+	}
+
+	var findings []model.AnalysisFinding
+	all := make([]*analyzer.ResourceAnalysis, 0, len(results))
+	byNamespace := map[string][]*analyzer.ResourceAnalysis{}
+	for _, r := range results {
+		all = append(all, r.Analysis)
+		namespace := r.Namespace
+		if r.Kind == "Namespace" {
+			namespace = r.Name
+		}
+		byNamespace[namespace] = append(byNamespace[namespace], r.Analysis)
+		for _, a := range r.Analysis.Anomalies {
+			findings = append(findings, newFinding(r, a))
+			switch a.Severity {
+			case analyzer.SeverityCritical:
+				scan.Critical++
+			case analyzer.SeverityHigh:
+				scan.High++
+			case analyzer.SeverityMedium:
+				scan.Medium++
+			case analyzer.SeverityLow:
+				scan.Low++
+			default:
+				scan.Info++
+			}
+		}
+		for _, a := range r.Analysis.Suppressed {
+			f := newFinding(r, a.Anomaly)
+			f.Suppressed = true
+			f.SuppressionReason = a.Reason
+			findings = append(findings, f)
+			scan.Suppressed++
+		}
+	}
+	rollup := analyzer.RollupScores(all)
+	scan.Resources = rollup.Resources
+	scan.Score = rollup.Score
+	scan.CategoryScores = analyzer.ToModelCategoryScores(rollup.Categories)
+
+	namespaceScores := make([]model.AnalysisNamespaceScore, 0, len(byNamespace))
+	for namespace, analyses := range byNamespace {
+		nsRollup := analyzer.RollupScores(analyses)
+		namespaceScores = append(namespaceScores, model.AnalysisNamespaceScore{
+			Namespace:      namespace,
+			ScannedAt:      start,
+			Resources:      nsRollup.Resources,
+			Score:          nsRollup.Score,
+			CategoryScores: analyzer.ToModelCategoryScores(nsRollup.Categories),
+		})
+	}
+
+	if err := model.SaveScanFindings(cs.Name, findings, listedKinds, start); err != nil {
+		klog.Errorf("Failed to save analysis findings for cluster %s: %v", cs.Name, err)
+		scan.Error = err.Error()
+	}
+	if err := model.SaveNamespaceScores(cs.Name, namespaceScores); err != nil {
+		klog.Errorf("Failed to save namespace scores for cluster %s: %v", cs.Name, err)
+		scan.Error = err.Error()
+	}
+	if err := model.DB.Create(&scan).Error; err != nil {
+		klog.Errorf("Failed to save analysis scan for cluster %s: %v", cs.Name, err)
+	}
+	klog.Infof("Analysis scan for cluster %s finished in %s: %d resources, %d findings (%d suppressed), score %d",
+		cs.Name, time.Since(start).Round(time.Millisecond), scan.Resources, len(findings), scan.Suppressed, scan.Score)
+}
+
+func newFinding(r analyzer.ScanResult, a analyzer.Anomaly) model.AnalysisFinding {
+	return model.AnalysisFinding{
+		Kind:         r.Kind,
+		ResourceType: r.ResourceType,
+		Namespace:    r.Namespace,
+		Name:         r.Name,
+		RuleID:       a.RuleID,
+		Severity:     string(a.Severity),
+		Title:        a.Title,
+		Message:      a.Message,
+		Remediation:  a.Remediation,
+		DocURL:       a.DocURL,
+	}
+}
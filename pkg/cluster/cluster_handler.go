@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/pixelvide/kube-sentinel/pkg/audit"
 	"github.com/pixelvide/kube-sentinel/pkg/common"
 	"github.com/pixelvide/kube-sentinel/pkg/model"
 	"github.com/pixelvide/kube-sentinel/pkg/rbac"
@@ -38,9 +39,10 @@ func (cm *ClusterManager) GetClusters(c *gin.Context) {
 		}
 
 		info := common.ClusterInfo{
-			Name:           cluster.Name,
-			IsDefault:      cluster.Name == cm.defaultContext,
-			SkipSystemSync: cluster.SkipSystemSync,
+			Name:             cluster.Name,
+			IsDefault:        cluster.Name == cm.defaultContext,
+			SkipSystemSync:   cluster.SkipSystemSync,
+			ImpersonateUsers: cluster.ImpersonateUsers,
 		}
 
 		// Check shared client
@@ -94,15 +96,16 @@ func (cm *ClusterManager) GetClusterList(c *gin.Context) {
 		}
 
 		clusterInfo := gin.H{
-			"id":             cluster.ID,
-			"name":           cluster.Name,
-			"description":    cluster.Description,
-			"enabled":        cluster.Enable,
-			"inCluster":      cluster.InCluster,
-			"isDefault":      cluster.IsDefault,
-			"prometheusURL":  cluster.PrometheusURL,
-			"config":         config,
-			"skipSystemSync": cluster.SkipSystemSync,
+			"id":               cluster.ID,
+			"name":             cluster.Name,
+			"description":      cluster.Description,
+			"enabled":          cluster.Enable,
+			"inCluster":        cluster.InCluster,
+			"isDefault":        cluster.IsDefault,
+			"prometheusURL":    cluster.PrometheusURL,
+			"config":           config,
+			"skipSystemSync":   cluster.SkipSystemSync,
+			"impersonateUsers": cluster.ImpersonateUsers,
 		}
 
 		if clientSet, exists := cm.clusters[cluster.Name]; exists {
@@ -132,13 +135,14 @@ func (cm *ClusterManager) GetClusterList(c *gin.Context) {
 
 func (cm *ClusterManager) CreateCluster(c *gin.Context) {
 	var req struct {
-		Name           string `json:"name" binding:"required"`
-		Description    string `json:"description"`
-		Config         string `json:"config"`
-		PrometheusURL  string `json:"prometheusURL"`
-		InCluster      bool   `json:"inCluster"`
-		IsDefault      bool   `json:"isDefault"`
-		SkipSystemSync bool   `json:"skipSystemSync"`
+		Name             string `json:"name" binding:"required"`
+		Description      string `json:"description"`
+		Config           string `json:"config"`
+		PrometheusURL    string `json:"prometheusURL"`
+		InCluster        bool   `json:"inCluster"`
+		IsDefault        bool   `json:"isDefault"`
+		SkipSystemSync   bool   `json:"skipSystemSync"`
+		ImpersonateUsers bool   `json:"impersonateUsers"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -162,17 +166,20 @@ func (cm *ClusterManager) CreateCluster(c *gin.Context) {
 	}
 
 	cluster := &model.Cluster{
-		Name:           req.Name,
-		Description:    req.Description,
-		Config:         model.SecretString(req.Config),
-		PrometheusURL:  req.PrometheusURL,
-		InCluster:      req.InCluster,
-		IsDefault:      req.IsDefault,
-		SkipSystemSync: req.SkipSystemSync,
-		Enable:         true,
+		Name:             req.Name,
+		Description:      req.Description,
+		Config:           model.SecretString(req.Config),
+		PrometheusURL:    req.PrometheusURL,
+		InCluster:        req.InCluster,
+		IsDefault:        req.IsDefault,
+		SkipSystemSync:   req.SkipSystemSync,
+		Enable:           true,
+		ImpersonateUsers: req.ImpersonateUsers,
 	}
 
-	if err := model.AddCluster(cluster); err != nil {
+	err := model.AddCluster(cluster)
+	recordClusterAudit(c, "create", cluster.Name, err)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -194,14 +201,15 @@ func (cm *ClusterManager) UpdateCluster(c *gin.Context) {
 	}
 
 	var req struct {
-		Name           string `json:"name"`
-		Description    string `json:"description"`
-		Config         string `json:"config"`
-		PrometheusURL  string `json:"prometheusURL"`
-		InCluster      bool   `json:"inCluster"`
-		IsDefault      bool   `json:"isDefault"`
-		Enabled        bool   `json:"enabled"`
-		SkipSystemSync bool   `json:"skipSystemSync"`
+		Name             string `json:"name"`
+		Description      string `json:"description"`
+		Config           string `json:"config"`
+		PrometheusURL    string `json:"prometheusURL"`
+		InCluster        bool   `json:"inCluster"`
+		IsDefault        bool   `json:"isDefault"`
+		Enabled          bool   `json:"enabled"`
+		SkipSystemSync   bool   `json:"skipSystemSync"`
+		ImpersonateUsers bool   `json:"impersonateUsers"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -227,12 +235,13 @@ func (cm *ClusterManager) UpdateCluster(c *gin.Context) {
 	}
 
 	updates := map[string]interface{}{
-		"description":      req.Description,
-		"prometheus_url":   req.PrometheusURL,
-		"in_cluster":       req.InCluster,
-		"is_default":       req.IsDefault,
-		"enable":           req.Enabled,
-		"skip_system_sync": req.SkipSystemSync,
+		"description":       req.Description,
+		"prometheus_url":    req.PrometheusURL,
+		"in_cluster":        req.InCluster,
+		"is_default":        req.IsDefault,
+		"enable":            req.Enabled,
+		"skip_system_sync":  req.SkipSystemSync,
+		"impersonate_users": req.ImpersonateUsers,
 	}
 
 	if req.Name != "" && req.Name != cluster.Name {
@@ -243,7 +252,9 @@ func (cm *ClusterManager) UpdateCluster(c *gin.Context) {
 		updates["config"] = model.SecretString(req.Config)
 	}
 
-	if err := model.UpdateCluster(cluster, updates); err != nil {
+	err = model.UpdateCluster(cluster, updates)
+	recordClusterAudit(c, "update", cluster.Name, err)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -276,7 +287,9 @@ func (cm *ClusterManager) DeleteCluster(c *gin.Context) {
 		return
 	}
 
-	if err := model.DeleteCluster(cluster); err != nil {
+	err = model.DeleteCluster(cluster)
+	recordClusterAudit(c, "delete", cluster.Name, err)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -307,7 +320,9 @@ func (cm *ClusterManager) ImportClustersFromKubeconfig(c *gin.Context) {
 			IsDefault:   true,
 			Enable:      true,
 		}
-		if err := model.AddCluster(cluster); err != nil {
+		err := model.AddCluster(cluster)
+		recordClusterAudit(c, "import", cluster.Name, err)
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
@@ -325,8 +340,21 @@ func (cm *ClusterManager) ImportClustersFromKubeconfig(c *gin.Context) {
 	}
 
 	importedCount := ImportClustersFromKubeconfig(kubeconfig)
+	entry := audit.FromRequest(c, "import")
+	entry.ResourceType = "clusters"
+	entry.Payload = map[string]interface{}{"importedCount": importedCount}
+	entry.Success = true
+	audit.Emit(entry)
 	syncNow <- struct{}{}
 	// wait for sync to complete
 	time.Sleep(1 * time.Second)
 	c.JSON(http.StatusCreated, gin.H{"message": fmt.Sprintf("imported %d clusters successfully", importedCount)})
 }
+
+func recordClusterAudit(c *gin.Context, action, name string, err error) {
+	entry := audit.FromRequest(c, action)
+	entry.ResourceType = "clusters"
+	entry.ResourceName = name
+	entry.ClusterName = name
+	audit.Emit(entry.WithError(err))
+}
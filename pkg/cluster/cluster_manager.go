@@ -30,9 +30,13 @@ type ClientSet struct {
 
 	Configuration *rest.Config
 
+	// ImpersonateUsers is set for clusters in impersonation mode, see ForUser
+	ImpersonateUsers bool
+
 	DiscoveredPrometheusURL string
 	config                  string
 	prometheusURL           string
+	impersonated            *impersonationCache
 }
 
 type UserClient struct {
@@ -49,6 +53,9 @@ type ClusterManager struct {
 	defaultContext string
 	mu             sync.RWMutex
 	activeUsersMu  sync.RWMutex
+
+	scanning   map[string]bool // clusters with an analysis scan in progress
+	scanningMu sync.Mutex
 }
 
 func createClientSetInCluster(name, prometheusURL string, skipSystemSync bool) (*ClientSet, error) {
@@ -227,7 +234,7 @@ func (cm *ClusterManager) GetClientSet(clusterName string, user *model.User) (*C
 		// If no default context is set, return the first available shared cluster
 		for _, cs := range cm.clusters {
 			cm.mu.RUnlock()
-			return cs, nil
+			return cs.forOptionalUser(user)
 		}
 		cm.mu.RUnlock()
 		return nil, fmt.Errorf("no clusters available")
@@ -253,7 +260,7 @@ func (cm *ClusterManager) GetClientSet(clusterName string, user *model.User) (*C
 	cs, ok := cm.clusters[clusterName]
 	if ok {
 		cm.mu.RUnlock()
-		return cs, nil
+		return cs.forOptionalUser(user)
 	}
 	cm.mu.RUnlock()
 
@@ -759,6 +766,11 @@ func shouldUpdateCluster(cs *ClientSet, cluster *model.Cluster) bool {
 		return true
 	}
 
+	if cs.ImpersonateUsers != cluster.ImpersonateUsers {
+		klog.Infof("Impersonation mode changed for cluster %s, updating, impersonate -> %v", cluster.Name, cluster.ImpersonateUsers)
+		return true
+	}
+
 	// k8s version change
 	// If SkipSystemSync is true, we skip the version check to avoid auth errors on user-only clusters
 	if cluster.SkipSystemSync {
@@ -779,10 +791,21 @@ func shouldUpdateCluster(cs *ClientSet, cluster *model.Cluster) bool {
 }
 
 func buildClientSet(cluster *model.Cluster) (*ClientSet, error) {
+	var cs *ClientSet
+	var err error
 	if cluster.InCluster {
-		return createClientSetInCluster(cluster.Name, cluster.PrometheusURL, cluster.SkipSystemSync)
+		cs, err = createClientSetInCluster(cluster.Name, cluster.PrometheusURL, cluster.SkipSystemSync)
+	} else {
+		cs, err = createClientSetFromConfig(cluster.Name, string(cluster.Config), cluster.PrometheusURL, cluster.SkipSystemSync)
+	}
+	if err != nil {
+		return nil, err
 	}
-	return createClientSetFromConfig(cluster.Name, string(cluster.Config), cluster.PrometheusURL, cluster.SkipSystemSync)
+	if cluster.ImpersonateUsers {
+		cs.ImpersonateUsers = true
+		cs.impersonated = newImpersonationCache()
+	}
+	return cs, nil
 }
 
 func NewClusterManager() (*ClusterManager, error) {
@@ -791,10 +814,13 @@ func NewClusterManager() (*ClusterManager, error) {
 	cm.userClients = make(map[string]map[uint]*UserClient)
 	cm.activeUsers = make(map[uint]time.Time)
 	cm.errors = make(map[string]string)
+	cm.scanning = make(map[string]bool)
 
 	// Start cleanup routine
 	go cm.startCleanupRoutine()
 
+	go cm.startAnalysisScanner()
+
 	go func() {
 		ticker := time.NewTicker(1 * time.Minute)
 		defer ticker.Stop()
@@ -0,0 +1,110 @@
+package cluster
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pixelvide/kube-sentinel/pkg/kube"
+	"github.com/pixelvide/kube-sentinel/pkg/model"
+	"k8s.io/client-go/rest"
+	"k8s.io/klog/v2"
+)
+
+// impersonatedClientTTL is how long an impersonating client is reused for
+// requests of the same user and groups
+const impersonatedClientTTL = 5 * time.Minute
+
+type impersonatedClient struct {
+	clientSet *ClientSet
+	expiresAt time.Time
+}
+
+// impersonationCache keeps the impersonating clients of a cluster, keyed by
+// the impersonated user and groups
+type impersonationCache struct {
+	mu      sync.Mutex
+	clients map[string]*impersonatedClient
+}
+
+func newImpersonationCache() *impersonationCache {
+	return &impersonationCache{clients: make(map[string]*impersonatedClient)}
+}
+
+// ImpersonationConfig returns the impersonation headers requests of the user
+// are sent with: Impersonate-User is the user key and Impersonate-Group the
+// OIDC groups of the login.
+func ImpersonationConfig(user model.User) rest.ImpersonationConfig {
+	groups := slices.Clone([]string(user.OIDCGroups))
+	slices.Sort(groups)
+	return rest.ImpersonationConfig{
+		UserName: user.Key(),
+		Groups:   slices.Compact(groups),
+	}
+}
+
+// ForUser returns the ClientSet to serve a request of the user with. For
+// clusters in impersonation mode the returned ClientSet talks to the API
+// server as the user, so cluster RBAC decides what they may do and API
+// server audit logs show them. Other clusters return cs itself.
+//
+// Impersonating clients read from the API server directly rather than from
+// the informer cache of the shared client, and share its REST mapping.
+// Background work such as analysis scans and Prometheus queries keeps using
+// the cluster credential.
+func (cs *ClientSet) ForUser(user model.User) (*ClientSet, error) {
+	if !cs.ImpersonateUsers || cs.impersonated == nil {
+		return cs, nil
+	}
+	impersonate := ImpersonationConfig(user)
+	key := impersonate.UserName + "\x00" + strings.Join(impersonate.Groups, "\x00")
+
+	cache := cs.impersonated
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	now := time.Now()
+	for k, ic := range cache.clients {
+		if now.After(ic.expiresAt) {
+			ic.clientSet.K8sClient.Stop(fmt.Sprintf("%s as %s", cs.Name, k))
+			delete(cache.clients, k)
+		}
+	}
+	if ic, ok := cache.clients[key]; ok {
+		return ic.clientSet, nil
+	}
+
+	k8sClient, err := kube.NewClient(kube.ClientOptions{
+		Config:      cs.Configuration,
+		Impersonate: impersonate,
+		Mapper:      cs.K8sClient.RESTMapper(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create impersonating client for cluster %s: %w", cs.Name, err)
+	}
+	klog.V(1).Infof("Created impersonating client for user %s (groups %v) in cluster %s",
+		impersonate.UserName, impersonate.Groups, cs.Name)
+	userCS := &ClientSet{
+		Name:                    cs.Name,
+		Version:                 cs.Version,
+		K8sClient:               k8sClient,
+		PromClient:              cs.PromClient,
+		Configuration:           k8sClient.Configuration,
+		ImpersonateUsers:        true,
+		DiscoveredPrometheusURL: cs.DiscoveredPrometheusURL,
+		config:                  cs.config,
+		prometheusURL:           cs.prometheusURL,
+	}
+	cache.clients[key] = &impersonatedClient{clientSet: userCS, expiresAt: now.Add(impersonatedClientTTL)}
+	return userCS, nil
+}
+
+// forOptionalUser is ForUser for callers that may not have a user, such as
+// background work, which uses the cluster credential
+func (cs *ClientSet) forOptionalUser(user *model.User) (*ClientSet, error) {
+	if user == nil {
+		return cs, nil
+	}
+	return cs.ForUser(*user)
+}
@@ -0,0 +1,94 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/pixelvide/kube-sentinel/pkg/kube"
+	"github.com/pixelvide/kube-sentinel/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+)
+
+// newTestClientSet returns a ClientSet for a fake API server that records
+// the impersonation headers of the requests it gets
+func newTestClientSet(t *testing.T, impersonate bool) (*ClientSet, func() http.Header) {
+	var mu sync.Mutex
+	var last http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		last = r.Header.Clone()
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(corev1.Pod{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("Pod"), meta.RESTScopeNamespace)
+	config := &rest.Config{Host: server.URL}
+	k8sClient, err := kube.NewClient(kube.ClientOptions{Config: config, DisableCache: true, Mapper: mapper})
+	require.NoError(t, err)
+
+	cs := &ClientSet{Name: "test", K8sClient: k8sClient, Configuration: config}
+	if impersonate {
+		cs.ImpersonateUsers = true
+		cs.impersonated = newImpersonationCache()
+	}
+	return cs, func() http.Header {
+		mu.Lock()
+		defer mu.Unlock()
+		return last
+	}
+}
+
+func TestClientSetForUserImpersonates(t *testing.T) {
+	cs, lastHeaders := newTestClientSet(t, true)
+	user := model.User{Username: "alice", OIDCGroups: model.SliceString{"ops", "dev", "ops"}}
+
+	userCS, err := cs.ForUser(user)
+	require.NoError(t, err)
+	assert.NotSame(t, cs, userCS)
+	assert.Equal(t, "alice", userCS.Configuration.Impersonate.UserName)
+
+	pod := &corev1.Pod{}
+	require.NoError(t, userCS.K8sClient.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "web"}, pod))
+	headers := lastHeaders()
+	assert.Equal(t, "alice", headers.Get("Impersonate-User"))
+	assert.Equal(t, []string{"dev", "ops"}, headers.Values("Impersonate-Group"))
+
+	// The shared client keeps using the cluster credential
+	require.NoError(t, cs.K8sClient.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "web"}, pod))
+	assert.Empty(t, lastHeaders().Get("Impersonate-User"))
+
+	// Clients are reused for the same user and groups
+	again, err := cs.ForUser(model.User{Username: "alice", OIDCGroups: model.SliceString{"dev", "ops"}})
+	require.NoError(t, err)
+	assert.Same(t, userCS, again)
+	other, err := cs.ForUser(model.User{Username: "alice"})
+	require.NoError(t, err)
+	assert.NotSame(t, userCS, other)
+}
+
+func TestClientSetForUserWithoutImpersonation(t *testing.T) {
+	cs, _ := newTestClientSet(t, false)
+	userCS, err := cs.ForUser(model.User{Username: "alice"})
+	require.NoError(t, err)
+	assert.Same(t, cs, userCS)
+
+	userCS, err = cs.forOptionalUser(nil)
+	require.NoError(t, err)
+	assert.Same(t, cs, userCS)
+}
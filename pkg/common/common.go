@@ -4,6 +4,7 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -49,6 +50,56 @@ var (
 	APIKeyProvider = "api_key"
 
 	AllowedOrigins []string
+
+	// Audit sinks, each one is enabled when its target is set
+	AuditFilePath         = ""
+	AuditFileMaxSizeMB    = int64(100)
+	AuditFileMaxBackups   = 5
+	AuditSyslogAddr       = ""
+	AuditWebhookURL       = ""
+	AuditWebhookToken     = ""
+	AuditWebhookBufferDir = ""
+	// AuditWebhookBufferMaxSizeMB limits the records waiting for delivery,
+	// the oldest ones are dropped beyond it
+	AuditWebhookBufferMaxSizeMB = int64(100)
+
+	// AnalysisScanInterval is how often clusters are scanned in the background, 0 disables it
+	AnalysisScanInterval = 30 * time.Minute
+
+	// AnalyzerRulesFile is an optional YAML file with custom analyzer rules
+	AnalyzerRulesFile = ""
+
+	// AnalyzerCategoryWeights overrides the weight of analyzer categories in
+	// the overall score, e.g. ANALYZER_CATEGORY_WEIGHTS=security=40,hygiene=5
+	AnalyzerCategoryWeights = map[string]float64{}
+
+	// AnalyzerTimeout bounds how long a single analyzer may run on one object
+	AnalyzerTimeout = 10 * time.Second
+
+	// JWTSigningAlgorithm signs session JWTs: HS256 with JwtSecret, or RS256
+	// and ES256 with rotated key pairs stored in the database
+	JWTSigningAlgorithm = "HS256"
+
+	// JWTKeyRotationInterval is how often a new signing key is generated for
+	// RS256 and ES256, 0 disables scheduled rotation
+	JWTKeyRotationInterval = 30 * 24 * time.Hour
+
+	// SessionMaxAge is the absolute lifetime of a login session; token
+	// refreshes do not extend a session past it
+	SessionMaxAge = 7 * 24 * time.Hour
+
+	// LoginMaxFailures is the number of consecutive failed password logins
+	// after which an account is locked for LoginLockoutDuration, 0 disables
+	// the lockout
+	LoginMaxFailures     = 5
+	LoginLockoutDuration = 15 * time.Minute
+	// LoginRateLimit is the number of password login attempts per minute
+	// allowed from one client IP, 0 disables the limit
+	LoginRateLimit = 10
+
+	// SCIMToken is the bearer token identity providers use for SCIM
+	// provisioning, empty disables the SCIM API
+	SCIMToken = ""
 )
 
 func GetTableName(schema, baseName string) string {
@@ -67,6 +118,21 @@ func GetCoreTableName(baseName string) string {
 }
 
 func LoadEnvs() {
+	if alg := os.Getenv("JWT_SIGNING_ALGORITHM"); alg != "" {
+		alg = strings.ToUpper(alg)
+		if alg != "HS256" && alg != "RS256" && alg != "ES256" {
+			klog.Fatalf("Invalid JWT_SIGNING_ALGORITHM: %s, must be one of HS256, RS256, ES256", alg)
+		}
+		JWTSigningAlgorithm = alg
+	}
+	if v := os.Getenv("JWT_KEY_ROTATION_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			JWTKeyRotationInterval = d
+		} else {
+			klog.Warningf("Invalid JWT_KEY_ROTATION_INTERVAL %q, using %s", v, JWTKeyRotationInterval)
+		}
+	}
+
 	if secret := os.Getenv("JWT_SECRET"); secret != "" {
 		JwtSecret = secret
 	} else {
@@ -77,7 +143,9 @@ func LoadEnvs() {
 			klog.Fatalf("Failed to generate random JWT secret: %v", err)
 		}
 		JwtSecret = base64.StdEncoding.EncodeToString(b)
-		klog.Warning("JWT_SECRET is not set, using a randomly generated secret. Sessions will be invalidated on restart.")
+		if JWTSigningAlgorithm == "HS256" {
+			klog.Warning("JWT_SECRET is not set, using a randomly generated secret. Sessions will be invalidated on restart.")
+		}
 	}
 
 	if port := os.Getenv("PORT"); port != "" {
@@ -148,4 +216,99 @@ func LoadEnvs() {
 		}
 		klog.Infof("CORS Allowed Origins: %v", AllowedOrigins)
 	}
+
+	if v := os.Getenv("AUDIT_FILE_PATH"); v != "" {
+		AuditFilePath = v
+	}
+	if v := os.Getenv("AUDIT_FILE_MAX_SIZE_MB"); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil && n > 0 {
+			AuditFileMaxSizeMB = n
+		} else {
+			klog.Warningf("Invalid AUDIT_FILE_MAX_SIZE_MB %q, using %d", v, AuditFileMaxSizeMB)
+		}
+	}
+	if v := os.Getenv("AUDIT_FILE_MAX_BACKUPS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			AuditFileMaxBackups = n
+		} else {
+			klog.Warningf("Invalid AUDIT_FILE_MAX_BACKUPS %q, using %d", v, AuditFileMaxBackups)
+		}
+	}
+	if v := os.Getenv("AUDIT_SYSLOG_ADDR"); v != "" {
+		AuditSyslogAddr = v
+	}
+	if v := os.Getenv("AUDIT_WEBHOOK_URL"); v != "" {
+		AuditWebhookURL = v
+	}
+	if v := os.Getenv("AUDIT_WEBHOOK_TOKEN"); v != "" {
+		AuditWebhookToken = v
+	}
+	if v := os.Getenv("AUDIT_WEBHOOK_BUFFER_DIR"); v != "" {
+		AuditWebhookBufferDir = v
+	}
+	if v := os.Getenv("AUDIT_WEBHOOK_BUFFER_MAX_SIZE_MB"); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil && n > 0 {
+			AuditWebhookBufferMaxSizeMB = n
+		} else {
+			klog.Warningf("Invalid AUDIT_WEBHOOK_BUFFER_MAX_SIZE_MB %q, using %d", v, AuditWebhookBufferMaxSizeMB)
+		}
+	}
+
+	if v := os.Getenv("ANALYSIS_SCAN_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			AnalysisScanInterval = d
+		} else {
+			klog.Warningf("Invalid ANALYSIS_SCAN_INTERVAL %q, using %s", v, AnalysisScanInterval)
+		}
+	}
+	if v := os.Getenv("ANALYZER_RULES_FILE"); v != "" {
+		AnalyzerRulesFile = v
+	}
+	if v := os.Getenv("ANALYZER_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			AnalyzerTimeout = d
+		} else {
+			klog.Warningf("Invalid ANALYZER_TIMEOUT %q, using %s", v, AnalyzerTimeout)
+		}
+	}
+	if v := os.Getenv("ANALYZER_CATEGORY_WEIGHTS"); v != "" {
+		for _, pair := range strings.Split(v, ",") {
+			category, weight, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			w, err := strconv.ParseFloat(weight, 64)
+			if !ok || err != nil || w < 0 {
+				klog.Warningf("Invalid ANALYZER_CATEGORY_WEIGHTS entry %q, ignoring", pair)
+				continue
+			}
+			AnalyzerCategoryWeights[strings.ToLower(category)] = w
+		}
+	}
+	if v := os.Getenv("SESSION_MAX_AGE"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			SessionMaxAge = d
+		} else {
+			klog.Warningf("Invalid SESSION_MAX_AGE %q, using %s", v, SessionMaxAge)
+		}
+	}
+	SCIMToken = os.Getenv("SCIM_TOKEN")
+	if v := os.Getenv("LOGIN_MAX_FAILURES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			LoginMaxFailures = n
+		} else {
+			klog.Warningf("Invalid LOGIN_MAX_FAILURES %q, using %d", v, LoginMaxFailures)
+		}
+	}
+	if v := os.Getenv("LOGIN_LOCKOUT_DURATION"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			LoginLockoutDuration = d
+		} else {
+			klog.Warningf("Invalid LOGIN_LOCKOUT_DURATION %q, using %s", v, LoginLockoutDuration)
+		}
+	}
+	if v := os.Getenv("LOGIN_RATE_LIMIT"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			LoginRateLimit = n
+		} else {
+			klog.Warningf("Invalid LOGIN_RATE_LIMIT %q, using %d", v, LoginRateLimit)
+		}
+	}
 }
@@ -12,6 +12,19 @@ const (
 	VerbExec   Verb = "exec"
 )
 
+// Role effects. A deny role overrides every allow role it overlaps with.
+const (
+	EffectAllow = "allow"
+	EffectDeny  = "deny"
+)
+
+// Subresources lists the subresources roles can grant or deny separately
+// from their resource, as "pods/exec" entries in Resources
+var Subresources = map[string][]string{
+	"pods":  {"exec", "log", "portforward", "files"},
+	"nodes": {"drain"},
+}
+
 type Role struct {
 	Name        string   `yaml:"name" json:"name"`
 	Description string   `yaml:"description" json:"-"`
@@ -19,6 +32,12 @@ type Role struct {
 	Resources   []string `yaml:"resources" json:"resources"`
 	Namespaces  []string `yaml:"namespaces" json:"namespaces"`
 	Verbs       []string `yaml:"verbs" json:"verbs"`
+	// ResourceNames limits the role to objects with matching names
+	ResourceNames []string `yaml:"resourceNames,omitempty" json:"resourceNames,omitempty"`
+	// LabelSelector limits the role to objects whose labels match it
+	LabelSelector string `yaml:"labelSelector,omitempty" json:"labelSelector,omitempty"`
+	// Effect is EffectAllow (the default) or EffectDeny
+	Effect string `yaml:"effect,omitempty" json:"effect,omitempty"`
 }
 
 type RoleMapping struct {
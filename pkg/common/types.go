@@ -34,6 +34,15 @@ type ResourceMetric struct {
 type PasswordLoginRequest struct {
 	Username string `json:"username" binding:"required"`
 	Password string `json:"password" binding:"required"`
+	// Code is a TOTP or recovery code for users with two-factor
+	// authentication
+	Code string `json:"code"`
+}
+
+type LDAPLoginRequest struct {
+	Provider string `json:"provider" binding:"required"`
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
 }
 
 type ImportClustersRequest struct {
@@ -42,11 +51,12 @@ type ImportClustersRequest struct {
 }
 
 type ClusterInfo struct {
-	Name           string `json:"name"`
-	Version        string `json:"version"`
-	IsDefault      bool   `json:"isDefault"`
-	Error          string `json:"error,omitempty"`
-	SkipSystemSync bool   `json:"skipSystemSync"`
+	Name             string `json:"name"`
+	Version          string `json:"version"`
+	IsDefault        bool   `json:"isDefault"`
+	Error            string `json:"error,omitempty"`
+	SkipSystemSync   bool   `json:"skipSystemSync"`
+	ImpersonateUsers bool   `json:"impersonateUsers"`
 }
 
 type MetricsCell struct {
@@ -0,0 +1,273 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pixelvide/kube-sentinel/pkg/ai/tools"
+	"github.com/pixelvide/kube-sentinel/pkg/audit"
+	"github.com/pixelvide/kube-sentinel/pkg/model"
+	openai "github.com/sashabaranov/go-openai"
+	"gorm.io/gorm"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// toolApprovalTimeout is how long a tool call waits for the user
+	toolApprovalTimeout = 15 * time.Minute
+	// toolApprovalPollInterval is how often a waiting chat checks the
+	// database, for decisions made on another replica
+	toolApprovalPollInterval = 2 * time.Second
+)
+
+var errApprovalInterrupted = errors.New("the chat was interrupted while waiting for an approval, the tool call can still be approved")
+
+// approvalWaiters wakes the chats waiting for a decision on an approval,
+// by approval ID
+var approvalWaiters sync.Map
+
+// runApprovedToolCall asks the user to approve a call of a tool that
+// changes resources, with a preview of the change, and runs it once
+// approved. The approval is stored, so a chat that was interrupted while
+// waiting continues with the same approval when it resumes.
+func runApprovedToolCall(ctx context.Context, c *gin.Context, session *model.AIChatSession, registry *tools.Registry, toolCtx context.Context, messageID uint, tc openai.ToolCall) (string, error) {
+	clusterName, _ := toolCtx.Value(tools.ClusterNameKey{}).(string)
+
+	var approval model.AIToolApproval
+	err := model.DB.Where("session_id = ? AND message_id = ? AND tool_call_id = ?", session.ID, messageID, tc.ID).First(&approval).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		preview, previewErr := registry.Preview(toolCtx, tc.Function.Name, tc.Function.Arguments)
+		var denied *tools.DeniedError
+		if errors.As(previewErr, &denied) {
+			return denied.Reason, nil
+		}
+		if previewErr != nil {
+			klog.Errorf("AI tool %s failed: %v", tc.Function.Name, previewErr)
+			return fmt.Sprintf("Error executing tool: %v", previewErr), nil
+		}
+		approval = model.AIToolApproval{
+			SessionID:   session.ID,
+			MessageID:   messageID,
+			ToolCallID:  tc.ID,
+			ToolName:    tc.Function.Name,
+			Arguments:   tc.Function.Arguments,
+			Preview:     preview,
+			ClusterName: clusterName,
+			Status:      model.AIToolApprovalPending,
+		}
+		err = model.DB.Create(&approval).Error
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to store the tool call approval: %w", err)
+	}
+
+	if approval.Status == model.AIToolApprovalPending {
+		c.SSEvent("approval_required", gin.H{"approval": approval})
+		c.Writer.Flush()
+		if err := waitForToolApproval(ctx, &approval); err != nil {
+			return "", err
+		}
+		c.SSEvent("approval_resolved", gin.H{"approval": approval})
+		c.Writer.Flush()
+	}
+
+	switch approval.Status {
+	case model.AIToolApprovalApproved:
+		if approval.ClusterName != clusterName {
+			return fmt.Sprintf("Not executed: the call was approved for cluster %s, but the chat now uses cluster %s.", approval.ClusterName, clusterName), nil
+		}
+		res, err := registry.Execute(toolCtx, tc.Function.Name, tc.Function.Arguments)
+		if err != nil {
+			klog.Errorf("AI tool %s failed: %v", tc.Function.Name, err)
+			return fmt.Sprintf("Error executing tool: %v", err), nil
+		}
+		return res, nil
+	case model.AIToolApprovalRejected:
+		return "The user rejected this call, it was not executed. Do not retry it, ask the user how to proceed instead.", nil
+	default:
+		return "The call was not approved in time and was not executed.", nil
+	}
+}
+
+// waitForToolApproval blocks until the user decided on an approval or it
+// expired, and reloads it
+func waitForToolApproval(ctx context.Context, approval *model.AIToolApproval) error {
+	wake := make(chan struct{}, 1)
+	approvalWaiters.Store(approval.ID, wake)
+	defer approvalWaiters.Delete(approval.ID)
+
+	expiry := time.NewTimer(time.Until(approval.CreatedAt.Add(toolApprovalTimeout)))
+	defer expiry.Stop()
+	poll := time.NewTicker(toolApprovalPollInterval)
+	defer poll.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return errApprovalInterrupted
+		case <-expiry.C:
+			model.DB.Model(approval).Where("status = ?", model.AIToolApprovalPending).Update("status", model.AIToolApprovalExpired)
+		case <-wake:
+		case <-poll.C:
+		}
+		if err := model.DB.First(approval, approval.ID).Error; err != nil {
+			return err
+		}
+		if approval.Status != model.AIToolApprovalPending {
+			return nil
+		}
+	}
+}
+
+// openToolCalls returns the last assistant message when some of its tool
+// calls have no result, because the chat stopped while waiting for an
+// approval, together with those calls
+func openToolCalls(messages []model.AIChatMessage) (*model.AIChatMessage, []openai.ToolCall) {
+	active := activeMessages(messages)
+	answered := make(map[string]bool)
+	for i := len(active) - 1; i >= 0; i-- {
+		m := active[i]
+		if m.Role == openai.ChatMessageRoleTool {
+			answered[m.ToolID] = true
+			continue
+		}
+		if m.Role != openai.ChatMessageRoleAssistant || m.ToolCalls == "" {
+			return nil, nil
+		}
+		var toolCalls []openai.ToolCall
+		if err := json.Unmarshal([]byte(m.ToolCalls), &toolCalls); err != nil {
+			return nil, nil
+		}
+		var open []openai.ToolCall
+		for _, tc := range toolCalls {
+			if !answered[tc.ID] {
+				open = append(open, tc)
+			}
+		}
+		if len(open) == 0 {
+			return nil, nil
+		}
+		return &active[i], open
+	}
+	return nil, nil
+}
+
+// resumeToolCalls runs the tool calls the chat stopped at, asking for the
+// approvals that are still pending. It reports whether there were any.
+func resumeToolCalls(ctx context.Context, c *gin.Context, session *model.AIChatSession, registry *tools.Registry, toolCtx context.Context) (bool, error) {
+	msg, open := openToolCalls(session.Messages)
+	if msg == nil {
+		return false, nil
+	}
+	var transcript strings.Builder
+	for _, tc := range open {
+		if _, err := runToolCall(ctx, c, session, registry, toolCtx, msg.ID, tc, &transcript); err != nil {
+			return true, err
+		}
+	}
+	return true, nil
+}
+
+// closeOpenToolCalls answers the tool calls the chat stopped at when the
+// user sends a new message instead of deciding on them, so the history
+// sent to the model stays valid
+func closeOpenToolCalls(session *model.AIChatSession) {
+	msg, open := openToolCalls(session.Messages)
+	if msg == nil {
+		return
+	}
+	model.DB.Model(&model.AIToolApproval{}).
+		Where("message_id = ? AND status = ?", msg.ID, model.AIToolApprovalPending).
+		Update("status", model.AIToolApprovalExpired)
+	for _, tc := range open {
+		result := model.AIChatMessage{
+			SessionID: session.ID,
+			Role:      openai.ChatMessageRoleTool,
+			Content:   "Not executed: the user sent a new message instead of approving the call.",
+			ToolID:    tc.ID,
+			CreatedAt: time.Now(),
+		}
+		if err := model.DB.Create(&result).Error; err != nil {
+			klog.Errorf("AI Chat: failed to close tool call %s of session %s: %v", tc.ID, session.ID, err)
+			continue
+		}
+		session.Messages = append(session.Messages, result)
+	}
+}
+
+// DecideAIToolApproval approves or rejects a pending tool call of a chat
+// session of the user, and wakes the chat waiting for it
+func DecideAIToolApproval(c *gin.Context) {
+	user := getUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	var input struct {
+		Approved bool `json:"approved"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var session model.AIChatSession
+	if err := model.DB.Where("id = ? AND user_id = ?", c.Param("id"), user.ID).First(&session).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+	var approval model.AIToolApproval
+	if err := model.DB.Where("session_id = ? AND tool_call_id = ? AND status = ?", session.ID, c.Param("toolCallID"), model.AIToolApprovalPending).
+		Order("id DESC").First(&approval).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No pending approval for this tool call"})
+		return
+	}
+
+	status, action := model.AIToolApprovalRejected, "reject"
+	if input.Approved {
+		status, action = model.AIToolApprovalApproved, "approve"
+	}
+	now := time.Now()
+	result := model.DB.Model(&approval).Where("status = ?", model.AIToolApprovalPending).Updates(map[string]interface{}{
+		"status":      status,
+		"approver_id": user.ID,
+		"decided_at":  now,
+	})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store the decision"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "The tool call was already decided"})
+		return
+	}
+	approval.Status, approval.ApproverID, approval.DecidedAt = status, &user.ID, &now
+
+	entry := audit.FromRequest(c, action)
+	entry.ClusterName = approval.ClusterName
+	entry.ResourceType = "ai-tool-calls"
+	entry.ResourceName = approval.ToolName
+	entry.Source = model.AuditSourceAI
+	entry.ChatSessionID = session.ID
+	entry.Payload = map[string]interface{}{
+		"toolCallId": approval.ToolCallID,
+		"arguments":  approval.Arguments,
+		"preview":    approval.Preview,
+	}
+	audit.Emit(entry.WithError(nil))
+
+	if wake, ok := approvalWaiters.Load(approval.ID); ok {
+		select {
+		case wake.(chan struct{}) <- struct{}{}:
+		default:
+		}
+	}
+	c.JSON(http.StatusOK, approval)
+}
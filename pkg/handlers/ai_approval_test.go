@@ -0,0 +1,260 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pixelvide/kube-sentinel/pkg/ai/tools"
+	"github.com/pixelvide/kube-sentinel/pkg/cluster"
+	"github.com/pixelvide/kube-sentinel/pkg/model"
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// restartTool is a tool changing resources, which records its calls
+type restartTool struct {
+	calls []string
+}
+
+func (t *restartTool) Name() string { return "restart_deployment" }
+func (t *restartTool) Definition() openai.Tool {
+	return openai.Tool{Type: openai.ToolTypeFunction, Function: &openai.FunctionDefinition{Name: "restart_deployment"}}
+}
+func (t *restartTool) Permissions(string) ([]tools.Permission, error) {
+	return []tools.Permission{}, nil
+}
+func (t *restartTool) Preview(_ context.Context, args string) (string, error) {
+	return "restart " + args, nil
+}
+func (t *restartTool) Execute(_ context.Context, args string) (string, error) {
+	t.calls = append(t.calls, args)
+	return "restarted " + args, nil
+}
+
+// restartClient calls the restart tool, then answers with the last tool
+// result
+type restartClient struct {
+	summaryClient
+}
+
+func (*restartClient) ChatCompletionStream(_ context.Context, messages []openai.ChatCompletionMessage, _ []openai.Tool) (chan openai.ChatCompletionStreamResponse, error) {
+	stream := make(chan openai.ChatCompletionStreamResponse, 1)
+	last := messages[len(messages)-1]
+	if last.Role == openai.ChatMessageRoleTool {
+		stream <- openai.ChatCompletionStreamResponse{Choices: []openai.ChatCompletionStreamChoice{{Delta: openai.ChatCompletionStreamChoiceDelta{Content: "Done: " + last.Content}}}}
+	} else {
+		index := 0
+		stream <- openai.ChatCompletionStreamResponse{Choices: []openai.ChatCompletionStreamChoice{{Delta: openai.ChatCompletionStreamChoiceDelta{ToolCalls: []openai.ToolCall{{
+			Index:    &index,
+			ID:       "call_restart_deployment",
+			Type:     openai.ToolTypeFunction,
+			Function: openai.FunctionCall{Name: "restart_deployment", Arguments: `{"name":"web"}`},
+		}}}}}}
+	}
+	close(stream)
+	return stream, nil
+}
+
+type approvalTest struct {
+	session  *model.AIChatSession
+	user     model.User
+	tool     *restartTool
+	registry *tools.Registry
+	toolCtx  context.Context
+	router   *gin.Engine
+}
+
+func setupApprovalTest(t *testing.T) *approvalTest {
+	session := createChatSession(t, 0, "")
+	require.NoError(t, model.DB.AutoMigrate(&model.App{}, &model.AIToolApproval{}, &model.AuditLog{}))
+	app := model.App{Name: "kube-sentinel"}
+	require.NoError(t, model.DB.FirstOrCreate(&app, model.App{Name: "kube-sentinel"}).Error)
+	currentApp := model.CurrentApp
+	model.CurrentApp = &app
+	// The chat and the approval handler run concurrently
+	sqlDB, err := model.DB.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+
+	user := model.User{Username: "approver"}
+	require.NoError(t, model.DB.FirstOrCreate(&user, model.User{Username: "approver"}).Error)
+	require.NoError(t, model.DB.Model(session).Update("user_id", user.ID).Error)
+	t.Cleanup(func() {
+		model.CurrentApp = currentApp
+		model.DB.Where("session_id = ?", session.ID).Delete(&model.AIToolApproval{})
+		model.DB.Where("chat_session_id = ?", session.ID).Delete(&model.AuditLog{})
+	})
+
+	at := &approvalTest{session: session, user: user, tool: &restartTool{}, registry: tools.NewRegistry()}
+	at.registry.Register(at.tool)
+	at.toolCtx = context.WithValue(context.Background(), tools.ClientSetKey{}, &cluster.ClientSet{Name: "dev"})
+	at.toolCtx = context.WithValue(at.toolCtx, tools.ClusterNameKey{}, "dev")
+
+	at.router = gin.New()
+	at.router.Use(func(c *gin.Context) {
+		c.Set("user", user)
+		c.Next()
+	})
+	at.router.POST("/ai/sessions/:id/tool-calls/:toolCallID/approval", DecideAIToolApproval)
+	return at
+}
+
+// chat runs the chat loop in the background, and returns a function
+// waiting for its result
+func (at *approvalTest) chat(ctx context.Context) func() (string, string, error) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	type result struct {
+		content string
+		err     error
+	}
+	done := make(chan result, 1)
+	go func() {
+		messages := []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Restart web"}}
+		content, err := executeAIChatStreamLoop(ctx, &restartClient{}, at.session, messages, nil, at.registry, at.toolCtx, c, 128000, nil)
+		done <- result{content, err}
+	}()
+	return func() (string, string, error) {
+		select {
+		case r := <-done:
+			return r.content, w.Body.String(), r.err
+		case <-time.After(10 * time.Second):
+			return "", "", context.DeadlineExceeded
+		}
+	}
+}
+
+func (at *approvalTest) pendingApproval(t *testing.T) model.AIToolApproval {
+	var approval model.AIToolApproval
+	require.Eventually(t, func() bool {
+		return model.DB.Where("session_id = ? AND status = ?", at.session.ID, model.AIToolApprovalPending).First(&approval).Error == nil
+	}, 5*time.Second, 10*time.Millisecond)
+	return approval
+}
+
+func (at *approvalTest) decide(toolCallID string, approved bool) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(map[string]bool{"approved": approved})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/ai/sessions/"+at.session.ID+"/tool-calls/"+toolCallID+"/approval", bytes.NewBuffer(body))
+	at.router.ServeHTTP(w, req)
+	return w
+}
+
+func TestToolCallApproved(t *testing.T) {
+	at := setupApprovalTest(t)
+	wait := at.chat(context.Background())
+
+	approval := at.pendingApproval(t)
+	assert.Equal(t, "restart_deployment", approval.ToolName)
+	assert.Equal(t, `restart {"name":"web"}`, approval.Preview)
+	assert.Equal(t, "dev", approval.ClusterName)
+	assert.Empty(t, at.tool.calls, "the tool waits for the approval")
+
+	w := at.decide(approval.ToolCallID, true)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	content, body, err := wait()
+	require.NoError(t, err)
+	assert.Equal(t, []string{`{"name":"web"}`}, at.tool.calls)
+	assert.Contains(t, content, `Done: restarted {"name":"web"}`)
+	assert.Contains(t, body, "event:approval_required")
+	assert.Contains(t, body, "event:approval_resolved")
+
+	require.NoError(t, model.DB.First(&approval, approval.ID).Error)
+	assert.Equal(t, model.AIToolApprovalApproved, approval.Status)
+	require.NotNil(t, approval.ApproverID)
+	assert.Equal(t, at.user.ID, *approval.ApproverID)
+
+	var log model.AuditLog
+	require.NoError(t, model.DB.Where("chat_session_id = ?", at.session.ID).First(&log).Error)
+	assert.Equal(t, "approve", log.Action)
+	assert.Equal(t, at.user.ID, log.ActorID)
+	assert.Equal(t, "restart_deployment", log.ResourceName)
+	assert.Equal(t, "dev", log.ClusterName)
+
+	w = at.decide(approval.ToolCallID, false)
+	assert.Equal(t, http.StatusNotFound, w.Code, "a decided call cannot be decided again")
+}
+
+func TestToolCallRejected(t *testing.T) {
+	at := setupApprovalTest(t)
+	wait := at.chat(context.Background())
+
+	approval := at.pendingApproval(t)
+	w := at.decide(approval.ToolCallID, false)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	content, _, err := wait()
+	require.NoError(t, err)
+	assert.Empty(t, at.tool.calls)
+	assert.Contains(t, content, "The user rejected this call")
+
+	var log model.AuditLog
+	require.NoError(t, model.DB.Where("chat_session_id = ?", at.session.ID).First(&log).Error)
+	assert.Equal(t, "reject", log.Action)
+}
+
+func TestToolCallApprovalAfterReconnect(t *testing.T) {
+	at := setupApprovalTest(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	wait := at.chat(ctx)
+
+	// The browser goes away while the call waits
+	approval := at.pendingApproval(t)
+	cancel()
+	_, _, err := wait()
+	require.ErrorIs(t, err, errApprovalInterrupted)
+
+	session, err := getOrCreateSession(at.session.ID, at.user.ID)
+	require.NoError(t, err)
+	msg, open := openToolCalls(session.Messages)
+	require.NotNil(t, msg)
+	require.Len(t, open, 1)
+	assert.Equal(t, approval.MessageID, msg.ID)
+
+	// The approval is still pending, and the chat resumes with it
+	w := at.decide(approval.ToolCallID, true)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	resumed, err := resumeToolCalls(context.Background(), c, session, at.registry, at.toolCtx)
+	require.NoError(t, err)
+	assert.True(t, resumed)
+	assert.Equal(t, []string{`{"name":"web"}`}, at.tool.calls)
+
+	session, err = getOrCreateSession(at.session.ID, at.user.ID)
+	require.NoError(t, err)
+	msg, _ = openToolCalls(session.Messages)
+	assert.Nil(t, msg)
+}
+
+func TestCloseOpenToolCalls(t *testing.T) {
+	at := setupApprovalTest(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	wait := at.chat(ctx)
+	approval := at.pendingApproval(t)
+	cancel()
+	_, _, err := wait()
+	require.ErrorIs(t, err, errApprovalInterrupted)
+
+	// The user sends a new message instead of deciding
+	session, err := getOrCreateSession(at.session.ID, at.user.ID)
+	require.NoError(t, err)
+	closeOpenToolCalls(session)
+	msg, _ := openToolCalls(session.Messages)
+	assert.Nil(t, msg)
+	last := session.Messages[len(session.Messages)-1]
+	assert.Equal(t, openai.ChatMessageRoleTool, last.Role)
+	assert.Equal(t, approval.ToolCallID, last.ToolID)
+
+	require.NoError(t, model.DB.First(&approval, approval.ID).Error)
+	assert.Equal(t, model.AIToolApprovalExpired, approval.Status)
+	assert.Equal(t, http.StatusNotFound, at.decide(approval.ToolCallID, true).Code)
+	assert.Empty(t, at.tool.calls)
+}
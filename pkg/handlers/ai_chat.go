@@ -3,6 +3,7 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -24,6 +25,7 @@ type ChatRequest struct {
 	Message   string      `json:"message"`
 	Model     string      `json:"model"` // Optional model override
 	Context   ChatContext `json:"context"`
+	Resume    bool        `json:"resume"` // Continue after the user decided on a tool call, without a message
 }
 
 type ChatContext struct {
@@ -109,11 +111,14 @@ func resolveAIConfig(user *model.User) (*ai.AIConfig, error) {
 			}
 
 			resolvedConfig = &ai.AIConfig{
-				Provider:     profile.Provider,
-				APIKey:       string(userSettings.APIKey),
-				BaseURL:      profile.BaseURL,
-				Model:        modelOverride,
-				DefaultModel: profile.DefaultModel,
+				ProfileID:     profile.ID,
+				Provider:      profile.Provider,
+				APIKey:        string(userSettings.APIKey),
+				BaseURL:       profile.BaseURL,
+				Model:         modelOverride,
+				DefaultModel:  profile.DefaultModel,
+				APIVersion:    profile.APIVersion,
+				ContextWindow: profile.ContextWindow,
 			}
 		}
 	}
@@ -123,11 +128,14 @@ func resolveAIConfig(user *model.User) (*ai.AIConfig, error) {
 		var profile model.AIProviderProfile
 		if err := model.DB.Where("is_system = ? AND is_enabled = ?", true, true).First(&profile).Error; err == nil {
 			resolvedConfig = &ai.AIConfig{
-				Provider:     profile.Provider,
-				APIKey:       string(profile.APIKey),
-				BaseURL:      profile.BaseURL,
-				Model:        profile.DefaultModel,
-				DefaultModel: profile.DefaultModel,
+				ProfileID:     profile.ID,
+				Provider:      profile.Provider,
+				APIKey:        string(profile.APIKey),
+				BaseURL:       profile.BaseURL,
+				Model:         profile.DefaultModel,
+				DefaultModel:  profile.DefaultModel,
+				APIVersion:    profile.APIVersion,
+				ContextWindow: profile.ContextWindow,
 			}
 		}
 	}
@@ -207,7 +215,7 @@ func getOrCreateSession(sessionID string, userID uint) (*model.AIChatSession, er
 	return &session, nil
 }
 
-func buildMessageHistory(session model.AIChatSession, userMessage string, chatCtx ChatContext, clusterName string, clusterID uint) []openai.ChatCompletionMessage {
+func buildMessageHistory(session model.AIChatSession, userMessage string, chatCtx ChatContext, clusterName string, clusterID uint, toolResultBudget int) []openai.ChatCompletionMessage {
 	var messages []openai.ChatCompletionMessage
 
 	systemPrompt := `You are an expert Kubernetes AI Assistant named "Kube Sentinel AI". You are embedded within the Kube Sentinel Dashboard.
@@ -226,7 +234,8 @@ To help users manage, debug, and understand their Kubernetes clusters efficientl
     -   Analyze the user's request, plan your steps, and explain *why* you are choosing a specific tool.
 3.  **SAFETY FIRST:**
     -   You are read-only by default.
-    -   If a user asks for a state-changing action (scale, delete, edit), you **MUST** ask for explicit confirmation unless they provided it in the prompt.
+    -   Tools that change resources (e.g. 'scale_deployment') only run after the user approves the call in the chat, which shows them a preview of the change. Call them when the user asks for the change instead of asking for confirmation in text.
+    -   If the user rejects a call, do not retry it. Ask how to proceed instead.
 4.  **UI NAVIGATION:**
     -   You can navigate the user's UI using 'navigate_to'.
     -   **Rule:** Only navigate if the user explicitly asks ("Go to...", "Show me..."). Do not navigate just because you found a resource.
@@ -319,17 +328,32 @@ If the user asks you to create or generate a resource (YAML/Manifest):
 		systemPrompt += fmt.Sprintf("\n\n**USER CONTEXT:**\nThe user is currently in namespace '%s'.", chatCtx.Namespace)
 	}
 
+	// Inject the summary of the earlier turns, which replaces them
+	history := activeMessages(session.Messages)
+	for _, m := range history {
+		if m.Summary {
+			systemPrompt += fmt.Sprintf("\n\n**SUMMARY OF THE EARLIER CONVERSATION:**\n%s", m.Content)
+		}
+	}
+
 	// System Prompt
 	messages = append(messages, openai.ChatCompletionMessage{
 		Role:    openai.ChatMessageRoleSystem,
 		Content: systemPrompt,
 	})
 
-	for _, m := range session.Messages {
+	for _, m := range history {
+		if m.Summary {
+			continue
+		}
 		msg := openai.ChatCompletionMessage{
 			Role:    m.Role,
 			Content: m.Content,
 		}
+		// Large tool results are kept in full in the database only
+		if m.Role == openai.ChatMessageRoleTool {
+			msg.Content = ai.TruncateToolResult(m.Content, toolResultBudget)
+		}
 		if m.ToolCalls != "" {
 			var tcs []openai.ToolCall
 			if err := json.Unmarshal([]byte(m.ToolCalls), &tcs); err == nil {
@@ -342,16 +366,19 @@ If the user asks you to create or generate a resource (YAML/Manifest):
 		messages = append(messages, msg)
 	}
 
-	// Add current user message
-	messages = append(messages, openai.ChatCompletionMessage{
-		Role:    openai.ChatMessageRoleUser,
-		Content: userMessage,
-	})
+	// Add current user message, there is none when the chat resumes after
+	// an approval
+	if userMessage != "" {
+		messages = append(messages, openai.ChatCompletionMessage{
+			Role:    openai.ChatMessageRoleUser,
+			Content: userMessage,
+		})
+	}
 
 	return messages
 }
 
-func generateChatTitle(ctx context.Context, aiClient ai.AIClient, userMessage string) string {
+func generateChatTitle(ctx context.Context, aiClient ai.AIClient, userMessage string, usage *usageRecorder) string {
 	prompt := fmt.Sprintf("Summarize the following user message into a short, descriptive chat title (max 4 words). Output ONLY the title text, no quotes or punctuation: %s", userMessage)
 	msgs := []openai.ChatCompletionMessage{
 		{
@@ -367,16 +394,30 @@ func generateChatTitle(ctx context.Context, aiClient ai.AIClient, userMessage st
 	}
 
 	if len(resp.Choices) > 0 {
+		usage.record(0, callUsage(&resp.Usage, msgs, resp.Choices[0].Message.Content))
 		return strings.TrimSpace(resp.Choices[0].Message.Content)
 	}
 	return ""
 }
 
-func executeAIChatStreamLoop(ctx context.Context, aiClient ai.AIClient, session *model.AIChatSession, messages []openai.ChatCompletionMessage, toolDefs []openai.Tool, registry *tools.Registry, toolCtx context.Context, c *gin.Context) (string, error) {
+func executeAIChatStreamLoop(ctx context.Context, aiClient ai.AIClient, session *model.AIChatSession, messages []openai.ChatCompletionMessage, toolDefs []openai.Tool, registry *tools.Registry, toolCtx context.Context, c *gin.Context, contextWindow int, usage *usageRecorder) (string, error) {
 	maxIterations := 50
 	var finalContent strings.Builder
+	toolResultBudget := ai.ToolResultBudget(contextWindow)
 
 	for i := 0; i < maxIterations; i++ {
+		messages = fitContextWindow(messages, contextWindow)
+		contextTokens := ai.EstimateMessageTokens(messages)
+		c.SSEvent("context", gin.H{"contextTokens": contextTokens, "contextWindow": contextWindow})
+		c.Writer.Flush()
+		model.DB.Model(session).Updates(map[string]interface{}{
+			"context_tokens": contextTokens,
+			"context_window": contextWindow,
+		})
+
+		if err := usage.checkBudgets(); err != nil {
+			return finalContent.String(), err
+		}
 		stream, err := aiClient.ChatCompletionStream(ctx, messages, toolDefs)
 		if err != nil {
 			return "", fmt.Errorf("AI Provider error: %w", err)
@@ -384,8 +425,18 @@ func executeAIChatStreamLoop(ctx context.Context, aiClient ai.AIClient, session
 
 		var currentAssistantMessage strings.Builder
 		var currentToolCalls []openai.ToolCall
+		var reportedUsage *openai.Usage
+		var streamErr error
 
 		for resp := range stream {
+			if err := ai.StreamError(resp); err != nil {
+				streamErr = err
+				continue
+			}
+			// The usage comes with the last chunk, which may have no choices
+			if resp.Usage != nil {
+				reportedUsage = resp.Usage
+			}
 			if len(resp.Choices) == 0 {
 				continue
 			}
@@ -424,20 +475,32 @@ func executeAIChatStreamLoop(ctx context.Context, aiClient ai.AIClient, session
 			}
 		}
 
+		if streamErr != nil {
+			return finalContent.String(), fmt.Errorf("AI Provider error: %w", streamErr)
+		}
+
 		// Construct assistant message
 		msg := openai.ChatCompletionMessage{
 			Role:      openai.ChatMessageRoleAssistant,
 			Content:   currentAssistantMessage.String(),
 			ToolCalls: currentToolCalls,
 		}
+		var completion strings.Builder
+		completion.WriteString(msg.Content)
+		for _, tc := range msg.ToolCalls {
+			completion.WriteString(tc.Function.Name + tc.Function.Arguments)
+		}
+		callTokens := callUsage(reportedUsage, messages, completion.String())
 		messages = append(messages, msg)
 
 		// Save assistant message to DB (excluding reasoning if separate, but here we save all)
 		dbMsg := model.AIChatMessage{
-			SessionID: session.ID,
-			Role:      msg.Role,
-			Content:   msg.Content,
-			CreatedAt: time.Now(),
+			SessionID:        session.ID,
+			Role:             msg.Role,
+			Content:          msg.Content,
+			PromptTokens:     callTokens.PromptTokens,
+			CompletionTokens: callTokens.CompletionTokens,
+			CreatedAt:        time.Now(),
 		}
 		if len(msg.ToolCalls) > 0 {
 			tcBytes, err := json.Marshal(msg.ToolCalls)
@@ -446,6 +509,7 @@ func executeAIChatStreamLoop(ctx context.Context, aiClient ai.AIClient, session
 			}
 		}
 		model.DB.Create(&dbMsg)
+		usage.record(dbMsg.ID, callTokens)
 
 		if len(currentToolCalls) > 0 {
 			// Notify user about tool execution
@@ -453,47 +517,18 @@ func executeAIChatStreamLoop(ctx context.Context, aiClient ai.AIClient, session
 			c.Writer.Flush()
 
 			for _, tc := range currentToolCalls {
-				klog.Infof("AI executing tool: %s args: %s", tc.Function.Name, tc.Function.Arguments)
-
-				// Stream tool call visual
-				callJSON := fmt.Sprintf(`{"name": "%s", "arguments": %s}`, tc.Function.Name, tc.Function.Arguments)
-				c.SSEvent("message", gin.H{"content": fmt.Sprintf("\n<tool_call>\n%s\n</tool_call>\n", callJSON)})
-				c.Writer.Flush()
-				finalContent.WriteString(fmt.Sprintf("\n<tool_call>\n%s\n</tool_call>\n", callJSON))
-
-				var result string
-				if val := toolCtx.Value(tools.ClientSetKey{}); val == nil {
-					result = "Error: No active cluster context. Please select a cluster in the dashboard."
-				} else {
-					res, err := registry.Execute(toolCtx, tc.Function.Name, tc.Function.Arguments)
-					if err != nil {
-						klog.Errorf("AI tool %s failed: %v", tc.Function.Name, err)
-						result = fmt.Sprintf("Error executing tool: %v", err)
-					} else {
-						result = res
-					}
+				result, err := runToolCall(ctx, c, session, registry, toolCtx, dbMsg.ID, tc, &finalContent)
+				if err != nil {
+					return finalContent.String(), err
 				}
 
-				// Stream tool result visual
-				c.SSEvent("message", gin.H{"content": fmt.Sprintf("\n<tool_result>\n%s\n</tool_result>\n", result)})
-				c.Writer.Flush()
-				finalContent.WriteString(fmt.Sprintf("\n<tool_result>\n%s\n</tool_result>\n", result))
-
 				// Append tool result
 				toolMsg := openai.ChatCompletionMessage{
 					Role:       openai.ChatMessageRoleTool,
-					Content:    result,
+					Content:    ai.TruncateToolResult(result, toolResultBudget),
 					ToolCallID: tc.ID,
 				}
 				messages = append(messages, toolMsg)
-
-				model.DB.Create(&model.AIChatMessage{
-					SessionID: session.ID,
-					Role:      openai.ChatMessageRoleTool,
-					Content:   result,
-					ToolID:    tc.ID,
-					CreatedAt: time.Now(),
-				})
 			}
 			continue
 		} else {
@@ -503,6 +538,52 @@ func executeAIChatStreamLoop(ctx context.Context, aiClient ai.AIClient, session
 	return finalContent.String(), nil
 }
 
+// runToolCall runs a tool call of the assistant message messageID, streams
+// it to the chat and stores its result. Calls of tools that change
+// resources only run once the user approved them.
+func runToolCall(ctx context.Context, c *gin.Context, session *model.AIChatSession, registry *tools.Registry, toolCtx context.Context, messageID uint, tc openai.ToolCall, transcript *strings.Builder) (string, error) {
+	klog.Infof("AI executing tool: %s args: %s", tc.Function.Name, tc.Function.Arguments)
+
+	// Stream tool call visual
+	callJSON := fmt.Sprintf(`{"name": "%s", "arguments": %s}`, tc.Function.Name, tc.Function.Arguments)
+	c.SSEvent("message", gin.H{"content": fmt.Sprintf("\n<tool_call>\n%s\n</tool_call>\n", callJSON)})
+	c.Writer.Flush()
+	transcript.WriteString(fmt.Sprintf("\n<tool_call>\n%s\n</tool_call>\n", callJSON))
+
+	var result string
+	if val := toolCtx.Value(tools.ClientSetKey{}); val == nil {
+		result = "Error: No active cluster context. Please select a cluster in the dashboard."
+	} else if registry.RequiresApproval(tc.Function.Name) {
+		res, err := runApprovedToolCall(ctx, c, session, registry, toolCtx, messageID, tc)
+		if err != nil {
+			return "", err
+		}
+		result = res
+	} else {
+		res, err := registry.Execute(toolCtx, tc.Function.Name, tc.Function.Arguments)
+		if err != nil {
+			klog.Errorf("AI tool %s failed: %v", tc.Function.Name, err)
+			result = fmt.Sprintf("Error executing tool: %v", err)
+		} else {
+			result = res
+		}
+	}
+
+	// Stream tool result visual
+	c.SSEvent("message", gin.H{"content": fmt.Sprintf("\n<tool_result>\n%s\n</tool_result>\n", result)})
+	c.Writer.Flush()
+	transcript.WriteString(fmt.Sprintf("\n<tool_result>\n%s\n</tool_result>\n", result))
+
+	model.DB.Create(&model.AIChatMessage{
+		SessionID: session.ID,
+		Role:      openai.ChatMessageRoleTool,
+		Content:   result,
+		ToolID:    tc.ID,
+		CreatedAt: time.Now(),
+	})
+	return result, nil
+}
+
 func AIChat(c *gin.Context) {
 	var req ChatRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -526,6 +607,14 @@ func AIChat(c *gin.Context) {
 	// 1.5 Override model if requested specifically in chat
 	validateAndOverrideModel(resolvedConfig, req.Model, user)
 
+	if err := model.CheckAIBudgets(user.ID, resolvedConfig.ProfileID, time.Now()); err != nil {
+		if errors.Is(err, model.ErrAIBudgetExceeded) {
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+			return
+		}
+		klog.Errorf("AI Chat: failed to check the token budgets of user %d: %v", user.ID, err)
+	}
+
 	// 2. Get ClientSet (for tool context)
 	var clientSet *cluster.ClientSet
 	if val, ok := c.Get("cluster"); ok && val != nil {
@@ -555,6 +644,7 @@ func AIChat(c *gin.Context) {
 	registry.Register(&tools.CheckImageSecurityTool{})
 	registry.Register(&tools.ListResourcesTool{})
 	registry.Register(&tools.GetClusterInfoTool{})
+	registry.Register(&tools.GetAnalysisScoresTool{})
 	registry.Register(&tools.NavigateToTool{})
 	registry.Register(&tools.KnowledgeTool{})
 	registry.Register(&tools.DebugAppConnectionTool{})
@@ -571,25 +661,8 @@ func AIChat(c *gin.Context) {
 			clusterID = c.ID
 		}
 	}
-	openAIMessages := buildMessageHistory(*session, req.Message, req.Context, clusterName, clusterID)
-
-	// Save user message to DB
-	model.DB.Create(&model.AIChatMessage{
-		SessionID: session.ID,
-		Role:      openai.ChatMessageRoleUser,
-		Content:   req.Message,
-		CreatedAt: time.Now(),
-	})
-
-	// Generate dynamic title if it's a new chat
-	if session.Title == "New Chat" {
-		go func(sID string, msg string, client ai.AIClient) {
-			newTitle := generateChatTitle(context.Background(), client, msg)
-			if newTitle != "" {
-				model.DB.Model(&model.AIChatSession{}).Where("id = ?", sID).Update("title", newTitle)
-			}
-		}(session.ID, req.Message, aiClient)
-	}
+	contextWindow := ai.ContextWindow(resolvedConfig)
+	usage := newUsageRecorder(resolvedConfig, user.ID, session.ID)
 
 	// 6. Execute Chat Loop
 	toolCtx := context.Background()
@@ -613,7 +686,51 @@ func AIChat(c *gin.Context) {
 	c.SSEvent("session", gin.H{"sessionID": session.ID})
 	c.Writer.Flush()
 
-	_, err = executeAIChatStreamLoop(c.Request.Context(), aiClient, session, openAIMessages, toolDefs, registry, toolCtx, c)
+	if req.Resume {
+		// Continue with the tool calls the chat stopped at to wait for an
+		// approval, e.g. after the browser reconnected
+		resumed, err := resumeToolCalls(c.Request.Context(), c, session, registry, toolCtx)
+		if err == nil && resumed {
+			session, err = getOrCreateSession(session.ID, user.ID)
+		}
+		if err != nil {
+			c.SSEvent("error", gin.H{"error": err.Error()})
+			c.Writer.Flush()
+			return
+		}
+		if !resumed {
+			c.SSEvent("done", gin.H{})
+			c.Writer.Flush()
+			return
+		}
+	} else {
+		closeOpenToolCalls(session)
+	}
+
+	summarizeHistoryIfNeeded(c.Request.Context(), aiClient, session, contextWindow, usage)
+	openAIMessages := buildMessageHistory(*session, req.Message, req.Context, clusterName, clusterID, ai.ToolResultBudget(contextWindow))
+
+	if req.Message != "" {
+		// Save user message to DB
+		model.DB.Create(&model.AIChatMessage{
+			SessionID: session.ID,
+			Role:      openai.ChatMessageRoleUser,
+			Content:   req.Message,
+			CreatedAt: time.Now(),
+		})
+
+		// Generate dynamic title if it's a new chat
+		if session.Title == "New Chat" {
+			go func(sID string, msg string, client ai.AIClient) {
+				newTitle := generateChatTitle(context.Background(), client, msg, usage)
+				if newTitle != "" {
+					model.DB.Model(&model.AIChatSession{}).Where("id = ?", sID).Update("title", newTitle)
+				}
+			}(session.ID, req.Message, aiClient)
+		}
+	}
+
+	_, err = executeAIChatStreamLoop(c.Request.Context(), aiClient, session, openAIMessages, toolDefs, registry, toolCtx, c, contextWindow, usage)
 	if err != nil {
 		c.SSEvent("error", gin.H{"error": err.Error()})
 		c.Writer.Flush()
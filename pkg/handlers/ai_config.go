@@ -1,11 +1,17 @@
 package handlers
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/hashicorp/golang-lru/v2/expirable"
+	"github.com/pixelvide/kube-sentinel/pkg/ai"
 	"github.com/pixelvide/kube-sentinel/pkg/model"
 	"gorm.io/gorm"
+	"k8s.io/klog/v2"
 )
 
 func getUser(c *gin.Context) *model.User {
@@ -187,7 +193,8 @@ func GetAIChatSession(c *gin.Context) {
 	// Preload messages order by created_at
 	if err := model.DB.Preload("Messages", func(db *gorm.DB) *gorm.DB {
 		return db.Order("created_at asc")
-	}).Where("id = ? AND user_id = ?", id, user.ID).First(&session).Error; err != nil {
+	}).Preload("PendingApprovals", "status = ?", model.AIToolApprovalPending).
+		Where("id = ? AND user_id = ?", id, user.ID).First(&session).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
 		return
 	}
@@ -240,7 +247,7 @@ func GetAvailableModels(c *gin.Context) {
 		var profile model.AIProviderProfile
 		if err := model.DB.Where("is_enabled = ?", true).First(&profile, userSettings.ProfileID).Error; err == nil {
 			c.JSON(http.StatusOK, gin.H{
-				"models":   profile.AllowedModels,
+				"models":   profileModels(c.Request.Context(), profile, string(userSettings.APIKey)),
 				"default":  profile.DefaultModel,
 				"provider": profile.Provider,
 			})
@@ -262,7 +269,7 @@ func GetAvailableModels(c *gin.Context) {
 	var profile model.AIProviderProfile
 	if err := model.DB.Where("is_system = ? AND is_enabled = ?", true, true).First(&profile).Error; err == nil {
 		c.JSON(http.StatusOK, gin.H{
-			"models":   profile.AllowedModels,
+			"models":   profileModels(c.Request.Context(), profile, string(profile.APIKey)),
 			"default":  profile.DefaultModel,
 			"provider": profile.Provider,
 		})
@@ -274,3 +281,49 @@ func GetAvailableModels(c *gin.Context) {
 		"message": "AI is not configured by the administrator.",
 	})
 }
+
+// discoveredModels caches the models self-hosted servers list, so the model
+// picker does not wait for the server on every request
+var discoveredModels = expirable.NewLRU[string, []string](100, nil, time.Minute)
+
+// profileModels returns the allowed models of a profile. Profiles of
+// self-hosted runtimes without allowed models offer the models their
+// server lists.
+func profileModels(ctx context.Context, profile model.AIProviderProfile, apiKey string) []string {
+	if len(profile.AllowedModels) > 0 || profile.Provider != "ollama" {
+		return profile.AllowedModels
+	}
+	key := fmt.Sprintf("%d|%s|%s", profile.ID, profile.BaseURL, apiKey)
+	if models, ok := discoveredModels.Get(key); ok {
+		return models
+	}
+	models := discoverModels(ctx, profile, apiKey)
+	discoveredModels.Add(key, models)
+	return models
+}
+
+func discoverModels(ctx context.Context, profile model.AIProviderProfile, apiKey string) []string {
+
+	client, err := ai.NewClient(&ai.AIConfig{
+		Provider:     profile.Provider,
+		APIKey:       apiKey,
+		BaseURL:      profile.BaseURL,
+		DefaultModel: profile.DefaultModel,
+	})
+	if err != nil {
+		return profile.AllowedModels
+	}
+	lister, ok := client.(ai.ModelLister)
+	if !ok {
+		return profile.AllowedModels
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	models, err := lister.ListModels(ctx)
+	if err != nil {
+		klog.Warningf("Failed to discover models of AI profile %s: %v", profile.Name, err)
+		return profile.AllowedModels
+	}
+	return models
+}
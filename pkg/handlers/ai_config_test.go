@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/pixelvide/kube-sentinel/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProfileModelsCachesDiscovery(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"object":"list","data":[{"id":"llama3.1:latest"}]}`))
+	}))
+	defer server.Close()
+
+	profile := model.AIProviderProfile{Model: model.Model{ID: 41}, Provider: "ollama", BaseURL: server.URL + "/v1/"}
+	for range 3 {
+		assert.Equal(t, []string{"llama3.1:latest"}, profileModels(context.Background(), profile, ""))
+	}
+	assert.Equal(t, int32(1), calls.Load())
+
+	// Allowed models are returned without asking the server
+	profile.AllowedModels = model.SliceString{"qwen3:8b"}
+	assert.Equal(t, []string{"qwen3:8b"}, []string(profileModels(context.Background(), profile, "")))
+	assert.Equal(t, int32(1), calls.Load())
+}
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pixelvide/kube-sentinel/pkg/ai"
+	"github.com/pixelvide/kube-sentinel/pkg/model"
+	openai "github.com/sashabaranov/go-openai"
+	"gorm.io/gorm"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// summarizeThreshold is the share of the context window the stored
+	// history may take before its older turns are summarized
+	summarizeThreshold = 0.75
+	// recentHistoryShare is the share of the context window kept verbatim
+	// when the history is summarized
+	recentHistoryShare = 0.3
+	// requestShare is the share of the context window a request may take,
+	// the rest is left for the answer
+	requestShare = 0.875
+	// minToolResultTokens is what tool results are cut down to when a
+	// request does not fit the context window otherwise
+	minToolResultTokens = 250
+	// summaryToolResultTokens is what tool results are cut down to in the
+	// transcript that is summarized
+	summaryToolResultTokens = 500
+)
+
+const summarizePrompt = `You summarize the earlier part of a conversation between a user and a Kubernetes assistant, so that the assistant can continue the conversation without it.
+Keep the goals of the user, the clusters, namespaces and resources involved, what was found, what was decided or changed, and open questions.
+Leave out greetings and tool output that did not lead anywhere. Answer with a concise bullet list only.`
+
+// activeMessages returns the stored messages that are still sent to the
+// model, the ones not replaced by a summary
+func activeMessages(messages []model.AIChatMessage) []model.AIChatMessage {
+	var active []model.AIChatMessage
+	for _, m := range messages {
+		if !m.Summarized {
+			active = append(active, m)
+		}
+	}
+	return active
+}
+
+// storedMessageTokens estimates the tokens a stored message takes in a
+// request
+func storedMessageTokens(m model.AIChatMessage, toolResultBudget int) int {
+	content := m.Content
+	if m.Role == openai.ChatMessageRoleTool {
+		content = ai.TruncateToolResult(content, toolResultBudget)
+	}
+	return ai.EstimateTokens(content) + ai.EstimateTokens(m.ToolCalls) + 4
+}
+
+// summarizeHistoryIfNeeded rolls the older turns of a session up into a
+// stored summary message when the history passes the summarize threshold
+// of the context window. The summarized messages stay in the database but
+// are no longer sent to the model. Failures are logged, the history is
+// then only shortened by truncating tool results.
+func summarizeHistoryIfNeeded(ctx context.Context, aiClient ai.AIClient, session *model.AIChatSession, contextWindow int, usage *usageRecorder) {
+	active := activeMessages(session.Messages)
+	toolResultBudget := ai.ToolResultBudget(contextWindow)
+
+	total := 0
+	for _, m := range active {
+		total += storedMessageTokens(m, toolResultBudget)
+	}
+	if float64(total) <= float64(contextWindow)*summarizeThreshold {
+		return
+	}
+
+	// Keep the latest turns that fit the recent share and start with a user
+	// message, so tool calls are never separated from their results
+	cut, recent := len(active), 0
+	for i := len(active) - 1; i > 0; i-- {
+		recent += storedMessageTokens(active[i], toolResultBudget)
+		if float64(recent) > float64(contextWindow)*recentHistoryShare {
+			break
+		}
+		if active[i].Role == openai.ChatMessageRoleUser {
+			cut = i
+		}
+	}
+	older := active[:cut]
+	if len(older) == 0 {
+		return
+	}
+
+	klog.Infof("AI Chat: summarizing %d messages of session %s (%d of %d tokens)", len(older), session.ID, total, contextWindow)
+	summary, err := summarizeMessages(ctx, aiClient, older, contextWindow, usage)
+	if err != nil {
+		klog.Errorf("AI Chat: failed to summarize session %s: %v", session.ID, err)
+		return
+	}
+
+	ids := make([]uint, 0, len(older))
+	for _, m := range older {
+		ids = append(ids, m.ID)
+	}
+	summaryMsg := model.AIChatMessage{
+		SessionID: session.ID,
+		Role:      openai.ChatMessageRoleAssistant,
+		Content:   summary,
+		Summary:   true,
+		// Sort the summary after the messages it replaces
+		CreatedAt: older[len(older)-1].CreatedAt.Add(time.Millisecond),
+	}
+	err = model.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.AIChatMessage{}).Where("id IN ?", ids).Update("summarized", true).Error; err != nil {
+			return err
+		}
+		return tx.Create(&summaryMsg).Error
+	})
+	if err != nil {
+		klog.Errorf("AI Chat: failed to store summary of session %s: %v", session.ID, err)
+		return
+	}
+
+	summarized := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		summarized[id] = true
+	}
+	messages := make([]model.AIChatMessage, 0, len(session.Messages)+1)
+	for _, m := range session.Messages {
+		m.Summarized = m.Summarized || summarized[m.ID]
+		messages = append(messages, m)
+		if m.ID == older[len(older)-1].ID {
+			messages = append(messages, summaryMsg)
+		}
+	}
+	session.Messages = messages
+}
+
+// summarizeMessages asks the model for a summary of messages, which may
+// include the summary of an earlier roll-up
+func summarizeMessages(ctx context.Context, aiClient ai.AIClient, messages []model.AIChatMessage, contextWindow int, usage *usageRecorder) (string, error) {
+	var transcript strings.Builder
+	for _, m := range messages {
+		switch {
+		case m.Summary:
+			fmt.Fprintf(&transcript, "Summary of the conversation before:\n%s\n\n", m.Content)
+		case m.Role == openai.ChatMessageRoleTool:
+			fmt.Fprintf(&transcript, "Tool result:\n%s\n\n", ai.TruncateToolResult(m.Content, summaryToolResultTokens))
+		case m.Role == openai.ChatMessageRoleAssistant:
+			fmt.Fprintf(&transcript, "Assistant: %s\n", m.Content)
+			var toolCalls []openai.ToolCall
+			if m.ToolCalls != "" && json.Unmarshal([]byte(m.ToolCalls), &toolCalls) == nil {
+				for _, tc := range toolCalls {
+					fmt.Fprintf(&transcript, "Assistant called %s with %s\n", tc.Function.Name, tc.Function.Arguments)
+				}
+			}
+			transcript.WriteString("\n")
+		default:
+			fmt.Fprintf(&transcript, "User: %s\n\n", m.Content)
+		}
+	}
+
+	request := []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleSystem, Content: summarizePrompt},
+		{Role: openai.ChatMessageRoleUser, Content: ai.TruncateToolResult(transcript.String(), contextWindow/2)},
+	}
+	resp, err := aiClient.ChatCompletion(ctx, request, nil)
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 || strings.TrimSpace(resp.Choices[0].Message.Content) == "" {
+		return "", fmt.Errorf("empty summary")
+	}
+	usage.record(0, callUsage(&resp.Usage, request, resp.Choices[0].Message.Content))
+	return strings.TrimSpace(resp.Choices[0].Message.Content), nil
+}
+
+// fitContextWindow cuts down the tool results of a request, oldest first,
+// until it fits the context window with room for the answer. The current
+// turn can outgrow the window when the model calls many tools in a row.
+func fitContextWindow(messages []openai.ChatCompletionMessage, contextWindow int) []openai.ChatCompletionMessage {
+	limit := int(float64(contextWindow) * requestShare)
+	total := ai.EstimateMessageTokens(messages)
+	for i := range messages {
+		if total <= limit {
+			break
+		}
+		if messages[i].Role != openai.ChatMessageRoleTool {
+			continue
+		}
+		before := ai.EstimateTokens(messages[i].Content)
+		messages[i].Content = ai.TruncateToolResult(messages[i].Content, minToolResultTokens)
+		total -= before - ai.EstimateTokens(messages[i].Content)
+	}
+	return messages
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pixelvide/kube-sentinel/pkg/ai"
+	"github.com/pixelvide/kube-sentinel/pkg/model"
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// summaryClient answers every completion with a fixed summary and records
+// the requests
+type summaryClient struct {
+	requests [][]openai.ChatCompletionMessage
+}
+
+func (s *summaryClient) ChatCompletion(_ context.Context, messages []openai.ChatCompletionMessage, _ []openai.Tool) (openai.ChatCompletionResponse, error) {
+	s.requests = append(s.requests, messages)
+	return openai.ChatCompletionResponse{Choices: []openai.ChatCompletionChoice{{
+		Message: openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: "- web-1 in namespace shop crashed with OOM"},
+	}}}, nil
+}
+
+func (s *summaryClient) ChatCompletionStream(context.Context, []openai.ChatCompletionMessage, []openai.Tool) (chan openai.ChatCompletionStreamResponse, error) {
+	return nil, nil
+}
+
+func createChatSession(t *testing.T, turns int, toolResult string) *model.AIChatSession {
+	setupTestDB()
+	require.NoError(t, model.DB.AutoMigrate(&model.AIChatSession{}, &model.AIChatMessage{}))
+
+	session := &model.AIChatSession{ID: "session-" + t.Name(), UserID: 1, Title: "Crash"}
+	require.NoError(t, model.DB.Create(session).Error)
+	t.Cleanup(func() {
+		model.DB.Where("session_id = ?", session.ID).Delete(&model.AIChatMessage{})
+		model.DB.Delete(session)
+	})
+
+	created := time.Now().Add(-time.Hour)
+	add := func(m model.AIChatMessage) {
+		created = created.Add(time.Second)
+		m.SessionID = session.ID
+		m.CreatedAt = created
+		require.NoError(t, model.DB.Create(&m).Error)
+	}
+	for i := 0; i < turns; i++ {
+		add(model.AIChatMessage{Role: openai.ChatMessageRoleUser, Content: "Why is web-1 crashing?"})
+		add(model.AIChatMessage{Role: openai.ChatMessageRoleAssistant, ToolCalls: `[{"id":"call_1","type":"function","function":{"name":"get_pod_logs","arguments":"{}"}}]`})
+		add(model.AIChatMessage{Role: openai.ChatMessageRoleTool, ToolID: "call_1", Content: toolResult})
+		add(model.AIChatMessage{Role: openai.ChatMessageRoleAssistant, Content: "It runs out of memory."})
+	}
+
+	session, err := getOrCreateSession(session.ID, 1)
+	require.NoError(t, err)
+	return session
+}
+
+func TestSummarizeHistoryIfNeeded(t *testing.T) {
+	session := createChatSession(t, 6, strings.Repeat("INFO request handled\n", 400))
+	client := &summaryClient{}
+
+	// The history fits a large context window
+	summarizeHistoryIfNeeded(context.Background(), client, session, 200000, nil)
+	assert.Empty(t, client.requests)
+
+	summarizeHistoryIfNeeded(context.Background(), client, session, 8192, nil)
+	require.Len(t, client.requests, 1)
+	transcript := client.requests[0][1].Content
+	assert.Contains(t, transcript, "User: Why is web-1 crashing?")
+	assert.Contains(t, transcript, "Assistant called get_pod_logs with {}")
+
+	// The older turns are marked in the database and replaced by the
+	// summary, the latest turns are kept
+	reloaded, err := getOrCreateSession(session.ID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, session.Messages, reloaded.Messages)
+	active := activeMessages(reloaded.Messages)
+	require.NotEmpty(t, active)
+	assert.True(t, active[0].Summary)
+	assert.Equal(t, "- web-1 in namespace shop crashed with OOM", active[0].Content)
+	assert.Equal(t, openai.ChatMessageRoleUser, active[1].Role)
+	assert.Less(t, len(active), len(reloaded.Messages)-1)
+
+	// The summary goes into the system prompt and tool results are
+	// truncated, while the database keeps them in full
+	messages := buildMessageHistory(*reloaded, "And now?", ChatContext{}, "local", 0, ai.ToolResultBudget(8192))
+	assert.Contains(t, messages[0].Content, "**SUMMARY OF THE EARLIER CONVERSATION:**\n- web-1 in namespace shop crashed with OOM")
+	assert.Len(t, messages, len(active)+1)
+	assert.Less(t, ai.EstimateMessageTokens(messages), 8192)
+	for _, m := range messages {
+		if m.Role == openai.ChatMessageRoleTool {
+			assert.Contains(t, m.Content, "characters truncated")
+		}
+	}
+	for _, m := range reloaded.Messages {
+		if m.Role == openai.ChatMessageRoleTool {
+			assert.NotContains(t, m.Content, "characters truncated")
+		}
+	}
+
+	// A later roll-up includes the earlier summary
+	summarizeHistoryIfNeeded(context.Background(), client, reloaded, 2048, nil)
+	require.Len(t, client.requests, 2)
+	assert.Contains(t, client.requests[1][1].Content, "Summary of the conversation before:\n- web-1")
+}
+
+func TestFitContextWindow(t *testing.T) {
+	logs := strings.Repeat("INFO request handled\n", 1000)
+	messages := []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleSystem, Content: "You are a Kubernetes assistant."},
+		{Role: openai.ChatMessageRoleUser, Content: "Check all pods"},
+		{Role: openai.ChatMessageRoleTool, Content: logs},
+		{Role: openai.ChatMessageRoleTool, Content: logs},
+		{Role: openai.ChatMessageRoleTool, Content: logs},
+	}
+
+	fitted := fitContextWindow(messages, 16000)
+	assert.LessOrEqual(t, ai.EstimateMessageTokens(fitted), 14000)
+	// Only the oldest results are cut down as far as needed
+	assert.Contains(t, fitted[2].Content, "characters truncated")
+	assert.Equal(t, logs, fitted[4].Content)
+}
@@ -0,0 +1,291 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pixelvide/kube-sentinel/pkg/ai"
+	"github.com/pixelvide/kube-sentinel/pkg/model"
+	openai "github.com/sashabaranov/go-openai"
+	"k8s.io/klog/v2"
+)
+
+// usageRecorder records the token usage of the AI calls made for a chat
+// request. A nil recorder records nothing.
+type usageRecorder struct {
+	userID    uint
+	profileID uint
+	sessionID string
+	model     string
+}
+
+func newUsageRecorder(config *ai.AIConfig, userID uint, sessionID string) *usageRecorder {
+	modelName := config.Model
+	if modelName == "" {
+		modelName = config.DefaultModel
+	}
+	return &usageRecorder{userID: userID, profileID: config.ProfileID, sessionID: sessionID, model: modelName}
+}
+
+// callUsage returns the usage a provider reported for a call, or an
+// estimate when it reported none
+func callUsage(usage *openai.Usage, messages []openai.ChatCompletionMessage, completion string) openai.Usage {
+	if usage != nil && usage.TotalTokens > 0 {
+		return *usage
+	}
+	estimated := openai.Usage{
+		PromptTokens:     ai.EstimateMessageTokens(messages),
+		CompletionTokens: ai.EstimateTokens(completion),
+	}
+	estimated.TotalTokens = estimated.PromptTokens + estimated.CompletionTokens
+	return estimated
+}
+
+func (r *usageRecorder) record(messageID uint, usage openai.Usage) {
+	if r == nil {
+		return
+	}
+	err := model.RecordAIUsage(&model.AIUsage{
+		UserID:           r.userID,
+		ProfileID:        r.profileID,
+		SessionID:        r.sessionID,
+		MessageID:        messageID,
+		ModelName:        r.model,
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		TotalTokens:      usage.TotalTokens,
+	})
+	if err != nil {
+		klog.Errorf("AI Chat: failed to record token usage of session %s: %v", r.sessionID, err)
+	}
+}
+
+// checkBudgets returns the budget error once a token budget of the user or
+// profile is used up. One chat message can take many calls to the model, so
+// the budgets are checked again before each of them.
+func (r *usageRecorder) checkBudgets() error {
+	if r == nil {
+		return nil
+	}
+	err := model.CheckAIBudgets(r.userID, r.profileID, time.Now())
+	if err != nil && !errors.Is(err, model.ErrAIBudgetExceeded) {
+		klog.Errorf("AI Chat: failed to check the token budgets of user %d: %v", r.userID, err)
+		return nil
+	}
+	return err
+}
+
+// --- Prices ---
+
+func ListAIModelPrices(c *gin.Context) {
+	var prices []model.AIModelPrice
+	if err := model.DB.Order("model").Find(&prices).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list model prices"})
+		return
+	}
+	c.JSON(http.StatusOK, prices)
+}
+
+func CreateAIModelPrice(c *gin.Context) {
+	var price model.AIModelPrice
+	if err := c.ShouldBindJSON(&price); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if price.ModelName == "" || price.PromptPrice < 0 || price.CompletionPrice < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A model and non-negative prices are required"})
+		return
+	}
+
+	if err := model.DB.Create(&price).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create model price"})
+		return
+	}
+	c.JSON(http.StatusOK, price)
+}
+
+func UpdateAIModelPrice(c *gin.Context) {
+	id := c.Param("id")
+	var price model.AIModelPrice
+	if err := model.DB.First(&price, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Model price not found"})
+		return
+	}
+
+	if err := c.ShouldBindJSON(&price); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if price.ModelName == "" || price.PromptPrice < 0 || price.CompletionPrice < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A model and non-negative prices are required"})
+		return
+	}
+
+	if err := model.DB.Save(&price).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update model price"})
+		return
+	}
+	c.JSON(http.StatusOK, price)
+}
+
+func DeleteAIModelPrice(c *gin.Context) {
+	id := c.Param("id")
+	if err := model.DB.Delete(&model.AIModelPrice{}, id).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete model price"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "deleted"})
+}
+
+// --- Budgets ---
+
+func ListAIBudgets(c *gin.Context) {
+	var budgets []model.AIBudget
+	if err := model.DB.Order("scope, scope_id, period").Find(&budgets).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list budgets"})
+		return
+	}
+	c.JSON(http.StatusOK, budgets)
+}
+
+func CreateAIBudget(c *gin.Context) {
+	var budget model.AIBudget
+	if err := c.ShouldBindJSON(&budget); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := budget.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := model.DB.Create(&budget).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create budget"})
+		return
+	}
+	c.JSON(http.StatusOK, budget)
+}
+
+func UpdateAIBudget(c *gin.Context) {
+	id := c.Param("id")
+	var budget model.AIBudget
+	if err := model.DB.First(&budget, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Budget not found"})
+		return
+	}
+
+	if err := c.ShouldBindJSON(&budget); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := budget.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := model.DB.Save(&budget).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update budget"})
+		return
+	}
+	c.JSON(http.StatusOK, budget)
+}
+
+func DeleteAIBudget(c *gin.Context) {
+	id := c.Param("id")
+	if err := model.DB.Delete(&model.AIBudget{}, id).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete budget"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "deleted"})
+}
+
+// --- Usage Report ---
+
+// GetAIUsageReport sums the token usage and cost between the from and to
+// dates (YYYY-MM-DD, to inclusive), grouped by user, profile, model,
+// session or day. It defaults to the current month grouped by user.
+func GetAIUsageReport(c *gin.Context) {
+	now := time.Now().UTC()
+	from := model.AIBudgetPeriodStart(model.AIBudgetPeriodMonth, now)
+	to := model.AIBudgetPeriodStart(model.AIBudgetPeriodDay, now).AddDate(0, 0, 1)
+	if v := c.Query("from"); v != "" {
+		t, err := time.Parse(time.DateOnly, v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date, expected YYYY-MM-DD"})
+			return
+		}
+		from = t
+	}
+	if v := c.Query("to"); v != "" {
+		t, err := time.Parse(time.DateOnly, v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date, expected YYYY-MM-DD"})
+			return
+		}
+		to = t.AddDate(0, 0, 1)
+	}
+	groupBy := c.DefaultQuery("groupBy", "user")
+
+	rows, err := model.GetAIUsageReport(from, to, groupBy)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	labelAIUsageRows(groupBy, rows)
+
+	var total model.AIUsageSummary
+	for _, row := range rows {
+		total.Requests += row.Requests
+		total.PromptTokens += row.PromptTokens
+		total.CompletionTokens += row.CompletionTokens
+		total.TotalTokens += row.TotalTokens
+		total.Cost += row.Cost
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":    from.Format(time.DateOnly),
+		"to":      to.AddDate(0, 0, -1).Format(time.DateOnly),
+		"groupBy": groupBy,
+		"rows":    rows,
+		"total":   total,
+	})
+}
+
+// labelAIUsageRows names the users, profiles and sessions of a report
+func labelAIUsageRows(groupBy string, rows []model.AIUsageSummary) {
+	keys := make([]string, 0, len(rows))
+	for _, row := range rows {
+		keys = append(keys, row.Key)
+	}
+	labels := make(map[string]string)
+	switch groupBy {
+	case "user":
+		var users []model.User
+		model.DB.Where("id IN ?", keys).Find(&users)
+		for _, u := range users {
+			labels[strconv.FormatUint(uint64(u.ID), 10)] = u.Key()
+		}
+	case "profile":
+		var profiles []model.AIProviderProfile
+		model.DB.Where("id IN ?", keys).Find(&profiles)
+		for _, p := range profiles {
+			labels[strconv.FormatUint(uint64(p.ID), 10)] = p.Name
+		}
+	case "session":
+		var sessions []model.AIChatSession
+		model.DB.Unscoped().Where("id IN ?", keys).Find(&sessions)
+		for _, s := range sessions {
+			labels[s.ID] = s.Title
+		}
+	}
+	for i := range rows {
+		if label, ok := labels[rows[i].Key]; ok {
+			rows[i].Label = label
+		} else {
+			rows[i].Label = rows[i].Key
+		}
+	}
+}
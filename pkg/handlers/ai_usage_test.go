@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pixelvide/kube-sentinel/pkg/ai"
+	"github.com/pixelvide/kube-sentinel/pkg/model"
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// usageStreamClient streams a fixed answer and reports its usage in a last
+// chunk without choices, the way OpenAI does
+type usageStreamClient struct {
+	summaryClient
+}
+
+func (*usageStreamClient) ChatCompletionStream(context.Context, []openai.ChatCompletionMessage, []openai.Tool) (chan openai.ChatCompletionStreamResponse, error) {
+	stream := make(chan openai.ChatCompletionStreamResponse, 3)
+	stream <- openai.ChatCompletionStreamResponse{Choices: []openai.ChatCompletionStreamChoice{{Delta: openai.ChatCompletionStreamChoiceDelta{Content: "All pods "}}}}
+	stream <- openai.ChatCompletionStreamResponse{Choices: []openai.ChatCompletionStreamChoice{{Delta: openai.ChatCompletionStreamChoiceDelta{Content: "are running."}, FinishReason: openai.FinishReasonStop}}}
+	stream <- openai.ChatCompletionStreamResponse{Usage: &openai.Usage{PromptTokens: 1200, CompletionTokens: 8, TotalTokens: 1208}}
+	close(stream)
+	return stream, nil
+}
+
+// failingStreamClient starts an answer, then the provider fails
+type failingStreamClient struct {
+	summaryClient
+}
+
+func (*failingStreamClient) ChatCompletionStream(context.Context, []openai.ChatCompletionMessage, []openai.Tool) (chan openai.ChatCompletionStreamResponse, error) {
+	stream := make(chan openai.ChatCompletionStreamResponse, 2)
+	stream <- openai.ChatCompletionStreamResponse{Choices: []openai.ChatCompletionStreamChoice{{Delta: openai.ChatCompletionStreamChoiceDelta{Content: "All pods "}}}}
+	stream <- ai.StreamErrorChunk(errors.New("overloaded_error: Overloaded"))
+	close(stream)
+	return stream, nil
+}
+
+// toolLoopClient keeps calling a tool and reports 1000 tokens per call
+type toolLoopClient struct {
+	summaryClient
+}
+
+func (*toolLoopClient) ChatCompletionStream(context.Context, []openai.ChatCompletionMessage, []openai.Tool) (chan openai.ChatCompletionStreamResponse, error) {
+	stream := make(chan openai.ChatCompletionStreamResponse, 2)
+	index := 0
+	stream <- openai.ChatCompletionStreamResponse{Choices: []openai.ChatCompletionStreamChoice{{Delta: openai.ChatCompletionStreamChoiceDelta{ToolCalls: []openai.ToolCall{{
+		Index:    &index,
+		ID:       "call_list_pods",
+		Type:     openai.ToolTypeFunction,
+		Function: openai.FunctionCall{Name: "list_pods", Arguments: "{}"},
+	}}}}}}
+	stream <- openai.ChatCompletionStreamResponse{Usage: &openai.Usage{PromptTokens: 900, CompletionTokens: 100, TotalTokens: 1000}}
+	close(stream)
+	return stream, nil
+}
+
+func setupAIUsageTestDB(t *testing.T) {
+	setupTestDB()
+	require.NoError(t, model.DB.AutoMigrate(
+		&model.App{}, &model.AppConfig{}, &model.UserConfig{}, &model.AIProviderProfile{}, &model.AISettings{},
+		&model.AIChatSession{}, &model.AIChatMessage{}, &model.AIUsage{}, &model.AIModelPrice{}, &model.AIBudget{},
+	))
+	t.Cleanup(func() {
+		for _, table := range []string{"ai_usages", "ai_model_prices", "ai_budgets", "ai_provider_profiles", "user_configs"} {
+			model.DB.Exec("DELETE FROM " + table)
+		}
+	})
+}
+
+func TestExecuteAIChatStreamLoopRecordsUsage(t *testing.T) {
+	session := createChatSession(t, 0, "")
+	setupAIUsageTestDB(t)
+	require.NoError(t, model.DB.Create(&model.AIModelPrice{ModelName: "gpt-4o", PromptPrice: 2.5, CompletionPrice: 10}).Error)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	recorder := &usageRecorder{userID: 1, profileID: 3, sessionID: session.ID, model: "gpt-4o"}
+	messages := []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Are my pods healthy?"}}
+	content, err := executeAIChatStreamLoop(context.Background(), &usageStreamClient{}, session, messages, nil, nil, context.Background(), c, 128000, recorder)
+	require.NoError(t, err)
+	assert.Equal(t, "All pods are running.", content)
+
+	var msg model.AIChatMessage
+	require.NoError(t, model.DB.Where("session_id = ? AND role = ?", session.ID, openai.ChatMessageRoleAssistant).First(&msg).Error)
+	assert.Equal(t, 1200, msg.PromptTokens)
+	assert.Equal(t, 8, msg.CompletionTokens)
+
+	var usage model.AIUsage
+	require.NoError(t, model.DB.First(&usage).Error)
+	assert.Equal(t, msg.ID, usage.MessageID)
+	assert.Equal(t, uint(3), usage.ProfileID)
+	assert.Equal(t, 1208, usage.TotalTokens)
+	assert.InDelta(t, 0.00308, usage.Cost, 1e-9)
+}
+
+func TestAIChatBudgetExceeded(t *testing.T) {
+	setupAIUsageTestDB(t)
+	currentApp := model.CurrentApp
+	t.Cleanup(func() { model.CurrentApp = currentApp })
+	model.CurrentApp = &model.App{}
+	user := model.User{Username: "budget-user"}
+	require.NoError(t, model.DB.FirstOrCreate(&user, model.User{Username: "budget-user"}).Error)
+	require.NoError(t, model.DB.Create(&model.UserConfig{UserID: user.ID, IsAIChatEnabled: true}).Error)
+	profile := model.AIProviderProfile{Name: "OpenAI", Provider: "openai", APIKey: "sk-test", DefaultModel: "gpt-4o", IsSystem: true, IsEnabled: true}
+	require.NoError(t, model.DB.Create(&profile).Error)
+	require.NoError(t, model.DB.Create(&model.AIBudget{Scope: model.AIBudgetScopeUser, Period: model.AIBudgetPeriodDay, TokenLimit: 1000}).Error)
+	require.NoError(t, model.RecordAIUsage(&model.AIUsage{UserID: user.ID, ProfileID: profile.ID, TotalTokens: 1500}))
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user", user)
+		c.Next()
+	})
+	r.POST("/ai/chat", AIChat)
+	r.GET("/admin/ai/usage", GetAIUsageReport)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/ai/chat", bytes.NewBufferString(`{"message":"Hello"}`))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Contains(t, w.Body.String(), "daily limit of 1000 tokens for your account is used up")
+
+	// The usage shows up in the report, labelled with the user name
+	w = httptest.NewRecorder()
+	today := time.Now().UTC().Format(time.DateOnly)
+	req, _ = http.NewRequest(http.MethodGet, "/admin/ai/usage?from="+today+"&to="+today, nil)
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	var report struct {
+		Rows  []model.AIUsageSummary `json:"rows"`
+		Total model.AIUsageSummary   `json:"total"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	require.Len(t, report.Rows, 1)
+	assert.Equal(t, "budget-user", report.Rows[0].Label)
+	assert.Equal(t, int64(1500), report.Total.TotalTokens)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/admin/ai/usage?groupBy=cluster", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestExecuteAIChatStreamLoopStreamError(t *testing.T) {
+	session := createChatSession(t, 0, "")
+	setupAIUsageTestDB(t)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	messages := []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Are my pods healthy?"}}
+	content, err := executeAIChatStreamLoop(context.Background(), &failingStreamClient{}, session, messages, nil, nil, context.Background(), c, 128000, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "overloaded_error: Overloaded")
+	assert.Equal(t, "All pods ", content)
+
+	var count int64
+	model.DB.Model(&model.AIChatMessage{}).Where("session_id = ? AND role = ?", session.ID, openai.ChatMessageRoleAssistant).Count(&count)
+	assert.Zero(t, count, "the failed answer is not stored")
+}
+
+func TestExecuteAIChatStreamLoopStopsAtBudget(t *testing.T) {
+	session := createChatSession(t, 0, "")
+	setupAIUsageTestDB(t)
+	require.NoError(t, model.DB.Create(&model.AIBudget{Scope: model.AIBudgetScopeUser, ScopeID: 1, Period: model.AIBudgetPeriodDay, TokenLimit: 1500}).Error)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	recorder := &usageRecorder{userID: 1, profileID: 3, sessionID: session.ID, model: "gpt-4o"}
+	messages := []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "List my pods"}}
+	_, err := executeAIChatStreamLoop(context.Background(), &toolLoopClient{}, session, messages, nil, nil, context.Background(), c, 128000, recorder)
+	require.ErrorIs(t, err, model.ErrAIBudgetExceeded)
+
+	// The second call passed the limit, the third one is not made
+	var calls int64
+	model.DB.Model(&model.AIUsage{}).Where("session_id = ?", session.ID).Count(&calls)
+	assert.Equal(t, int64(2), calls)
+}
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pixelvide/kube-sentinel/pkg/analyzer"
+	"github.com/pixelvide/kube-sentinel/pkg/cluster"
+	"github.com/pixelvide/kube-sentinel/pkg/common"
+	"github.com/pixelvide/kube-sentinel/pkg/model"
+	"github.com/pixelvide/kube-sentinel/pkg/rbac"
+	"gorm.io/gorm"
+)
+
+// canViewFinding reports whether the user may see the resource a finding is about
+func canViewFinding(user model.User, clusterName string, f model.AnalysisFinding) bool {
+	if f.Kind == "Namespace" {
+		return rbac.CanAccessNamespace(user, clusterName, f.Name)
+	}
+	return rbac.CanAccess(user, f.ResourceType, string(common.VerbGet), clusterName, f.Namespace)
+}
+
+// ListAnalysisFindings returns persisted analyzer findings of the current
+// cluster, filtered by severity, rule, namespace, kind, status and whether
+// they are suppressed
+func ListAnalysisFindings(c *gin.Context) {
+	cs := c.MustGet("cluster").(*cluster.ClientSet)
+	user := c.MustGet("user").(model.User)
+
+	page := 1
+	size := 50
+	if p := strings.TrimSpace(c.Query("page")); p != "" {
+		parsed, err := strconv.Atoi(p)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid page parameter"})
+			return
+		}
+		page = parsed
+	}
+	if s := strings.TrimSpace(c.Query("size")); s != "" {
+		parsed, err := strconv.Atoi(s)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid size parameter"})
+			return
+		}
+		size = parsed
+	}
+
+	query := model.DB.Model(&model.AnalysisFinding{}).Where("cluster_name = ?", cs.Name)
+	if v := strings.TrimSpace(c.Query("severity")); v != "" {
+		query = query.Where("severity IN ?", strings.Split(v, ","))
+	}
+	if v := strings.TrimSpace(c.Query("ruleId")); v != "" {
+		query = query.Where("rule_id IN ?", strings.Split(v, ","))
+	}
+	if v := strings.TrimSpace(c.Query("namespace")); v != "" && v != "_all" {
+		query = query.Where("namespace = ?", v)
+	}
+	if v := strings.TrimSpace(c.Query("kind")); v != "" {
+		query = query.Where("kind = ?", v)
+	}
+	switch c.DefaultQuery("status", "open") {
+	case "open":
+		query = query.Where("resolved_at IS NULL")
+	case "resolved":
+		query = query.Where("resolved_at IS NOT NULL")
+	case "all":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be one of open, resolved, all"})
+		return
+	}
+	// Suppressed findings are listed separately from the ones that count
+	switch c.DefaultQuery("suppressed", "false") {
+	case "false":
+		query = query.Where("suppressed = ?", false)
+	case "true":
+		query = query.Where("suppressed = ?", true)
+	case "all":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "suppressed must be one of true, false, all"})
+		return
+	}
+
+	var findings []model.AnalysisFinding
+	if err := query.Order("last_seen DESC, id DESC").Find(&findings).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// RBAC filtering happens after the query since roles can use patterns
+	visible := make([]model.AnalysisFinding, 0, len(findings))
+	for _, f := range findings {
+		if canViewFinding(user, cs.Name, f) {
+			visible = append(visible, f)
+		}
+	}
+
+	total := len(visible)
+	start := min((page-1)*size, total)
+	end := min(start+size, total)
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  visible[start:end],
+		"total": total,
+		"page":  page,
+		"size":  size,
+	})
+}
+
+// GetAnalysisTrend returns the cluster score and finding counts of the
+// background scans over the last days (default 30)
+func GetAnalysisTrend(c *gin.Context) {
+	cs := c.MustGet("cluster").(*cluster.ClientSet)
+
+	days := 30
+	if d := strings.TrimSpace(c.Query("days")); d != "" {
+		parsed, err := strconv.Atoi(d)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid days parameter"})
+			return
+		}
+		days = parsed
+	}
+
+	since := time.Now().AddDate(0, 0, -days)
+	scans := []model.AnalysisScan{}
+	if err := model.DB.Where("cluster_name = ? AND scanned_at >= ?", cs.Name, since).
+		Order("scanned_at ASC").Find(&scans).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"cluster": cs.Name,
+		"data":    scans,
+	})
+}
+
+// analysisScoreResponse is a score rollup with its human readable summary
+type analysisScoreResponse struct {
+	analyzer.ScoreRollup
+	Namespace string `json:"namespace,omitempty"`
+	Summary   string `json:"summary"`
+}
+
+// GetAnalysisScores returns the cluster score of the latest background scan
+// and the scores of the namespaces the user can access, lowest first
+func GetAnalysisScores(c *gin.Context) {
+	cs := c.MustGet("cluster").(*cluster.ClientSet)
+	user := c.MustGet("user").(model.User)
+
+	scan, err := model.LatestAnalysisScan(cs.Name)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusOK, gin.H{"cluster": nil, "namespaces": []analysisScoreResponse{}})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	scores, err := model.ListNamespaceScores(cs.Name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	clusterRollup := analyzer.RollupFromModel(scan.Score, scan.Resources, scan.CategoryScores)
+	namespaces := make([]analysisScoreResponse, 0, len(scores))
+	for _, s := range scores {
+		if !rbac.CanAccessNamespace(user, cs.Name, s.Namespace) {
+			continue
+		}
+		rollup := analyzer.RollupFromModel(s.Score, s.Resources, s.CategoryScores)
+		namespaces = append(namespaces, analysisScoreResponse{
+			ScoreRollup: rollup,
+			Namespace:   s.Namespace,
+			Summary:     rollup.Describe("namespace " + s.Namespace),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"cluster": analysisScoreResponse{
+			ScoreRollup: clusterRollup,
+			Summary:     clusterRollup.Describe("cluster " + cs.Name),
+		},
+		"scannedAt":  scan.ScannedAt,
+		"namespaces": namespaces,
+	})
+}
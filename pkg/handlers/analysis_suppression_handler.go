@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pixelvide/kube-sentinel/pkg/analyzer"
+	"github.com/pixelvide/kube-sentinel/pkg/audit"
+	"github.com/pixelvide/kube-sentinel/pkg/model"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+type analysisSuppressionRequest struct {
+	RuleID        string    `json:"ruleId" binding:"required"`
+	ClusterName   string    `json:"clusterName"`
+	Namespace     string    `json:"namespace"`
+	Kind          string    `json:"kind"`
+	Name          string    `json:"name"`
+	LabelSelector string    `json:"labelSelector"`
+	Reason        string    `json:"reason" binding:"required"`
+	ExpiresAt     time.Time `json:"expiresAt" binding:"required"`
+}
+
+func (r analysisSuppressionRequest) validate() error {
+	if r.ClusterName == "" && r.Namespace == "" && r.Name == "" && r.LabelSelector == "" {
+		return errors.New("a suppression must be scoped by cluster, namespace, name or label selector")
+	}
+	if r.LabelSelector != "" {
+		if _, err := labels.Parse(r.LabelSelector); err != nil {
+			return errors.New("invalid label selector: " + err.Error())
+		}
+	}
+	if !r.ExpiresAt.After(time.Now()) {
+		return errors.New("expiresAt must be in the future")
+	}
+	return nil
+}
+
+func (r analysisSuppressionRequest) apply(s *model.AnalysisSuppression) {
+	s.RuleID = r.RuleID
+	s.ClusterName = r.ClusterName
+	s.Namespace = r.Namespace
+	s.Kind = r.Kind
+	s.Name = r.Name
+	s.LabelSelector = r.LabelSelector
+	s.Reason = r.Reason
+	s.ExpiresAt = r.ExpiresAt
+}
+
+func syncAnalysisSuppressions() {
+	select {
+	case analyzer.SyncSuppressionsNow <- struct{}{}:
+	default:
+	}
+}
+
+func recordSuppressionAudit(c *gin.Context, action string, s model.AnalysisSuppression, err error) {
+	entry := audit.FromRequest(c, action)
+	entry.ResourceType = "analysis-suppressions"
+	entry.ResourceName = strconv.FormatUint(uint64(s.ID), 10)
+	entry.ClusterName = s.ClusterName
+	entry.Namespace = s.Namespace
+	entry.Payload = map[string]interface{}{
+		"ruleId":    s.RuleID,
+		"kind":      s.Kind,
+		"name":      s.Name,
+		"selector":  s.LabelSelector,
+		"reason":    s.Reason,
+		"expiresAt": s.ExpiresAt,
+	}
+	audit.Emit(entry.WithError(err))
+}
+
+// ListAnalysisSuppressions returns suppressions, optionally filtered by
+// cluster and rule. Expired ones are only included with expired=true.
+func ListAnalysisSuppressions(c *gin.Context) {
+	query := model.DB.Model(&model.AnalysisSuppression{})
+	if v := c.Query("cluster"); v != "" {
+		query = query.Where("cluster_name = ?", v)
+	}
+	if v := c.Query("ruleId"); v != "" {
+		query = query.Where("rule_id = ?", v)
+	}
+	if c.Query("expired") != "true" {
+		query = query.Where("expires_at > ?", time.Now())
+	}
+	suppressions := []model.AnalysisSuppression{}
+	if err := query.Order("id DESC").Find(&suppressions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list suppressions: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"suppressions": suppressions})
+}
+
+// CreateAnalysisSuppression stores a new suppression created by the current user
+func CreateAnalysisSuppression(c *gin.Context) {
+	user := c.MustGet("user").(model.User)
+	var req analysisSuppressionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := req.validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	s := model.AnalysisSuppression{CreatedBy: user.ID, CreatedByName: user.Username}
+	req.apply(&s)
+	err := model.DB.Create(&s).Error
+	recordSuppressionAudit(c, "create_analysis_suppression", s, err)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create suppression: " + err.Error()})
+		return
+	}
+	syncAnalysisSuppressions()
+	c.JSON(http.StatusCreated, gin.H{"suppression": s})
+}
+
+// UpdateAnalysisSuppression changes the scope, reason or expiry of a suppression
+func UpdateAnalysisSuppression(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid suppression id"})
+		return
+	}
+	var req analysisSuppressionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := req.validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var s model.AnalysisSuppression
+	if err := model.DB.First(&s, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "suppression not found"})
+		return
+	}
+	req.apply(&s)
+	err = model.DB.Save(&s).Error
+	recordSuppressionAudit(c, "update_analysis_suppression", s, err)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update suppression: " + err.Error()})
+		return
+	}
+	syncAnalysisSuppressions()
+	c.JSON(http.StatusOK, gin.H{"suppression": s})
+}
+
+// DeleteAnalysisSuppression removes a suppression
+func DeleteAnalysisSuppression(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid suppression id"})
+		return
+	}
+	var s model.AnalysisSuppression
+	if err := model.DB.First(&s, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "suppression not found"})
+		return
+	}
+	err = model.DB.Delete(&s).Error
+	recordSuppressionAudit(c, "delete_analysis_suppression", s, err)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete suppression: " + err.Error()})
+		return
+	}
+	syncAnalysisSuppressions()
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pixelvide/kube-sentinel/pkg/analyzer"
+	"github.com/pixelvide/kube-sentinel/pkg/audit"
+	"github.com/pixelvide/kube-sentinel/pkg/model"
+	"gorm.io/gorm"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+// analyzerRuleRequest is the body of create and update requests. Enabled is
+// a pointer so new rules default to enabled.
+type analyzerRuleRequest struct {
+	analyzer.RuleSpec
+	Enabled *bool `json:"enabled"`
+}
+
+func (r analyzerRuleRequest) apply(rule *model.AnalyzerRule) {
+	rule.RuleID = r.RuleID
+	rule.Title = r.Title
+	rule.Description = r.Description
+	rule.Severity = r.Severity
+	rule.Category = r.Category
+	rule.Kinds = r.Kinds
+	rule.Match = r.Match
+	rule.Expression = r.Expression
+	rule.Message = r.Message
+	rule.Remediation = r.Remediation
+	rule.DocURL = r.DocURL
+	if r.Enabled != nil {
+		rule.Enabled = *r.Enabled
+	}
+}
+
+func syncAnalyzerRules() {
+	select {
+	case analyzer.SyncRulesNow <- struct{}{}:
+	default:
+	}
+}
+
+func recordAnalyzerRuleAudit(c *gin.Context, action, ruleID string, err error) {
+	entry := audit.FromRequest(c, action)
+	entry.ResourceType = "analyzer-rules"
+	entry.ResourceName = ruleID
+	audit.Emit(entry.WithError(err))
+}
+
+// ListAnalyzerRules returns the rules stored in the database and the
+// read-only rules of the mounted rules file
+func ListAnalyzerRules(c *gin.Context) {
+	var rules []model.AnalyzerRule
+	if err := model.DB.Order("rule_id").Find(&rules).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list analyzer rules: " + err.Error()})
+		return
+	}
+	fileRules, err := analyzer.LoadRulesFile()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load analyzer rules file: " + err.Error()})
+		return
+	}
+	if fileRules == nil {
+		fileRules = []analyzer.RuleSpec{}
+	}
+	c.JSON(http.StatusOK, gin.H{"rules": rules, "fileRules": fileRules})
+}
+
+// GetAnalyzerRule returns a single stored rule by id
+func GetAnalyzerRule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid rule id"})
+		return
+	}
+	var rule model.AnalyzerRule
+	if err := model.DB.First(&rule, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "analyzer rule not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"rule": rule})
+}
+
+// CreateAnalyzerRule validates and stores a new rule
+func CreateAnalyzerRule(c *gin.Context) {
+	var req analyzerRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if _, err := analyzer.CompileRule(req.RuleSpec); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule := model.AnalyzerRule{Enabled: true}
+	req.apply(&rule)
+	err := model.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&rule).Error; err != nil {
+			return err
+		}
+		// Enabled defaults to true in the database, so a disabled rule has
+		// to be written explicitly
+		if !rule.Enabled {
+			return tx.Model(&rule).Update("enabled", false).Error
+		}
+		return nil
+	})
+	recordAnalyzerRuleAudit(c, "create_analyzer_rule", rule.RuleID, err)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create analyzer rule: " + err.Error()})
+		return
+	}
+	syncAnalyzerRules()
+	c.JSON(http.StatusCreated, gin.H{"rule": rule})
+}
+
+// UpdateAnalyzerRule validates and replaces an existing rule
+func UpdateAnalyzerRule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid rule id"})
+		return
+	}
+	var req analyzerRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if _, err := analyzer.CompileRule(req.RuleSpec); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var rule model.AnalyzerRule
+	if err := model.DB.First(&rule, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "analyzer rule not found"})
+		return
+	}
+	req.apply(&rule)
+	err = model.DB.Save(&rule).Error
+	recordAnalyzerRuleAudit(c, "update_analyzer_rule", rule.RuleID, err)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update analyzer rule: " + err.Error()})
+		return
+	}
+	syncAnalyzerRules()
+	c.JSON(http.StatusOK, gin.H{"rule": rule})
+}
+
+// DeleteAnalyzerRule removes a stored rule
+func DeleteAnalyzerRule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid rule id"})
+		return
+	}
+	var rule model.AnalyzerRule
+	if err := model.DB.First(&rule, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "analyzer rule not found"})
+		return
+	}
+	err = model.DB.Delete(&rule).Error
+	recordAnalyzerRuleAudit(c, "delete_analyzer_rule", rule.RuleID, err)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete analyzer rule: " + err.Error()})
+		return
+	}
+	syncAnalyzerRules()
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// DryRunAnalyzerRule evaluates a rule against a pasted manifest without
+// saving anything. The rule is either given inline or referenced by the
+// ruleId of an active rule.
+func DryRunAnalyzerRule(c *gin.Context) {
+	var req struct {
+		RuleID   string             `json:"ruleId"`
+		Rule     *analyzer.RuleSpec `json:"rule"`
+		Manifest string             `json:"manifest" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var rule *analyzer.CompiledRule
+	switch {
+	case req.Rule != nil:
+		compiled, err := analyzer.CompileRule(*req.Rule)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		rule = compiled
+	case req.RuleID != "":
+		if rule = analyzer.ActiveRule(req.RuleID); rule == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "analyzer rule not found"})
+			return
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "rule or ruleId is required"})
+		return
+	}
+
+	obj, err := parseManifest(req.Manifest)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	anomaly, err := rule.Evaluate(c.Request.Context(), obj)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to evaluate rule: " + err.Error()})
+		return
+	}
+	anomalies := []analyzer.Anomaly{}
+	if anomaly != nil {
+		anomalies = append(anomalies, *anomaly)
+	}
+	c.JSON(http.StatusOK, gin.H{"passed": anomaly == nil, "anomalies": anomalies})
+}
+
+// parseManifest decodes a single YAML or JSON object
+func parseManifest(manifest string) (*unstructured.Unstructured, error) {
+	data, err := yaml.YAMLToJSON([]byte(manifest))
+	if err != nil {
+		return nil, errors.New("invalid manifest: " + err.Error())
+	}
+	obj := &unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON(data); err != nil {
+		return nil, errors.New("invalid manifest: " + err.Error())
+	}
+	return obj, nil
+}
@@ -3,10 +3,14 @@ package handlers
 import (
 	"fmt"
 	"net/http"
+	"regexp"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/pixelvide/kube-sentinel/pkg/common"
 	"github.com/pixelvide/kube-sentinel/pkg/model"
 	"github.com/pixelvide/kube-sentinel/pkg/rbac"
 )
@@ -14,6 +18,60 @@ import (
 type CreateAPIKeyRequest struct {
 	Name      string `json:"name" binding:"required"`
 	ExpiresAt string `json:"expiresAt"` // Optional, format: 2006-01-02
+	// Scope optionally limits the token to part of the owner's access
+	Scope model.TokenScope `json:"scope"`
+	// ReadOnly is a shorthand for a scope with only the get verb
+	ReadOnly bool `json:"readOnly"`
+}
+
+var tokenScopeVerbs = []string{
+	"*",
+	string(common.VerbGet),
+	string(common.VerbList),
+	string(common.VerbCreate),
+	string(common.VerbUpdate),
+	string(common.VerbDelete),
+	string(common.VerbLog),
+	string(common.VerbExec),
+}
+
+// validateTokenScope checks that scope entries can be stored and matched
+// the way role entries are
+func validateTokenScope(scope model.TokenScope) error {
+	fields := map[string][]string{
+		"clusters":   scope.Clusters,
+		"namespaces": scope.Namespaces,
+		"resources":  scope.Resources,
+		"verbs":      scope.Verbs,
+	}
+	for field, values := range fields {
+		for _, v := range values {
+			if v == "" || strings.Contains(v, ",") {
+				return fmt.Errorf("invalid %s entry %q", field, v)
+			}
+			if _, err := regexp.Compile(strings.TrimPrefix(v, "!")); err != nil {
+				return fmt.Errorf("invalid %s entry %q: %v", field, v, err)
+			}
+		}
+	}
+	for _, v := range scope.Verbs {
+		if !slices.Contains(tokenScopeVerbs, v) {
+			return fmt.Errorf("unknown verb %q", v)
+		}
+	}
+	return nil
+}
+
+// rejectScopedToken writes a 403 when the request authenticated with a
+// scoped personal access token. Such tokens may not manage the account,
+// e.g. create an unscoped token or disable two-factor authentication, as
+// that would lift the limits of their scope.
+func rejectScopedToken(c *gin.Context, user model.User) bool {
+	if user.TokenScope == nil {
+		return false
+	}
+	c.JSON(http.StatusForbidden, gin.H{"error": "scoped API keys cannot manage account settings"})
+	return true
 }
 
 func ListAPIKeys(c *gin.Context) {
@@ -51,6 +109,9 @@ func CreateAPIKey(c *gin.Context) {
 		return
 	}
 	u := user.(model.User)
+	if rejectScopedToken(c, u) {
+		return
+	}
 
 	var expiresAt *time.Time
 	if req.ExpiresAt != "" {
@@ -66,7 +127,22 @@ func CreateAPIKey(c *gin.Context) {
 		expiresAt = &t
 	}
 
-	token, pat, err := model.NewPersonalAccessToken(u.ID, req.Name, expiresAt)
+	scope := req.Scope
+	if req.ReadOnly {
+		for _, v := range scope.Verbs {
+			if v != string(common.VerbGet) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "a read-only token can only have the get verb"})
+				return
+			}
+		}
+		scope.Verbs = model.SliceString{string(common.VerbGet)}
+	}
+	if err := validateTokenScope(scope); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	token, pat, err := model.NewPersonalAccessToken(u.ID, req.Name, expiresAt, scope)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to create API key: %v", err)})
 		return
@@ -91,6 +167,9 @@ func DeleteAPIKey(c *gin.Context) {
 		return
 	}
 	u := user.(model.User)
+	if rejectScopedToken(c, u) {
+		return
+	}
 
 	if err := model.DeletePersonalAccessToken(uint(id), u.ID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete API key"})
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pixelvide/kube-sentinel/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupAccountRouter(user model.User) *gin.Engine {
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user", user)
+		c.Next()
+	})
+	r.POST("/settings/api-keys", CreateAPIKey)
+	r.DELETE("/settings/api-keys/:id", DeleteAPIKey)
+	r.DELETE("/settings/sessions/:id", RevokeSession)
+	r.POST("/settings/two-factor/setup", SetupTwoFactor)
+	r.POST("/settings/two-factor/enable", EnableTwoFactor)
+	r.POST("/settings/two-factor/recovery-codes", RegenerateRecoveryCodes)
+	r.POST("/settings/two-factor/disable", DisableTwoFactor)
+	return r
+}
+
+func TestScopedTokenCannotManageAccount(t *testing.T) {
+	setupTestDB()
+	require.NoError(t, model.DB.AutoMigrate(&model.PersonalAccessToken{}))
+	owner := model.User{Username: "scoped-owner", Password: "hashed"}
+	require.NoError(t, model.DB.FirstOrCreate(&owner, model.User{Username: "scoped-owner"}).Error)
+	t.Cleanup(func() {
+		model.DB.Where("user_id = ?", owner.ID).Delete(&model.PersonalAccessToken{})
+	})
+
+	scoped := owner
+	scoped.TokenScope = &model.TokenScope{Namespaces: model.SliceString{"dev"}}
+	r := setupAccountRouter(scoped)
+
+	for _, tc := range []struct {
+		method, path, body string
+	}{
+		{http.MethodPost, "/settings/api-keys", `{"name":"escalated"}`},
+		{http.MethodPost, "/settings/api-keys", `{"name":"narrower","scope":{"namespaces":["dev"],"verbs":["get"]}}`},
+		{http.MethodDelete, "/settings/api-keys/1", ""},
+		{http.MethodDelete, "/settings/sessions/1", ""},
+		{http.MethodPost, "/settings/two-factor/setup", ""},
+		{http.MethodPost, "/settings/two-factor/enable", `{"code":"123456"}`},
+		{http.MethodPost, "/settings/two-factor/recovery-codes", `{"code":"123456"}`},
+		{http.MethodPost, "/settings/two-factor/disable", `{"code":"123456"}`},
+	} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(tc.method, tc.path, bytes.NewBufferString(tc.body))
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusForbidden, w.Code, "%s %s", tc.method, tc.path)
+	}
+
+	var count int64
+	model.DB.Model(&model.PersonalAccessToken{}).Where("user_id = ?", owner.ID).Count(&count)
+	assert.Zero(t, count, "a scoped token creates no API keys")
+
+	// Signed in without a token scope, the owner manages their keys
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/settings/api-keys", bytes.NewBufferString(`{"name":"ci"}`))
+	setupAccountRouter(owner).ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+}
@@ -1,27 +1,28 @@
 package handlers
 
 import (
+	"encoding/csv"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/pixelvide/kube-sentinel/pkg/audit"
 	"github.com/pixelvide/kube-sentinel/pkg/model"
+	"gorm.io/gorm"
+	"k8s.io/klog/v2"
 )
 
+// ListAuditLogs returns audit logs newest first. Filters are exact matches on
+// the indexed columns; pagination uses the id of the last returned row as the
+// cursor for the next page.
 func ListAuditLogs(c *gin.Context) {
-	page := 1
 	size := 20
+	cursor := uint64(0)
 
-	if p := strings.TrimSpace(c.Query("page")); p != "" {
-		if parsed, err := strconv.Atoi(p); err == nil && parsed > 0 {
-			page = parsed
-		} else {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid page parameter"})
-			return
-		}
-	}
 	if s := strings.TrimSpace(c.Query("size")); s != "" {
 		if parsed, err := strconv.Atoi(s); err == nil && parsed > 0 {
 			size = parsed
@@ -30,6 +31,14 @@ func ListAuditLogs(c *gin.Context) {
 			return
 		}
 	}
+	if cur := strings.TrimSpace(c.Query("cursor")); cur != "" {
+		parsed, err := strconv.ParseUint(cur, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cursor parameter"})
+			return
+		}
+		cursor = parsed
+	}
 
 	actorID := uint64(0)
 	if op := strings.TrimSpace(c.Query("operatorId")); op != "" {
@@ -45,26 +54,34 @@ func ListAuditLogs(c *gin.Context) {
 	resourceType := strings.TrimSpace(c.Query("resourceType"))
 	resourceName := strings.TrimSpace(c.Query("resourceName"))
 	namespace := strings.TrimSpace(c.Query("namespace"))
+	source := strings.TrimSpace(c.Query("source"))
+	chatSessionID := strings.TrimSpace(c.Query("chatSessionId"))
 
 	query := model.DB.Model(&model.AuditLog{})
 	if actorID > 0 {
 		query = query.Where("actor_id = ?", actorID)
 	}
 	if clusterName != "" {
-		query = query.Where("payload LIKE ?", "%"+clusterName+"%")
+		query = query.Where("cluster_name = ?", clusterName)
 	}
 	if resourceType != "" {
-		query = query.Where("payload LIKE ?", "%"+resourceType+"%")
+		query = query.Where("resource_type = ?", resourceType)
 	}
 	if resourceName != "" {
-		query = query.Where("payload LIKE ?", "%"+resourceName+"%")
+		query = query.Where("resource_name = ?", resourceName)
 	}
 	if namespace != "" {
-		query = query.Where("payload LIKE ?", "%"+namespace+"%")
+		query = query.Where("namespace = ?", namespace)
+	}
+	if source != "" {
+		query = query.Where("source = ?", source)
+	}
+	if chatSessionID != "" {
+		query = query.Where("chat_session_id = ?", chatSessionID)
 	}
 	if search != "" {
 		like := "%" + search + "%"
-		query = query.Where("payload LIKE ?", like)
+		query = query.Where("resource_name LIKE ? OR namespace LIKE ? OR cluster_name LIKE ?", like, like, like)
 	}
 	if operation != "" {
 		query = query.Where("action = ?", operation)
@@ -76,20 +93,28 @@ func ListAuditLogs(c *gin.Context) {
 		return
 	}
 
+	if cursor > 0 {
+		query = query.Where("id < ?", cursor)
+	}
+
+	// Fetch one extra row to know whether there is a next page
 	logs := []model.AuditLog{}
-	if err := query.Preload("Actor").Order("created_at DESC").Offset((page - 1) * size).Limit(size).Find(&logs).Error; err != nil {
+	if err := query.Preload("Actor").Order("id DESC").Limit(size + 1).Find(&logs).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	hasMore := len(logs) > size
+	if hasMore {
+		logs = logs[:size]
+	}
+	var nextCursor uint
+	if hasMore {
+		nextCursor = logs[len(logs)-1].ID
+	}
 
 	// Map to readable format
 	history := make([]map[string]interface{}, 0, len(logs))
 	for _, l := range logs {
-		var p map[string]interface{}
-		_ = json.Unmarshal([]byte(l.Payload), &p)
-		if p == nil {
-			p = make(map[string]interface{})
-		}
 		actorName := ""
 		if l.Actor != nil {
 			actorName = l.Actor.Username
@@ -98,10 +123,12 @@ func ListAuditLogs(c *gin.Context) {
 			"id":            l.ID,
 			"createdAt":     l.CreatedAt,
 			"updatedAt":     l.UpdatedAt,
-			"clusterName":   p["clusterName"],
-			"resourceType":  p["resourceType"],
-			"resourceName":  p["resourceName"],
-			"namespace":     p["namespace"],
+			"clusterName":   l.ClusterName,
+			"resourceType":  l.ResourceType,
+			"resourceName":  l.ResourceName,
+			"namespace":     l.Namespace,
+			"source":        l.Source,
+			"chatSessionId": l.ChatSessionID,
 			"operationType": l.Action,
 			"actor":         actorName,
 			"operator": map[string]interface{}{
@@ -114,9 +141,96 @@ func ListAuditLogs(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"data":  history,
-		"total": total,
-		"page":  page,
-		"size":  size,
+		"data":       history,
+		"total":      total,
+		"size":       size,
+		"nextCursor": nextCursor,
+		"hasMore":    hasMore,
 	})
 }
+
+const auditExportBatchSize = 500
+
+// ExportAuditLogs streams all audit logs created in [from, to) as NDJSON or
+// CSV. Both bounds are RFC3339 timestamps; to defaults to now.
+func ExportAuditLogs(c *gin.Context) {
+	format := strings.ToLower(c.DefaultQuery("format", "ndjson"))
+	if format != "ndjson" && format != "csv" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be ndjson or csv"})
+		return
+	}
+
+	from, err := time.Parse(time.RFC3339, c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or missing from parameter, expected RFC3339"})
+		return
+	}
+	to := time.Now()
+	if v := c.Query("to"); v != "" {
+		to, err = time.Parse(time.RFC3339, v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to parameter, expected RFC3339"})
+			return
+		}
+	}
+	if !from.Before(to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must be before to"})
+		return
+	}
+
+	entry := audit.FromRequest(c, "export")
+	entry.ResourceType = "audit_logs"
+	entry.Payload = map[string]interface{}{
+		"from":   from,
+		"to":     to,
+		"format": format,
+	}
+	entry.Success = true
+	audit.Emit(entry)
+
+	filename := fmt.Sprintf("audit-logs-%s-%s.%s", from.UTC().Format("20060102T150405Z"), to.UTC().Format("20060102T150405Z"), format)
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	if format == "csv" {
+		c.Header("Content-Type", "text/csv")
+	} else {
+		c.Header("Content-Type", "application/x-ndjson")
+	}
+	c.Status(http.StatusOK)
+
+	csvWriter := csv.NewWriter(c.Writer)
+	if format == "csv" {
+		_ = csvWriter.Write(audit.CSVHeader)
+	}
+	encoder := json.NewEncoder(c.Writer)
+
+	var batch []model.AuditLog
+	result := model.DB.Preload("Actor").
+		Where("created_at >= ? AND created_at < ?", from, to).
+		FindInBatches(&batch, auditExportBatchSize, func(tx *gorm.DB, _ int) error {
+			for _, l := range batch {
+				record := audit.NewRecord(l)
+				if format == "csv" {
+					if err := csvWriter.Write(record.CSVRow()); err != nil {
+						return err
+					}
+				} else if err := encoder.Encode(record); err != nil {
+					return err
+				}
+			}
+			if format == "csv" {
+				csvWriter.Flush()
+				if err := csvWriter.Error(); err != nil {
+					return err
+				}
+			}
+			c.Writer.Flush()
+			return nil
+		})
+	if format == "csv" {
+		csvWriter.Flush()
+	}
+	if result.Error != nil {
+		// Headers are already sent, the truncated body is all we can signal
+		klog.Errorf("Failed to export audit logs: %v", result.Error)
+	}
+}
@@ -45,8 +45,11 @@ func (h *LogsHandler) HandleLogsWebSocket(c *gin.Context) {
 			return
 		}
 
-		if !rbac.CanAccess(user, "pods", "log", cs.Name, namespace) {
-			_ = sendErrorMessage(ws, rbac.NoAccess(user.Key(), string(common.VerbLog), "pods", namespace, cs.Name))
+		if !rbac.AuthorizeObject(ctx, cs.K8sClient, user, rbac.AccessRequest{
+			Cluster: cs.Name, Namespace: namespace, Resource: "pods", Subresource: "log",
+			Verb: string(common.VerbLog), Name: podName,
+		}) {
+			_ = sendErrorMessage(ws, rbac.NoAccess(user.Key(), string(common.VerbLog), "pods/log", namespace, cs.Name))
 			return
 		}
 
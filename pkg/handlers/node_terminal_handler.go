@@ -45,7 +45,9 @@ func (h *NodeTerminalHandler) HandleNodeTerminalWebSocket(c *gin.Context) {
 		defer func() {
 			_ = conn.Close()
 		}()
-		if !rbac.CanAccess(user, "nodes", "exec", cs.Name, "") {
+		if !rbac.AuthorizeObject(context.TODO(), cs.K8sClient, user, rbac.AccessRequest{
+			Cluster: cs.Name, Resource: "nodes", Verb: string(common.VerbExec), Name: nodeName,
+		}) {
 			h.sendErrorMessage(conn, rbac.NoAccess(user.Key(), string(common.VerbExec), "nodes", "", cs.Name))
 			return
 		}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/pixelvide/kube-sentinel/pkg/cluster"
+	"github.com/pixelvide/kube-sentinel/pkg/common"
 	"github.com/pixelvide/kube-sentinel/pkg/kube"
 	"github.com/pixelvide/kube-sentinel/pkg/model"
 	"github.com/pixelvide/kube-sentinel/pkg/rbac"
@@ -39,7 +40,15 @@ func (h *ProxyHandler) HandleProxy(c *gin.Context) {
 		return
 	}
 
-	if !rbac.CanAccess(user, kind, "get", cs.Name, namespace) {
+	// Proxying to a pod is port forwarding, which roles can grant separately
+	req := rbac.AccessRequest{
+		Cluster: cs.Name, Namespace: namespace, Resource: kind,
+		Verb: string(common.VerbGet), Name: name,
+	}
+	if kind == "pods" {
+		req.Subresource = "portforward"
+	}
+	if !rbac.AuthorizeObject(c.Request.Context(), cs.K8sClient, user, req) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
 		return
 	}
@@ -1,11 +1,11 @@
 package handlers
 
 import (
-	"encoding/json"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/pixelvide/kube-sentinel/pkg/audit"
 	"github.com/pixelvide/kube-sentinel/pkg/cluster"
 	"github.com/pixelvide/kube-sentinel/pkg/common"
 	"github.com/pixelvide/kube-sentinel/pkg/model"
@@ -52,7 +52,14 @@ func (h *ResourceApplyHandler) ApplyResource(c *gin.Context) {
 	}
 
 	resource := strings.ToLower(obj.GetKind()) + "s"
-	if !rbac.CanAccess(user, resource, "create", cs.Name, obj.GetNamespace()) {
+	objLabels := obj.GetLabels()
+	if objLabels == nil {
+		objLabels = map[string]string{}
+	}
+	if !rbac.Authorize(user, rbac.AccessRequest{
+		Cluster: cs.Name, Namespace: obj.GetNamespace(), Resource: resource,
+		Verb: string(common.VerbCreate), Name: obj.GetName(), Labels: objLabels,
+	}) {
 		c.JSON(http.StatusForbidden, gin.H{
 			"error": rbac.NoAccess(user.Key(), string(common.VerbCreate), resource, obj.GetNamespace(), cs.Name)})
 		return
@@ -76,34 +83,17 @@ func (h *ResourceApplyHandler) ApplyResource(c *gin.Context) {
 			existingObj.SetManagedFields(nil)
 			previousYAML, _ = syaml.Marshal(existingObj)
 		}
-		errMessage := ""
-		if err != nil {
-			errMessage = err.Error()
-		}
 
-		payloadData := map[string]interface{}{
-			"clusterName":  cs.Name,
-			"resourceType": resource,
-			"resourceName": obj.GetName(),
-			"namespace":    obj.GetNamespace(),
+		entry := audit.FromRequest(c, "apply")
+		entry.ClusterName = cs.Name
+		entry.ResourceType = resource
+		entry.ResourceName = obj.GetName()
+		entry.Namespace = obj.GetNamespace()
+		entry.Payload = map[string]interface{}{
 			"resourceYaml": req.YAML,
 			"previousYaml": string(previousYAML),
 		}
-		payloadBytes, err := json.Marshal(payloadData)
-		if err != nil {
-			klog.Errorf("Failed to marshal audit payload: %v", err)
-		}
-
-		model.DB.Create(&model.AuditLog{
-			AppID:        model.CurrentApp.ID,
-			Action:       "apply",
-			ActorID:      user.ID,
-			Payload:      string(payloadBytes),
-			Success:      err == nil,
-			ErrorMessage: errMessage,
-			IPAddress:    c.ClientIP(),
-			UserAgent:    c.Request.UserAgent(),
-		})
+		audit.Emit(entry.WithError(err))
 	}()
 
 	switch {
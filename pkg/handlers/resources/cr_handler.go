@@ -116,7 +116,7 @@ func (h *CRHandler) List(c *gin.Context) {
 					allItems = append(allItems, nsList.Items...)
 				}
 				crList.Items = allItems
-				c.JSON(http.StatusOK, crList)
+				c.JSON(http.StatusOK, filterCRList(c, crdName, crList))
 				return
 			}
 			opts.Namespace = namespaces[0]
@@ -128,7 +128,23 @@ func (h *CRHandler) List(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, crList)
+	c.JSON(http.StatusOK, filterCRList(c, crdName, crList))
+}
+
+// filterCRList drops the custom resources the user's roles do not allow
+func filterCRList(c *gin.Context, crdName string, list *unstructured.UnstructuredList) *unstructured.UnstructuredList {
+	allowed := objectFilter(c, crdName)
+	if allowed == nil {
+		return list
+	}
+	items := make([]unstructured.Unstructured, 0, len(list.Items))
+	for i := range list.Items {
+		if allowed(&list.Items[i]) {
+			items = append(items, list.Items[i])
+		}
+	}
+	list.Items = items
+	return list
 }
 
 func (h *CRHandler) Get(c *gin.Context) {
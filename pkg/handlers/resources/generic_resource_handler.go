@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"math"
 	"net/http"
 	"reflect"
 	"sort"
@@ -14,6 +13,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/pixelvide/kube-sentinel/pkg/analyzer"
+	"github.com/pixelvide/kube-sentinel/pkg/audit"
 	"github.com/pixelvide/kube-sentinel/pkg/cluster"
 	"github.com/pixelvide/kube-sentinel/pkg/common"
 	"github.com/pixelvide/kube-sentinel/pkg/kube"
@@ -80,7 +80,6 @@ func (h *GenericResourceHandler[T, V]) getGroupKind() schema.GroupKind {
 
 func (h *GenericResourceHandler[T, V]) recordHistory(c *gin.Context, opType string, prev, curr T, success bool, errMsg string) {
 	cs := c.MustGet("cluster").(*cluster.ClientSet)
-	user := c.MustGet("user").(model.User)
 
 	var name, namespace string
 	// Safely get name and namespace from either prev or curr
@@ -92,33 +91,18 @@ func (h *GenericResourceHandler[T, V]) recordHistory(c *gin.Context, opType stri
 		namespace = prev.GetNamespace()
 	}
 
-	payloadData := map[string]interface{}{
-		"clusterName":  cs.Name,
-		"resourceType": h.name,
-		"resourceName": name,
-		"namespace":    namespace,
+	entry := audit.FromRequest(c, opType)
+	entry.ClusterName = cs.Name
+	entry.ResourceType = h.name
+	entry.ResourceName = name
+	entry.Namespace = namespace
+	entry.Payload = map[string]interface{}{
 		"resourceYaml": h.ToYAML(curr),
 		"previousYaml": h.ToYAML(prev),
 	}
-	payloadBytes, err := json.Marshal(payloadData)
-	if err != nil {
-		klog.Errorf("Failed to marshal audit payload: %v", err)
-	}
-
-	auditLog := model.AuditLog{
-		AppID:        model.CurrentApp.ID,
-		Action:       opType,
-		ActorID:      user.ID,
-		Payload:      string(payloadBytes),
-		Success:      success,
-		ErrorMessage: errMsg,
-		IPAddress:    c.ClientIP(),
-		UserAgent:    c.Request.UserAgent(),
-	}
-
-	if err := model.DB.Create(&auditLog).Error; err != nil {
-		klog.Errorf("Failed to create audit log: %v", err)
-	}
+	entry.Success = success
+	entry.ErrorMessage = errMsg
+	audit.Emit(entry)
 }
 
 func (h *GenericResourceHandler[T, V]) IsClusterScoped() bool {
@@ -155,6 +139,11 @@ func (h *GenericResourceHandler[T, V]) Get(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
 			return
 		}
+		if errors.IsForbidden(err) {
+			// Clusters in impersonation mode refuse what cluster RBAC denies
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -185,7 +174,7 @@ func (h *GenericResourceHandler[T, V]) GetAnalysis(c *gin.Context) {
 
 	obj := object.(client.Object)
 	cs := c.MustGet("cluster").(*cluster.ClientSet)
-	analysis := analyzer.Analyze(c.Request.Context(), cs.K8sClient, obj)
+	analysis := analyzer.Analyze(c.Request.Context(), cs.Name, cs.K8sClient, obj)
 
 	c.JSON(http.StatusOK, analysis)
 }
@@ -250,6 +239,10 @@ func (h *GenericResourceHandler[T, V]) list(c *gin.Context) (V, error) {
 	}
 
 	if err := cs.K8sClient.List(ctx, objectList, listOpts...); err != nil {
+		if errors.IsForbidden(err) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return zero, err
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return zero, err
 	}
@@ -279,6 +272,7 @@ func (h *GenericResourceHandler[T, V]) list(c *gin.Context) (V, error) {
 	})
 
 	user := c.MustGet("user").(model.User)
+	allowed := objectFilter(c, h.Name())
 	filterItems := make([]runtime.Object, 0, len(items))
 	for i := range items {
 		obj, err := meta.Accessor(items[i])
@@ -313,6 +307,9 @@ func (h *GenericResourceHandler[T, V]) list(c *gin.Context) (V, error) {
 				continue
 			}
 		}
+		if allowed != nil && !allowed(obj) {
+			continue
+		}
 		filterItems = append(filterItems, items[i])
 	}
 	_ = meta.SetList(objectList, filterItems)
@@ -594,13 +591,13 @@ func (h *GenericResourceHandler[T, V]) ListHistory(c *gin.Context) {
 	namespace := c.Param("namespace")
 	resourceName := c.Param("name")
 	pageSize, err := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
-	if err != nil {
+	if err != nil || pageSize <= 0 {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pageSize parameter"})
 		return
 	}
-	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	cursor, err := strconv.ParseUint(c.DefaultQuery("cursor", "0"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid page parameter"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cursor parameter"})
 		return
 	}
 
@@ -608,12 +605,10 @@ func (h *GenericResourceHandler[T, V]) ListHistory(c *gin.Context) {
 	var total int64
 	query := model.DB.Model(&model.AuditLog{}).
 		Where("action IN (?)", []string{"create", "update", "patch", "delete", "apply"}).
-		Where("payload LIKE ?", "%"+cs.Name+"%").
-		Where("payload LIKE ?", "%"+h.name+"%").
-		Where("payload LIKE ?", "%"+resourceName+"%")
+		Where("cluster_name = ? AND resource_type = ? AND resource_name = ?", cs.Name, h.name, resourceName)
 
-	if namespace != "" {
-		query = query.Where("payload LIKE ?", "%"+namespace+"%")
+	if namespace != "" && namespace != "_all" {
+		query = query.Where("namespace = ?", namespace)
 	}
 
 	if err := query.Count(&total).Error; err != nil {
@@ -621,12 +616,24 @@ func (h *GenericResourceHandler[T, V]) ListHistory(c *gin.Context) {
 		return
 	}
 
-	// Get paginated audit logs
+	if cursor > 0 {
+		query = query.Where("id < ?", cursor)
+	}
+
+	// Get a page of audit logs, fetching one extra row to detect the next page
 	logs := []model.AuditLog{}
-	if err := query.Preload("Actor").Order("created_at DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&logs).Error; err != nil {
+	if err := query.Preload("Actor").Order("id DESC").Limit(pageSize + 1).Find(&logs).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	hasNextPage := len(logs) > pageSize
+	if hasNextPage {
+		logs = logs[:pageSize]
+	}
+	var nextCursor uint
+	if hasNextPage {
+		nextCursor = logs[len(logs)-1].ID
+	}
 
 	// Map logs back to a format similar to ResourceHistory for frontend compatibility
 	history := make([]map[string]interface{}, 0, len(logs))
@@ -637,50 +644,38 @@ func (h *GenericResourceHandler[T, V]) ListHistory(c *gin.Context) {
 			p = make(map[string]interface{})
 		}
 
-		// Check if it's actually for this specific resource (naive LIKE check above might match others)
-		if p["clusterName"] == cs.Name && p["resourceType"] == h.name && p["resourceName"] == resourceName {
-			if namespace != "" && p["namespace"] != namespace {
-				continue
-			}
-			actorName := fmt.Sprintf("%d", l.ActorID) // Fallback
-			if l.Actor != nil {
-				actorName = l.Actor.Username
-			}
-			history = append(history, map[string]interface{}{
-				"id":            l.ID,
-				"createdAt":     l.CreatedAt,
-				"updatedAt":     l.UpdatedAt,
-				"clusterName":   p["clusterName"],
-				"resourceType":  p["resourceType"],
-				"resourceName":  p["resourceName"],
-				"namespace":     p["namespace"],
-				"operationType": l.Action,
-				"resourceYaml":  p["resourceYaml"],
-				"previousYaml":  p["previousYaml"],
-				"success":       l.Success,
-				"errorMessage":  l.ErrorMessage,
-				"actor":         actorName,
-				"operator": map[string]interface{}{
-					"username": actorName,
-				},
-			})
+		actorName := fmt.Sprintf("%d", l.ActorID) // Fallback
+		if l.Actor != nil {
+			actorName = l.Actor.Username
 		}
+		history = append(history, map[string]interface{}{
+			"id":            l.ID,
+			"createdAt":     l.CreatedAt,
+			"updatedAt":     l.UpdatedAt,
+			"clusterName":   l.ClusterName,
+			"resourceType":  l.ResourceType,
+			"resourceName":  l.ResourceName,
+			"namespace":     l.Namespace,
+			"source":        l.Source,
+			"operationType": l.Action,
+			"resourceYaml":  p["resourceYaml"],
+			"previousYaml":  p["previousYaml"],
+			"success":       l.Success,
+			"errorMessage":  l.ErrorMessage,
+			"actor":         actorName,
+			"operator": map[string]interface{}{
+				"username": actorName,
+			},
+		})
 	}
 
-	// Calculate pagination info
-	totalPages := int(math.Ceil(float64(total) / float64(pageSize)))
-	hasNextPage := page < totalPages
-	hasPrevPage := page > 1
-
 	response := gin.H{
 		"data": history,
 		"pagination": gin.H{
-			"page":        page,
 			"pageSize":    pageSize,
 			"total":       total,
-			"totalPages":  totalPages,
+			"nextCursor":  nextCursor,
 			"hasNextPage": hasNextPage,
-			"hasPrevPage": hasPrevPage,
 		},
 	}
 
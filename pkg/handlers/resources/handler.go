@@ -6,6 +6,8 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/pixelvide/kube-sentinel/pkg/cluster"
 	"github.com/pixelvide/kube-sentinel/pkg/common"
+	"github.com/pixelvide/kube-sentinel/pkg/model"
+	"github.com/pixelvide/kube-sentinel/pkg/rbac"
 	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
 	appsv1 "k8s.io/api/apps/v1"
 	autoscalingv2 "k8s.io/api/autoscaling/v2"
@@ -20,6 +22,7 @@ import (
 	schedulingv1 "k8s.io/api/scheduling/v1"
 	storagev1 "k8s.io/api/storage/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
@@ -232,3 +235,31 @@ func GetHandler(resource string) (resourceHandler, error) {
 	}
 	return handler, nil
 }
+
+// objectFilter returns a check for the objects of a list when the user holds
+// deny roles or roles limited to some objects, and nil otherwise
+func objectFilter(c *gin.Context, resource string) func(obj metav1.Object) bool {
+	user := c.MustGet("user").(model.User)
+	if !rbac.HasObjectRules(user) {
+		return nil
+	}
+	cs := c.MustGet("cluster").(*cluster.ClientSet)
+	return func(obj metav1.Object) bool {
+		namespace := obj.GetNamespace()
+		if namespace == "" {
+			namespace = "_all"
+		}
+		labels := obj.GetLabels()
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		return rbac.Authorize(user, rbac.AccessRequest{
+			Cluster:   cs.Name,
+			Namespace: namespace,
+			Resource:  resource,
+			Verb:      string(common.VerbGet),
+			Name:      obj.GetName(),
+			Labels:    labels,
+		})
+	}
+}
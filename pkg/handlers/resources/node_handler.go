@@ -5,17 +5,23 @@ import (
 	"fmt"
 	"net/http"
 	"sort"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/pixelvide/kube-sentinel/pkg/audit"
 	"github.com/pixelvide/kube-sentinel/pkg/cluster"
 	"github.com/pixelvide/kube-sentinel/pkg/common"
 	"github.com/pixelvide/kube-sentinel/pkg/kube"
 	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/klog/v2"
 	metricsv1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 type NodeHandler struct {
@@ -32,48 +38,251 @@ func NewNodeHandler() *NodeHandler {
 	}
 }
 
-// DrainNode drains a node by evicting all pods
+// drainOptions mirrors the options accepted by kubectl drain.
+type drainOptions struct {
+	Force            bool `json:"force"`
+	GracePeriod      *int `json:"gracePeriod" binding:"omitempty,min=0"`
+	DeleteLocal      bool `json:"deleteLocalData"`
+	IgnoreDaemonsets bool `json:"ignoreDaemonsets"`
+	// Timeout is the overall drain timeout in seconds
+	Timeout int `json:"timeout" binding:"omitempty,min=0"`
+}
+
+// drainEvent is a single progress update streamed to the client while draining
+type drainEvent struct {
+	Status    string `json:"status"`
+	Pod       string `json:"pod,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Message   string `json:"message,omitempty"`
+}
+
+const (
+	drainStatusSkipped  = "skipped"
+	drainStatusEvicting = "evicting"
+	drainStatusRetrying = "retrying"
+	drainStatusEvicted  = "evicted"
+	drainStatusDeleted  = "deleted"
+	drainStatusFailed   = "failed"
+
+	defaultDrainTimeout  = 5 * time.Minute
+	evictionRetryBackoff = 5 * time.Second
+	podDeletionPollDelay = 2 * time.Second
+)
+
+// classifyPodForDrain decides what drain should do with a pod. It returns
+// skip=true for pods that are left in place, and an error for pods that block
+// the drain with the given options.
+func classifyPodForDrain(pod *corev1.Pod, opts drainOptions) (skip bool, reason string, err error) {
+	if _, ok := pod.Annotations[corev1.MirrorPodAnnotationKey]; ok {
+		return true, "mirror pod", nil
+	}
+	// Completed pods can always be removed
+	if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+		return false, "", nil
+	}
+
+	controller := metav1.GetControllerOf(pod)
+	if controller != nil && controller.Kind == "DaemonSet" {
+		if opts.IgnoreDaemonsets {
+			return true, "managed by DaemonSet", nil
+		}
+		return false, "", fmt.Errorf("pod is managed by DaemonSet %s (use ignoreDaemonsets)", controller.Name)
+	}
+	if controller == nil && !opts.Force {
+		return false, "", fmt.Errorf("pod is not managed by a controller (use force)")
+	}
+	if !opts.DeleteLocal {
+		for _, v := range pod.Spec.Volumes {
+			if v.EmptyDir != nil {
+				return false, "", fmt.Errorf("pod uses emptyDir volume %s (use deleteLocalData)", v.Name)
+			}
+		}
+	}
+	return false, "", nil
+}
+
+// DrainNode cordons a node and evicts its pods through the eviction API,
+// streaming per-pod progress as server-sent events
 func (h *NodeHandler) DrainNode(c *gin.Context) {
 	nodeName := c.Param("name")
-	ctx := c.Request.Context()
 	cs := c.MustGet("cluster").(*cluster.ClientSet)
-	// Parse the request body for drain options
-	var drainRequest struct {
-		Force            bool `json:"force" binding:"required"`
-		GracePeriod      int  `json:"gracePeriod" binding:"min=0"`
-		DeleteLocal      bool `json:"deleteLocalData"`
-		IgnoreDaemonsets bool `json:"ignoreDaemonsets"`
-	}
 
-	if err := c.ShouldBindJSON(&drainRequest); err != nil {
+	var opts drainOptions
+	if err := c.ShouldBindJSON(&opts); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
 		return
 	}
 
-	// Get the node first to ensure it exists
-	var node corev1.Node
-	if err := cs.K8sClient.Get(ctx, types.NamespacedName{Name: nodeName}, &node); err != nil {
+	timeout := defaultDrainTimeout
+	if opts.Timeout > 0 {
+		timeout = time.Duration(opts.Timeout) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+	defer cancel()
+
+	// Cordon first so nothing new is scheduled while we evict
+	if err := h.markNodeSchedulable(ctx, cs.K8sClient, nodeName, false); err != nil {
 		if errors.IsNotFound(err) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Node not found"})
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cordon node: " + err.Error()})
 		return
 	}
+	_ = writeSSE(c, "cordoned", gin.H{"node": nodeName})
 
-	// TODO: Implement actual drain logic
-	// For now, we'll simulate the drain operation
-	// In a real implementation, you would:
-	// 1. Mark the node as unschedulable (cordon)
-	// 2. Evict all pods from the node
-	// 3. Handle daemonsets appropriately
-	// 4. Wait for pods to be evicted or force delete them
+	var pods corev1.PodList
+	if err := cs.K8sClient.List(ctx, &pods, client.MatchingFields{"spec.nodeName": nodeName}); err != nil {
+		_ = writeSSE(c, "error", gin.H{"error": "Failed to list pods on node: " + err.Error()})
+		return
+	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": fmt.Sprintf("Node %s drain initiated", nodeName),
-		"node":    node.Name,
-		"options": drainRequest,
-	})
+	var toEvict []corev1.Pod
+	var blockers []string
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		skip, reason, err := classifyPodForDrain(pod, opts)
+		if err != nil {
+			blockers = append(blockers, fmt.Sprintf("%s/%s: %v", pod.Namespace, pod.Name, err))
+			continue
+		}
+		if skip {
+			_ = writeSSE(c, "progress", drainEvent{Status: drainStatusSkipped, Pod: pod.Name, Namespace: pod.Namespace, Message: reason})
+			continue
+		}
+		toEvict = append(toEvict, *pod)
+	}
+	if len(blockers) > 0 {
+		// Like kubectl, refuse to evict anything when some pods cannot be drained.
+		// The node stays cordoned.
+		_ = writeSSE(c, "error", gin.H{"error": "Cannot drain node", "pods": blockers})
+		return
+	}
+
+	events := make(chan drainEvent)
+	var wg sync.WaitGroup
+	for _, pod := range toEvict {
+		wg.Add(1)
+		go func(pod corev1.Pod) {
+			defer wg.Done()
+			h.evictPod(ctx, c, cs, nodeName, pod, opts, events)
+		}(pod)
+	}
+	go func() {
+		wg.Wait()
+		close(events)
+	}()
+
+	ticker := time.NewTicker(15 * time.Second)
+	defer ticker.Stop()
+	flusher, _ := c.Writer.(http.Flusher)
+
+	failed := 0
+	for {
+		select {
+		case <-ticker.C:
+			_, _ = fmt.Fprintf(c.Writer, ": ping\n\n")
+			if flusher != nil {
+				flusher.Flush()
+			}
+		case ev, ok := <-events:
+			if !ok {
+				if ctx.Err() != nil {
+					_ = writeSSE(c, "error", gin.H{"error": "Drain did not complete: " + ctx.Err().Error()})
+					return
+				}
+				_ = writeSSE(c, "done", gin.H{
+					"node":    nodeName,
+					"evicted": len(toEvict) - failed,
+					"failed":  failed,
+				})
+				return
+			}
+			if ev.Status == drainStatusFailed {
+				failed++
+			}
+			_ = writeSSE(c, "progress", ev)
+		}
+	}
+}
+
+// evictPod evicts a single pod, retrying while a PodDisruptionBudget blocks
+// the eviction, then waits for the pod to be gone
+func (h *NodeHandler) evictPod(ctx context.Context, c *gin.Context, cs *cluster.ClientSet, nodeName string, pod corev1.Pod, opts drainOptions, events chan<- drainEvent) {
+	send := func(status, message string) {
+		select {
+		case events <- drainEvent{Status: status, Pod: pod.Name, Namespace: pod.Namespace, Message: message}:
+		case <-ctx.Done():
+		}
+	}
+
+	deleteOptions := &metav1.DeleteOptions{}
+	if opts.GracePeriod != nil {
+		gracePeriod := int64(*opts.GracePeriod)
+		deleteOptions.GracePeriodSeconds = &gracePeriod
+	}
+	eviction := &policyv1.Eviction{
+		ObjectMeta:    metav1.ObjectMeta{Name: pod.Name, Namespace: pod.Namespace},
+		DeleteOptions: deleteOptions,
+	}
+
+	send(drainStatusEvicting, "")
+	for {
+		err := cs.K8sClient.ClientSet.CoreV1().Pods(pod.Namespace).EvictV1(ctx, eviction)
+		if err == nil || errors.IsNotFound(err) {
+			break
+		}
+		if errors.IsTooManyRequests(err) {
+			send(drainStatusRetrying, err.Error())
+			select {
+			case <-time.After(evictionRetryBackoff):
+				continue
+			case <-ctx.Done():
+				err = ctx.Err()
+			}
+		}
+		h.recordEviction(c, cs, nodeName, pod, false, err.Error())
+		send(drainStatusFailed, err.Error())
+		return
+	}
+	h.recordEviction(c, cs, nodeName, pod, true, "")
+	send(drainStatusEvicted, "")
+
+	if err := waitForPodDeletion(ctx, cs, pod); err != nil {
+		send(drainStatusFailed, "Evicted but not deleted: "+err.Error())
+		return
+	}
+	send(drainStatusDeleted, "")
+}
+
+func waitForPodDeletion(ctx context.Context, cs *cluster.ClientSet, pod corev1.Pod) error {
+	ticker := time.NewTicker(podDeletionPollDelay)
+	defer ticker.Stop()
+	for {
+		current, err := cs.K8sClient.ClientSet.CoreV1().Pods(pod.Namespace).Get(ctx, pod.Name, metav1.GetOptions{})
+		if errors.IsNotFound(err) || (err == nil && current.UID != pod.UID) {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (h *NodeHandler) recordEviction(c *gin.Context, cs *cluster.ClientSet, nodeName string, pod corev1.Pod, success bool, errMsg string) {
+	entry := audit.FromRequest(c, "evict")
+	entry.ClusterName = cs.Name
+	entry.ResourceType = "pods"
+	entry.ResourceName = pod.Name
+	entry.Namespace = pod.Namespace
+	entry.Payload = map[string]interface{}{
+		"nodeName": nodeName,
+	}
+	entry.Success = success
+	entry.ErrorMessage = errMsg
+	audit.Emit(entry)
 }
 
 func (h *NodeHandler) markNodeSchedulable(ctx context.Context, client *kube.K8sClient, nodeName string, schedulable bool) error {
@@ -0,0 +1,73 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func drainTestPod(ownerKind string) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "p", Namespace: "default"},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	if ownerKind != "" {
+		controller := true
+		pod.OwnerReferences = []metav1.OwnerReference{{Kind: ownerKind, Name: "owner", Controller: &controller}}
+	}
+	return pod
+}
+
+func TestClassifyPodForDrain(t *testing.T) {
+	t.Run("managed pod is evicted", func(t *testing.T) {
+		skip, _, err := classifyPodForDrain(drainTestPod("ReplicaSet"), drainOptions{})
+		assert.NoError(t, err)
+		assert.False(t, skip)
+	})
+
+	t.Run("mirror pod is skipped", func(t *testing.T) {
+		pod := drainTestPod("")
+		pod.Annotations = map[string]string{corev1.MirrorPodAnnotationKey: "x"}
+		skip, _, err := classifyPodForDrain(pod, drainOptions{})
+		assert.NoError(t, err)
+		assert.True(t, skip)
+	})
+
+	t.Run("daemonset pod", func(t *testing.T) {
+		_, _, err := classifyPodForDrain(drainTestPod("DaemonSet"), drainOptions{})
+		assert.Error(t, err)
+
+		skip, _, err := classifyPodForDrain(drainTestPod("DaemonSet"), drainOptions{IgnoreDaemonsets: true})
+		assert.NoError(t, err)
+		assert.True(t, skip)
+	})
+
+	t.Run("unmanaged pod requires force", func(t *testing.T) {
+		_, _, err := classifyPodForDrain(drainTestPod(""), drainOptions{})
+		assert.Error(t, err)
+
+		skip, _, err := classifyPodForDrain(drainTestPod(""), drainOptions{Force: true})
+		assert.NoError(t, err)
+		assert.False(t, skip)
+	})
+
+	t.Run("emptyDir requires deleteLocalData", func(t *testing.T) {
+		pod := drainTestPod("ReplicaSet")
+		pod.Spec.Volumes = []corev1.Volume{{Name: "cache", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}}}
+		_, _, err := classifyPodForDrain(pod, drainOptions{})
+		assert.Error(t, err)
+
+		_, _, err = classifyPodForDrain(pod, drainOptions{DeleteLocal: true})
+		assert.NoError(t, err)
+	})
+
+	t.Run("completed pods are always removable", func(t *testing.T) {
+		pod := drainTestPod("")
+		pod.Status.Phase = corev1.PodSucceeded
+		skip, _, err := classifyPodForDrain(pod, drainOptions{})
+		assert.NoError(t, err)
+		assert.False(t, skip)
+	})
+}
@@ -260,8 +260,11 @@ func (h *PodHandler) registerCustomRoutes(group *gin.RouterGroup) {
 		user := c.MustGet("user").(model.User)
 		cs := c.MustGet("cluster").(*cluster.ClientSet)
 		namespace := c.Param("namespace")
-		if !rbac.CanAccess(user, "pods", string(common.VerbExec), cs.Name, namespace) {
-			c.JSON(http.StatusForbidden, gin.H{"error": rbac.NoAccess(user.Key(), string(common.VerbExec), "pods", namespace, cs.Name)})
+		if !rbac.AuthorizeObject(c.Request.Context(), cs.K8sClient, user, rbac.AccessRequest{
+			Cluster: cs.Name, Namespace: namespace, Resource: "pods", Subresource: "files",
+			Verb: string(common.VerbExec), Name: c.Param("name"),
+		}) {
+			c.JSON(http.StatusForbidden, gin.H{"error": rbac.NoAccess(user.Key(), string(common.VerbExec), "pods/files", namespace, cs.Name)})
 			c.Abort()
 			return
 		}
@@ -47,42 +47,6 @@ func discoverServices(ctx context.Context, k8sClient *kube.K8sClient, namespace
 	return relatedServices, nil
 }
 
-func discoverIngressServices(namespace string, ingress *v1.Ingress) []common.RelatedResource {
-	seen := make(map[string]struct{})
-	var relatedServices []common.RelatedResource
-	addService := func(svcName string) {
-		if _, exist := seen[svcName]; exist {
-			return
-		}
-		seen[svcName] = struct{}{}
-		relatedServices = append(relatedServices, common.RelatedResource{
-			Type:      "services",
-			Namespace: namespace,
-			Name:      svcName,
-		})
-	}
-
-	for _, rule := range ingress.Spec.Rules {
-		if rule.HTTP == nil {
-			continue
-		}
-
-		for _, path := range rule.HTTP.Paths {
-			if path.Backend.Service == nil {
-				continue
-			}
-			addService(path.Backend.Service.Name)
-		}
-	}
-	if ingress.Spec.DefaultBackend != nil && ingress.Spec.DefaultBackend.Service != nil {
-		if _, exist := seen[ingress.Spec.DefaultBackend.Service.Name]; !exist {
-			addService(ingress.Spec.DefaultBackend.Service.Name)
-		}
-	}
-
-	return relatedServices
-}
-
 func discoverConfigs(namespace string, podSpec *corev1.PodTemplateSpec) []common.RelatedResource {
 	if podSpec == nil {
 		return []common.RelatedResource{}
@@ -344,11 +308,11 @@ func GetRelatedResources(c *gin.Context) {
 		}
 		result = append(result, workloads...)
 	case *gatewayapiv1.HTTPRoute:
-		result = getHTTPRouteRelatedResouces(res, namespace)
+		result = kube.HTTPRouteRelatedResources(res, namespace)
 	case *autoscalingv2.HorizontalPodAutoscaler:
 		result = getAutoScalingRelatedResources(res, namespace)
 	case *v1.Ingress:
-		services := discoverIngressServices(namespace, res)
+		services := kube.IngressServices(namespace, res)
 		result = append(result, services...)
 	}
 
@@ -409,55 +373,6 @@ func discoverOwners(ctx context.Context, cs *cluster.ClientSet, resource client.
 	return result
 }
 
-func getHTTPRouteRelatedResouces(res *gatewayapiv1.HTTPRoute, namespace string) []common.RelatedResource {
-	var result []common.RelatedResource
-	for _, parentRef := range res.Spec.ParentRefs {
-		var parentResourceType string
-		if parentRef.Kind != nil && *parentRef.Kind != "" {
-			parentResourceType = strings.ToLower(string(*parentRef.Kind)) + "s"
-		} else {
-			parentResourceType = "gateways"
-		}
-		result = append(result, common.RelatedResource{
-			Type: parentResourceType,
-			Name: string(parentRef.Name),
-			Namespace: func() string {
-				if parentRef.Namespace != nil && *parentRef.Namespace != "" {
-					return string(*parentRef.Namespace)
-				}
-				return namespace
-			}(),
-			APIVersion: gatewayapiv1.GroupVersion.String(),
-		})
-	}
-
-	for _, rule := range res.Spec.Rules {
-		for _, backend := range rule.BackendRefs {
-			var backendType, apiVersion string
-			if backend.Kind != nil && *backend.Kind != "" {
-				backendType = strings.ToLower(string(*backend.Kind)) + "s"
-			} else {
-				backendType = "services"
-			}
-			if backendType == "services" {
-				apiVersion = corev1.SchemeGroupVersion.String()
-			}
-			result = append(result, common.RelatedResource{
-				Type: backendType,
-				Name: string(backend.Name),
-				Namespace: func() string {
-					if backend.Namespace != nil && *backend.Namespace != "" {
-						return string(*backend.Namespace)
-					}
-					return namespace
-				}(),
-				APIVersion: apiVersion,
-			})
-		}
-	}
-	return result
-}
-
 func getAutoScalingRelatedResources(res *autoscalingv2.HorizontalPodAutoscaler, namespace string) []common.RelatedResource {
 	var result []common.RelatedResource
 	scaleTarget := res.Spec.ScaleTargetRef
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pixelvide/kube-sentinel/pkg/model"
+	"gorm.io/gorm"
+)
+
+// ListSessions returns the active login sessions of the current user
+func ListSessions(c *gin.Context) {
+	user := c.MustGet("user").(model.User)
+	sessions, err := model.ListUserSessions(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list sessions"})
+		return
+	}
+	current := c.GetUint("sessionID")
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == current
+	}
+	c.JSON(http.StatusOK, sessions)
+}
+
+// RevokeSession revokes one of the current user's sessions
+func RevokeSession(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ID"})
+		return
+	}
+	user := c.MustGet("user").(model.User)
+	if rejectScopedToken(c, user) {
+		return
+	}
+
+	if err := model.RevokeUserSession(user.ID, uint(id)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "session not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke session"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked successfully"})
+}
+
+// ListUserSessions returns the active sessions of a user for admins
+func ListUserSessions(c *gin.Context) {
+	var id uint
+	if _, err := fmt.Sscanf(c.Param("id"), "%d", &id); err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	sessions, err := model.ListUserSessions(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list sessions"})
+		return
+	}
+	c.JSON(http.StatusOK, sessions)
+}
+
+// RevokeUserSessions signs a user out everywhere by revoking all of its
+// sessions
+func RevokeUserSessions(c *gin.Context) {
+	var id uint
+	if _, err := fmt.Sscanf(c.Param("id"), "%d", &id); err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	revoked, err := model.RevokeUserSessions(id)
+	recordUserAudit(c, "revoke_sessions", id, "", err)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke sessions"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "revoked": revoked})
+}
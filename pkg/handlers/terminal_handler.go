@@ -44,10 +44,13 @@ func (h *TerminalHandler) HandleTerminalWebSocket(c *gin.Context) {
 		session := kube.NewTerminalSession(cs.K8sClient, ws, namespace, podName, container)
 		defer session.Close()
 
-		if !rbac.CanAccess(user, "pods", "exec", cs.Name, namespace) {
+		if !rbac.AuthorizeObject(ctx, cs.K8sClient, user, rbac.AccessRequest{
+			Cluster: cs.Name, Namespace: namespace, Resource: "pods", Subresource: "exec",
+			Verb: string(common.VerbExec), Name: podName,
+		}) {
 			h.sendErrorMessage(
 				ws,
-				rbac.NoAccess(user.Key(), string(common.VerbExec), "pods", namespace, cs.Name),
+				rbac.NoAccess(user.Key(), string(common.VerbExec), "pods/exec", namespace, cs.Name),
 			)
 			return
 		}
@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pixelvide/kube-sentinel/pkg/audit"
+	"github.com/pixelvide/kube-sentinel/pkg/model"
+	"github.com/pixelvide/kube-sentinel/pkg/rbac"
+	"github.com/pixelvide/kube-sentinel/pkg/utils"
+	"k8s.io/klog/v2"
+)
+
+type twoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// passwordUser returns the current user when it signs in with a password,
+// and writes a 400 otherwise. Users of identity providers use their MFA.
+// Scoped API keys cannot change the second factor.
+func passwordUser(c *gin.Context) (model.User, bool) {
+	user := c.MustGet("user").(model.User)
+	if rejectScopedToken(c, user) {
+		return user, false
+	}
+	if user.Password == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "two-factor authentication is only available for password users"})
+		return user, false
+	}
+	return user, true
+}
+
+// GetTwoFactorStatus returns the two-factor settings of the current user
+func GetTwoFactorStatus(c *gin.Context) {
+	user := c.MustGet("user").(model.User)
+	enabled, err := model.IsTwoFactorEnabled(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load two-factor settings"})
+		return
+	}
+	var remaining int64
+	if enabled {
+		if remaining, err = model.CountRecoveryCodes(user.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load recovery codes"})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"available":              user.Password != "",
+		"enabled":                enabled,
+		"required":               rbac.TwoFactorRequired(user),
+		"recoveryCodesRemaining": remaining,
+	})
+}
+
+// SetupTwoFactor starts two-factor enrollment of the current user and
+// returns the secret and provisioning URI for an authenticator app
+func SetupTwoFactor(c *gin.Context) {
+	user, ok := passwordUser(c)
+	if !ok {
+		return
+	}
+	secret, err := model.StartTwoFactorSetup(user.ID)
+	if errors.Is(err, model.ErrTwoFactorEnabled) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		klog.Errorf("Failed to start two-factor setup of user %s: %v", user.Username, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start two-factor setup"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"secret": secret,
+		"uri":    utils.TOTPProvisioningURI(utils.TOTPIssuer, user.Username, secret),
+	})
+}
+
+// EnableTwoFactor confirms enrollment with a code from the authenticator app
+// and returns the recovery codes of the current user
+func EnableTwoFactor(c *gin.Context) {
+	user, ok := passwordUser(c)
+	if !ok {
+		return
+	}
+	var req twoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	codes, err := model.EnableTwoFactor(user.ID, req.Code)
+	recordUserAudit(c, "enable_two_factor", user.ID, user.Username, err)
+	switch {
+	case errors.Is(err, model.ErrInvalidTwoFactorCode), errors.Is(err, model.ErrTwoFactorNotStarted):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, model.ErrTwoFactorEnabled):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to enable two-factor authentication"})
+	default:
+		c.JSON(http.StatusOK, gin.H{"recoveryCodes": codes})
+	}
+}
+
+// verifyCurrentTwoFactor checks a code of the current user before a change
+// to its second factor, and writes the error response when it is wrong
+func verifyCurrentTwoFactor(c *gin.Context, user model.User) bool {
+	var req twoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	if _, err := model.VerifyTwoFactor(user.ID, req.Code); err != nil {
+		if errors.Is(err, model.ErrInvalidTwoFactorCode) || errors.Is(err, model.ErrTwoFactorNotStarted) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": model.ErrInvalidTwoFactorCode.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify two-factor code"})
+		}
+		return false
+	}
+	return true
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the current user
+func RegenerateRecoveryCodes(c *gin.Context) {
+	user, ok := passwordUser(c)
+	if !ok || !verifyCurrentTwoFactor(c, user) {
+		return
+	}
+	codes, err := model.RegenerateRecoveryCodes(user.ID)
+	recordUserAudit(c, "regenerate_recovery_codes", user.ID, user.Username, err)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate recovery codes"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"recoveryCodes": codes})
+}
+
+// DisableTwoFactor turns off two-factor authentication of the current user,
+// unless it is required for them
+func DisableTwoFactor(c *gin.Context) {
+	user, ok := passwordUser(c)
+	if !ok {
+		return
+	}
+	if rbac.TwoFactorRequired(user) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "two-factor authentication is required for admins"})
+		return
+	}
+	if !verifyCurrentTwoFactor(c, user) {
+		return
+	}
+	err := model.DisableTwoFactor(user.ID)
+	recordUserAudit(c, "disable_two_factor", user.ID, user.Username, err)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to disable two-factor authentication"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// ResetUserTwoFactor removes the second factor of a user who lost their
+// authenticator app and recovery codes
+func ResetUserTwoFactor(c *gin.Context) {
+	var id uint
+	if _, err := fmt.Sscanf(c.Param("id"), "%d", &id); err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	err := model.DisableTwoFactor(id)
+	recordUserAudit(c, "reset_two_factor", id, "", err)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reset two-factor authentication"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// GetSecuritySettings returns the login security settings
+func GetSecuritySettings(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"requireAdminTwoFactor": model.IsAdminTwoFactorRequired(),
+	})
+}
+
+// UpdateSecuritySettings updates the login security settings
+func UpdateSecuritySettings(c *gin.Context) {
+	var req struct {
+		RequireAdminTwoFactor bool `json:"requireAdminTwoFactor"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	value := strconv.FormatBool(req.RequireAdminTwoFactor)
+	err := model.SetAppConfig(model.CurrentApp.ID, model.RequireAdminTwoFactorKey, value)
+
+	entry := audit.FromRequest(c, "update_security_settings")
+	entry.ResourceType = "settings"
+	entry.ResourceName = model.RequireAdminTwoFactorKey
+	entry.Payload = map[string]interface{}{"value": value}
+	audit.Emit(entry.WithError(err))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update security settings"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"requireAdminTwoFactor": req.RequireAdminTwoFactor})
+}
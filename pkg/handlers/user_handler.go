@@ -3,9 +3,11 @@ package handlers
 import (
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/pixelvide/kube-sentinel/pkg/audit"
 	"github.com/pixelvide/kube-sentinel/pkg/model"
 	"github.com/pixelvide/kube-sentinel/pkg/rbac"
 	"k8s.io/klog/v2"
@@ -45,6 +47,13 @@ func CreateSuperUser(c *gin.Context) {
 		c.JSON(500, gin.H{"error": "failed to create super user"})
 		return
 	}
+	// There is no authenticated actor yet, the new super user is the actor
+	entry := audit.FromRequest(c, "create_super_user")
+	entry.ActorID = user.ID
+	entry.ResourceType = "users"
+	entry.ResourceName = user.Username
+	entry.Success = true
+	audit.Emit(entry)
 	rbac.SyncNow <- struct{}{}
 	c.JSON(201, user)
 }
@@ -69,7 +78,9 @@ func CreatePasswordUser(c *gin.Context) {
 		return
 	}
 
-	if err := model.AddUser(user); err != nil {
+	err = model.AddUser(user)
+	recordUserAudit(c, "create", user.ID, user.Username, err)
+	if err != nil {
 		c.JSON(500, gin.H{"error": "failed to create user"})
 		return
 	}
@@ -146,7 +157,9 @@ func UpdateUser(c *gin.Context) {
 		user.AvatarURL = req.AvatarURL
 	}
 
-	if err := model.UpdateUser(user); err != nil {
+	err = model.UpdateUser(user)
+	recordUserAudit(c, "update", user.ID, user.Username, err)
+	if err != nil {
 		c.JSON(500, gin.H{"error": "failed to update user"})
 		return
 	}
@@ -160,7 +173,9 @@ func DeleteUser(c *gin.Context) {
 		return
 	}
 
-	if err := model.DeleteUserByID(id); err != nil {
+	err := model.DeleteUserByID(id)
+	recordUserAudit(c, "delete", id, "", err)
+	if err != nil {
 		c.JSON(500, gin.H{"error": "failed to delete user"})
 		return
 	}
@@ -180,7 +195,9 @@ func ResetPassword(c *gin.Context) {
 		c.JSON(400, gin.H{"error": err.Error()})
 		return
 	}
-	if err := model.ResetPasswordByID(id, req.Password); err != nil {
+	err := model.ResetPasswordByID(id, req.Password)
+	recordUserAudit(c, "reset_password", id, "", err)
+	if err != nil {
 		c.JSON(500, gin.H{"error": "failed to reset password"})
 		return
 	}
@@ -200,7 +217,13 @@ func SetUserEnabled(c *gin.Context) {
 		c.JSON(400, gin.H{"error": err.Error()})
 		return
 	}
-	if err := model.SetUserEnabled(id, req.Enabled); err != nil {
+	err := model.SetUserEnabled(id, req.Enabled)
+	action := "disable"
+	if req.Enabled {
+		action = "enable"
+	}
+	recordUserAudit(c, action, id, "", err)
+	if err != nil {
 		c.JSON(500, gin.H{"error": "failed to set enabled"})
 		return
 	}
@@ -228,7 +251,13 @@ func ToggleUserAIChat(c *gin.Context) {
 	}
 
 	config.IsAIChatEnabled = req.Enabled
-	if err := model.DB.Save(config).Error; err != nil {
+	err = model.DB.Save(config).Error
+	action := "disable_ai_chat"
+	if req.Enabled {
+		action = "enable_ai_chat"
+	}
+	recordUserAudit(c, action, id, "", err)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update user config"})
 		return
 	}
@@ -236,6 +265,19 @@ func ToggleUserAIChat(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"success": true})
 }
 
+// recordUserAudit records an admin action on a user account. The username is
+// used as the resource name when known, the id otherwise.
+func recordUserAudit(c *gin.Context, action string, userID uint, username string, err error) {
+	entry := audit.FromRequest(c, action)
+	entry.ResourceType = "users"
+	entry.ResourceName = username
+	if entry.ResourceName == "" {
+		entry.ResourceName = strconv.FormatUint(uint64(userID), 10)
+	}
+	entry.Payload = map[string]interface{}{"userId": userID}
+	audit.Emit(entry.WithError(err))
+}
+
 func UpdateSidebarPreference(c *gin.Context) {
 	user := c.MustGet("user").(model.User)
 	var req struct {
@@ -9,10 +9,12 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
 	toolscache "k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
@@ -51,16 +53,30 @@ type K8sClient struct {
 type ClientOptions struct {
 	Config       *rest.Config
 	DisableCache bool
+	// Impersonate makes every request act as the given user and groups.
+	// The informer cache would serve objects the user may not read, so
+	// impersonating clients always read from the API server.
+	Impersonate rest.ImpersonationConfig
+	// Mapper reuses the REST mapping of an existing client instead of
+	// running discovery again
+	Mapper meta.RESTMapper
 }
 
 // NewClient creates a K8sClient from ClientOptions
 func NewClient(opts ClientOptions) (*K8sClient, error) {
-	clientset, err := kubernetes.NewForConfig(opts.Config)
+	config := opts.Config
+	if opts.Impersonate.UserName != "" {
+		config = rest.CopyConfig(opts.Config)
+		config.Impersonate = opts.Impersonate
+		opts.DisableCache = true
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
 	if err != nil {
 		return nil, err
 	}
 
-	metricsClient, err := metricsclient.NewForConfig(opts.Config)
+	metricsClient, err := metricsclient.NewForConfig(config)
 	if err != nil {
 		klog.Warningf("failed to create metrics client: %v", err)
 	}
@@ -71,8 +87,9 @@ func NewClient(opts ClientOptions) (*K8sClient, error) {
 	disableCache := opts.DisableCache || os.Getenv("DISABLE_CACHE") == "true"
 
 	if disableCache {
-		c, err = client.New(opts.Config, client.Options{
+		c, err = client.New(config, client.Options{
 			Scheme: runtimeScheme,
+			Mapper: opts.Mapper,
 		})
 		if err != nil {
 			cancel()
@@ -121,12 +138,19 @@ func NewClient(opts ClientOptions) (*K8sClient, error) {
 	return &K8sClient{
 		Client:        c,
 		ClientSet:     clientset,
-		Configuration: opts.Config,
+		Configuration: config,
 		MetricsClient: metricsClient,
 		cancel:        cancel,
 	}, nil
 }
 
+// Events returns the events API of a namespace. Events are listed from the
+// API server with field selectors, as the informer cache would have to watch
+// the events of the whole cluster.
+func (c *K8sClient) Events(namespace string) typedcorev1.EventInterface {
+	return c.ClientSet.CoreV1().Events(namespace)
+}
+
 func (c *K8sClient) Stop(name string) {
 	klog.Infof("Stopping K8s client for %s", name)
 	c.cancel()
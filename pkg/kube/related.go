@@ -0,0 +1,98 @@
+package kube
+
+import (
+	"strings"
+
+	"github.com/pixelvide/kube-sentinel/pkg/common"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	gatewayapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+// IngressServices returns the services an ingress routes to
+func IngressServices(namespace string, ingress *networkingv1.Ingress) []common.RelatedResource {
+	seen := make(map[string]struct{})
+	var relatedServices []common.RelatedResource
+	addService := func(svcName string) {
+		if _, exist := seen[svcName]; exist {
+			return
+		}
+		seen[svcName] = struct{}{}
+		relatedServices = append(relatedServices, common.RelatedResource{
+			Type:      "services",
+			Namespace: namespace,
+			Name:      svcName,
+		})
+	}
+
+	for _, rule := range ingress.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+
+		for _, path := range rule.HTTP.Paths {
+			if path.Backend.Service == nil {
+				continue
+			}
+			addService(path.Backend.Service.Name)
+		}
+	}
+	if ingress.Spec.DefaultBackend != nil && ingress.Spec.DefaultBackend.Service != nil {
+		if _, exist := seen[ingress.Spec.DefaultBackend.Service.Name]; !exist {
+			addService(ingress.Spec.DefaultBackend.Service.Name)
+		}
+	}
+
+	return relatedServices
+}
+
+// HTTPRouteRelatedResources returns the parents and backends of an HTTPRoute.
+// References without a namespace resolve to the route's namespace.
+func HTTPRouteRelatedResources(res *gatewayapiv1.HTTPRoute, namespace string) []common.RelatedResource {
+	var result []common.RelatedResource
+	for _, parentRef := range res.Spec.ParentRefs {
+		var parentResourceType string
+		if parentRef.Kind != nil && *parentRef.Kind != "" {
+			parentResourceType = strings.ToLower(string(*parentRef.Kind)) + "s"
+		} else {
+			parentResourceType = "gateways"
+		}
+		result = append(result, common.RelatedResource{
+			Type: parentResourceType,
+			Name: string(parentRef.Name),
+			Namespace: func() string {
+				if parentRef.Namespace != nil && *parentRef.Namespace != "" {
+					return string(*parentRef.Namespace)
+				}
+				return namespace
+			}(),
+			APIVersion: gatewayapiv1.GroupVersion.String(),
+		})
+	}
+
+	for _, rule := range res.Spec.Rules {
+		for _, backend := range rule.BackendRefs {
+			var backendType, apiVersion string
+			if backend.Kind != nil && *backend.Kind != "" {
+				backendType = strings.ToLower(string(*backend.Kind)) + "s"
+			} else {
+				backendType = "services"
+			}
+			if backendType == "services" {
+				apiVersion = corev1.SchemeGroupVersion.String()
+			}
+			result = append(result, common.RelatedResource{
+				Type: backendType,
+				Name: string(backend.Name),
+				Namespace: func() string {
+					if backend.Namespace != nil && *backend.Namespace != "" {
+						return string(*backend.Namespace)
+					}
+					return namespace
+				}(),
+				APIVersion: apiVersion,
+			})
+		}
+	}
+	return result
+}
@@ -0,0 +1,71 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/pixelvide/kube-sentinel/pkg/cluster"
+	"github.com/pixelvide/kube-sentinel/pkg/common"
+	"github.com/pixelvide/kube-sentinel/pkg/model"
+	"github.com/pixelvide/kube-sentinel/pkg/rbac"
+	"k8s.io/klog/v2"
+)
+
+type userKey struct{}
+
+// WithUser returns a copy of ctx carrying the user MCP tools run as
+func WithUser(ctx context.Context, user model.User) context.Context {
+	return context.WithValue(ctx, userKey{}, user)
+}
+
+func userFromContext(ctx context.Context) (model.User, bool) {
+	user, ok := ctx.Value(userKey{}).(model.User)
+	return user, ok
+}
+
+// UserContext passes the authenticated user of the request on to the MCP
+// tool handlers, which only see the request context
+func UserContext() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := c.MustGet("user").(model.User)
+		c.Request = c.Request.WithContext(WithUser(c.Request.Context(), user))
+		c.Next()
+	}
+}
+
+// authorize checks the caller's kube-sentinel RBAC for a tool call the same
+// way RBACMiddleware does and returns the cluster on success. When the call
+// is not allowed the returned tool result explains why.
+func (m *MCPServer) authorize(ctx context.Context, clusterName, resource string, verb common.Verb, namespace string) (*cluster.ClientSet, *mcp.CallToolResult) {
+	user, ok := userFromContext(ctx)
+	if !ok {
+		return nil, mcp.NewToolResultError("Permission denied: no authenticated user")
+	}
+	if err := checkAccess(user, resource, verb, clusterName, namespace); err != nil {
+		klog.Infof("MCP tool call denied for user %s: %v", user.Key(), err)
+		return nil, mcp.NewToolResultError(fmt.Sprintf("Permission denied: %v", err))
+	}
+	cs, err := m.cm.GetCluster(clusterName)
+	if err != nil {
+		return nil, mcp.NewToolResultError(fmt.Sprintf("Error: cluster %s not found", clusterName))
+	}
+	cs, err = cs.ForUser(user)
+	if err != nil {
+		return nil, mcp.NewToolResultError(fmt.Sprintf("Error: %v", err))
+	}
+	return cs, nil
+}
+
+// checkAccess checks a resource of a tool call, which may name a
+// subresource such as "pods/log", against the RBAC of the user
+func checkAccess(user model.User, resource string, verb common.Verb, clusterName, namespace string) error {
+	resource, subresource, _ := strings.Cut(resource, "/")
+	resource = strings.ToLower(getGVR(resource).Resource)
+	if subresource != "" {
+		resource += "/" + subresource
+	}
+	return rbac.CheckAccess(user, resource, string(verb), clusterName, namespace)
+}
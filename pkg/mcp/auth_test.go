@@ -0,0 +1,23 @@
+package mcp
+
+import (
+	"testing"
+
+	"github.com/pixelvide/kube-sentinel/pkg/common"
+	"github.com/pixelvide/kube-sentinel/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckAccessPodLogs(t *testing.T) {
+	viewer := common.Role{Name: "viewer", Clusters: []string{"*"}, Namespaces: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"}}
+	noLogs := common.Role{Name: "no-logs", Effect: common.EffectDeny, Clusters: []string{"*"}, Namespaces: []string{"*"}, Resources: []string{"pods/log"}, Verbs: []string{"*"}}
+	logsOnly := common.Role{Name: "logs-only", Clusters: []string{"*"}, Namespaces: []string{"dev"}, Resources: []string{"pods/log"}, Verbs: []string{"log"}}
+
+	denied := model.User{Username: "dev", Roles: []common.Role{viewer, noLogs}}
+	assert.Error(t, checkAccess(denied, "pods/log", common.VerbLog, "prod", "dev"), "a deny role on pods/log blocks the logs")
+	assert.NoError(t, checkAccess(denied, "pods", common.VerbGet, "prod", "dev"))
+
+	allowed := model.User{Username: "dev", Roles: []common.Role{logsOnly}}
+	assert.NoError(t, checkAccess(allowed, "pods/log", common.VerbLog, "prod", "dev"))
+	assert.Error(t, checkAccess(allowed, "pods/log", common.VerbLog, "prod", "prod"))
+}
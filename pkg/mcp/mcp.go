@@ -6,6 +6,7 @@ import (
 
 	"github.com/mark3labs/mcp-go/server"
 	"github.com/pixelvide/kube-sentinel/pkg/cluster"
+	"github.com/pixelvide/kube-sentinel/pkg/model"
 	"k8s.io/klog/v2"
 )
 
@@ -30,10 +31,11 @@ func NewMCPServer(cm *cluster.ClusterManager) *MCPServer {
 	return m
 }
 
-func (m *MCPServer) ServeStdio() error {
+// ServeStdio serves MCP on stdio, running every tool call as user
+func (m *MCPServer) ServeStdio(user model.User) error {
 	klog.Info("Starting MCP server on stdio")
 	stdio := server.NewStdioServer(m.server)
-	return stdio.Listen(context.Background(), nil, nil)
+	return stdio.Listen(WithUser(context.Background(), user), nil, nil)
 }
 
 func (m *MCPServer) SSEHandler(baseURL string) http.Handler {
@@ -8,6 +8,8 @@ import (
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/pixelvide/kube-sentinel/pkg/analyzer"
+	"github.com/pixelvide/kube-sentinel/pkg/common"
+	"github.com/pixelvide/kube-sentinel/pkg/rbac"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -59,7 +61,16 @@ func (m *MCPServer) registerTools() {
 }
 
 func (m *MCPServer) handleListClusters(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	clusters := m.cm.GetActiveClusters()
+	user, ok := userFromContext(ctx)
+	if !ok {
+		return mcp.NewToolResultError("Permission denied: no authenticated user"), nil
+	}
+	clusters := []string{}
+	for _, name := range m.cm.GetActiveClusters() {
+		if rbac.CanAccessCluster(user, name) {
+			clusters = append(clusters, name)
+		}
+	}
 	data, err := json.Marshal(clusters)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal clusters: %w", err)
@@ -72,9 +83,9 @@ func (m *MCPServer) handleListResources(ctx context.Context, request mcp.CallToo
 	resourceType, _ := request.RequireString("resource")
 	namespace := request.GetString("namespace", "")
 
-	cs, err := m.cm.GetCluster(clusterName)
-	if err != nil {
-		return mcp.NewToolResultText(fmt.Sprintf("Error: cluster %s not found", clusterName)), err
+	cs, denied := m.authorize(ctx, clusterName, resourceType, common.VerbGet, namespace)
+	if denied != nil {
+		return denied, nil
 	}
 
 	gvr := getGVR(resourceType)
@@ -112,9 +123,9 @@ func (m *MCPServer) handleGetResourceYAML(ctx context.Context, request mcp.CallT
 	namespace, _ := request.RequireString("namespace")
 	name, _ := request.RequireString("name")
 
-	cs, err := m.cm.GetCluster(clusterName)
-	if err != nil {
-		return mcp.NewToolResultText(fmt.Sprintf("Error: cluster %s not found", clusterName)), err
+	cs, denied := m.authorize(ctx, clusterName, resourceType, common.VerbGet, namespace)
+	if denied != nil {
+		return denied, nil
 	}
 
 	gvr := getGVR(resourceType)
@@ -125,7 +136,7 @@ func (m *MCPServer) handleGetResourceYAML(ctx context.Context, request mcp.CallT
 		Kind:    guessKind(resourceType),
 	})
 
-	err = cs.K8sClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, obj)
+	err := cs.K8sClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, obj)
 	if err != nil {
 		return mcp.NewToolResultText(fmt.Sprintf("Error fetching resource: %v", err)), nil
 	}
@@ -143,9 +154,9 @@ func (m *MCPServer) handleGetPodLogs(ctx context.Context, request mcp.CallToolRe
 	name, _ := request.RequireString("name")
 	tailLines := int64(request.GetInt("tailLines", 100))
 
-	cs, err := m.cm.GetCluster(clusterName)
-	if err != nil {
-		return mcp.NewToolResultText(fmt.Sprintf("Error: cluster %s not found", clusterName)), err
+	cs, denied := m.authorize(ctx, clusterName, "pods/log", common.VerbLog, namespace)
+	if denied != nil {
+		return denied, nil
 	}
 
 	opts := &corev1.PodLogOptions{
@@ -167,9 +178,9 @@ func (m *MCPServer) handleRunSecurityScan(ctx context.Context, request mcp.CallT
 	namespace, _ := request.RequireString("namespace")
 	name, _ := request.RequireString("name")
 
-	cs, err := m.cm.GetCluster(clusterName)
-	if err != nil {
-		return mcp.NewToolResultText(fmt.Sprintf("Error: cluster %s not found", clusterName)), err
+	cs, denied := m.authorize(ctx, clusterName, resourceType, common.VerbGet, namespace)
+	if denied != nil {
+		return denied, nil
 	}
 
 	gvr := getGVR(resourceType)
@@ -180,12 +191,12 @@ func (m *MCPServer) handleRunSecurityScan(ctx context.Context, request mcp.CallT
 		Kind:    guessKind(resourceType),
 	})
 
-	err = cs.K8sClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, obj)
+	err := cs.K8sClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, obj)
 	if err != nil {
 		return mcp.NewToolResultText(fmt.Sprintf("Error fetching resource: %v", err)), nil
 	}
 
-	results := analyzer.Analyze(ctx, cs.K8sClient, obj)
+	results := analyzer.Analyze(ctx, cs.Name, cs.K8sClient, obj)
 	data, err := json.MarshalIndent(results, "", "  ")
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal security results: %w", err)
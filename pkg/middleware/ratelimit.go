@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pixelvide/kube-sentinel/pkg/audit"
+	"github.com/pixelvide/kube-sentinel/pkg/common"
+	"golang.org/x/time/rate"
+	"k8s.io/klog/v2"
+)
+
+// rateLimiterIdleTTL is how long the limiter of a client IP is kept after
+// its last request
+const rateLimiterIdleTTL = 10 * time.Minute
+
+type clientLimiter struct {
+	limiter   *rate.Limiter
+	lastSeen  time.Time
+	throttled bool
+}
+
+// ipRateLimiter keeps a token bucket per client IP
+type ipRateLimiter struct {
+	mu          sync.Mutex
+	limit       rate.Limit
+	burst       int
+	clients     map[string]*clientLimiter
+	lastCleanup time.Time
+}
+
+func newIPRateLimiter(limit rate.Limit, burst int) *ipRateLimiter {
+	return &ipRateLimiter{
+		limit:   limit,
+		burst:   burst,
+		clients: make(map[string]*clientLimiter),
+	}
+}
+
+// allow reports whether a request of ip is allowed at now, and whether a
+// rejected request is the first one since the client was last allowed
+func (l *ipRateLimiter) allow(ip string, now time.Time) (allowed, firstRejection bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastCleanup) > time.Minute {
+		for k, c := range l.clients {
+			if now.Sub(c.lastSeen) > rateLimiterIdleTTL {
+				delete(l.clients, k)
+			}
+		}
+		l.lastCleanup = now
+	}
+
+	c, ok := l.clients[ip]
+	if !ok {
+		c = &clientLimiter{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.clients[ip] = c
+	}
+	c.lastSeen = now
+	if c.limiter.AllowN(now, 1) {
+		c.throttled = false
+		return true, false
+	}
+	firstRejection = !c.throttled
+	c.throttled = true
+	return false, firstRejection
+}
+
+// LoginRateLimit limits login attempts to common.LoginRateLimit a minute per
+// client IP. The first rejected attempt of a client is written to the audit
+// log, later ones until it is allowed again are only rejected.
+func LoginRateLimit() gin.HandlerFunc {
+	perMinute := common.LoginRateLimit
+	if perMinute <= 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+	limiter := newIPRateLimiter(rate.Every(time.Minute/time.Duration(perMinute)), perMinute)
+	retryAfter := strconv.Itoa(int(math.Ceil(60 / float64(perMinute))))
+
+	return func(c *gin.Context) {
+		allowed, firstRejection := limiter.allow(c.ClientIP(), time.Now())
+		if allowed {
+			c.Next()
+			return
+		}
+		if firstRejection {
+			klog.Warningf("Rate limiting login attempts from %s", c.ClientIP())
+			entry := audit.FromRequest(c, "login_rate_limited")
+			entry.ResourceType = "users"
+			audit.Emit(entry.WithError(errors.New("too many login attempts")))
+		}
+		c.Header("Retry-After", retryAfter)
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many login attempts, try again later"})
+		c.Abort()
+	}
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pixelvide/kube-sentinel/pkg/common"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/time/rate"
+)
+
+func TestIPRateLimiter(t *testing.T) {
+	l := newIPRateLimiter(rate.Every(time.Minute/2), 2)
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		allowed, _ := l.allow("10.0.0.1", now)
+		assert.True(t, allowed)
+	}
+	allowed, first := l.allow("10.0.0.1", now)
+	assert.False(t, allowed)
+	assert.True(t, first)
+	allowed, first = l.allow("10.0.0.1", now)
+	assert.False(t, allowed)
+	assert.False(t, first, "only the first rejection is reported")
+
+	// Other clients have their own bucket
+	allowed, _ = l.allow("10.0.0.2", now)
+	assert.True(t, allowed)
+
+	// Tokens refill over time
+	allowed, _ = l.allow("10.0.0.1", now.Add(30*time.Second))
+	assert.True(t, allowed)
+	_, first = l.allow("10.0.0.1", now.Add(30*time.Second))
+	assert.True(t, first, "a client throttled again after being allowed is reported again")
+
+	// Idle clients are dropped
+	l.allow("10.0.0.3", now.Add(time.Hour))
+	assert.Len(t, l.clients, 1)
+}
+
+func TestLoginRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	original := common.LoginRateLimit
+	defer func() { common.LoginRateLimit = original }()
+
+	common.LoginRateLimit = 1
+	r := gin.New()
+	r.POST("/login", LoginRateLimit(), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/login", nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/login", nil))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+}
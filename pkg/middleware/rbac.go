@@ -1,7 +1,11 @@
 package middleware
 
 import (
+	"bytes"
+	"encoding/json"
+	"io"
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -9,6 +13,7 @@ import (
 	"github.com/pixelvide/kube-sentinel/pkg/common"
 	"github.com/pixelvide/kube-sentinel/pkg/model"
 	"github.com/pixelvide/kube-sentinel/pkg/rbac"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func RBACMiddleware() gin.HandlerFunc {
@@ -30,16 +35,83 @@ func RBACMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		canAccess := rbac.CanAccess(user, resource, verbs, cs.Name, ns)
+		name, subresource := url2object(c.Request.URL.Path, resource)
+		req := rbac.AccessRequest{
+			Cluster:     cs.Name,
+			Namespace:   ns,
+			Resource:    resource,
+			Subresource: subresource,
+			Verb:        verbs,
+			Name:        name,
+		}
+
+		var canAccess bool
+		switch {
+		case name != "":
+			canAccess = rbac.AuthorizeObject(c.Request.Context(), cs.K8sClient, user, req)
+		case c.Request.Method == http.MethodPost:
+			req.Name, req.Labels = createdObject(c)
+			canAccess = rbac.Authorize(user, req)
+		default:
+			// Lists are filtered per object by the resource handlers
+			canAccess = rbac.AuthorizeCollection(user, req)
+		}
 		if canAccess {
 			c.Next()
 		} else {
+			if subresource != "" {
+				resource += "/" + subresource
+			}
 			c.AbortWithStatusJSON(http.StatusForbidden,
 				gin.H{"error": rbac.NoAccess(user.Key(), verbs, resource, ns, cs.Name)})
 		}
 	}
 }
 
+// url2object returns the object name and subresource of a resource URL.
+// Only subresources listed in common.Subresources are returned.
+//
+// - /api/v1/pods/default => "", ""
+// - /api/v1/pods/default/web/files => web, files
+// - /api/v1/nodes/_all/node-1/drain => node-1, drain
+// - /api/v1/pods/default/web/describe => web, ""
+func url2object(url, resource string) (name, subresource string) {
+	parts := strings.Split(url, "/")
+	if len(parts) < 6 {
+		return
+	}
+	name = parts[5]
+	if name == "watch" && len(parts) == 6 {
+		return "", ""
+	}
+	if len(parts) > 6 && slices.Contains(common.Subresources[resource], parts[6]) {
+		subresource = parts[6]
+	}
+	return
+}
+
+// createdObject reads the name and labels of the object in a create request
+// body and leaves the body in place for the handler
+func createdObject(c *gin.Context) (string, map[string]string) {
+	if c.Request.Body == nil {
+		return "", nil
+	}
+	body, err := io.ReadAll(c.Request.Body)
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return "", nil
+	}
+	var obj metav1.PartialObjectMetadata
+	if err := json.Unmarshal(body, &obj); err != nil || obj.Name == "" {
+		return "", nil
+	}
+	labels := obj.Labels
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	return obj.Name, labels
+}
+
 func method2verb(method string) string {
 	switch method {
 	case http.MethodPost:
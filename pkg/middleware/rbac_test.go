@@ -59,3 +59,30 @@ func TestUrl2NamespaceResource(t *testing.T) {
 		})
 	}
 }
+
+func TestUrl2Object(t *testing.T) {
+	testCases := []struct {
+		url             string
+		resource        string
+		wantName        string
+		wantSubresource string
+	}{
+		{"/api/v1/pods/default", "pods", "", ""},
+		{"/api/v1/pods/default/watch", "pods", "", ""},
+		{"/api/v1/pods/default/web", "pods", "web", ""},
+		{"/api/v1/pods/default/web/files", "pods", "web", "files"},
+		{"/api/v1/pods/default/web/describe", "pods", "web", ""},
+		{"/api/v1/nodes/_all/node-1/drain", "nodes", "node-1", "drain"},
+		{"/api/v1/deployments/default/web/files", "deployments", "web", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.url, func(t *testing.T) {
+			gotName, gotSubresource := url2object(tc.url, tc.resource)
+			if gotName != tc.wantName || gotSubresource != tc.wantSubresource {
+				t.Errorf("url2object(%q) = (%q, %q), want (%q, %q)",
+					tc.url, gotName, gotSubresource, tc.wantName, tc.wantSubresource)
+			}
+		})
+	}
+}
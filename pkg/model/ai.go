@@ -10,8 +10,10 @@ import (
 type AIProviderProfile struct {
 	Model
 	Name              string       `json:"name"`
-	Provider          string       `json:"provider"` // "gemini", "openai", "azure", "custom"
+	Provider          string       `json:"provider"` // "gemini", "openai", "anthropic", "azure", "custom"
 	BaseURL           string       `json:"baseUrl"`
+	APIVersion        string       `json:"apiVersion"`    // Azure OpenAI api-version, empty for the default
+	ContextWindow     int          `json:"contextWindow"` // Tokens the models accept, 0 to look up by model
 	DefaultModel      string       `json:"defaultModel"`
 	APIKey            SecretString `json:"apiKey" gorm:"type:text"` // Global key for this profile
 	IsSystem          bool         `json:"isSystem"`
@@ -39,13 +41,18 @@ func (AISettings) TableName() string {
 }
 
 type AIChatSession struct {
-	ID        string          `json:"id" gorm:"primaryKey"` // UUID
-	UserID    uint            `json:"userID" gorm:"index"`
-	Title     string          `json:"title"`
-	CreatedAt time.Time       `json:"createdAt"`
-	UpdatedAt time.Time       `json:"updatedAt"`
-	DeletedAt gorm.DeletedAt  `json:"deletedAt" gorm:"index"`
-	Messages  []AIChatMessage `json:"messages" gorm:"foreignKey:SessionID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	ID            string          `json:"id" gorm:"primaryKey"` // UUID
+	UserID        uint            `json:"userID" gorm:"index"`
+	Title         string          `json:"title"`
+	ContextTokens int             `json:"contextTokens"` // Estimated tokens of the history sent to the model at the last message
+	ContextWindow int             `json:"contextWindow"` // Tokens the model of the last message accepts
+	CreatedAt     time.Time       `json:"createdAt"`
+	UpdatedAt     time.Time       `json:"updatedAt"`
+	DeletedAt     gorm.DeletedAt  `json:"deletedAt" gorm:"index"`
+	Messages      []AIChatMessage `json:"messages" gorm:"foreignKey:SessionID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	// PendingApprovals are the tool calls waiting for the user, only loaded
+	// by GetAIChatSession
+	PendingApprovals []AIToolApproval `json:"pendingApprovals,omitempty" gorm:"foreignKey:SessionID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
 }
 
 func (AIChatSession) TableName() string {
@@ -53,17 +60,50 @@ func (AIChatSession) TableName() string {
 }
 
 type AIChatMessage struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	SessionID string         `json:"sessionID" gorm:"index"`
-	Role      string         `json:"role"`                                 // "system", "user", "assistant", "tool"
-	Content   string         `json:"content"`                              // Text content
-	ToolCalls string         `json:"toolCalls,omitempty" gorm:"type:text"` // JSON encoded tool calls
-	ToolID    string         `json:"toolID,omitempty"`                     // For tool messages
-	CreatedAt time.Time      `json:"createdAt"`
-	DeletedAt gorm.DeletedAt `json:"deletedAt" gorm:"index"`
+	ID               uint           `json:"id" gorm:"primaryKey"`
+	SessionID        string         `json:"sessionID" gorm:"index"`
+	Role             string         `json:"role"`                                 // "system", "user", "assistant", "tool"
+	Content          string         `json:"content"`                              // Text content
+	ToolCalls        string         `json:"toolCalls,omitempty" gorm:"type:text"` // JSON encoded tool calls
+	ToolID           string         `json:"toolID,omitempty"`                     // For tool messages
+	Summary          bool           `json:"summary,omitempty"`                    // Summary of the summarized messages before it
+	Summarized       bool           `json:"summarized,omitempty"`                 // Replaced by a summary in the history sent to the model
+	PromptTokens     int            `json:"promptTokens,omitempty"`               // Tokens of the request that produced an assistant message
+	CompletionTokens int            `json:"completionTokens,omitempty"`           // Tokens of an assistant message
+	CreatedAt        time.Time      `json:"createdAt"`
+	DeletedAt        gorm.DeletedAt `json:"deletedAt" gorm:"index"`
 }
 
 func (AIChatMessage) TableName() string {
 	return common.GetAppTableName("ai_chat_messages")
 }
+
+const (
+	AIToolApprovalPending  = "pending"
+	AIToolApprovalApproved = "approved"
+	AIToolApprovalRejected = "rejected"
+	AIToolApprovalExpired  = "expired"
+)
+
+// AIToolApproval is a call of a tool that changes resources, which the user
+// of the chat session has to approve before it runs. It is stored while it
+// waits, so the decision can be made after the chat reconnects.
+type AIToolApproval struct {
+	Model
+	SessionID   string     `json:"sessionID" gorm:"index"`
+	MessageID   uint       `json:"messageID" gorm:"index"` // Assistant message with the tool call
+	ToolCallID  string     `json:"toolCallID" gorm:"index"`
+	ToolName    string     `json:"toolName"`
+	Arguments   string     `json:"arguments" gorm:"type:text"`
+	Preview     string     `json:"preview" gorm:"type:text"`
+	ClusterName string     `json:"clusterName"`                          // Cluster the call is made against
+	Status      string     `json:"status" gorm:"type:varchar(20);index"` // "pending", "approved", "rejected", "expired"
+	ApproverID  *uint      `json:"approverID,omitempty"`
+	DecidedAt   *time.Time `json:"decidedAt,omitempty"`
+}
+
+func (AIToolApproval) TableName() string {
+	return common.GetAppTableName("ai_tool_approvals")
+}
+
 // Force PR update
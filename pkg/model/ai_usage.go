@@ -0,0 +1,244 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pixelvide/kube-sentinel/pkg/common"
+	"gorm.io/gorm"
+)
+
+const (
+	AIBudgetScopeUser    = "user"
+	AIBudgetScopeProfile = "profile"
+
+	AIBudgetPeriodDay   = "day"
+	AIBudgetPeriodMonth = "month"
+)
+
+var ErrAIBudgetExceeded = errors.New("AI token budget exceeded")
+
+// AIUsage is the token usage of a single call to an AI provider
+type AIUsage struct {
+	Model
+	UserID           uint    `json:"userId" gorm:"index"`
+	ProfileID        uint    `json:"profileId" gorm:"index"`
+	SessionID        string  `json:"sessionId" gorm:"index"`
+	MessageID        uint    `json:"messageId,omitempty"` // Assistant message the call answered with, 0 for titles and summaries
+	ModelName        string  `json:"model" gorm:"column:model"`
+	PromptTokens     int     `json:"promptTokens"`
+	CompletionTokens int     `json:"completionTokens"`
+	TotalTokens      int     `json:"totalTokens"`
+	Cost             float64 `json:"cost"` // Priced with the model price at the time of the call
+}
+
+func (AIUsage) TableName() string {
+	return common.GetAppTableName("ai_usages")
+}
+
+// AIModelPrice is the price of a model in the currency of the provider
+// bill, per million tokens
+type AIModelPrice struct {
+	Model
+	ModelName       string  `json:"model" gorm:"column:model;type:varchar(255);uniqueIndex;not null"`
+	PromptPrice     float64 `json:"promptPrice"`
+	CompletionPrice float64 `json:"completionPrice"`
+}
+
+func (AIModelPrice) TableName() string {
+	return common.GetAppTableName("ai_model_prices")
+}
+
+// AIBudget limits the tokens a user, or all users of a profile together,
+// may use per day or month. A user budget with ScopeID 0 is the default for
+// users without a budget of their own.
+type AIBudget struct {
+	Model
+	Scope      string `json:"scope" gorm:"type:varchar(20);uniqueIndex:idx_ai_budget_scope;not null"` // "user" or "profile"
+	ScopeID    uint   `json:"scopeId" gorm:"uniqueIndex:idx_ai_budget_scope"`
+	Period     string `json:"period" gorm:"type:varchar(20);uniqueIndex:idx_ai_budget_scope;not null"` // "day" or "month"
+	TokenLimit int64  `json:"tokenLimit"`
+}
+
+func (AIBudget) TableName() string {
+	return common.GetAppTableName("ai_budgets")
+}
+
+// Validate checks the scope and period of a budget
+func (b *AIBudget) Validate() error {
+	switch b.Scope {
+	case AIBudgetScopeUser:
+	case AIBudgetScopeProfile:
+		if b.ScopeID == 0 {
+			return errors.New("a profile budget requires a profile")
+		}
+	default:
+		return fmt.Errorf("invalid budget scope %q", b.Scope)
+	}
+	if b.Period != AIBudgetPeriodDay && b.Period != AIBudgetPeriodMonth {
+		return fmt.Errorf("invalid budget period %q", b.Period)
+	}
+	if b.TokenLimit <= 0 {
+		return errors.New("the token limit must be positive")
+	}
+	return nil
+}
+
+// AIBudgetPeriodStart returns the UTC start of the budget period that
+// contains now
+func AIBudgetPeriodStart(period string, now time.Time) time.Time {
+	now = now.UTC()
+	if period == AIBudgetPeriodMonth {
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func aiBudgetPeriodEnd(period string, start time.Time) time.Time {
+	if period == AIBudgetPeriodMonth {
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// RecordAIUsage stores the usage of a call and prices it with the current
+// price of its model
+func RecordAIUsage(usage *AIUsage) error {
+	var price AIModelPrice
+	err := DB.Where("model = ?", usage.ModelName).First(&price).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	usage.Cost = (float64(usage.PromptTokens)*price.PromptPrice + float64(usage.CompletionTokens)*price.CompletionPrice) / 1e6
+	return DB.Create(usage).Error
+}
+
+// aiTokensSince sums the tokens used in a scope since start
+func aiTokensSince(scope string, scopeID uint, start time.Time) (int64, error) {
+	column := "user_id"
+	if scope == AIBudgetScopeProfile {
+		column = "profile_id"
+	}
+	var total int64
+	err := DB.Model(&AIUsage{}).
+		Where(column+" = ? AND created_at >= ?", scopeID, start).
+		Select("COALESCE(SUM(total_tokens), 0)").
+		Scan(&total).Error
+	return total, err
+}
+
+// userAIBudgets returns the budgets of a user per period, the budgets of
+// the user itself take precedence over the defaults
+func userAIBudgets(userID uint) ([]AIBudget, error) {
+	var budgets []AIBudget
+	if err := DB.Where("scope = ? AND scope_id IN ?", AIBudgetScopeUser, []uint{0, userID}).
+		Order("scope_id DESC").Find(&budgets).Error; err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var effective []AIBudget
+	for _, b := range budgets {
+		if seen[b.Period] {
+			continue
+		}
+		seen[b.Period] = true
+		effective = append(effective, b)
+	}
+	return effective, nil
+}
+
+// CheckAIBudgets returns an error wrapping ErrAIBudgetExceeded when the
+// user or the profile has used up one of its budgets
+func CheckAIBudgets(userID, profileID uint, now time.Time) error {
+	budgets, err := userAIBudgets(userID)
+	if err != nil {
+		return err
+	}
+	var profileBudgets []AIBudget
+	if profileID != 0 {
+		if err := DB.Where("scope = ? AND scope_id = ?", AIBudgetScopeProfile, profileID).Find(&profileBudgets).Error; err != nil {
+			return err
+		}
+	}
+	budgets = append(budgets, profileBudgets...)
+
+	for _, b := range budgets {
+		scopeID := userID
+		if b.Scope == AIBudgetScopeProfile {
+			scopeID = profileID
+		}
+		start := AIBudgetPeriodStart(b.Period, now)
+		used, err := aiTokensSince(b.Scope, scopeID, start)
+		if err != nil {
+			return err
+		}
+		if used < b.TokenLimit {
+			continue
+		}
+
+		period := "daily"
+		if b.Period == AIBudgetPeriodMonth {
+			period = "monthly"
+		}
+		owner := "your account"
+		if b.Scope == AIBudgetScopeProfile {
+			owner = "this AI provider profile"
+		}
+		return fmt.Errorf("%w: the %s limit of %d tokens for %s is used up (%d used), it resets at %s",
+			ErrAIBudgetExceeded, period, b.TokenLimit, owner, used,
+			aiBudgetPeriodEnd(b.Period, start).Format("2006-01-02 15:04 MST"))
+	}
+	return nil
+}
+
+// AIUsageSummary is the usage of one group in a usage report
+type AIUsageSummary struct {
+	Key              string  `json:"key" gorm:"column:group_key"`
+	Label            string  `json:"label"`
+	Requests         int64   `json:"requests"`
+	PromptTokens     int64   `json:"promptTokens"`
+	CompletionTokens int64   `json:"completionTokens"`
+	TotalTokens      int64   `json:"totalTokens"`
+	Cost             float64 `json:"cost"`
+}
+
+// aiUsageGroupColumns are the columns a usage report can be grouped by
+var aiUsageGroupColumns = map[string]string{
+	"user":    "user_id",
+	"profile": "profile_id",
+	"model":   "model",
+	"session": "session_id",
+	"day":     "DATE(created_at)",
+}
+
+// GetAIUsageReport sums the usage between from and to, grouped by user,
+// profile, model, session or day
+func GetAIUsageReport(from, to time.Time, groupBy string) ([]AIUsageSummary, error) {
+	column, ok := aiUsageGroupColumns[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("invalid group %q", groupBy)
+	}
+
+	rows := []AIUsageSummary{}
+	err := DB.Model(&AIUsage{}).
+		Select(column+" AS group_key, COUNT(*) AS requests, "+
+			"SUM(prompt_tokens) AS prompt_tokens, SUM(completion_tokens) AS completion_tokens, "+
+			"SUM(total_tokens) AS total_tokens, SUM(cost) AS cost").
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Group(column).
+		Order("total_tokens DESC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	if groupBy == "day" {
+		// Postgres returns dates as timestamps
+		for i := range rows {
+			if len(rows[i].Key) > 10 {
+				rows[i].Key = rows[i].Key[:10]
+			}
+		}
+	}
+	return rows, nil
+}
@@ -0,0 +1,102 @@
+package model
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func setupAIUsageTestDB(t *testing.T) {
+	var err error
+	DB, err = gorm.Open(sqlite.Open("file:ai_usage?mode=memory&cache=shared"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, DB.AutoMigrate(&AIUsage{}, &AIModelPrice{}, &AIBudget{}))
+	DB.Exec("DELETE FROM ai_usages")
+	DB.Exec("DELETE FROM ai_model_prices")
+	DB.Exec("DELETE FROM ai_budgets")
+}
+
+func TestRecordAIUsage(t *testing.T) {
+	setupAIUsageTestDB(t)
+	require.NoError(t, DB.Create(&AIModelPrice{ModelName: "gpt-4o", PromptPrice: 2.5, CompletionPrice: 10}).Error)
+
+	priced := &AIUsage{UserID: 1, ModelName: "gpt-4o", PromptTokens: 200000, CompletionTokens: 10000, TotalTokens: 210000}
+	require.NoError(t, RecordAIUsage(priced))
+	assert.InDelta(t, 0.6, priced.Cost, 1e-9)
+
+	unpriced := &AIUsage{UserID: 1, ModelName: "llama3.1", PromptTokens: 1000, TotalTokens: 1000}
+	require.NoError(t, RecordAIUsage(unpriced))
+	assert.Zero(t, unpriced.Cost)
+}
+
+func TestCheckAIBudgets(t *testing.T) {
+	setupAIUsageTestDB(t)
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	record := func(userID, profileID uint, tokens int, at time.Time) {
+		usage := AIUsage{UserID: userID, ProfileID: profileID, TotalTokens: tokens}
+		usage.CreatedAt = at
+		require.NoError(t, DB.Create(&usage).Error)
+	}
+	record(1, 1, 900, now.Add(-time.Hour))
+	record(1, 1, 5000, now.AddDate(0, 0, -2))
+	record(2, 1, 3000, now.Add(-time.Hour))
+
+	require.NoError(t, CheckAIBudgets(1, 1, now))
+
+	// The default budget applies to users without a budget of their own
+	require.NoError(t, DB.Create(&AIBudget{Scope: AIBudgetScopeUser, Period: AIBudgetPeriodDay, TokenLimit: 1000}).Error)
+	require.NoError(t, DB.Create(&AIBudget{Scope: AIBudgetScopeUser, ScopeID: 2, Period: AIBudgetPeriodDay, TokenLimit: 10000}).Error)
+	require.NoError(t, CheckAIBudgets(1, 1, now))
+	require.NoError(t, CheckAIBudgets(2, 1, now))
+	record(1, 1, 100, now.Add(-time.Minute))
+	err := CheckAIBudgets(1, 1, now)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrAIBudgetExceeded))
+	assert.Contains(t, err.Error(), "daily limit of 1000 tokens for your account is used up (1000 used)")
+	assert.Contains(t, err.Error(), "resets at 2026-03-16 00:00 UTC")
+
+	// The profile budget counts the usage of all users, over the month
+	require.NoError(t, DB.Create(&AIBudget{Scope: AIBudgetScopeProfile, ScopeID: 1, Period: AIBudgetPeriodMonth, TokenLimit: 9000}).Error)
+	err = CheckAIBudgets(2, 1, now)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "monthly limit of 9000 tokens for this AI provider profile is used up (9000 used)")
+	assert.Contains(t, err.Error(), "resets at 2026-04-01 00:00 UTC")
+	require.NoError(t, CheckAIBudgets(2, 2, now))
+}
+
+func TestGetAIUsageReport(t *testing.T) {
+	setupAIUsageTestDB(t)
+	day := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	for _, u := range []AIUsage{
+		{UserID: 1, ModelName: "gpt-4o", PromptTokens: 100, CompletionTokens: 50, TotalTokens: 150, Cost: 0.5},
+		{UserID: 1, ModelName: "gpt-4o", PromptTokens: 200, CompletionTokens: 20, TotalTokens: 220, Cost: 1},
+		{UserID: 2, ModelName: "claude-sonnet-4-5", PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+	} {
+		u.CreatedAt = day
+		require.NoError(t, DB.Create(&u).Error)
+	}
+	old := AIUsage{UserID: 1, ModelName: "gpt-4o", TotalTokens: 1000}
+	old.CreatedAt = day.AddDate(0, -1, 0)
+	require.NoError(t, DB.Create(&old).Error)
+
+	from, to := day.AddDate(0, 0, -1), day.AddDate(0, 0, 1)
+	rows, err := GetAIUsageReport(from, to, "user")
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, AIUsageSummary{Key: "1", Requests: 2, PromptTokens: 300, CompletionTokens: 70, TotalTokens: 370, Cost: 1.5}, rows[0])
+	assert.Equal(t, "2", rows[1].Key)
+
+	rows, err = GetAIUsageReport(from, to, "day")
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, "2026-03-15", rows[0].Key)
+	assert.Equal(t, int64(385), rows[0].TotalTokens)
+
+	_, err = GetAIUsageReport(from, to, "cluster")
+	assert.Error(t, err)
+}
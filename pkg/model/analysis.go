@@ -0,0 +1,198 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"github.com/pixelvide/kube-sentinel/pkg/common"
+	"gorm.io/gorm"
+)
+
+// AnalysisFinding is an anomaly reported by the background analyzer scan.
+// A finding is identified by cluster, resource and rule; it stays open while
+// scans keep reporting it and is resolved once a scan no longer does.
+type AnalysisFinding struct {
+	Model
+	ClusterName  string     `json:"clusterName" gorm:"type:varchar(100);not null;uniqueIndex:idx_analysis_finding_key,priority:1"`
+	Kind         string     `json:"kind" gorm:"type:varchar(100);not null;uniqueIndex:idx_analysis_finding_key,priority:2"`
+	ResourceType string     `json:"resourceType" gorm:"type:varchar(100)"`
+	Namespace    string     `json:"namespace" gorm:"type:varchar(255);index;uniqueIndex:idx_analysis_finding_key,priority:3"`
+	Name         string     `json:"name" gorm:"type:varchar(255);not null;uniqueIndex:idx_analysis_finding_key,priority:4"`
+	RuleID       string     `json:"ruleId" gorm:"type:varchar(50);index;not null;uniqueIndex:idx_analysis_finding_key,priority:5"`
+	Severity     string     `json:"severity" gorm:"type:varchar(20);index"`
+	Title        string     `json:"title" gorm:"type:varchar(255)"`
+	Message      string     `json:"message" gorm:"type:text"`
+	Remediation  string     `json:"remediation,omitempty" gorm:"type:text"`
+	DocURL       string     `json:"docUrl,omitempty" gorm:"type:varchar(255)"`
+	FirstSeen    time.Time  `json:"firstSeen"`
+	LastSeen     time.Time  `json:"lastSeen" gorm:"index"`
+	ResolvedAt   *time.Time `json:"resolvedAt,omitempty" gorm:"index"`
+
+	// Suppressed findings are kept but reported separately from open ones
+	Suppressed        bool   `json:"suppressed" gorm:"index"`
+	SuppressionReason string `json:"suppressionReason,omitempty" gorm:"type:text"`
+}
+
+func (AnalysisFinding) TableName() string {
+	return common.GetAppTableName("k8s_analysis_findings")
+}
+
+// CategoryScores holds the analyzer sub-score of every scoring category
+type CategoryScores struct {
+	SecurityScore    int `json:"securityScore"`
+	ReliabilityScore int `json:"reliabilityScore"`
+	ResourcesScore   int `json:"resourcesScore"`
+	TopologyScore    int `json:"topologyScore"`
+	HygieneScore     int `json:"hygieneScore"`
+}
+
+// AnalysisScan records the outcome of one cluster scan, used for trends
+type AnalysisScan struct {
+	Model
+	ClusterName string    `json:"clusterName" gorm:"type:varchar(100);index;not null"`
+	ScannedAt   time.Time `json:"scannedAt" gorm:"index"`
+	DurationMs  int64     `json:"durationMs"`
+	Resources   int       `json:"resources"`
+	Score       int       `json:"score"`
+	Critical    int       `json:"critical"`
+	High        int       `json:"high"`
+	Medium      int       `json:"medium"`
+	Low         int       `json:"low"`
+	Info        int       `json:"info"`
+	Suppressed  int       `json:"suppressed"`
+	Error       string    `json:"error,omitempty" gorm:"type:text"`
+	CategoryScores
+}
+
+func (AnalysisScan) TableName() string {
+	return common.GetAppTableName("k8s_analysis_scans")
+}
+
+// AnalysisNamespaceScore is the score of a namespace in the latest scan
+type AnalysisNamespaceScore struct {
+	Model
+	ClusterName string    `json:"clusterName" gorm:"type:varchar(100);not null;uniqueIndex:idx_analysis_namespace_score,priority:1"`
+	Namespace   string    `json:"namespace" gorm:"type:varchar(255);not null;uniqueIndex:idx_analysis_namespace_score,priority:2"`
+	ScannedAt   time.Time `json:"scannedAt"`
+	Resources   int       `json:"resources"`
+	Score       int       `json:"score" gorm:"index"`
+	CategoryScores
+}
+
+func (AnalysisNamespaceScore) TableName() string {
+	return common.GetAppTableName("k8s_analysis_namespace_scores")
+}
+
+// SaveNamespaceScores replaces the namespace scores of a cluster with the
+// ones of a completed scan
+func SaveNamespaceScores(clusterName string, scores []AnalysisNamespaceScore) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("cluster_name = ?", clusterName).Delete(&AnalysisNamespaceScore{}).Error; err != nil {
+			return err
+		}
+		for i := range scores {
+			scores[i].ClusterName = clusterName
+		}
+		if len(scores) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(scores, 100).Error
+	})
+}
+
+// LatestAnalysisScan returns the latest successful scan of a cluster
+func LatestAnalysisScan(clusterName string) (*AnalysisScan, error) {
+	var scan AnalysisScan
+	if err := DB.Where("cluster_name = ? AND (error = ? OR error IS NULL)", clusterName, "").
+		Order("scanned_at DESC").First(&scan).Error; err != nil {
+		return nil, err
+	}
+	return &scan, nil
+}
+
+// ListNamespaceScores returns the namespace scores of a cluster, lowest first
+func ListNamespaceScores(clusterName string) ([]AnalysisNamespaceScore, error) {
+	var scores []AnalysisNamespaceScore
+	err := DB.Where("cluster_name = ?", clusterName).Order("score ASC, namespace ASC").Find(&scores).Error
+	return scores, err
+}
+
+// AnalysisSuppression silences an analyzer rule for the resources it scopes.
+// Empty scope fields match any value, and the suppression stops applying
+// once it expires.
+type AnalysisSuppression struct {
+	Model
+	RuleID        string    `json:"ruleId" gorm:"type:varchar(50);index;not null"`
+	ClusterName   string    `json:"clusterName" gorm:"type:varchar(100);index"`
+	Namespace     string    `json:"namespace" gorm:"type:varchar(255)"`
+	Kind          string    `json:"kind" gorm:"type:varchar(100)"`
+	Name          string    `json:"name" gorm:"type:varchar(255)"`
+	LabelSelector string    `json:"labelSelector" gorm:"type:varchar(255)"`
+	Reason        string    `json:"reason" gorm:"type:text;not null"`
+	ExpiresAt     time.Time `json:"expiresAt" gorm:"index"`
+	CreatedBy     uint      `json:"createdBy" gorm:"index"`
+	CreatedByName string    `json:"createdByName" gorm:"type:varchar(255)"`
+}
+
+func (AnalysisSuppression) TableName() string {
+	return common.GetAppTableName("k8s_analysis_suppressions")
+}
+
+// ActiveSuppressions returns the suppressions that have not expired yet
+func ActiveSuppressions(now time.Time) ([]AnalysisSuppression, error) {
+	var suppressions []AnalysisSuppression
+	err := DB.Where("expires_at > ?", now).Order("id").Find(&suppressions).Error
+	return suppressions, err
+}
+
+// SaveScanFindings stores the findings of a completed scan. Findings seen
+// before keep their FirstSeen and get LastSeen bumped, new ones are created,
+// and open findings of the cluster that were not reported are resolved.
+// Only findings of the listed kinds are resolved, so a kind the scan failed
+// to list keeps its findings open.
+func SaveScanFindings(clusterName string, findings []AnalysisFinding, listedKinds []string, scannedAt time.Time) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		for _, f := range findings {
+			var existing AnalysisFinding
+			err := tx.Where("cluster_name = ? AND kind = ? AND namespace = ? AND name = ? AND rule_id = ?",
+				clusterName, f.Kind, f.Namespace, f.Name, f.RuleID).First(&existing).Error
+			switch {
+			case err == nil:
+				updates := map[string]interface{}{
+					"severity":      f.Severity,
+					"title":         f.Title,
+					"message":       f.Message,
+					"remediation":   f.Remediation,
+					"doc_url":       f.DocURL,
+					"resource_type": f.ResourceType,
+					"last_seen":     scannedAt,
+					"resolved_at":   nil,
+					// Suppressions are applied on every scan, so findings
+					// follow suppressions that are added, expire or get deleted
+					"suppressed":         f.Suppressed,
+					"suppression_reason": f.SuppressionReason,
+				}
+				if err := tx.Model(&existing).Updates(updates).Error; err != nil {
+					return err
+				}
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				f.ClusterName = clusterName
+				f.FirstSeen = scannedAt
+				f.LastSeen = scannedAt
+				f.ResolvedAt = nil
+				if err := tx.Create(&f).Error; err != nil {
+					return err
+				}
+			default:
+				return err
+			}
+		}
+
+		if len(listedKinds) == 0 {
+			return nil
+		}
+		return tx.Model(&AnalysisFinding{}).
+			Where("cluster_name = ? AND kind IN ? AND resolved_at IS NULL AND last_seen < ?", clusterName, listedKinds, scannedAt).
+			Update("resolved_at", scannedAt).Error
+	})
+}
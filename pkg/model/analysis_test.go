@@ -0,0 +1,125 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// scannedKinds are the kinds the test scans listed
+var scannedKinds = []string{"Deployment", "Pod"}
+
+func TestSaveScanFindings(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:analysis_findings?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect database: %v", err)
+	}
+	DB = db
+	if err := DB.AutoMigrate(&AnalysisFinding{}); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+
+	finding := func(name, rule string) AnalysisFinding {
+		return AnalysisFinding{Kind: "Deployment", Namespace: "default", Name: name, RuleID: rule, Severity: "high"}
+	}
+	load := func(name, rule string) AnalysisFinding {
+		var f AnalysisFinding
+		assert.NoError(t, DB.Where("cluster_name = ? AND name = ? AND rule_id = ?", "prod", name, rule).First(&f).Error)
+		return f
+	}
+
+	t1 := time.Now().Add(-2 * time.Hour).Truncate(time.Second)
+	assert.NoError(t, SaveScanFindings("prod", []AnalysisFinding{finding("web", "SEC-001"), finding("api", "REL-001")}, scannedKinds, t1))
+
+	t2 := t1.Add(time.Hour)
+	assert.NoError(t, SaveScanFindings("prod", []AnalysisFinding{finding("web", "SEC-001")}, scannedKinds, t2))
+
+	web := load("web", "SEC-001")
+	assert.True(t, web.FirstSeen.Equal(t1))
+	assert.True(t, web.LastSeen.Equal(t2))
+	assert.Nil(t, web.ResolvedAt)
+
+	api := load("api", "REL-001")
+	if assert.NotNil(t, api.ResolvedAt) {
+		assert.True(t, api.ResolvedAt.Equal(t2))
+	}
+
+	// A resolved finding that shows up again is reopened
+	t3 := t2.Add(time.Hour)
+	assert.NoError(t, SaveScanFindings("prod", []AnalysisFinding{finding("web", "SEC-001"), finding("api", "REL-001")}, scannedKinds, t3))
+	api = load("api", "REL-001")
+	assert.Nil(t, api.ResolvedAt)
+	assert.True(t, api.FirstSeen.Equal(t1))
+
+	var count int64
+	DB.Model(&AnalysisFinding{}).Count(&count)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestSaveScanFindingsSuppression(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:analysis_suppressed?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect database: %v", err)
+	}
+	DB = db
+	if err := DB.AutoMigrate(&AnalysisFinding{}); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+
+	web := AnalysisFinding{Kind: "Deployment", Namespace: "default", Name: "web", RuleID: "SEC-001", Severity: "high"}
+	load := func() AnalysisFinding {
+		var f AnalysisFinding
+		assert.NoError(t, DB.Where("cluster_name = ? AND name = ?", "prod", "web").First(&f).Error)
+		return f
+	}
+
+	t1 := time.Now().Add(-3 * time.Hour).Truncate(time.Second)
+	assert.NoError(t, SaveScanFindings("prod", []AnalysisFinding{web}, scannedKinds, t1))
+	assert.False(t, load().Suppressed)
+
+	// A suppression added after the finding was stored applies on the next scan
+	suppressed := web
+	suppressed.Suppressed = true
+	suppressed.SuppressionReason = "accepted risk"
+	assert.NoError(t, SaveScanFindings("prod", []AnalysisFinding{suppressed}, scannedKinds, t1.Add(time.Hour)))
+	f := load()
+	assert.True(t, f.Suppressed)
+	assert.Equal(t, "accepted risk", f.SuppressionReason)
+
+	// Once the suppression expires or is deleted, the finding is open again
+	assert.NoError(t, SaveScanFindings("prod", []AnalysisFinding{web}, scannedKinds, t1.Add(2*time.Hour)))
+	f = load()
+	assert.False(t, f.Suppressed)
+	assert.Empty(t, f.SuppressionReason)
+}
+
+func TestSaveScanFindingsPartialScan(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:analysis_partial?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect database: %v", err)
+	}
+	DB = db
+	if err := DB.AutoMigrate(&AnalysisFinding{}); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+
+	web := AnalysisFinding{Kind: "Deployment", Namespace: "default", Name: "web", RuleID: "SEC-001", Severity: "high"}
+	debug := AnalysisFinding{Kind: "Pod", Namespace: "default", Name: "debug", RuleID: "SEC-002", Severity: "high"}
+	load := func(name string) AnalysisFinding {
+		var f AnalysisFinding
+		assert.NoError(t, DB.Where("cluster_name = ? AND name = ?", "prod", name).First(&f).Error)
+		return f
+	}
+
+	t1 := time.Now().Add(-2 * time.Hour).Truncate(time.Second)
+	assert.NoError(t, SaveScanFindings("prod", []AnalysisFinding{web, debug}, scannedKinds, t1))
+
+	// Listing pods failed, so the pod finding is not resolved
+	t2 := t1.Add(time.Hour)
+	assert.NoError(t, SaveScanFindings("prod", nil, []string{"Deployment"}, t2))
+	assert.NotNil(t, load("web").ResolvedAt)
+	assert.Nil(t, load("debug").ResolvedAt)
+}
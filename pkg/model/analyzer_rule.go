@@ -0,0 +1,26 @@
+package model
+
+import "github.com/pixelvide/kube-sentinel/pkg/common"
+
+// AnalyzerRule is a custom analyzer check written as CEL expressions.
+// Match selects the objects the rule applies to (empty matches everything),
+// and Expression must evaluate to true for the object to pass.
+type AnalyzerRule struct {
+	Model
+	RuleID      string      `json:"ruleId" gorm:"type:varchar(50);uniqueIndex;not null"`
+	Title       string      `json:"title" gorm:"type:varchar(255);not null"`
+	Description string      `json:"description" gorm:"type:text"`
+	Severity    string      `json:"severity" gorm:"type:varchar(20);not null"`
+	Category    string      `json:"category" gorm:"type:varchar(20)"`
+	Kinds       SliceString `json:"kinds" gorm:"type:text"`
+	Match       string      `json:"match" gorm:"type:text"`
+	Expression  string      `json:"expression" gorm:"type:text;not null"`
+	Message     string      `json:"message" gorm:"type:text"`
+	Remediation string      `json:"remediation" gorm:"type:text"`
+	DocURL      string      `json:"docUrl" gorm:"type:varchar(255)"`
+	Enabled     bool        `json:"enabled" gorm:"type:boolean;default:true"`
+}
+
+func (AnalyzerRule) TableName() string {
+	return common.GetAppTableName("analyzer_rules")
+}
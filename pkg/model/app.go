@@ -53,6 +53,9 @@ const (
 	AIAllowUserKeys      = "AI_ALLOW_USER_KEYS"
 	AIForceUserKeys      = "AI_FORCE_USER_KEYS"
 	AIAllowUserOverride  = "AI_ALLOW_USER_OVERRIDE"
+	// RequireAdminTwoFactorKey makes two-factor authentication mandatory
+	// for password users with the admin role
+	RequireAdminTwoFactorKey = "REQUIRE_ADMIN_2FA"
 )
 
 var (
@@ -174,6 +177,27 @@ func IsLocalLoginEnabled() bool {
 	return config.Value == "true"
 }
 
+// IsAdminTwoFactorRequired reports whether password users with the admin
+// role must use two-factor authentication
+func IsAdminTwoFactorRequired() bool {
+	var appID uint
+	if CurrentApp != nil {
+		appID = CurrentApp.ID
+	} else {
+		app, err := GetApp(common.AppName)
+		if err != nil {
+			return false
+		}
+		appID = app.ID
+	}
+
+	config, err := GetAppConfig(appID, RequireAdminTwoFactorKey)
+	if err != nil {
+		return false
+	}
+	return config.Value == "true"
+}
+
 func IsAIAllowUserOverrideEnabled() bool {
 	var appID uint
 	if CurrentApp != nil {
@@ -1,6 +1,25 @@
 package model
 
-import "github.com/pixelvide/kube-sentinel/pkg/common"
+import (
+	"encoding/json"
+
+	"github.com/pixelvide/kube-sentinel/pkg/common"
+	"gorm.io/gorm"
+	"k8s.io/klog/v2"
+)
+
+// Audit log sources identify which entry point performed an action
+const (
+	AuditSourceUI     = "ui"
+	AuditSourceAI     = "ai"
+	AuditSourceAPIKey = "api-key"
+	AuditSourceMCP    = "mcp"
+	AuditSourceSCIM   = "scim"
+
+	// AuditSourceContextKey is the gin context key holding the audit source of
+	// the current request. Requests without it are attributed to the UI.
+	AuditSourceContextKey = "auditSource"
+)
 
 type AuditLog struct {
 	Model
@@ -13,6 +32,13 @@ type AuditLog struct {
 	Success      bool   `json:"success"`
 	ErrorMessage string `json:"errorMessage" gorm:"type:text"`
 
+	ClusterName   string `json:"clusterName" gorm:"type:varchar(255);index;index:idx_audit_logs_resource,priority:1"`
+	ResourceType  string `json:"resourceType" gorm:"type:varchar(100);index;index:idx_audit_logs_resource,priority:2"`
+	ResourceName  string `json:"resourceName" gorm:"type:varchar(255);index;index:idx_audit_logs_resource,priority:3"`
+	Namespace     string `json:"namespace" gorm:"type:varchar(255);index"`
+	Source        string `json:"source" gorm:"type:varchar(20);index"`
+	ChatSessionID string `json:"chatSessionId" gorm:"type:varchar(64);index"`
+
 	// Relationships
 	App   *App  `json:"app" gorm:"foreignKey:AppID;constraint:OnDelete:CASCADE"`
 	Actor *User `json:"actor" gorm:"foreignKey:ActorID;constraint:OnDelete:CASCADE"`
@@ -21,3 +47,53 @@ type AuditLog struct {
 func (AuditLog) TableName() string {
 	return common.GetAppTableName("audit_logs")
 }
+
+// ResolveAuditSource returns the given source, defaulting to the UI
+func ResolveAuditSource(source string) string {
+	if source == "" {
+		return AuditSourceUI
+	}
+	return source
+}
+
+// auditPayloadColumns are the payload keys that were promoted to columns
+type auditPayloadColumns struct {
+	ClusterName   string `json:"clusterName"`
+	ResourceType  string `json:"resourceType"`
+	ResourceName  string `json:"resourceName"`
+	Namespace     string `json:"namespace"`
+	Source        string `json:"source"`
+	ChatSessionID string `json:"chatSessionId"`
+}
+
+// BackfillAuditLogColumns populates the structured columns of audit logs
+// written before they existed, using the JSON payload. Rows are marked by
+// setting Source, so the backfill only touches each row once.
+func BackfillAuditLogColumns() error {
+	var logs []AuditLog
+	result := DB.Select("id", "payload").
+		Where("source = ? OR source IS NULL", "").
+		FindInBatches(&logs, 500, func(tx *gorm.DB, batch int) error {
+			for _, l := range logs {
+				var p auditPayloadColumns
+				if l.Payload != "" {
+					if err := json.Unmarshal([]byte(l.Payload), &p); err != nil {
+						klog.Warningf("Failed to parse payload of audit log %d: %v", l.ID, err)
+					}
+				}
+				updates := map[string]interface{}{
+					"cluster_name":    p.ClusterName,
+					"resource_type":   p.ResourceType,
+					"resource_name":   p.ResourceName,
+					"namespace":       p.Namespace,
+					"source":          ResolveAuditSource(p.Source),
+					"chat_session_id": p.ChatSessionID,
+				}
+				if err := DB.Model(&AuditLog{}).Where("id = ?", l.ID).UpdateColumns(updates).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	return result.Error
+}
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestBackfillAuditLogColumns(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:audit_backfill?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect database: %v", err)
+	}
+	DB = db
+	if err := DB.AutoMigrate(&AuditLog{}); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+
+	legacy := []AuditLog{
+		{Action: "update", Payload: `{"clusterName":"prod","resourceType":"pods","resourceName":"web","namespace":"default"}`},
+		{Action: "scale", Payload: `{"clusterName":"prod","resourceType":"deployments","resourceName":"api","namespace":"apps","source":"ai","chatSessionId":"s-1"}`},
+		{Action: "login", Payload: ""},
+	}
+	assert.NoError(t, DB.Create(&legacy).Error)
+
+	assert.NoError(t, BackfillAuditLogColumns())
+
+	var logs []AuditLog
+	assert.NoError(t, DB.Order("id").Find(&logs).Error)
+	assert.Len(t, logs, 3)
+
+	assert.Equal(t, "prod", logs[0].ClusterName)
+	assert.Equal(t, "pods", logs[0].ResourceType)
+	assert.Equal(t, "web", logs[0].ResourceName)
+	assert.Equal(t, "default", logs[0].Namespace)
+	assert.Equal(t, AuditSourceUI, logs[0].Source)
+
+	assert.Equal(t, AuditSourceAI, logs[1].Source)
+	assert.Equal(t, "s-1", logs[1].ChatSessionID)
+
+	assert.Equal(t, AuditSourceUI, logs[2].Source)
+	assert.Empty(t, logs[2].ClusterName)
+
+	// Already backfilled rows are left untouched
+	assert.NoError(t, DB.Model(&logs[0]).UpdateColumn("cluster_name", "changed").Error)
+	assert.NoError(t, BackfillAuditLogColumns())
+	var reloaded AuditLog
+	assert.NoError(t, DB.First(&reloaded, logs[0].ID).Error)
+	assert.Equal(t, "changed", reloaded.ClusterName)
+}
@@ -12,6 +12,9 @@ type Cluster struct {
 	IsDefault      bool         `json:"is_default" gorm:"type:boolean;default:false"`
 	Enable         bool         `json:"enable" gorm:"type:boolean;default:true"`
 	SkipSystemSync bool         `json:"skip_system_sync" gorm:"type:boolean;default:false"`
+	// ImpersonateUsers makes requests on behalf of users impersonate them,
+	// so cluster RBAC applies and audit logs show the user
+	ImpersonateUsers bool `json:"impersonate_users" gorm:"type:boolean;default:false"`
 }
 
 func (Cluster) TableName() string {
@@ -0,0 +1,114 @@
+package model
+
+import (
+	"strings"
+
+	"github.com/pixelvide/kube-sentinel/pkg/common"
+)
+
+// LDAPProvider is an LDAP or Active Directory server users log in with
+// their directory username and password
+type LDAPProvider struct {
+	Model
+	AppID uint            `json:"appId" gorm:"not null;uniqueIndex:idx_ldap_app_name"`
+	Name  LowerCaseString `json:"name" gorm:"type:varchar(100);uniqueIndex:idx_ldap_app_name;not null"`
+	// URL of the server, ldap://host:389 or ldaps://host:636
+	URL                string `json:"url" gorm:"type:varchar(255);not null"`
+	StartTLS           bool   `json:"startTLS" gorm:"type:boolean"`
+	InsecureSkipVerify bool   `json:"insecureSkipVerify" gorm:"type:boolean"`
+
+	// BindDN and BindPassword are the service account users are searched
+	// with. Searches are anonymous when BindDN is empty.
+	BindDN       string       `json:"bindDN" gorm:"type:varchar(255)"`
+	BindPassword SecretString `json:"bindPassword" gorm:"type:text"`
+
+	// UserFilter finds the entry of a user, {username} is replaced with the
+	// escaped login name, e.g. (sAMAccountName={username}) for AD
+	UserSearchBase    string `json:"userSearchBase" gorm:"type:varchar(255);not null"`
+	UserFilter        string `json:"userFilter" gorm:"type:varchar(255)"`
+	UsernameAttribute string `json:"usernameAttribute" gorm:"type:varchar(100)"`
+	NameAttribute     string `json:"nameAttribute" gorm:"type:varchar(100)"`
+	EmailAttribute    string `json:"emailAttribute" gorm:"type:varchar(100)"`
+
+	// GroupFilter finds the groups of a user under GroupSearchBase, {dn} is
+	// replaced with the user DN and {username} with the login name. Without
+	// GroupSearchBase groups are read from the memberOf attribute of the user.
+	GroupSearchBase    string `json:"groupSearchBase" gorm:"type:varchar(255)"`
+	GroupFilter        string `json:"groupFilter" gorm:"type:varchar(255)"`
+	GroupNameAttribute string `json:"groupNameAttribute" gorm:"type:varchar(100)"`
+
+	Enabled bool `json:"enabled" gorm:"type:boolean;default:true"`
+
+	App App `json:"-" gorm:"foreignKey:AppID"`
+}
+
+func (LDAPProvider) TableName() string {
+	return common.GetCoreTableName("ldap_providers")
+}
+
+// GetAllLDAPProviders returns all LDAP providers from database
+func GetAllLDAPProviders() ([]LDAPProvider, error) {
+	var providers []LDAPProvider
+	err := DB.Where("app_id = ?", CurrentApp.ID).Find(&providers).Error
+	return providers, err
+}
+
+// GetEnabledLDAPProviders returns only enabled LDAP providers
+func GetEnabledLDAPProviders() ([]LDAPProvider, error) {
+	var providers []LDAPProvider
+	err := DB.Where("app_id = ? AND enabled = ?", CurrentApp.ID, true).Find(&providers).Error
+	return providers, err
+}
+
+// GetLDAPProviderByName returns an enabled LDAP provider by name
+func GetLDAPProviderByName(name string) (LDAPProvider, error) {
+	var provider LDAPProvider
+	name = strings.ToLower(name)
+	err := DB.Where("app_id = ? AND name = ? AND enabled = ?", CurrentApp.ID, name, true).First(&provider).Error
+	if err != nil {
+		return LDAPProvider{}, err
+	}
+	return provider, nil
+}
+
+// GetLDAPProviderByID returns an LDAP provider by ID
+func GetLDAPProviderByID(id uint) (LDAPProvider, error) {
+	var provider LDAPProvider
+	err := DB.Where("app_id = ?", CurrentApp.ID).First(&provider, id).Error
+	return provider, err
+}
+
+// IsLoginProviderNameTaken reports whether an OAuth or LDAP provider other
+// than the LDAP provider excludeLDAPID already uses the name. Users are
+// linked to their login by provider name, so names must be unique across
+// provider types.
+func IsLoginProviderNameTaken(name string, excludeLDAPID uint) (bool, error) {
+	name = strings.ToLower(name)
+	if name == "password" {
+		return true, nil
+	}
+	var count int64
+	if err := DB.Model(&OAuthProvider{}).Where("app_id = ? AND name = ?", CurrentApp.ID, name).
+		Count(&count).Error; err != nil || count > 0 {
+		return count > 0, err
+	}
+	err := DB.Model(&LDAPProvider{}).Where("app_id = ? AND name = ? AND id <> ?", CurrentApp.ID, name, excludeLDAPID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// CreateLDAPProvider creates a new LDAP provider
+func CreateLDAPProvider(provider *LDAPProvider) error {
+	provider.AppID = CurrentApp.ID
+	return DB.Create(provider).Error
+}
+
+// UpdateLDAPProvider updates an existing LDAP provider
+func UpdateLDAPProvider(provider *LDAPProvider, updates map[string]interface{}) error {
+	return DB.Model(provider).Where("app_id = ?", CurrentApp.ID).Updates(updates).Error
+}
+
+// DeleteLDAPProvider deletes an LDAP provider by ID
+func DeleteLDAPProvider(id uint) error {
+	return DB.Where("app_id = ?", CurrentApp.ID).Delete(&LDAPProvider{}, id).Error
+}
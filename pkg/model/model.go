@@ -111,6 +111,10 @@ func InitDB() {
 
 		User{},
 		PersonalAccessToken{},
+		UserSession{},
+		UserTwoFactor{},
+		UserRecoveryCode{},
+		SigningKey{},
 		UserConfig{},
 		UserIdentity{},
 		AppUser{},
@@ -121,16 +125,32 @@ func InitDB() {
 		ClusterKnowledgeBase{},
 
 		OAuthProvider{},
+		LDAPProvider{},
 		Role{},
 		RoleAssignment{},
+		SCIMGroup{},
+		SCIMGroupMember{},
 		ResourceTemplate{},
 
 		AuditLog{},
 
+		AnalysisFinding{},
+		AnalysisScan{},
+		AnalysisSuppression{},
+		AnalysisNamespaceScore{},
+		AnalyzerRule{},
+
 		AIProviderProfile{},
 		AISettings{},
 		AIChatSession{},
 		AIChatMessage{},
+		AIToolApproval{},
+		AIUsage{},
+		AIModelPrice{},
+		AIBudget{},
+	}
+	if err := DB.SetupJoinTable(&SCIMGroup{}, "Members", &SCIMGroupMember{}); err != nil {
+		panic("failed to set up SCIM group members: " + err.Error())
 	}
 	for _, model := range models {
 		err = DB.AutoMigrate(model)
@@ -139,6 +159,10 @@ func InitDB() {
 		}
 	}
 
+	if err := BackfillAuditLogColumns(); err != nil {
+		klog.Errorf("Failed to backfill audit log columns: %v", err)
+	}
+
 	seedGitlabHosts()
 
 	// Try to get the default app
@@ -160,11 +184,12 @@ func InitDB() {
 
 	// Initialize default configs if missing
 	defaultConfigs := map[string]string{
-		DefaultUserAccessKey: "true",
-		LocalLoginEnabledKey: "true",
-		AIAllowUserKeys:      "true",
-		AIForceUserKeys:      "false",
-		AIAllowUserOverride:  "true",
+		DefaultUserAccessKey:     "true",
+		LocalLoginEnabledKey:     "true",
+		AIAllowUserKeys:          "true",
+		AIForceUserKeys:          "false",
+		AIAllowUserOverride:      "true",
+		RequireAdminTwoFactorKey: "false",
 	}
 	for key, value := range defaultConfigs {
 		if _, err := GetAppConfig(CurrentApp.ID, key); err != nil {
@@ -15,6 +15,11 @@ type Role struct {
 	Namespaces SliceString `json:"namespaces" gorm:"type:text"`
 	Verbs      SliceString `json:"verbs" gorm:"type:text"`
 
+	// Object constraints, see common.Role
+	ResourceNames SliceString `json:"resourceNames" gorm:"type:text"`
+	LabelSelector string      `json:"labelSelector" gorm:"type:text"`
+	Effect        string      `json:"effect" gorm:"type:varchar(10);not null;default:allow"`
+
 	Assignments []RoleAssignment `json:"assignments" gorm:"foreignKey:RoleID;constraint:OnDelete:CASCADE"`
 }
 
@@ -0,0 +1,127 @@
+package model
+
+import (
+	"slices"
+
+	"github.com/pixelvide/kube-sentinel/pkg/common"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"k8s.io/klog/v2"
+)
+
+// SCIMGroup is a group provisioned by an identity provider through SCIM.
+// Members get the roles assigned to a group of the same name, like groups
+// from an OIDC token, whether or not they logged in recently.
+type SCIMGroup struct {
+	Model
+	DisplayName string `json:"displayName" gorm:"type:varchar(255);uniqueIndex;not null"`
+	ExternalID  string `json:"externalId,omitempty" gorm:"type:varchar(255);index"`
+
+	Members []User `json:"members,omitempty" gorm:"many2many:scim_group_members;joinForeignKey:GroupID;joinReferences:UserID"`
+}
+
+func (SCIMGroup) TableName() string {
+	return common.GetAppTableName("scim_groups")
+}
+
+// SCIMGroupMember is a user in a SCIMGroup
+type SCIMGroupMember struct {
+	GroupID uint `json:"groupId" gorm:"primaryKey"`
+	UserID  uint `json:"userId" gorm:"primaryKey;index"`
+}
+
+func (SCIMGroupMember) TableName() string {
+	return common.GetAppTableName("scim_group_members")
+}
+
+// GetSCIMGroup returns a group with its members
+func GetSCIMGroup(id uint) (*SCIMGroup, error) {
+	var group SCIMGroup
+	if err := DB.Preload("Members").First(&group, id).Error; err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+// ListSCIMGroups returns groups with their members, optionally only the one
+// with the given display name
+func ListSCIMGroups(displayName string, offset, limit int) (groups []SCIMGroup, total int64, err error) {
+	query := DB.Model(&SCIMGroup{})
+	if displayName != "" {
+		query = query.Where("display_name = ?", displayName)
+	}
+	if err = query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err = query.Preload("Members").Order("id").Offset(offset).Limit(limit).Find(&groups).Error
+	return groups, total, err
+}
+
+// SaveSCIMGroup creates or updates a group and replaces its members with
+// the users of the given IDs
+func SaveSCIMGroup(group *SCIMGroup, memberIDs []uint) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Members").Save(group).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("group_id = ?", group.ID).Delete(&SCIMGroupMember{}).Error; err != nil {
+			return err
+		}
+		return addSCIMGroupMembers(tx, group.ID, memberIDs)
+	})
+}
+
+func addSCIMGroupMembers(db *gorm.DB, groupID uint, userIDs []uint) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	members := make([]SCIMGroupMember, 0, len(userIDs))
+	for _, id := range userIDs {
+		members = append(members, SCIMGroupMember{GroupID: groupID, UserID: id})
+	}
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&members).Error
+}
+
+// DeleteSCIMGroup deletes a group and its memberships
+func DeleteSCIMGroup(id uint) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("group_id = ?", id).Delete(&SCIMGroupMember{}).Error; err != nil {
+			return err
+		}
+		res := tx.Delete(&SCIMGroup{}, id)
+		if res.Error == nil && res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return res.Error
+	})
+}
+
+// RemoveUserFromSCIMGroups removes a user from all groups
+func RemoveUserFromSCIMGroups(userID uint) error {
+	return DB.Where("user_id = ?", userID).Delete(&SCIMGroupMember{}).Error
+}
+
+// ListSCIMGroupsOfUser returns the groups a user is a member of
+func ListSCIMGroupsOfUser(userID uint) ([]SCIMGroup, error) {
+	var groups []SCIMGroup
+	err := DB.Where("id IN (?)", DB.Model(&SCIMGroupMember{}).Select("group_id").Where("user_id = ?", userID)).
+		Order("display_name").Find(&groups).Error
+	return groups, err
+}
+
+// WithSCIMGroups returns the groups of a login together with the SCIM
+// groups of the user. Errors are logged and leave the login groups as is.
+func WithSCIMGroups(userID uint, groups []string) SliceString {
+	scimGroups, err := ListSCIMGroupsOfUser(userID)
+	if err != nil {
+		klog.Errorf("Failed to load SCIM groups of user %d: %v", userID, err)
+		return groups
+	}
+	merged := slices.Clone(groups)
+	for _, group := range scimGroups {
+		if !slices.Contains(merged, group.DisplayName) {
+			merged = append(merged, group.DisplayName)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,171 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pixelvide/kube-sentinel/pkg/common"
+	"github.com/pixelvide/kube-sentinel/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// sessionTouchInterval limits how often the last seen time of a session is
+// written, so authenticated requests do not each cause a write
+const sessionTouchInterval = time.Minute
+
+// UserSession is a server-side login session. The session JWT in the
+// auth_token cookie carries SessionID as its jti claim, and a request is only
+// authenticated while its session exists, is not revoked and has not expired.
+type UserSession struct {
+	Model
+	SessionID  string     `json:"-" gorm:"type:varchar(64);uniqueIndex;not null"`
+	UserID     uint       `json:"userId" gorm:"not null;index"`
+	Provider   string     `json:"provider" gorm:"type:varchar(100)"`
+	Device     string     `json:"device" gorm:"type:varchar(100)"`
+	UserAgent  string     `json:"userAgent" gorm:"type:varchar(500)"`
+	IP         string     `json:"ip" gorm:"type:varchar(100)"`
+	LastSeenAt time.Time  `json:"lastSeenAt" gorm:"type:timestamp"`
+	ExpiresAt  time.Time  `json:"expiresAt" gorm:"type:timestamp;index"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty" gorm:"type:timestamp;index"`
+
+	// Current is set in listings for the session making the request
+	Current bool `json:"current" gorm:"-"`
+}
+
+func (UserSession) TableName() string {
+	return common.GetAppTableName("user_sessions")
+}
+
+// CreateUserSession starts a session for a user that lasts
+// common.SessionMaxAge, and drops the user's expired sessions
+func CreateUserSession(userID uint, provider, ip, userAgent string) (*UserSession, error) {
+	now := time.Now()
+	if err := DB.Where("user_id = ? AND expires_at < ?", userID, now).Delete(&UserSession{}).Error; err != nil {
+		return nil, err
+	}
+	if len(userAgent) > 500 {
+		userAgent = userAgent[:500]
+	}
+	session := &UserSession{
+		SessionID:  utils.RandomString(32),
+		UserID:     userID,
+		Provider:   provider,
+		Device:     DescribeUserAgent(userAgent),
+		UserAgent:  userAgent,
+		IP:         ip,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(common.SessionMaxAge),
+	}
+	if err := DB.Create(session).Error; err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// GetActiveUserSession returns the session with the given jti if it is
+// neither revoked nor expired
+func GetActiveUserSession(sessionID string) (*UserSession, error) {
+	var session UserSession
+	err := DB.Where("session_id = ? AND revoked_at IS NULL AND expires_at > ?", sessionID, time.Now()).
+		First(&session).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// TouchUserSession records activity on a session, at most once per minute
+func TouchUserSession(session *UserSession, ip string) error {
+	now := time.Now()
+	if now.Sub(session.LastSeenAt) < sessionTouchInterval && session.IP == ip {
+		return nil
+	}
+	session.LastSeenAt = now
+	session.IP = ip
+	return DB.Model(session).Updates(map[string]interface{}{
+		"last_seen_at": now,
+		"ip":           ip,
+	}).Error
+}
+
+// ListUserSessions returns the active sessions of a user, most recently
+// seen first
+func ListUserSessions(userID uint) ([]UserSession, error) {
+	var sessions []UserSession
+	err := DB.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at desc").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// RevokeUserSession revokes one session of a user. It returns
+// gorm.ErrRecordNotFound if the user has no such active session.
+func RevokeUserSession(userID, id uint) error {
+	res := DB.Model(&UserSession{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", time.Now())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// RevokeSessionByID revokes the session with the given jti, e.g. on logout
+func RevokeSessionByID(sessionID string) error {
+	return DB.Model(&UserSession{}).
+		Where("session_id = ? AND revoked_at IS NULL", sessionID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeUserSessions revokes every active session of a user and returns how
+// many were revoked
+func RevokeUserSessions(userID uint) (int64, error) {
+	res := DB.Model(&UserSession{}).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Update("revoked_at", time.Now())
+	return res.RowsAffected, res.Error
+}
+
+// DescribeUserAgent turns a User-Agent header into a short device label such
+// as "Chrome on macOS"
+func DescribeUserAgent(ua string) string {
+	if ua == "" {
+		return "Unknown device"
+	}
+	browser := "Unknown browser"
+	for _, b := range []struct{ token, name string }{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+		{"curl/", "curl"},
+	} {
+		if strings.Contains(ua, b.token) {
+			browser = b.name
+			break
+		}
+	}
+	platform := ""
+	for _, o := range []struct{ token, name string }{
+		{"iPhone", "iOS"},
+		{"iPad", "iPadOS"},
+		{"Android", "Android"},
+		{"Windows", "Windows"},
+		{"Mac OS X", "macOS"},
+		{"CrOS", "ChromeOS"},
+		{"Linux", "Linux"},
+	} {
+		if strings.Contains(ua, o.token) {
+			platform = o.name
+			break
+		}
+	}
+	if platform == "" {
+		return browser
+	}
+	return browser + " on " + platform
+}
@@ -0,0 +1,74 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func setupSessionTestDB(t *testing.T) *User {
+	var err error
+	DB, err = gorm.Open(sqlite.Open("file:sessions?mode=memory&cache=shared"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, DB.AutoMigrate(&User{}, &UserSession{}))
+	DB.Exec("DELETE FROM users")
+	DB.Exec("DELETE FROM user_sessions")
+
+	user := &User{Username: "alice", Provider: "password", Enabled: true}
+	require.NoError(t, DB.Create(user).Error)
+	return user
+}
+
+func TestUserSessionLifecycle(t *testing.T) {
+	user := setupSessionTestDB(t)
+
+	ua := "Mozilla/5.0 (Macintosh; Intel Mac OS X 14_0) AppleWebKit/537.36 Chrome/120.0 Safari/537.36"
+	first, err := CreateUserSession(user.ID, "password", "10.0.0.1", ua)
+	require.NoError(t, err)
+	assert.Equal(t, "Chrome on macOS", first.Device)
+	second, err := CreateUserSession(user.ID, "password", "10.0.0.2", "curl/8.0")
+	require.NoError(t, err)
+	assert.NotEqual(t, first.SessionID, second.SessionID)
+
+	sessions, err := ListUserSessions(user.ID)
+	require.NoError(t, err)
+	assert.Len(t, sessions, 2)
+
+	// Another user cannot revoke the session
+	assert.ErrorIs(t, RevokeUserSession(user.ID+1, first.ID), gorm.ErrRecordNotFound)
+	require.NoError(t, RevokeUserSession(user.ID, first.ID))
+	_, err = GetActiveUserSession(first.SessionID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	assert.ErrorIs(t, RevokeUserSession(user.ID, first.ID), gorm.ErrRecordNotFound)
+
+	// Expired sessions are not active
+	require.NoError(t, DB.Model(second).Update("expires_at", time.Now().Add(-time.Minute)).Error)
+	_, err = GetActiveUserSession(second.SessionID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	sessions, err = ListUserSessions(user.ID)
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+}
+
+func TestDisablingUserRevokesSessions(t *testing.T) {
+	user := setupSessionTestDB(t)
+
+	session, err := CreateUserSession(user.ID, "password", "10.0.0.1", "")
+	require.NoError(t, err)
+	_, err = GetActiveUserSession(session.SessionID)
+	require.NoError(t, err)
+
+	require.NoError(t, SetUserEnabled(user.ID, false))
+	_, err = GetActiveUserSession(session.SessionID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	session, err = CreateUserSession(user.ID, "password", "10.0.0.1", "")
+	require.NoError(t, err)
+	require.NoError(t, ResetPasswordByID(user.ID, "new-password"))
+	_, err = GetActiveUserSession(session.SessionID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
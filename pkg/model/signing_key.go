@@ -0,0 +1,45 @@
+package model
+
+import (
+	"time"
+
+	"github.com/pixelvide/kube-sentinel/pkg/common"
+)
+
+// SigningKey is an asymmetric key pair used to sign session JWTs. The newest
+// key that is not retired signs new tokens; every stored key still verifies
+// tokens, so rotation does not end existing sessions.
+type SigningKey struct {
+	Model
+	KID        string       `json:"kid" gorm:"type:varchar(64);uniqueIndex;not null"`
+	Algorithm  string       `json:"algorithm" gorm:"type:varchar(16);not null"`
+	PrivateKey SecretString `json:"-" gorm:"type:text;not null"`
+	PublicKey  string       `json:"publicKey" gorm:"type:text;not null"`
+	RetiredAt  *time.Time   `json:"retiredAt,omitempty" gorm:"type:timestamp;index"`
+}
+
+func (SigningKey) TableName() string {
+	return common.GetAppTableName("signing_keys")
+}
+
+// ListSigningKeys returns all stored signing keys, newest first
+func ListSigningKeys() ([]SigningKey, error) {
+	var keys []SigningKey
+	err := DB.Order("created_at desc, id desc").Find(&keys).Error
+	return keys, err
+}
+
+// CreateSigningKey stores a new key and retires the keys signing so far
+func CreateSigningKey(key *SigningKey) error {
+	if err := DB.Create(key).Error; err != nil {
+		return err
+	}
+	return DB.Model(&SigningKey{}).
+		Where("id <> ? AND retired_at IS NULL", key.ID).
+		Update("retired_at", time.Now()).Error
+}
+
+// DeleteSigningKeysRetiredBefore removes keys that were retired before t
+func DeleteSigningKeysRetiredBefore(t time.Time) error {
+	return DB.Where("retired_at IS NOT NULL AND retired_at < ?", t).Delete(&SigningKey{}).Error
+}
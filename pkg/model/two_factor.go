@@ -0,0 +1,243 @@
+package model
+
+import (
+	"crypto/rand"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/pixelvide/kube-sentinel/pkg/common"
+	"github.com/pixelvide/kube-sentinel/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// recoveryCodeCount is the number of recovery codes generated at a time
+const recoveryCodeCount = 10
+
+var (
+	ErrTwoFactorEnabled     = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotStarted  = errors.New("two-factor setup has not been started")
+	ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+)
+
+// UserTwoFactor is the TOTP second factor of a password user. The secret is
+// stored when setup starts, and the second factor is enabled once the user
+// confirms it with a code from their authenticator app.
+type UserTwoFactor struct {
+	Model
+	UserID  uint         `json:"userId" gorm:"uniqueIndex;not null"`
+	Secret  SecretString `json:"-" gorm:"type:text;not null"`
+	Enabled bool         `json:"enabled"`
+	// LastUsedStep is the TOTP time step of the last accepted code, so a
+	// code cannot be used twice
+	LastUsedStep int64 `json:"-"`
+}
+
+func (UserTwoFactor) TableName() string {
+	return common.GetAppTableName("user_two_factors")
+}
+
+// UserRecoveryCode is a one-time code that replaces a TOTP code when the
+// authenticator app is lost. Only its SHA-256 hash is stored.
+type UserRecoveryCode struct {
+	Model
+	UserID   uint       `json:"userId" gorm:"not null;index"`
+	CodeHash string     `json:"-" gorm:"type:varchar(64);not null"`
+	UsedAt   *time.Time `json:"usedAt,omitempty" gorm:"type:timestamp"`
+}
+
+func (UserRecoveryCode) TableName() string {
+	return common.GetAppTableName("user_recovery_codes")
+}
+
+// GetUserTwoFactor returns the second factor of a user, enabled or not
+func GetUserTwoFactor(userID uint) (*UserTwoFactor, error) {
+	var tf UserTwoFactor
+	if err := DB.Where("user_id = ?", userID).First(&tf).Error; err != nil {
+		return nil, err
+	}
+	return &tf, nil
+}
+
+// IsTwoFactorEnabled reports whether a user has confirmed a second factor
+func IsTwoFactorEnabled(userID uint) (bool, error) {
+	tf, err := GetUserTwoFactor(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return tf.Enabled, nil
+}
+
+// StartTwoFactorSetup stores a new TOTP secret for a user and returns it.
+// The second factor is not enforced until EnableTwoFactor confirms it.
+func StartTwoFactorSetup(userID uint) (string, error) {
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return "", err
+	}
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		var tf UserTwoFactor
+		err := tx.Where("user_id = ?", userID).First(&tf).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if tf.Enabled {
+			return ErrTwoFactorEnabled
+		}
+		tf.UserID = userID
+		tf.Secret = SecretString(secret)
+		tf.LastUsedStep = 0
+		return tx.Save(&tf).Error
+	})
+	return secret, err
+}
+
+// EnableTwoFactor confirms the pending secret of a user with a TOTP code,
+// enables the second factor and returns a fresh set of recovery codes
+func EnableTwoFactor(userID uint, code string) ([]string, error) {
+	tf, err := GetUserTwoFactor(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrTwoFactorNotStarted
+	}
+	if err != nil {
+		return nil, err
+	}
+	if tf.Enabled {
+		return nil, ErrTwoFactorEnabled
+	}
+	step, ok := utils.ValidateTOTP(string(tf.Secret), code, time.Now())
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	var codes []string
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(tf).Updates(map[string]interface{}{"enabled": true, "last_used_step": step}).Error; err != nil {
+			return err
+		}
+		codes, err = replaceRecoveryCodes(tx, userID)
+		return err
+	})
+	return codes, err
+}
+
+// VerifyTwoFactor checks a TOTP code or an unused recovery code of a user.
+// Recovery codes are consumed, and usedRecoveryCode tells which kind of
+// code was accepted.
+func VerifyTwoFactor(userID uint, code string) (usedRecoveryCode bool, err error) {
+	tf, err := GetUserTwoFactor(userID)
+	if err != nil {
+		return false, err
+	}
+	if !tf.Enabled {
+		return false, ErrTwoFactorNotStarted
+	}
+
+	code = strings.TrimSpace(code)
+	if len(strings.ReplaceAll(code, " ", "")) == utils.TOTPDigits {
+		step, ok := utils.ValidateTOTP(string(tf.Secret), code, time.Now())
+		if !ok {
+			return false, ErrInvalidTwoFactorCode
+		}
+		// Only the first request with a code of a step advances the step,
+		// which rejects replays of the same code
+		res := DB.Model(&UserTwoFactor{}).
+			Where("id = ? AND last_used_step < ?", tf.ID, step).
+			Update("last_used_step", step)
+		if res.Error != nil {
+			return false, res.Error
+		}
+		if res.RowsAffected == 0 {
+			return false, ErrInvalidTwoFactorCode
+		}
+		return false, nil
+	}
+
+	res := DB.Model(&UserRecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hashRecoveryCode(code)).
+		Update("used_at", time.Now())
+	if res.Error != nil {
+		return false, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return false, ErrInvalidTwoFactorCode
+	}
+	return true, nil
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of a user
+func RegenerateRecoveryCodes(userID uint) ([]string, error) {
+	var codes []string
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		codes, err = replaceRecoveryCodes(tx, userID)
+		return err
+	})
+	return codes, err
+}
+
+// CountRecoveryCodes returns the number of unused recovery codes of a user
+func CountRecoveryCodes(userID uint) (count int64, err error) {
+	err = DB.Model(&UserRecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
+
+// DisableTwoFactor removes the second factor and recovery codes of a user
+func DisableTwoFactor(userID uint) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&UserRecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&UserTwoFactor{}).Error
+	})
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&UserRecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+	codes := make([]string, 0, recoveryCodeCount)
+	rows := make([]UserRecoveryCode, 0, recoveryCodeCount)
+	for range recoveryCodeCount {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		rows = append(rows, UserRecoveryCode{UserID: userID, CodeHash: hashRecoveryCode(code)})
+	}
+	if err := tx.Create(&rows).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// recoveryCodeAlphabet is Crockford's base32 alphabet, without the letters
+// that are easily mistaken for digits
+const recoveryCodeAlphabet = "0123456789abcdefghjkmnpqrstvwxyz"
+
+// newRecoveryCode returns a random code like "k7mq2-x9hpt"
+func newRecoveryCode() (string, error) {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	for i, v := range b {
+		if i == 5 {
+			sb.WriteByte('-')
+		}
+		sb.WriteByte(recoveryCodeAlphabet[v%32])
+	}
+	return sb.String(), nil
+}
+
+// hashRecoveryCode hashes a recovery code ignoring case, spaces and dashes
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.NewReplacer("-", "", " ", "").Replace(code)
+	return utils.SHA256Hash(code)
+}
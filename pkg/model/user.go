@@ -3,6 +3,7 @@ package model
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/pixelvide/kube-sentinel/pkg/common"
@@ -20,6 +21,13 @@ type User struct {
 	AvatarURL   string     `json:"avatar_url,omitempty" gorm:"type:varchar(500)"`
 	LastLoginAt *time.Time `json:"lastLoginAt,omitempty" gorm:"type:timestamp;index"`
 	Enabled     bool       `json:"enabled" gorm:"type:boolean;default:true"`
+	// ExternalID is the id of the user at the identity provider that
+	// provisions it through SCIM
+	ExternalID string `json:"externalId,omitempty" gorm:"type:varchar(255);index"`
+	// FailedLogins counts consecutive failed password logins. The account is
+	// locked until LockedUntil once they reach common.LoginMaxFailures.
+	FailedLogins int        `json:"-" gorm:"not null;default:0"`
+	LockedUntil  *time.Time `json:"lockedUntil,omitempty" gorm:"type:timestamp"`
 
 	// Transient fields (OIDC/Authentication)
 	// Provider is a transient field used during OIDC authentication to pass the provider name.
@@ -30,6 +38,10 @@ type User struct {
 	// Sub is a transient field used during OIDC authentication to pass the subject identifier.
 	Sub string `json:"sub,omitempty" gorm:"-"`
 
+	// TokenScope is a transient field set when the request authenticated with
+	// a scoped personal access token. RBAC checks intersect it with Roles.
+	TokenScope *TokenScope `json:"tokenScope,omitempty" gorm:"-"`
+
 	// Relations
 	Roles  []common.Role `json:"roles,omitempty" gorm:"-"`
 	Config *UserConfig   `json:"config,omitempty" gorm:"foreignKey:UserID"`
@@ -48,6 +60,7 @@ type PersonalAccessToken struct {
 	ExpiresAt   *time.Time `json:"expiresAt" gorm:"type:timestamp"`
 	LastUsedAt  *time.Time `json:"lastUsedAt" gorm:"type:timestamp"`
 	LastUsedIP  string     `json:"lastUsedIP" gorm:"type:text"` // Comma-separated or just the last used IP(s)
+	Scope       TokenScope `json:"scope" gorm:"embedded;embeddedPrefix:scope_"`
 
 	// Relationship
 	User User `json:"user" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
@@ -57,6 +70,21 @@ func (PersonalAccessToken) TableName() string {
 	return common.GetAppTableName("personal_access_tokens")
 }
 
+// TokenScope limits a personal access token to part of its owner's access.
+// The lists have the shape of common.Role, and an empty list leaves that
+// dimension unrestricted.
+type TokenScope struct {
+	Clusters   SliceString `json:"clusters" gorm:"type:text"`
+	Namespaces SliceString `json:"namespaces" gorm:"type:text"`
+	Resources  SliceString `json:"resources" gorm:"type:text"`
+	Verbs      SliceString `json:"verbs" gorm:"type:text"`
+}
+
+// IsEmpty reports whether the scope does not restrict anything
+func (s TokenScope) IsEmpty() bool {
+	return len(s.Clusters) == 0 && len(s.Namespaces) == 0 && len(s.Resources) == 0 && len(s.Verbs) == 0
+}
+
 type UserIdentity struct {
 	Model
 	UserID      uint        `json:"user_id" gorm:"index;not null;uniqueIndex:idx_user_provider"`
@@ -235,6 +263,60 @@ func GetUserByUsername(username string) (*User, error) {
 	return &user, nil
 }
 
+// GetUserGroups returns the groups of a user: the OIDC groups of all of its
+// identities as of their last login, and its SCIM groups
+func GetUserGroups(userID uint) ([]string, error) {
+	groups, err := listUserGroups(DB.Where("user_id = ?", userID), userID)
+	if err != nil {
+		return nil, err
+	}
+	return groups[userID], nil
+}
+
+// ListUserGroups returns the groups of all users, keyed by user ID
+func ListUserGroups() (map[uint][]string, error) {
+	return listUserGroups(DB, 0)
+}
+
+// listUserGroups collects the groups of the identities db selects and the
+// SCIM groups of userID, or of all users when it is 0
+func listUserGroups(db *gorm.DB, userID uint) (map[uint][]string, error) {
+	var identities []UserIdentity
+	if err := db.Find(&identities).Error; err != nil {
+		return nil, err
+	}
+	var memberships []struct {
+		UserID      uint
+		DisplayName string
+	}
+	members, groupTable := SCIMGroupMember{}.TableName(), SCIMGroup{}.TableName()
+	query := DB.Model(&SCIMGroupMember{}).
+		Select(fmt.Sprintf("%s.user_id, %s.display_name", members, groupTable)).
+		Joins(fmt.Sprintf("JOIN %s ON %s.id = %s.group_id", groupTable, groupTable, members))
+	if userID != 0 {
+		query = query.Where(fmt.Sprintf("%s.user_id = ?", members), userID)
+	}
+	if err := query.Scan(&memberships).Error; err != nil {
+		return nil, err
+	}
+
+	groups := make(map[uint][]string)
+	add := func(id uint, group string) {
+		if !slices.Contains(groups[id], group) {
+			groups[id] = append(groups[id], group)
+		}
+	}
+	for _, identity := range identities {
+		for _, group := range identity.OIDCGroups {
+			add(identity.UserID, group)
+		}
+	}
+	for _, m := range memberships {
+		add(m.UserID, m.DisplayName)
+	}
+	return groups, nil
+}
+
 // ListUsers returns users with pagination. If limit is 0, defaults to 20.
 func ListUsers(limit int, offset int, search string, sortBy string, sortOrder string, role string) (users []User, total int64, err error) {
 	if limit <= 0 {
@@ -311,7 +393,8 @@ func UpdateUser(user *User) error {
 	return DB.Save(user).Error
 }
 
-// ResetPasswordByID sets a new password (hashed) for user with given id
+// ResetPasswordByID sets a new password (hashed) for user with given id,
+// unlocks the account and revokes the user's sessions
 func ResetPasswordByID(id uint, plainPassword string) error {
 	var u User
 	if err := DB.First(&u, id).Error; err != nil {
@@ -322,12 +405,54 @@ func ResetPasswordByID(id uint, plainPassword string) error {
 		return err
 	}
 	u.Password = hash
-	return DB.Save(&u).Error
+	u.FailedLogins = 0
+	u.LockedUntil = nil
+	if err := DB.Save(&u).Error; err != nil {
+		return err
+	}
+	_, err = RevokeUserSessions(id)
+	return err
 }
 
-// SetUserEnabled sets enabled flag for a user
+// SetUserEnabled sets enabled flag for a user. Disabling a user revokes all
+// of its sessions.
 func SetUserEnabled(id uint, enabled bool) error {
-	return DB.Model(&User{}).Where("id = ?", id).Update("enabled", enabled).Error
+	if err := DB.Model(&User{}).Where("id = ?", id).Update("enabled", enabled).Error; err != nil {
+		return err
+	}
+	if !enabled {
+		_, err := RevokeUserSessions(id)
+		return err
+	}
+	return nil
+}
+
+// IsLocked reports whether password logins of the user are locked
+func (u *User) IsLocked(now time.Time) bool {
+	return u.LockedUntil != nil && now.Before(*u.LockedUntil)
+}
+
+// RecordFailedLogin counts a failed password login of a user and locks the
+// account for common.LoginLockoutDuration when it reaches
+// common.LoginMaxFailures. It returns the lock expiry when it locked it.
+func RecordFailedLogin(id uint) (*time.Time, error) {
+	if err := DB.Model(&User{}).Where("id = ?", id).
+		UpdateColumn("failed_logins", gorm.Expr("failed_logins + 1")).Error; err != nil {
+		return nil, err
+	}
+	var u User
+	if err := DB.Select("id", "failed_logins").First(&u, id).Error; err != nil {
+		return nil, err
+	}
+	if common.LoginMaxFailures <= 0 || u.FailedLogins < common.LoginMaxFailures {
+		return nil, nil
+	}
+	lockedUntil := time.Now().Add(common.LoginLockoutDuration)
+	err := DB.Model(&User{}).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+		"failed_logins": 0,
+		"locked_until":  lockedUntil,
+	}).Error
+	return &lockedUntil, err
 }
 
 func CheckPassword(hashedPassword, plainPassword string) bool {
@@ -347,7 +472,7 @@ func AddSuperUser(user *User) error {
 	return nil
 }
 
-func NewPersonalAccessToken(userID uint, name string, expiresAt *time.Time) (string, *PersonalAccessToken, error) {
+func NewPersonalAccessToken(userID uint, name string, expiresAt *time.Time, scope TokenScope) (string, *PersonalAccessToken, error) {
 	token := "cspat-" + utils.RandomString(32)
 	digest := utils.SHA256Hash(token)
 	pat := &PersonalAccessToken{
@@ -356,6 +481,7 @@ func NewPersonalAccessToken(userID uint, name string, expiresAt *time.Time) (str
 		TokenDigest: digest,
 		Prefix:      token[:10], // cspat- plus first 4 chars
 		ExpiresAt:   expiresAt,
+		Scope:       scope,
 	}
 	if err := DB.Create(pat).Error; err != nil {
 		return "", nil, err
@@ -1,11 +1,17 @@
 package rbac
 
 import (
+	"fmt"
 	"net/http"
+	"regexp"
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/pixelvide/kube-sentinel/pkg/common"
 	"github.com/pixelvide/kube-sentinel/pkg/model"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 // ListRoles returns all roles with assignments
@@ -45,6 +51,10 @@ func CreateRole(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "role name is required"})
 		return
 	}
+	if err := validateRole(&role); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err := model.DB.Create(&role).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create role: " + err.Error()})
 		return
@@ -70,6 +80,10 @@ func UpdateRole(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := validateRole(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	var role model.Role
 	if err := model.DB.First(&role, uint(dbID)).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "role not found"})
@@ -82,6 +96,9 @@ func UpdateRole(c *gin.Context) {
 	role.Namespaces = req.Namespaces
 	role.Resources = req.Resources
 	role.Verbs = req.Verbs
+	role.ResourceNames = req.ResourceNames
+	role.LabelSelector = req.LabelSelector
+	role.Effect = req.Effect
 
 	if err := model.DB.Save(&role).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update role: " + err.Error()})
@@ -94,6 +111,104 @@ func UpdateRole(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"role": role})
 }
 
+type accessReviewRequest struct {
+	User      string            `json:"user"`
+	Groups    []string          `json:"groups"`
+	Cluster   string            `json:"cluster" binding:"required"`
+	Namespace string            `json:"namespace"`
+	Resource  string            `json:"resource" binding:"required"`
+	Verb      string            `json:"verb" binding:"required"`
+	Name      string            `json:"name"`
+	Labels    map[string]string `json:"labels"`
+}
+
+// accessRequest converts the review request the way RBACMiddleware builds
+// requests: an empty namespace stands for all namespaces and the resource may
+// name a subresource such as "pods/exec"
+func (r accessReviewRequest) accessRequest() AccessRequest {
+	req := AccessRequest{
+		Cluster:   r.Cluster,
+		Namespace: r.Namespace,
+		Verb:      r.Verb,
+		Name:      r.Name,
+		Labels:    r.Labels,
+	}
+	if req.Namespace == "" {
+		req.Namespace = "_all"
+	}
+	req.Resource, req.Subresource, _ = strings.Cut(r.Resource, "/")
+	if req.Name != "" && req.Labels == nil {
+		req.Labels = map[string]string{}
+	}
+	return req
+}
+
+// HandleAccessReview explains whether a user or group may perform an action,
+// with the roles they hold, where the roles come from and how they matched.
+// The groups of a known user are those of their last login, plus any given
+// in the request.
+func HandleAccessReview(c *gin.Context) {
+	var req accessReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.User == "" && len(req.Groups) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user or groups is required"})
+		return
+	}
+
+	user := model.User{Username: req.User, OIDCGroups: req.Groups}
+	userFound := false
+	if req.User != "" {
+		if u, err := model.GetUserByUsername(req.User); err == nil {
+			userFound = true
+			groups, err := model.GetUserGroups(u.ID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load user groups: " + err.Error()})
+				return
+			}
+			for _, group := range groups {
+				if !slices.Contains(user.OIDCGroups, group) {
+					user.OIDCGroups = append(user.OIDCGroups, group)
+				}
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"review":    ReviewAccess(user, req.accessRequest()),
+		"user":      user.Username,
+		"userFound": userFound,
+		"groups":    user.OIDCGroups,
+	})
+}
+
+// HandleWhoCan lists the users and groups that may perform an action
+func HandleWhoCan(c *gin.Context) {
+	var req accessReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var users []model.User
+	if err := model.DB.Where("enabled = ?", true).Find(&users).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list users: " + err.Error()})
+		return
+	}
+	groups, err := model.ListUserGroups()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load user groups: " + err.Error()})
+		return
+	}
+	for i := range users {
+		users[i].OIDCGroups = groups[users[i].ID]
+	}
+
+	c.JSON(http.StatusOK, gin.H{"subjects": WhoCan(req.accessRequest(), users)})
+}
+
 // DeleteRole deletes a role and its assignments
 func DeleteRole(c *gin.Context) {
 	id := c.Param("id")
@@ -191,3 +306,32 @@ func UnassignRole(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, gin.H{"success": true})
 }
+
+// validateRole normalizes the effect of a role and rejects label selectors
+// and patterns that would never match
+func validateRole(role *model.Role) error {
+	role.Effect = strings.ToLower(role.Effect)
+	switch role.Effect {
+	case "":
+		role.Effect = common.EffectAllow
+	case common.EffectAllow, common.EffectDeny:
+	default:
+		return fmt.Errorf("invalid effect %q, must be %q or %q", role.Effect, common.EffectAllow, common.EffectDeny)
+	}
+	if role.LabelSelector != "" {
+		if _, err := labels.Parse(role.LabelSelector); err != nil {
+			return fmt.Errorf("invalid label selector: %w", err)
+		}
+	}
+	for _, list := range [][]string{role.Clusters, role.Namespaces, role.Resources, role.Verbs, role.ResourceNames} {
+		for _, v := range list {
+			if v == "*" || strings.HasPrefix(v, "!") {
+				continue
+			}
+			if _, err := regexp.Compile(v); err != nil {
+				return fmt.Errorf("invalid pattern %q: %w", v, err)
+			}
+		}
+	}
+	return nil
+}
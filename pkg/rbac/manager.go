@@ -45,6 +45,10 @@ func loadRolesFromDB() error {
 			Namespaces:  r.Namespaces,
 			Resources:   r.Resources,
 			Verbs:       r.Verbs,
+
+			ResourceNames: r.ResourceNames,
+			LabelSelector: r.LabelSelector,
+			Effect:        r.Effect,
 		}
 		cfg.Roles = append(cfg.Roles, cr)
 
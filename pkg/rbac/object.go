@@ -0,0 +1,54 @@
+package rbac
+
+import (
+	"context"
+
+	"github.com/pixelvide/kube-sentinel/pkg/model"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// LoadObjectLabels sets req.Labels from the cluster when the decision for
+// the user depends on them. A missing object has no labels.
+func LoadObjectLabels(ctx context.Context, c client.Client, user model.User, req *AccessRequest) error {
+	if req.Name == "" || req.Labels != nil || !NeedsLabels(user, *req) {
+		return nil
+	}
+	gvk, err := c.RESTMapper().KindFor(schema.GroupVersionResource{Resource: req.Resource})
+	if err != nil {
+		return err
+	}
+	obj := &metav1.PartialObjectMetadata{}
+	obj.SetGroupVersionKind(gvk)
+	namespace := req.Namespace
+	if namespace == "_all" {
+		namespace = ""
+	}
+	err = c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: req.Name}, obj)
+	if apierrors.IsNotFound(err) {
+		req.Labels = map[string]string{}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	req.Labels = obj.GetLabels()
+	if req.Labels == nil {
+		req.Labels = map[string]string{}
+	}
+	return nil
+}
+
+// AuthorizeObject loads the labels of the object a request targets if needed
+// and decides the request. Errors loading the object deny access.
+func AuthorizeObject(ctx context.Context, c client.Client, user model.User, req AccessRequest) bool {
+	if err := LoadObjectLabels(ctx, c, user, &req); err != nil {
+		klog.Warningf("RBAC Check - failed to load labels of %s: %v", req, err)
+		return false
+	}
+	return Authorize(user, req)
+}
@@ -1,6 +1,7 @@
 package rbac
 
 import (
+	"errors"
 	"fmt"
 	"regexp"
 	"slices"
@@ -8,31 +9,199 @@ import (
 
 	"github.com/pixelvide/kube-sentinel/pkg/common"
 	"github.com/pixelvide/kube-sentinel/pkg/model"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/klog/v2"
 )
 
-// CanAccess checks if user/oidcGroup can access resource with verb in cluster/namespace
-func CanAccess(user model.User, resource, verb, cluster, namespace string) bool {
+// AccessRequest is one access check. Name and Labels describe the object
+// the request targets; an empty Name means the object is not known, as for
+// lists or checks that are not about one object.
+type AccessRequest struct {
+	Cluster     string
+	Namespace   string
+	Resource    string
+	Subresource string
+	Verb        string
+	Name        string
+	Labels      map[string]string
+}
+
+func (r AccessRequest) String() string {
+	resource := r.Resource
+	if r.Subresource != "" {
+		resource += "/" + r.Subresource
+	}
+	if r.Name != "" {
+		resource += " " + r.Name
+	}
+	return fmt.Sprintf("Resource: %s, Verb: %s, Cluster: %s, Namespace: %s", resource, r.Verb, r.Cluster, r.Namespace)
+}
+
+// Authorize decides a request. A deny role that applies overrides all allow
+// roles. Roles limited by resource names or a label selector only grant
+// access to a known object that matches, while such deny roles also apply
+// when the object is not known.
+func Authorize(user model.User, req AccessRequest) bool {
+	return authorize(user, req, false)
+}
+
+// AuthorizeCollection decides a request on a collection, such as a list,
+// whose objects are filtered with Authorize afterwards. Roles limited to some
+// objects count as granting access, and deny roles limited to some objects
+// are left to the per-object check.
+func AuthorizeCollection(user model.User, req AccessRequest) bool {
+	return authorize(user, req, true)
+}
+
+func authorize(user model.User, req AccessRequest, collection bool) bool {
+	if !scopeAllows(user.TokenScope, req) {
+		klog.V(1).Infof("RBAC Check - User: %s, %s, Outside Token Scope", user.Key(), req)
+		return false
+	}
 	roles := GetUserRoles(user)
 	for _, role := range roles {
-		if match(role.Clusters, cluster) &&
-			match(role.Namespaces, namespace) &&
-			match(role.Resources, resource) &&
-			match(role.Verbs, verb) {
-			klog.V(1).Infof("RBAC Check - User: %s, OIDC Groups: %v, Resource: %s, Verb: %s, Cluster: %s, Namespace: %s, Hit Role: %v",
-				user.Key(), user.OIDCGroups, resource, verb, cluster, namespace, role.Name)
+		if !isDeny(role) || !roleCovers(role, req) {
+			continue
+		}
+		if !objectConstrained(role) || (!collection && (req.Name == "" || matchObject(role, req, true))) {
+			klog.V(1).Infof("RBAC Check - User: %s, OIDC Groups: %v, %s, Denied by Role: %v",
+				user.Key(), user.OIDCGroups, req, role.Name)
+			return false
+		}
+	}
+	for _, role := range roles {
+		if isDeny(role) || !roleCovers(role, req) {
+			continue
+		}
+		if !objectConstrained(role) || collection || (req.Name != "" && matchObject(role, req, false)) {
+			klog.V(1).Infof("RBAC Check - User: %s, OIDC Groups: %v, %s, Hit Role: %v",
+				user.Key(), user.OIDCGroups, req, role.Name)
+			return true
+		}
+	}
+	klog.V(1).Infof("RBAC Check - User: %s, OIDC Groups: %v, %s, No Access",
+		user.Key(), user.OIDCGroups, req)
+	return false
+}
+
+// CanAccess checks if user/oidcGroup can access resource with verb in
+// cluster/namespace, without a specific object. resource may name a
+// subresource such as "pods/exec".
+func CanAccess(user model.User, resource, verb, cluster, namespace string) bool {
+	resource, subresource, _ := strings.Cut(resource, "/")
+	return Authorize(user, AccessRequest{
+		Cluster:     cluster,
+		Namespace:   namespace,
+		Resource:    resource,
+		Subresource: subresource,
+		Verb:        verb,
+	})
+}
+
+// HasObjectRules reports whether the user holds deny roles or roles limited
+// to some objects, so listed objects need to be checked one by one
+func HasObjectRules(user model.User) bool {
+	for _, role := range GetUserRoles(user) {
+		if isDeny(role) || objectConstrained(role) {
+			return true
+		}
+	}
+	return false
+}
+
+// NeedsLabels reports whether deciding the request depends on the labels of
+// the object
+func NeedsLabels(user model.User, req AccessRequest) bool {
+	for _, role := range GetUserRoles(user) {
+		if role.LabelSelector != "" && roleCovers(role, req) {
 			return true
 		}
 	}
-	klog.V(1).Infof("RBAC Check - User: %s, OIDC Groups: %v, Resource: %s, Verb: %s, Cluster: %s, Namespace: %s, No Access",
-		user.Key(), user.OIDCGroups, resource, verb, cluster, namespace)
 	return false
 }
 
+func isDeny(role common.Role) bool {
+	return strings.EqualFold(role.Effect, common.EffectDeny)
+}
+
+func roleCovers(role common.Role, req AccessRequest) bool {
+	return match(role.Clusters, req.Cluster) &&
+		match(role.Namespaces, req.Namespace) &&
+		matchResource(role.Resources, req.Resource, req.Subresource) &&
+		match(role.Verbs, req.Verb)
+}
+
+func objectConstrained(role common.Role) bool {
+	return len(role.ResourceNames) > 0 || role.LabelSelector != ""
+}
+
+// matchObject checks the object constraints of a role. An invalid label
+// selector matches nothing for allow roles and everything for deny roles.
+func matchObject(role common.Role, req AccessRequest, deny bool) bool {
+	if len(role.ResourceNames) > 0 && !matchName(role.ResourceNames, req.Name) {
+		return false
+	}
+	if role.LabelSelector != "" {
+		selector, err := labels.Parse(role.LabelSelector)
+		if err != nil {
+			klog.Errorf("invalid label selector in role %s: %v", role.Name, err)
+			return deny
+		}
+		if !selector.Matches(labels.Set(req.Labels)) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchResource matches a resource and optional subresource against role
+// entries. An entry without a slash covers the resource and all of its
+// subresources, while "pods/exec" only covers that subresource.
+func matchResource(list []string, resource, subresource string) bool {
+	ok, _, _ := matchResourceEntry(list, resource, subresource)
+	return ok
+}
+
+// matchResourceEntry is matchResource that also returns the deciding entry
+// and how it matched, as matchEntry does
+func matchResourceEntry(list []string, resource, subresource string) (bool, string, matchKind) {
+	var resources, subresources []string
+	for _, v := range list {
+		if strings.Contains(v, "/") {
+			subresources = append(subresources, v)
+		} else {
+			resources = append(resources, v)
+		}
+	}
+	if subresource != "" {
+		if ok, entry, kind := matchEntry(subresources, resource+"/"+subresource); ok {
+			return ok, entry, kind
+		}
+	}
+	return matchEntry(resources, resource)
+}
+
+// CheckAccess checks a permission the same way RBACMiddleware does for the
+// REST API, for callers such as AI and MCP tools that do not go through it.
+// An empty namespace stands for all namespaces, like "_all" in resource URLs.
+// A denied check returns the NoAccess message as error.
+func CheckAccess(user model.User, resource, verb, cluster, namespace string) error {
+	if namespace == "" {
+		namespace = "_all"
+	}
+	if !CanAccess(user, resource, verb, cluster, namespace) {
+		return errors.New(NoAccess(user.Key(), verb, resource, namespace, cluster))
+	}
+	return nil
+}
+
 func CanAccessCluster(user model.User, name string) bool {
+	if user.TokenScope != nil && !matchScope(user.TokenScope.Clusters, name) {
+		return false
+	}
 	roles := GetUserRoles(user)
 	for _, role := range roles {
-		if match(role.Clusters, name) {
+		if !isDeny(role) && match(role.Clusters, name) {
 			return true
 		}
 	}
@@ -40,15 +209,35 @@ func CanAccessCluster(user model.User, name string) bool {
 }
 
 func CanAccessNamespace(user model.User, cluster, name string) bool {
+	if user.TokenScope != nil && (!matchScope(user.TokenScope.Clusters, cluster) || !matchScope(user.TokenScope.Namespaces, name)) {
+		return false
+	}
 	roles := GetUserRoles(user)
 	for _, role := range roles {
-		if match(role.Clusters, cluster) && match(role.Namespaces, name) {
+		if !isDeny(role) && match(role.Clusters, cluster) && match(role.Namespaces, name) {
 			return true
 		}
 	}
 	return false
 }
 
+// scopeAllows reports whether a personal access token scope permits an
+// access. Requests without a scoped token are not restricted.
+func scopeAllows(scope *model.TokenScope, req AccessRequest) bool {
+	if scope == nil {
+		return true
+	}
+	return matchScope(scope.Clusters, req.Cluster) &&
+		matchScope(scope.Namespaces, req.Namespace) &&
+		(len(scope.Resources) == 0 || matchResource(scope.Resources, req.Resource, req.Subresource)) &&
+		matchScope(scope.Verbs, req.Verb)
+}
+
+// matchScope is match for token scopes, where an empty list allows all
+func matchScope(list []string, val string) bool {
+	return len(list) == 0 || match(list, val)
+}
+
 // GetUserRoles returns all roles for a user/oidcGroups
 func GetUserRoles(user model.User) []common.Role {
 	if user.Roles != nil {
@@ -90,26 +279,69 @@ func findRole(name string) *common.Role {
 }
 
 func match(list []string, val string) bool {
+	ok, _, _ := matchEntry(list, val)
+	return ok
+}
+
+// matchKind tells how a role entry decided a match
+type matchKind string
+
+const (
+	matchNone     matchKind = ""
+	matchExact    matchKind = "exact"
+	matchWildcard matchKind = "wildcard"
+	matchRegexp   matchKind = "regexp"
+	matchExcluded matchKind = "excluded"
+)
+
+// matchEntry matches val against the entries of a role field in order and
+// returns the entry that decided the result. "!name" excludes a value, "*"
+// matches anything, and other entries match exactly or as an unanchored
+// regular expression.
+func matchEntry(list []string, val string) (bool, string, matchKind) {
+	return matchEntryPattern(list, val, false)
+}
+
+// matchName matches an object name against the resourceNames of a role
+func matchName(list []string, val string) bool {
+	ok, _, _ := matchNameEntry(list, val)
+	return ok
+}
+
+// matchNameEntry is matchEntry for resourceNames, whose regular expressions
+// have to match the whole name, so "web" does not grant "web-admin"
+func matchNameEntry(list []string, val string) (bool, string, matchKind) {
+	return matchEntryPattern(list, val, true)
+}
+
+func matchEntryPattern(list []string, val string, anchored bool) (bool, string, matchKind) {
 	for _, v := range list {
 		if len(v) > 1 && strings.HasPrefix(v, "!") {
 			if v[1:] == val {
-				return false
+				return false, v, matchExcluded
 			}
 		}
-		if v == "*" || v == val {
-			return true
+		if v == "*" {
+			return true, v, matchWildcard
+		}
+		if v == val {
+			return true, v, matchExact
 		}
 
-		re, err := regexp.Compile(v)
+		pattern := v
+		if anchored {
+			pattern = "^(?:" + v + ")$"
+		}
+		re, err := regexp.Compile(pattern)
 		if err != nil {
 			klog.Error(err)
 			continue
 		}
 		if re.MatchString(val) {
-			return true
+			return true, v, matchRegexp
 		}
 	}
-	return false
+	return false, "", matchNone
 }
 
 func contains(list []string, val string) bool {
@@ -128,7 +360,21 @@ func NoAccess(user, verb, resource, ns, cluster string) string {
 		user, verb, resource, ns, cluster)
 }
 
+// TwoFactorRequired reports whether a user must sign in with a second
+// factor: password users with the admin role, when the REQUIRE_ADMIN_2FA
+// setting is on. Users of identity providers rely on their MFA.
+func TwoFactorRequired(user model.User) bool {
+	return user.Password != "" && model.IsAdminTwoFactorRequired() &&
+		UserHasRole(user, model.DefaultAdminRole.Name)
+}
+
+// UserHasRole reports whether the user holds a role by name. Requests made
+// with a scoped personal access token never count as holding a role, so a
+// token scoped to a few resources cannot reach role-gated APIs such as admin.
 func UserHasRole(user model.User, roleName string) bool {
+	if user.TokenScope != nil {
+		return false
+	}
 	roles := GetUserRoles(user)
 	for _, role := range roles {
 		if role.Name == roleName {
@@ -5,6 +5,7 @@ import (
 
 	"github.com/pixelvide/kube-sentinel/pkg/common"
 	"github.com/pixelvide/kube-sentinel/pkg/model"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestCanAccess(t *testing.T) {
@@ -275,3 +276,113 @@ func TestCanAccess(t *testing.T) {
 		})
 	}
 }
+
+func TestCanAccessWithTokenScope(t *testing.T) {
+	owner := model.User{
+		Username: "ci-bot",
+		Roles: []common.Role{
+			{Name: "admin", Clusters: []string{"*"}, Namespaces: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"}},
+		},
+		TokenScope: &model.TokenScope{
+			Clusters:  model.SliceString{"prod-eu", "prod-us"},
+			Resources: model.SliceString{"deployments"},
+			Verbs:     model.SliceString{"get"},
+		},
+	}
+	limited := model.User{
+		Username: "dev",
+		Roles: []common.Role{
+			{Name: "dev", Clusters: []string{"prod-eu"}, Namespaces: []string{"dev"}, Resources: []string{"*"}, Verbs: []string{"*"}},
+		},
+		TokenScope: owner.TokenScope,
+	}
+
+	tests := []struct {
+		name      string
+		user      model.User
+		resource  string
+		verb      string
+		cluster   string
+		namespace string
+		expected  bool
+	}{
+		{"inside scope", owner, "deployments", "get", "prod-us", "web", true},
+		{"verb outside scope", owner, "deployments", "update", "prod-us", "web", false},
+		{"resource outside scope", owner, "secrets", "get", "prod-us", "web", false},
+		{"cluster outside scope", owner, "deployments", "get", "staging", "web", false},
+		{"scope does not widen roles", limited, "deployments", "get", "prod-us", "dev", false},
+		{"intersection of scope and roles", limited, "deployments", "get", "prod-eu", "dev", true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, CanAccess(tc.user, tc.resource, tc.verb, tc.cluster, tc.namespace))
+		})
+	}
+
+	assert.True(t, CanAccessCluster(owner, "prod-eu"))
+	assert.False(t, CanAccessCluster(owner, "staging"))
+	assert.False(t, UserHasRole(owner, "admin"), "scoped tokens do not act with named roles")
+}
+
+func TestAuthorizeObjectRules(t *testing.T) {
+	viewer := common.Role{Name: "viewer", Clusters: []string{"*"}, Namespaces: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"get", "log"}}
+	noSecrets := common.Role{Name: "no-secrets", Effect: common.EffectDeny, Clusters: []string{"prod"}, Namespaces: []string{"*"}, Resources: []string{"secrets"}, Verbs: []string{"*"}}
+	execOnly := common.Role{Name: "exec-only", Clusters: []string{"*"}, Namespaces: []string{"dev"}, Resources: []string{"pods/exec"}, Verbs: []string{"exec"}}
+	webOnly := common.Role{Name: "web-only", Clusters: []string{"*"}, Namespaces: []string{"*"}, Resources: []string{"deployments"}, Verbs: []string{"update"}, ResourceNames: []string{"web-.*"}}
+	teamA := common.Role{Name: "team-a", Clusters: []string{"*"}, Namespaces: []string{"*"}, Resources: []string{"pods"}, Verbs: []string{"delete"}, LabelSelector: "team=a"}
+	noCritical := common.Role{Name: "no-critical", Effect: common.EffectDeny, Clusters: []string{"*"}, Namespaces: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"delete"}, LabelSelector: "tier=critical"}
+
+	user := model.User{Username: "alice", Roles: []common.Role{viewer, noSecrets, execOnly, webOnly, teamA, noCritical}}
+	teamALabels := map[string]string{"team": "a"}
+
+	tests := []struct {
+		name     string
+		req      AccessRequest
+		expected bool
+	}{
+		{"allow without deny", AccessRequest{Cluster: "prod", Namespace: "web", Resource: "pods", Verb: "get"}, true},
+		{"deny overrides allow", AccessRequest{Cluster: "prod", Namespace: "web", Resource: "secrets", Verb: "get"}, false},
+		{"deny limited to cluster", AccessRequest{Cluster: "dev", Namespace: "web", Resource: "secrets", Verb: "get"}, true},
+		{"resource entry covers subresources", AccessRequest{Cluster: "dev", Namespace: "web", Resource: "pods", Subresource: "log", Verb: "log"}, true},
+		{"subresource entry", AccessRequest{Cluster: "dev", Namespace: "dev", Resource: "pods", Subresource: "exec", Verb: "exec", Name: "api"}, true},
+		{"subresource entry does not cover other subresources", AccessRequest{Cluster: "dev", Namespace: "dev", Resource: "pods", Subresource: "portforward", Verb: "exec"}, false},
+		{"subresource entry does not cover the resource", AccessRequest{Cluster: "dev", Namespace: "dev", Resource: "pods", Verb: "exec"}, false},
+		{"resource name matches", AccessRequest{Cluster: "dev", Namespace: "web", Resource: "deployments", Verb: "update", Name: "web-frontend"}, true},
+		{"resource name does not match", AccessRequest{Cluster: "dev", Namespace: "web", Resource: "deployments", Verb: "update", Name: "db"}, false},
+		{"resource name must match the whole name", AccessRequest{Cluster: "dev", Namespace: "web", Resource: "deployments", Verb: "update", Name: "old-web-frontend"}, false},
+		{"resource names need a known object", AccessRequest{Cluster: "dev", Namespace: "web", Resource: "deployments", Verb: "update"}, false},
+		{"label selector matches", AccessRequest{Cluster: "dev", Namespace: "web", Resource: "pods", Verb: "delete", Name: "a-1", Labels: teamALabels}, true},
+		{"label selector does not match", AccessRequest{Cluster: "dev", Namespace: "web", Resource: "pods", Verb: "delete", Name: "b-1", Labels: map[string]string{"team": "b"}}, false},
+		{"deny label selector matches", AccessRequest{Cluster: "dev", Namespace: "web", Resource: "pods", Verb: "delete", Name: "a-2", Labels: map[string]string{"team": "a", "tier": "critical"}}, false},
+		{"deny label selector applies to unknown objects", AccessRequest{Cluster: "dev", Namespace: "web", Resource: "pods", Verb: "delete"}, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, Authorize(user, tc.req))
+		})
+	}
+
+	// Lists are allowed by constrained roles and filtered per object
+	list := AccessRequest{Cluster: "dev", Namespace: "web", Resource: "pods", Verb: "delete"}
+	assert.True(t, AuthorizeCollection(user, list))
+	assert.False(t, AuthorizeCollection(user, AccessRequest{Cluster: "prod", Namespace: "web", Resource: "secrets", Verb: "get"}))
+	assert.True(t, HasObjectRules(user))
+	assert.True(t, NeedsLabels(user, list))
+	assert.False(t, NeedsLabels(user, AccessRequest{Cluster: "dev", Namespace: "web", Resource: "pods", Verb: "get"}))
+
+	// Existing roles keep granting pods/exec through the pods entry
+	assert.True(t, CanAccess(model.User{Username: "bob", Roles: []common.Role{{
+		Name: "dev", Clusters: []string{"*"}, Namespaces: []string{"*"}, Resources: []string{"pods"}, Verbs: []string{"exec"},
+	}}}, "pods/exec", "exec", "dev", "web"))
+}
+
+func TestValidateRole(t *testing.T) {
+	role := &model.Role{Name: "r", Resources: model.SliceString{"pods/exec"}}
+	assert.NoError(t, validateRole(role))
+	assert.Equal(t, common.EffectAllow, role.Effect)
+
+	assert.NoError(t, validateRole(&model.Role{Name: "r", Effect: "Deny", LabelSelector: "team in (a,b),!legacy"}))
+	assert.Error(t, validateRole(&model.Role{Name: "r", Effect: "block"}))
+	assert.Error(t, validateRole(&model.Role{Name: "r", LabelSelector: "team==="}))
+	assert.Error(t, validateRole(&model.Role{Name: "r", ResourceNames: model.SliceString{"web-("}}))
+}
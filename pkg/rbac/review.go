@@ -0,0 +1,326 @@
+package rbac
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/pixelvide/kube-sentinel/pkg/common"
+	"github.com/pixelvide/kube-sentinel/pkg/model"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Subject types of RoleSource and SubjectAccess
+const (
+	SubjectUser  = "user"
+	SubjectGroup = "group"
+)
+
+// FieldMatch explains how one field of a request matched the entries of a role
+type FieldMatch struct {
+	Field       string   `json:"field"`
+	Value       string   `json:"value"`
+	Entries     []string `json:"entries"`
+	Matched     bool     `json:"matched"`
+	Entry       string   `json:"entry,omitempty"`
+	Explanation string   `json:"explanation"`
+}
+
+// RoleSource is a role mapping that gives the reviewed subject a role
+type RoleSource struct {
+	Type    string `json:"type"`
+	Subject string `json:"subject"`
+}
+
+// RoleReview is the evaluation of one role of the reviewed subject. Applies
+// is set when the role takes part in the decision: an allow role that grants
+// the request, or a deny role that refuses it.
+type RoleReview struct {
+	Role    string       `json:"role"`
+	Effect  string       `json:"effect"`
+	Sources []RoleSource `json:"sources"`
+	Applies bool         `json:"applies"`
+	Reason  string       `json:"reason"`
+	Fields  []FieldMatch `json:"fields"`
+}
+
+// AccessReview explains the decision Authorize makes for a user
+type AccessReview struct {
+	Allowed   bool         `json:"allowed"`
+	Reason    string       `json:"reason"`
+	DecidedBy []string     `json:"decidedBy"`
+	Roles     []RoleReview `json:"roles"`
+}
+
+// ReviewAccess decides a request like Authorize and explains the decision
+// with the roles of the user, where each of them comes from, and how every
+// field of the request matched them
+func ReviewAccess(user model.User, req AccessRequest) AccessReview {
+	review := AccessReview{
+		Allowed:   Authorize(user, req),
+		DecidedBy: []string{},
+		Roles:     []RoleReview{},
+	}
+	sources := roleSources(user)
+	roles := GetUserRoles(user)
+	sort.Slice(roles, func(i, j int) bool { return roles[i].Name < roles[j].Name })
+
+	var denied, allowed []string
+	for _, role := range roles {
+		rr := reviewRole(role, req)
+		rr.Sources = sources[role.Name]
+		if rr.Applies {
+			if isDeny(role) {
+				denied = append(denied, role.Name)
+			} else {
+				allowed = append(allowed, role.Name)
+			}
+		}
+		review.Roles = append(review.Roles, rr)
+	}
+
+	switch {
+	case user.TokenScope != nil && !scopeAllows(user.TokenScope, req):
+		review.Reason = "the request is outside the scope of the token"
+	case len(roles) == 0:
+		review.Reason = "no roles are mapped to the user or its groups"
+	case len(denied) > 0:
+		review.DecidedBy = denied
+		review.Reason = fmt.Sprintf("denied by deny role %s, which overrides allow roles", strings.Join(denied, ", "))
+	case len(allowed) > 0:
+		review.DecidedBy = allowed
+		review.Reason = fmt.Sprintf("allowed by role %s", strings.Join(allowed, ", "))
+	default:
+		review.Reason = fmt.Sprintf("none of the %d roles of the user matches the request", len(roles))
+	}
+	return review
+}
+
+// reviewRole matches a request against one role the same way authorize does
+func reviewRole(role common.Role, req AccessRequest) RoleReview {
+	rr := RoleReview{Role: role.Name, Effect: common.EffectAllow}
+	if isDeny(role) {
+		rr.Effect = common.EffectDeny
+	}
+
+	resource := req.Resource
+	if req.Subresource != "" {
+		resource += "/" + req.Subresource
+	}
+	rr.Fields = []FieldMatch{
+		fieldMatch("cluster", role.Clusters, req.Cluster, matchEntry),
+		fieldMatch("namespace", role.Namespaces, req.Namespace, matchEntry),
+		fieldMatch("resource", role.Resources, resource, func(list []string, _ string) (bool, string, matchKind) {
+			return matchResourceEntry(list, req.Resource, req.Subresource)
+		}),
+		fieldMatch("verb", role.Verbs, req.Verb, matchEntry),
+	}
+	for _, f := range rr.Fields {
+		if !f.Matched {
+			rr.Reason = fmt.Sprintf("%s does not match: %s", f.Field, f.Explanation)
+			return rr
+		}
+	}
+
+	if !objectConstrained(role) {
+		rr.Applies = true
+		rr.Reason = "the role covers the request"
+		return rr
+	}
+
+	if len(role.ResourceNames) > 0 {
+		if req.Name == "" {
+			rr.Fields = append(rr.Fields, FieldMatch{
+				Field:       "resourceName",
+				Entries:     role.ResourceNames,
+				Explanation: "the request does not name an object",
+			})
+		} else {
+			rr.Fields = append(rr.Fields, fieldMatch("resourceName", role.ResourceNames, req.Name, matchNameEntry))
+		}
+	}
+	if role.LabelSelector != "" {
+		rr.Fields = append(rr.Fields, labelMatch(role.LabelSelector, req))
+	}
+
+	if req.Name == "" {
+		// Constrained deny roles refuse requests on unknown objects, while
+		// constrained allow roles do not grant them
+		rr.Applies = isDeny(role)
+		rr.Reason = "the role is limited to some objects and the request does not name one"
+		return rr
+	}
+	rr.Applies = matchObject(role, req, isDeny(role))
+	if rr.Applies {
+		rr.Reason = "the role covers the request and the object"
+	} else {
+		rr.Reason = "the object does not match the resource names or label selector of the role"
+	}
+	return rr
+}
+
+func fieldMatch(field string, entries []string, val string, matcher func([]string, string) (bool, string, matchKind)) FieldMatch {
+	ok, entry, kind := matcher(entries, val)
+	f := FieldMatch{
+		Field:   field,
+		Value:   val,
+		Entries: entries,
+		Matched: ok,
+		Entry:   entry,
+	}
+	switch kind {
+	case matchExact:
+		f.Explanation = fmt.Sprintf("%q equals the entry %q", val, entry)
+	case matchWildcard:
+		f.Explanation = "the entry \"*\" matches any value"
+	case matchExcluded:
+		f.Explanation = fmt.Sprintf("the entry %q excludes %q", entry, val)
+	case matchRegexp:
+		f.Explanation = explainRegexp(entry, val)
+	default:
+		if len(entries) == 0 {
+			f.Explanation = "the role has no entries"
+		} else {
+			f.Explanation = fmt.Sprintf("%q matches none of the entries %s", val, strings.Join(quoteAll(entries), ", "))
+		}
+	}
+	return f
+}
+
+// explainRegexp describes a regular expression match. Entries are not
+// anchored, so "dev" also matches "my-dev-cluster"; the explanation points
+// that out when only part of the value matched.
+func explainRegexp(entry, val string) string {
+	if full, err := regexp.MatchString("^(?:"+entry+")$", val); err == nil && full {
+		return fmt.Sprintf("%q matches the regular expression %q", val, entry)
+	}
+	re := regexp.MustCompile(entry)
+	return fmt.Sprintf("%q matches the regular expression %q at %q; entries are not anchored, use ^%s$ to match whole values only",
+		val, entry, re.FindString(val), entry)
+}
+
+func labelMatch(selector string, req AccessRequest) FieldMatch {
+	f := FieldMatch{
+		Field:   "labels",
+		Value:   labels.Set(req.Labels).String(),
+		Entries: []string{selector},
+		Entry:   selector,
+	}
+	parsed, err := labels.Parse(selector)
+	switch {
+	case err != nil:
+		f.Explanation = fmt.Sprintf("the label selector is invalid: %v", err)
+	case req.Name == "":
+		f.Explanation = "the request does not name an object"
+	default:
+		f.Matched = parsed.Matches(labels.Set(req.Labels))
+		if f.Matched {
+			f.Explanation = fmt.Sprintf("the labels match the selector %q", selector)
+		} else {
+			f.Explanation = fmt.Sprintf("the labels do not match the selector %q", selector)
+		}
+	}
+	return f
+}
+
+func quoteAll(list []string) []string {
+	quoted := make([]string, len(list))
+	for i, v := range list {
+		quoted[i] = fmt.Sprintf("%q", v)
+	}
+	return quoted
+}
+
+// roleSources returns the mappings that give the user each of its roles, the
+// same way GetUserRoles resolves them
+func roleSources(user model.User) map[string][]RoleSource {
+	sources := make(map[string][]RoleSource)
+	rwlock.RLock()
+	defer rwlock.RUnlock()
+	add := func(role string, source RoleSource) {
+		if !slices.Contains(sources[role], source) {
+			sources[role] = append(sources[role], source)
+		}
+	}
+	for _, mapping := range RBACConfig.RoleMapping {
+		if contains(mapping.Users, "*") {
+			add(mapping.Name, RoleSource{Type: SubjectUser, Subject: "*"})
+		}
+		if contains(mapping.Users, user.Key()) {
+			add(mapping.Name, RoleSource{Type: SubjectUser, Subject: user.Key()})
+		}
+		for _, group := range user.OIDCGroups {
+			if contains(mapping.OIDCGroups, group) {
+				add(mapping.Name, RoleSource{Type: SubjectGroup, Subject: group})
+			}
+		}
+	}
+	return sources
+}
+
+// SubjectAccess is a user or group that is allowed a request, with the roles
+// that grant it
+type SubjectAccess struct {
+	Type  string   `json:"type"`
+	Name  string   `json:"name"`
+	Roles []string `json:"roles"`
+}
+
+// WhoCan lists the users and groups that are allowed a request. Users are
+// checked with their own groups, and "*" stands for every user. Groups are
+// checked with the roles membership alone gives, so a member may still be
+// refused by a deny role mapped to them directly.
+func WhoCan(req AccessRequest, users []model.User) []SubjectAccess {
+	rwlock.RLock()
+	var userNames, groups []string
+	for _, mapping := range RBACConfig.RoleMapping {
+		userNames = append(userNames, mapping.Users...)
+		groups = append(groups, mapping.OIDCGroups...)
+	}
+	rwlock.RUnlock()
+
+	candidates := make(map[string]model.User)
+	for _, u := range users {
+		candidates[u.Key()] = u
+	}
+	for _, name := range userNames {
+		if _, ok := candidates[name]; !ok {
+			candidates[name] = model.User{Username: name}
+		}
+	}
+
+	result := []SubjectAccess{}
+	for name, u := range candidates {
+		if Authorize(u, req) {
+			result = append(result, SubjectAccess{Type: SubjectUser, Name: name, Roles: grantingRoles(u, req)})
+		}
+	}
+	slices.Sort(groups)
+	for _, group := range slices.Compact(groups) {
+		u := model.User{OIDCGroups: []string{group}}
+		if Authorize(u, req) {
+			result = append(result, SubjectAccess{Type: SubjectGroup, Name: group, Roles: grantingRoles(u, req)})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Type != result[j].Type {
+			return result[i].Type > result[j].Type
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// grantingRoles returns the allow roles of the user that grant the request
+func grantingRoles(user model.User, req AccessRequest) []string {
+	var names []string
+	for _, role := range GetUserRoles(user) {
+		if !isDeny(role) && reviewRole(role, req).Applies {
+			names = append(names, role.Name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,116 @@
+package rbac
+
+import (
+	"testing"
+
+	"github.com/pixelvide/kube-sentinel/pkg/common"
+	"github.com/pixelvide/kube-sentinel/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupReviewConfig(t *testing.T) {
+	prev := RBACConfig
+	t.Cleanup(func() { RBACConfig = prev })
+	RBACConfig = &common.RolesConfig{
+		Roles: []common.Role{
+			{Name: "viewer", Clusters: []string{"*"}, Namespaces: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"get"}},
+			{Name: "dev", Clusters: []string{"dev"}, Namespaces: []string{"!kube-system", "team-.*"}, Resources: []string{"pods", "deployments"}, Verbs: []string{"*"}},
+			{Name: "no-secrets", Effect: common.EffectDeny, Clusters: []string{"*"}, Namespaces: []string{"*"}, Resources: []string{"secrets"}, Verbs: []string{"*"}},
+		},
+		RoleMapping: []common.RoleMapping{
+			{Name: "viewer", Users: []string{"*"}},
+			{Name: "dev", OIDCGroups: []string{"developers"}},
+			{Name: "dev", Users: []string{"alice"}},
+			{Name: "no-secrets", OIDCGroups: []string{"contractors"}},
+		},
+	}
+}
+
+func findRoleReview(t *testing.T, review AccessReview, name string) RoleReview {
+	for _, rr := range review.Roles {
+		if rr.Role == name {
+			return rr
+		}
+	}
+	require.Failf(t, "role not in review", "role %s", name)
+	return RoleReview{}
+}
+
+func TestReviewAccess(t *testing.T) {
+	setupReviewConfig(t)
+	bob := model.User{Username: "bob", OIDCGroups: []string{"developers"}}
+
+	review := ReviewAccess(bob, AccessRequest{Cluster: "dev", Namespace: "team-a", Resource: "pods", Verb: "delete"})
+	assert.True(t, review.Allowed)
+	assert.Equal(t, []string{"dev"}, review.DecidedBy)
+	require.Len(t, review.Roles, 2)
+
+	dev := findRoleReview(t, review, "dev")
+	assert.True(t, dev.Applies)
+	assert.Equal(t, []RoleSource{{Type: SubjectGroup, Subject: "developers"}}, dev.Sources)
+	namespace := dev.Fields[1]
+	assert.Equal(t, "team-.*", namespace.Entry)
+	assert.Contains(t, namespace.Explanation, "regular expression")
+	assert.NotContains(t, namespace.Explanation, "not anchored")
+
+	viewer := findRoleReview(t, review, "viewer")
+	assert.False(t, viewer.Applies)
+	assert.Equal(t, []RoleSource{{Type: SubjectUser, Subject: "*"}}, viewer.Sources)
+	assert.Contains(t, viewer.Reason, "verb does not match")
+
+	// Unanchored regexp entries are pointed out
+	review = ReviewAccess(bob, AccessRequest{Cluster: "dev", Namespace: "my-team-a", Resource: "pods", Verb: "delete"})
+	assert.True(t, review.Allowed)
+	assert.Contains(t, findRoleReview(t, review, "dev").Fields[1].Explanation, "not anchored")
+
+	// Exclusions
+	review = ReviewAccess(bob, AccessRequest{Cluster: "dev", Namespace: "kube-system", Resource: "pods", Verb: "delete"})
+	assert.False(t, review.Allowed)
+	assert.Equal(t, "!kube-system", findRoleReview(t, review, "dev").Fields[1].Entry)
+	assert.Contains(t, review.Reason, "none of the 2 roles")
+
+	// Deny roles override
+	carol := model.User{Username: "carol", OIDCGroups: []string{"contractors"}}
+	review = ReviewAccess(carol, AccessRequest{Cluster: "dev", Namespace: "default", Resource: "secrets", Verb: "get"})
+	assert.False(t, review.Allowed)
+	assert.Equal(t, []string{"no-secrets"}, review.DecidedBy)
+	assert.Contains(t, review.Reason, "deny role no-secrets")
+
+	// The review agrees with Authorize for the user
+	req := AccessRequest{Cluster: "prod", Namespace: "default", Resource: "secrets", Verb: "get"}
+	assert.Equal(t, Authorize(bob, req), ReviewAccess(bob, req).Allowed)
+}
+
+func TestReviewAccessWithoutRoles(t *testing.T) {
+	prev := RBACConfig
+	t.Cleanup(func() { RBACConfig = prev })
+	RBACConfig = &common.RolesConfig{}
+
+	review := ReviewAccess(model.User{Username: "dave"}, AccessRequest{Cluster: "dev", Namespace: "default", Resource: "pods", Verb: "get"})
+	assert.False(t, review.Allowed)
+	assert.Equal(t, "no roles are mapped to the user or its groups", review.Reason)
+}
+
+func TestWhoCan(t *testing.T) {
+	setupReviewConfig(t)
+	users := []model.User{
+		{Username: "bob", OIDCGroups: []string{"developers"}},
+		{Username: "carol", OIDCGroups: []string{"developers", "contractors"}},
+	}
+
+	subjects := WhoCan(AccessRequest{Cluster: "dev", Namespace: "team-a", Resource: "pods", Verb: "delete"}, users)
+	assert.Equal(t, []SubjectAccess{
+		{Type: SubjectUser, Name: "alice", Roles: []string{"dev"}},
+		{Type: SubjectUser, Name: "bob", Roles: []string{"dev"}},
+		{Type: SubjectUser, Name: "carol", Roles: []string{"dev"}},
+		{Type: SubjectGroup, Name: "developers", Roles: []string{"dev"}},
+	}, subjects)
+
+	subjects = WhoCan(AccessRequest{Cluster: "dev", Namespace: "team-a", Resource: "secrets", Verb: "get"}, users)
+	var names []string
+	for _, s := range subjects {
+		names = append(names, s.Type+":"+s.Name)
+	}
+	assert.Equal(t, []string{"user:*", "user:alice", "user:bob", "group:developers"}, names)
+}
@@ -0,0 +1,289 @@
+package scim
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pixelvide/kube-sentinel/pkg/model"
+	"gorm.io/gorm"
+	"k8s.io/klog/v2"
+)
+
+type groupResource struct {
+	Schemas     []string    `json:"schemas"`
+	ID          string      `json:"id,omitempty"`
+	ExternalID  string      `json:"externalId,omitempty"`
+	DisplayName string      `json:"displayName"`
+	Members     []memberRef `json:"members"`
+	Meta        *meta       `json:"meta,omitempty"`
+}
+
+func toGroupResource(group *model.SCIMGroup) groupResource {
+	res := groupResource{
+		Schemas:     []string{schemaGroup},
+		ID:          strconv.FormatUint(uint64(group.ID), 10),
+		ExternalID:  group.ExternalID,
+		DisplayName: group.DisplayName,
+		Members:     make([]memberRef, 0, len(group.Members)),
+		Meta:        newMeta("Group", "Groups", group.Model),
+	}
+	for _, u := range group.Members {
+		res.Members = append(res.Members, memberRef{
+			Value:   strconv.FormatUint(uint64(u.ID), 10),
+			Display: u.Username,
+		})
+	}
+	return res
+}
+
+// memberIDs returns the IDs of the existing users among the given members.
+// Members that are not users of kube-sentinel are ignored.
+func memberIDs(members []memberRef) ([]uint, error) {
+	ids := make([]uint, 0, len(members))
+	for _, m := range members {
+		id, err := strconv.ParseUint(m.Value, 10, 32)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, uint(id))
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var existing []uint
+	err := model.DB.Model(&model.User{}).Where("id IN ?", ids).Pluck("id", &existing).Error
+	return existing, err
+}
+
+func listGroups(c *gin.Context) {
+	attribute, value, err := parseFilter(c.Query("filter"))
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "invalidFilter", err.Error())
+		return
+	}
+	if attribute != "" && attribute != "displayname" {
+		writeError(c, http.StatusBadRequest, "invalidFilter", fmt.Sprintf("filtering by %s is not supported", attribute))
+		return
+	}
+
+	startIndex, count := pagination(c)
+	groups, total, err := model.ListSCIMGroups(value, startIndex-1, count)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "", err.Error())
+		return
+	}
+	resources := make([]groupResource, 0, len(groups))
+	for i := range groups {
+		resources = append(resources, toGroupResource(&groups[i]))
+	}
+	c.JSON(http.StatusOK, listResponse{
+		Schemas:      []string{schemaListResponse},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	})
+}
+
+// findGroup loads the group of the request path and writes a 404 when there
+// is none
+func findGroup(c *gin.Context) (*model.SCIMGroup, bool) {
+	id, ok := parseID(c)
+	if !ok {
+		return nil, false
+	}
+	group, err := model.GetSCIMGroup(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		writeError(c, http.StatusNotFound, "", "group not found")
+		return nil, false
+	}
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "", err.Error())
+		return nil, false
+	}
+	return group, true
+}
+
+func getGroup(c *gin.Context) {
+	group, ok := findGroup(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, toGroupResource(group))
+}
+
+// saveGroup stores a group with the given members and writes the resource
+func saveGroup(c *gin.Context, action string, status int, group *model.SCIMGroup, members []memberRef) {
+	if group.DisplayName == "" {
+		writeError(c, http.StatusBadRequest, "invalidValue", "displayName is required")
+		return
+	}
+	var existing model.SCIMGroup
+	err := model.DB.Where("display_name = ?", group.DisplayName).First(&existing).Error
+	if err == nil && existing.ID != group.ID {
+		writeError(c, http.StatusConflict, "uniqueness", fmt.Sprintf("group %s already exists", group.DisplayName))
+		return
+	}
+
+	ids, err := memberIDs(members)
+	if err == nil {
+		err = model.SaveSCIMGroup(group, ids)
+	}
+	recordSCIMAudit(c, action, "groups", group.DisplayName, err)
+	if err != nil {
+		klog.Errorf("Failed to save SCIM group %s: %v", group.DisplayName, err)
+		writeError(c, http.StatusInternalServerError, "", err.Error())
+		return
+	}
+	saved, err := model.GetSCIMGroup(group.ID)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "", err.Error())
+		return
+	}
+	c.JSON(status, toGroupResource(saved))
+}
+
+func createGroup(c *gin.Context) {
+	var req groupResource
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalidSyntax", err.Error())
+		return
+	}
+	group := &model.SCIMGroup{DisplayName: req.DisplayName, ExternalID: req.ExternalID}
+	saveGroup(c, "scim_create_group", http.StatusCreated, group, req.Members)
+}
+
+func replaceGroup(c *gin.Context) {
+	group, ok := findGroup(c)
+	if !ok {
+		return
+	}
+	var req groupResource
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalidSyntax", err.Error())
+		return
+	}
+	group.DisplayName = req.DisplayName
+	group.ExternalID = req.ExternalID
+	saveGroup(c, "scim_update_group", http.StatusOK, group, req.Members)
+}
+
+// memberFilterPath matches the members[value eq "id"] path identity
+// providers use to remove a single member
+var memberFilterPath = regexp.MustCompile(`(?i)^members\[\s*value\s+eq\s+"([^"]*)"\s*\]$`)
+
+func patchGroup(c *gin.Context) {
+	group, ok := findGroup(c)
+	if !ok {
+		return
+	}
+	var req patchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalidSyntax", err.Error())
+		return
+	}
+
+	// Apply the operations to the in-memory group and its member set, then
+	// save both at once
+	members := make(map[string]bool, len(group.Members))
+	for _, u := range group.Members {
+		members[strconv.FormatUint(uint64(u.ID), 10)] = true
+	}
+	for _, op := range req.Operations {
+		if err := applyGroupPatch(group, members, op); err != nil {
+			writeError(c, http.StatusBadRequest, "invalidValue", err.Error())
+			return
+		}
+	}
+	refs := make([]memberRef, 0, len(members))
+	for id := range members {
+		refs = append(refs, memberRef{Value: id})
+	}
+	saveGroup(c, "scim_update_group", http.StatusOK, group, refs)
+}
+
+// applyGroupPatch applies a PATCH operation to a group and its member set
+func applyGroupPatch(group *model.SCIMGroup, members map[string]bool, op patchOp) error {
+	path := strings.ToLower(op.Path)
+	switch strings.ToLower(op.Op) {
+	case "add", "replace":
+		if path == "" {
+			var values map[string]json.RawMessage
+			if err := json.Unmarshal(op.Value, &values); err != nil {
+				return fmt.Errorf("invalid value of %s operation: %w", op.Op, err)
+			}
+			for attr, value := range values {
+				if err := applyGroupPatch(group, members, patchOp{Op: op.Op, Path: attr, Value: value}); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		switch path {
+		case "displayname":
+			return json.Unmarshal(op.Value, &group.DisplayName)
+		case "externalid":
+			return json.Unmarshal(op.Value, &group.ExternalID)
+		case "members":
+			var refs []memberRef
+			if err := json.Unmarshal(op.Value, &refs); err != nil {
+				return fmt.Errorf("invalid members: %w", err)
+			}
+			if strings.EqualFold(op.Op, "replace") {
+				clear(members)
+			}
+			for _, ref := range refs {
+				members[ref.Value] = true
+			}
+			return nil
+		}
+		return fmt.Errorf("unsupported path %q", op.Path)
+	case "remove":
+		if m := memberFilterPath.FindStringSubmatch(op.Path); m != nil {
+			delete(members, m[1])
+			return nil
+		}
+		switch path {
+		case "members":
+			if len(op.Value) == 0 || string(op.Value) == "null" {
+				clear(members)
+				return nil
+			}
+			var refs []memberRef
+			if err := json.Unmarshal(op.Value, &refs); err != nil {
+				return fmt.Errorf("invalid members: %w", err)
+			}
+			for _, ref := range refs {
+				delete(members, ref.Value)
+			}
+			return nil
+		case "externalid":
+			group.ExternalID = ""
+			return nil
+		}
+		return fmt.Errorf("unsupported path %q", op.Path)
+	default:
+		return fmt.Errorf("unsupported operation %q", op.Op)
+	}
+}
+
+func deleteGroup(c *gin.Context) {
+	group, ok := findGroup(c)
+	if !ok {
+		return
+	}
+	err := model.DeleteSCIMGroup(group.ID)
+	recordSCIMAudit(c, "scim_delete_group", "groups", group.DisplayName, err)
+	if err != nil {
+		klog.Errorf("Failed to delete SCIM group %s: %v", group.DisplayName, err)
+		writeError(c, http.StatusInternalServerError, "", err.Error())
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,217 @@
+// Package scim implements the SCIM 2.0 provisioning API (RFC 7643, RFC 7644)
+// identity providers use to create, update and deprovision users and groups.
+package scim
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pixelvide/kube-sentinel/pkg/audit"
+	"github.com/pixelvide/kube-sentinel/pkg/common"
+	"github.com/pixelvide/kube-sentinel/pkg/model"
+)
+
+const (
+	schemaUser                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	schemaGroup                 = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	schemaListResponse          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	schemaError                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+	schemaServiceProviderConfig = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+
+	contentType = "application/scim+json"
+
+	// maxResults bounds the page size of list requests
+	maxResults = 200
+)
+
+// RegisterRoutes adds the SCIM API under /scim/v2
+func RegisterRoutes(r *gin.RouterGroup) {
+	g := r.Group("/scim/v2", RequireToken())
+	{
+		g.GET("/ServiceProviderConfig", serviceProviderConfig)
+
+		g.GET("/Users", listUsers)
+		g.POST("/Users", createUser)
+		g.GET("/Users/:id", getUser)
+		g.PUT("/Users/:id", replaceUser)
+		g.PATCH("/Users/:id", patchUser)
+		g.DELETE("/Users/:id", deleteUser)
+
+		g.GET("/Groups", listGroups)
+		g.POST("/Groups", createGroup)
+		g.GET("/Groups/:id", getGroup)
+		g.PUT("/Groups/:id", replaceGroup)
+		g.PATCH("/Groups/:id", patchGroup)
+		g.DELETE("/Groups/:id", deleteGroup)
+	}
+}
+
+// RequireToken authenticates requests with the SCIM_TOKEN bearer token. The
+// API is disabled when no token is configured.
+func RequireToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Content-Type", contentType)
+		token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if common.SCIMToken == "" || !ok ||
+			subtle.ConstantTimeCompare([]byte(token), []byte(common.SCIMToken)) != 1 {
+			writeError(c, http.StatusUnauthorized, "", "invalid or missing SCIM token")
+			c.Abort()
+			return
+		}
+		c.Set(model.AuditSourceContextKey, model.AuditSourceSCIM)
+		c.Next()
+	}
+}
+
+type scimError struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail"`
+}
+
+func writeError(c *gin.Context, status int, scimType, detail string) {
+	c.JSON(status, scimError{
+		Schemas:  []string{schemaError},
+		Status:   strconv.Itoa(status),
+		ScimType: scimType,
+		Detail:   detail,
+	})
+}
+
+type meta struct {
+	ResourceType string `json:"resourceType"`
+	Created      string `json:"created"`
+	LastModified string `json:"lastModified"`
+	Location     string `json:"location"`
+}
+
+func newMeta(resourceType, path string, m model.Model) *meta {
+	return &meta{
+		ResourceType: resourceType,
+		Created:      m.CreatedAt.UTC().Format(time.RFC3339),
+		LastModified: m.UpdatedAt.UTC().Format(time.RFC3339),
+		Location:     fmt.Sprintf("/scim/v2/%s/%d", path, m.ID),
+	}
+}
+
+// memberRef references a user from a group or a group from a user
+type memberRef struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+}
+
+type listResponse struct {
+	Schemas      []string `json:"schemas"`
+	TotalResults int64    `json:"totalResults"`
+	StartIndex   int      `json:"startIndex"`
+	ItemsPerPage int      `json:"itemsPerPage"`
+	Resources    any      `json:"Resources"`
+}
+
+// pagination reads the 1-based startIndex and count query parameters
+func pagination(c *gin.Context) (startIndex, count int) {
+	startIndex, _ = strconv.Atoi(c.Query("startIndex"))
+	if startIndex < 1 {
+		startIndex = 1
+	}
+	count, err := strconv.Atoi(c.Query("count"))
+	if err != nil || count < 0 || count > maxResults {
+		count = maxResults
+	}
+	return startIndex, count
+}
+
+var filterPattern = regexp.MustCompile(`(?i)^\s*([a-z.]+)\s+eq\s+"((?:[^"\\]|\\.)*)"\s*$`)
+
+// parseFilter supports the `attribute eq "value"` filters identity providers
+// use to look up a resource before creating it. Attributes are returned in
+// lower case.
+func parseFilter(filter string) (attribute, value string, err error) {
+	if filter == "" {
+		return "", "", nil
+	}
+	m := filterPattern.FindStringSubmatch(filter)
+	if m == nil {
+		return "", "", fmt.Errorf("unsupported filter %q", filter)
+	}
+	if err := json.Unmarshal([]byte(`"`+m[2]+`"`), &value); err != nil {
+		return "", "", fmt.Errorf("invalid filter value %q", m[2])
+	}
+	return strings.ToLower(m[1]), value, nil
+}
+
+// flexBool accepts JSON booleans and the "True"/"False" strings some identity
+// providers send
+type flexBool bool
+
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	switch t := v.(type) {
+	case bool:
+		*b = flexBool(t)
+	case string:
+		parsed, err := strconv.ParseBool(t)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", t)
+		}
+		*b = flexBool(parsed)
+	default:
+		return fmt.Errorf("invalid boolean %s", data)
+	}
+	return nil
+}
+
+type patchOp struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"`
+}
+
+type patchRequest struct {
+	Schemas    []string  `json:"schemas"`
+	Operations []patchOp `json:"Operations"`
+}
+
+func parseID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		writeError(c, http.StatusNotFound, "", "resource not found")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func recordSCIMAudit(c *gin.Context, action, resourceType, name string, err error) {
+	entry := audit.FromRequest(c, action)
+	entry.ResourceType = resourceType
+	entry.ResourceName = name
+	audit.Emit(entry.WithError(err))
+}
+
+func serviceProviderConfig(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"schemas":        []string{schemaServiceProviderConfig},
+		"patch":          gin.H{"supported": true},
+		"bulk":           gin.H{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         gin.H{"supported": true, "maxResults": maxResults},
+		"changePassword": gin.H{"supported": false},
+		"sort":           gin.H{"supported": false},
+		"etag":           gin.H{"supported": false},
+		"authenticationSchemes": []gin.H{{
+			"type":        "oauthbearertoken",
+			"name":        "Bearer Token",
+			"description": "The SCIM_TOKEN configured in kube-sentinel",
+		}},
+	})
+}
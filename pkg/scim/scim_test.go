@@ -0,0 +1,225 @@
+package scim
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pixelvide/kube-sentinel/pkg/common"
+	"github.com/pixelvide/kube-sentinel/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testToken = "scim-test-token"
+
+func setupSCIM(t *testing.T) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	common.DBType = "sqlite"
+	common.DBDSN = "file:scim?mode=memory&cache=shared"
+	common.SCIMToken = testToken
+	if model.DB == nil {
+		model.InitDB()
+	}
+	for _, table := range []any{&model.SCIMGroupMember{}, &model.SCIMGroup{}, &model.UserSession{}, &model.User{}} {
+		require.NoError(t, model.DB.Where("1 = 1").Delete(table).Error)
+	}
+
+	r := gin.New()
+	RegisterRoutes(&r.RouterGroup)
+	return r
+}
+
+func do(t *testing.T, r *gin.Engine, method, path string, body any) (*httptest.ResponseRecorder, map[string]any) {
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		require.NoError(t, err)
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	req.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var resp map[string]any
+	if w.Body.Len() > 0 {
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp), w.Body.String())
+	}
+	return w, resp
+}
+
+func TestRequireToken(t *testing.T) {
+	r := setupSCIM(t)
+	for _, header := range []string{"", "Bearer wrong", testToken} {
+		req := httptest.NewRequest(http.MethodGet, "/scim/v2/Users", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code, header)
+	}
+
+	common.SCIMToken = ""
+	req := httptest.NewRequest(http.MethodGet, "/scim/v2/Users", nil)
+	req.Header.Set("Authorization", "Bearer ")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestUserLifecycle(t *testing.T) {
+	r := setupSCIM(t)
+
+	w, created := do(t, r, http.MethodPost, "/scim/v2/Users", map[string]any{
+		"schemas":    []string{schemaUser},
+		"userName":   "alice@example.com",
+		"externalId": "00u1",
+		"name":       map[string]string{"givenName": "Alice", "familyName": "Smith"},
+		"emails":     []map[string]any{{"value": "alice@example.com", "primary": true}},
+		"active":     true,
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Equal(t, contentType, w.Header().Get("Content-Type"))
+	id := created["id"].(string)
+
+	user, err := model.GetUserByUsername("alice@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "Alice Smith", user.Name)
+	assert.Equal(t, "00u1", user.ExternalID)
+	assert.True(t, user.Enabled)
+
+	w, _ = do(t, r, http.MethodPost, "/scim/v2/Users", map[string]any{"userName": "alice@example.com"})
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w, list := do(t, r, http.MethodGet, `/scim/v2/Users?filter=userName+eq+"alice@example.com"`, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.EqualValues(t, 1, list["totalResults"])
+	w, list = do(t, r, http.MethodGet, `/scim/v2/Users?filter=externalId+eq+"missing"`, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.EqualValues(t, 0, list["totalResults"])
+	w, _ = do(t, r, http.MethodGet, `/scim/v2/Users?filter=title+co+"x"`, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Entra ID deactivates users with a string boolean
+	w, patched := do(t, r, http.MethodPatch, "/scim/v2/Users/"+id, map[string]any{
+		"Operations": []map[string]any{
+			{"op": "Replace", "path": "active", "value": "False"},
+			{"op": "replace", "path": "name.givenName", "value": "Alicia"},
+			{"op": "replace", "path": "name.familyName", "value": "Smith"},
+		},
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, false, patched["active"])
+	user, err = model.GetUserByUsername("alice@example.com")
+	require.NoError(t, err)
+	assert.False(t, user.Enabled)
+	assert.Equal(t, "Alicia Smith", user.Name)
+
+	// Okta reactivates with a path-less replace
+	w, _ = do(t, r, http.MethodPatch, "/scim/v2/Users/"+id, map[string]any{
+		"Operations": []map[string]any{{"op": "replace", "value": map[string]any{"active": true}}},
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	user, err = model.GetUserByUsername("alice@example.com")
+	require.NoError(t, err)
+	assert.True(t, user.Enabled)
+
+	w, _ = do(t, r, http.MethodDelete, "/scim/v2/Users/"+id, nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	user, err = model.GetUserByUsername("alice@example.com")
+	require.NoError(t, err, "deprovisioned users are kept")
+	assert.False(t, user.Enabled)
+
+	w, _ = do(t, r, http.MethodGet, "/scim/v2/Users/999999", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestCreateInactiveUser(t *testing.T) {
+	r := setupSCIM(t)
+	w, created := do(t, r, http.MethodPost, "/scim/v2/Users", map[string]any{
+		"userName": "bob",
+		"active":   false,
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Equal(t, false, created["active"])
+	user, err := model.GetUserByUsername("bob")
+	require.NoError(t, err)
+	assert.False(t, user.Enabled)
+}
+
+func TestGroupMembership(t *testing.T) {
+	r := setupSCIM(t)
+	var ids []string
+	for _, name := range []string{"alice", "bob"} {
+		w, created := do(t, r, http.MethodPost, "/scim/v2/Users", map[string]any{"userName": name})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		ids = append(ids, created["id"].(string))
+	}
+	alice, err := model.GetUserByUsername("alice")
+	require.NoError(t, err)
+	bob, err := model.GetUserByUsername("bob")
+	require.NoError(t, err)
+
+	w, group := do(t, r, http.MethodPost, "/scim/v2/Groups", map[string]any{
+		"displayName": "platform",
+		"members":     []map[string]string{{"value": ids[0]}, {"value": "not-a-user"}},
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	groupID := group["id"].(string)
+	assert.Len(t, group["members"], 1)
+
+	w, _ = do(t, r, http.MethodPost, "/scim/v2/Groups", map[string]any{"displayName": "platform"})
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	groups, err := model.GetUserGroups(alice.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"platform"}, groups)
+	assert.Equal(t, model.SliceString{"dev", "platform"}, model.WithSCIMGroups(alice.ID, []string{"dev"}))
+
+	w, _ = do(t, r, http.MethodPatch, "/scim/v2/Groups/"+groupID, map[string]any{
+		"Operations": []map[string]any{
+			{"op": "add", "path": "members", "value": []map[string]string{{"value": ids[1]}}},
+			{"op": "remove", "path": `members[value eq "` + ids[0] + `"]`},
+			{"op": "replace", "value": map[string]any{"displayName": "platform-team"}},
+		},
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Empty(t, model.WithSCIMGroups(alice.ID, nil))
+	assert.Equal(t, model.SliceString{"platform-team"}, model.WithSCIMGroups(bob.ID, nil))
+
+	w, list := do(t, r, http.MethodGet, `/scim/v2/Groups?filter=displayName+eq+"platform-team"`, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.EqualValues(t, 1, list["totalResults"])
+
+	w, user := do(t, r, http.MethodGet, "/scim/v2/Users/"+ids[1], nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, user["groups"], 1)
+
+	// Deprovisioning a user removes its memberships
+	w, _ = do(t, r, http.MethodDelete, "/scim/v2/Users/"+ids[1], nil)
+	require.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, model.WithSCIMGroups(bob.ID, nil))
+
+	w, _ = do(t, r, http.MethodDelete, "/scim/v2/Groups/"+groupID, nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w, _ = do(t, r, http.MethodGet, "/scim/v2/Groups/"+groupID, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestParseFilter(t *testing.T) {
+	attr, value, err := parseFilter(`userName eq "a\"b"`)
+	require.NoError(t, err)
+	assert.Equal(t, "username", attr)
+	assert.Equal(t, `a"b`, value)
+
+	_, _, err = parseFilter(`userName sw "a"`)
+	assert.Error(t, err)
+}
@@ -0,0 +1,424 @@
+package scim
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pixelvide/kube-sentinel/pkg/model"
+	"gorm.io/gorm"
+	"k8s.io/klog/v2"
+)
+
+// maxUsernameLength is the size of the username column
+const maxUsernameLength = 50
+
+type userName struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+type email struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+type userResource struct {
+	Schemas     []string    `json:"schemas"`
+	ID          string      `json:"id,omitempty"`
+	ExternalID  string      `json:"externalId,omitempty"`
+	UserName    string      `json:"userName"`
+	Name        *userName   `json:"name,omitempty"`
+	DisplayName string      `json:"displayName,omitempty"`
+	Emails      []email     `json:"emails,omitempty"`
+	Active      *flexBool   `json:"active,omitempty"`
+	Groups      []memberRef `json:"groups,omitempty"`
+	Meta        *meta       `json:"meta,omitempty"`
+}
+
+// displayName returns the name kube-sentinel shows for the user
+func (r *userResource) displayName() string {
+	if r.DisplayName != "" {
+		return r.DisplayName
+	}
+	if r.Name != nil {
+		if r.Name.Formatted != "" {
+			return r.Name.Formatted
+		}
+		return strings.TrimSpace(r.Name.GivenName + " " + r.Name.FamilyName)
+	}
+	return ""
+}
+
+// primaryEmail returns the primary email, or the first one
+func (r *userResource) primaryEmail() string {
+	for _, e := range r.Emails {
+		if e.Primary {
+			return e.Value
+		}
+	}
+	if len(r.Emails) > 0 {
+		return r.Emails[0].Value
+	}
+	return ""
+}
+
+func (r *userResource) active() bool {
+	return r.Active == nil || bool(*r.Active)
+}
+
+func (r *userResource) validate() error {
+	if r.UserName == "" {
+		return errors.New("userName is required")
+	}
+	if len(r.UserName) > maxUsernameLength {
+		return fmt.Errorf("userName must be at most %d characters", maxUsernameLength)
+	}
+	return nil
+}
+
+// applyTo copies the attributes kube-sentinel stores to a user
+func (r *userResource) applyTo(user *model.User) {
+	user.Username = r.UserName
+	user.ExternalID = r.ExternalID
+	user.Name = r.displayName()
+	user.Email = r.primaryEmail()
+}
+
+func toUserResource(user *model.User, groups []model.SCIMGroup) userResource {
+	active := flexBool(user.Enabled)
+	res := userResource{
+		Schemas:     []string{schemaUser},
+		ID:          strconv.FormatUint(uint64(user.ID), 10),
+		ExternalID:  user.ExternalID,
+		UserName:    user.Username,
+		DisplayName: user.Name,
+		Active:      &active,
+		Meta:        newMeta("User", "Users", user.Model),
+	}
+	if user.Name != "" {
+		res.Name = &userName{Formatted: user.Name}
+	}
+	if user.Email != "" {
+		res.Emails = []email{{Value: user.Email, Type: "work", Primary: true}}
+	}
+	for _, g := range groups {
+		res.Groups = append(res.Groups, memberRef{
+			Value:   strconv.FormatUint(uint64(g.ID), 10),
+			Display: g.DisplayName,
+		})
+	}
+	return res
+}
+
+// userResponse returns the resource of a user with its groups
+func userResponse(user *model.User) (userResource, error) {
+	groups, err := model.ListSCIMGroupsOfUser(user.ID)
+	if err != nil {
+		return userResource{}, err
+	}
+	return toUserResource(user, groups), nil
+}
+
+func listUsers(c *gin.Context) {
+	attribute, value, err := parseFilter(c.Query("filter"))
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "invalidFilter", err.Error())
+		return
+	}
+	query := model.DB.Model(&model.User{})
+	switch attribute {
+	case "":
+	case "username":
+		query = query.Where("username = ?", value)
+	case "externalid":
+		query = query.Where("external_id = ?", value)
+	case "emails.value", "emails":
+		query = query.Where("email = ?", value)
+	default:
+		writeError(c, http.StatusBadRequest, "invalidFilter", fmt.Sprintf("filtering by %s is not supported", attribute))
+		return
+	}
+
+	startIndex, count := pagination(c)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		writeError(c, http.StatusInternalServerError, "", err.Error())
+		return
+	}
+	var users []model.User
+	if err := query.Order("id").Offset(startIndex - 1).Limit(count).Find(&users).Error; err != nil {
+		writeError(c, http.StatusInternalServerError, "", err.Error())
+		return
+	}
+	resources := make([]userResource, 0, len(users))
+	for i := range users {
+		res, err := userResponse(&users[i])
+		if err != nil {
+			writeError(c, http.StatusInternalServerError, "", err.Error())
+			return
+		}
+		resources = append(resources, res)
+	}
+	c.JSON(http.StatusOK, listResponse{
+		Schemas:      []string{schemaListResponse},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	})
+}
+
+// findUser loads the user of the request path and writes a 404 when there
+// is none
+func findUser(c *gin.Context) (*model.User, bool) {
+	id, ok := parseID(c)
+	if !ok {
+		return nil, false
+	}
+	user, err := model.GetUserByID(uint64(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		writeError(c, http.StatusNotFound, "", "user not found")
+		return nil, false
+	}
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "", err.Error())
+		return nil, false
+	}
+	return user, true
+}
+
+func getUser(c *gin.Context) {
+	user, ok := findUser(c)
+	if !ok {
+		return
+	}
+	res, err := userResponse(user)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "", err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func createUser(c *gin.Context) {
+	var req userResource
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalidSyntax", err.Error())
+		return
+	}
+	if err := req.validate(); err != nil {
+		writeError(c, http.StatusBadRequest, "invalidValue", err.Error())
+		return
+	}
+	if _, err := model.GetUserByUsername(req.UserName); err == nil {
+		writeError(c, http.StatusConflict, "uniqueness", fmt.Sprintf("user %s already exists", req.UserName))
+		return
+	}
+
+	// SCIM users have no password and sign in through the identity provider
+	user := &model.User{Enabled: true}
+	req.applyTo(user)
+	err := model.DB.Create(user).Error
+	if err == nil && !req.active() {
+		err = model.SetUserEnabled(user.ID, false)
+		user.Enabled = false
+	}
+	recordSCIMAudit(c, "scim_create_user", "users", req.UserName, err)
+	if err != nil {
+		klog.Errorf("Failed to create SCIM user %s: %v", req.UserName, err)
+		writeError(c, http.StatusInternalServerError, "", err.Error())
+		return
+	}
+	c.JSON(http.StatusCreated, toUserResource(user, nil))
+}
+
+// saveUser stores the attributes of res on the user and writes the updated
+// resource
+func saveUser(c *gin.Context, user *model.User, res *userResource) {
+	if err := res.validate(); err != nil {
+		writeError(c, http.StatusBadRequest, "invalidValue", err.Error())
+		return
+	}
+	if res.UserName != user.Username {
+		if _, err := model.GetUserByUsername(res.UserName); err == nil {
+			writeError(c, http.StatusConflict, "uniqueness", fmt.Sprintf("user %s already exists", res.UserName))
+			return
+		}
+	}
+
+	res.applyTo(user)
+	err := model.DB.Model(user).Select("username", "external_id", "name", "email").Updates(user).Error
+	if err == nil && user.Enabled != res.active() {
+		err = model.SetUserEnabled(user.ID, res.active())
+		user.Enabled = res.active()
+	}
+	recordSCIMAudit(c, "scim_update_user", "users", user.Username, err)
+	if err != nil {
+		klog.Errorf("Failed to update SCIM user %s: %v", user.Username, err)
+		writeError(c, http.StatusInternalServerError, "", err.Error())
+		return
+	}
+	updated, err := userResponse(user)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "", err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, updated)
+}
+
+func replaceUser(c *gin.Context) {
+	user, ok := findUser(c)
+	if !ok {
+		return
+	}
+	var req userResource
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalidSyntax", err.Error())
+		return
+	}
+	saveUser(c, user, &req)
+}
+
+func patchUser(c *gin.Context) {
+	user, ok := findUser(c)
+	if !ok {
+		return
+	}
+	var req patchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalidSyntax", err.Error())
+		return
+	}
+	res := toUserResource(user, nil)
+	for _, op := range req.Operations {
+		if err := applyUserPatch(&res, op); err != nil {
+			writeError(c, http.StatusBadRequest, "invalidValue", err.Error())
+			return
+		}
+	}
+	saveUser(c, user, &res)
+}
+
+// applyUserPatch applies a PATCH operation to a user resource. Attributes
+// kube-sentinel does not store are ignored.
+func applyUserPatch(res *userResource, op patchOp) error {
+	switch strings.ToLower(op.Op) {
+	case "add", "replace":
+		if op.Path != "" {
+			return setUserAttribute(res, op.Path, op.Value)
+		}
+		var values map[string]json.RawMessage
+		if err := json.Unmarshal(op.Value, &values); err != nil {
+			return fmt.Errorf("invalid value of %s operation: %w", op.Op, err)
+		}
+		for path, value := range values {
+			if err := setUserAttribute(res, path, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "remove":
+		return setUserAttribute(res, op.Path, nil)
+	default:
+		return fmt.Errorf("unsupported operation %q", op.Op)
+	}
+}
+
+// setUserAttribute sets an attribute of a user resource, or clears it when
+// value is nil
+func setUserAttribute(res *userResource, path string, value json.RawMessage) error {
+	if value == nil {
+		value = json.RawMessage("null")
+	}
+	setString := func(dst *string) error {
+		*dst = ""
+		return json.Unmarshal(value, dst)
+	}
+	// kube-sentinel stores one display name, so setting a part of the name
+	// drops the other sources it could be derived from
+	name := func() *userName {
+		res.DisplayName = ""
+		if res.Name == nil {
+			res.Name = &userName{}
+		}
+		return res.Name
+	}
+
+	lower := strings.ToLower(path)
+	switch {
+	case lower == "username":
+		return setString(&res.UserName)
+	case lower == "externalid":
+		return setString(&res.ExternalID)
+	case lower == "displayname":
+		res.Name = nil
+		return setString(&res.DisplayName)
+	case lower == "name":
+		res.DisplayName = ""
+		res.Name = nil
+		return json.Unmarshal(value, &res.Name)
+	case lower == "name.formatted":
+		return setString(&name().Formatted)
+	case lower == "name.givenname":
+		n := name()
+		n.Formatted = ""
+		return setString(&n.GivenName)
+	case lower == "name.familyname":
+		n := name()
+		n.Formatted = ""
+		return setString(&n.FamilyName)
+	case lower == "active":
+		if string(value) == "null" {
+			return errors.New("active cannot be removed")
+		}
+		var active flexBool
+		if err := json.Unmarshal(value, &active); err != nil {
+			return err
+		}
+		res.Active = &active
+		return nil
+	case lower == "emails":
+		res.Emails = nil
+		return json.Unmarshal(value, &res.Emails)
+	case strings.HasPrefix(lower, "emails[") && strings.HasSuffix(lower, "].value"):
+		// Identity providers address the work email as
+		// emails[type eq "work"].value, kube-sentinel keeps a single email
+		var v string
+		if err := json.Unmarshal(value, &v); err != nil {
+			return err
+		}
+		res.Emails = nil
+		if v != "" {
+			res.Emails = []email{{Value: v, Primary: true}}
+		}
+		return nil
+	}
+	return nil
+}
+
+// deleteUser deprovisions a user. The user is disabled rather than deleted
+// so its audit history is kept, and removed from all groups.
+func deleteUser(c *gin.Context) {
+	user, ok := findUser(c)
+	if !ok {
+		return
+	}
+	err := model.SetUserEnabled(user.ID, false)
+	if err == nil {
+		err = model.RemoveUserFromSCIMGroups(user.ID)
+	}
+	recordSCIMAudit(c, "scim_disable_user", "users", user.Username, err)
+	if err != nil {
+		klog.Errorf("Failed to deprovision SCIM user %s: %v", user.Username, err)
+		writeError(c, http.StatusInternalServerError, "", err.Error())
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
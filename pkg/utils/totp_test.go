@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTOTPCodeRFC6238(t *testing.T) {
+	// SHA1 test vectors of RFC 6238 appendix B, truncated to 6 digits
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+	for unix, want := range map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1111111111: "050471",
+		1234567890: "005924",
+		2000000000: "279037",
+	} {
+		code, err := TOTPCode(secret, TOTPStep(time.Unix(unix, 0)))
+		require.NoError(t, err)
+		assert.Equal(t, want, code, unix)
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	require.NoError(t, err)
+	now := time.Unix(1700000000, 0)
+
+	code, err := TOTPCode(secret, TOTPStep(now))
+	require.NoError(t, err)
+	step, ok := ValidateTOTP(secret, code, now)
+	assert.True(t, ok)
+	assert.Equal(t, TOTPStep(now), step)
+
+	// Codes of the previous step are accepted for clock drift
+	step, ok = ValidateTOTP(secret, code[:3]+" "+code[3:], now.Add(TOTPPeriod))
+	assert.True(t, ok)
+	assert.Equal(t, TOTPStep(now), step)
+
+	_, ok = ValidateTOTP(secret, code, now.Add(3*TOTPPeriod))
+	assert.False(t, ok)
+	_, ok = ValidateTOTP(secret, "12345", now)
+	assert.False(t, ok)
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	uri := TOTPProvisioningURI("Kube Sentinel", "alice", "JBSWY3DPEHPK3PXP")
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/Kube%20Sentinel:alice?"), uri)
+	assert.Contains(t, uri, "secret=JBSWY3DPEHPK3PXP")
+	assert.Contains(t, uri, "issuer=Kube+Sentinel")
+}
@@ -5,6 +5,7 @@ import {
   IconArrowsMaximize,
   IconArrowsMinimize,
   IconBulb,
+  IconCheck,
   IconChevronDown,
   IconChevronLeft,
   IconHistory,
@@ -25,8 +26,14 @@ import { matchPath, useLocation, useNavigate } from 'react-router-dom'
 import remarkGfm from 'remark-gfm'
 import { toast } from 'sonner'
 
-import { AIChatMessage, AIChatSession, AIModelsResponse } from '@/types/ai'
 import {
+  AIChatMessage,
+  AIChatSession,
+  AIModelsResponse,
+  AIToolApproval,
+} from '@/types/ai'
+import {
+  decideAIToolApproval,
   deleteAIChatSession,
   fetchAIModels,
   getAIChatSession,
@@ -46,6 +53,10 @@ import {
 } from '@/components/ui/select'
 import { Textarea } from '@/components/ui/textarea'
 
+function formatTokens(tokens: number) {
+  return tokens >= 1000 ? `${(tokens / 1000).toFixed(1)}k` : `${tokens}`
+}
+
 export function FloatingAIChat() {
   const { t } = useTranslation()
   const { config } = useAuth()
@@ -63,6 +74,12 @@ export function FloatingAIChat() {
   const [sessions, setSessions] = useState<AIChatSession[]>([])
   const [loadingSessions, setLoadingSessions] = useState(false)
   const [isExpanded, setIsExpanded] = useState(false)
+  const [contextUsage, setContextUsage] = useState<{
+    tokens: number
+    window: number
+  } | null>(null)
+  // Tool calls changing resources which wait for the user to approve them
+  const [approvals, setApprovals] = useState<AIToolApproval[]>([])
 
   const location = useLocation()
   const navigate = useNavigate()
@@ -193,6 +210,12 @@ export function FloatingAIChat() {
 
     setMessages((prev) => [...prev, userMsg])
     setInputValue('')
+    await streamChat(userMsg.content)
+  }
+
+  // streamChat sends a message and streams the answer. Without a message it
+  // resumes the tool calls the chat stopped at to wait for an approval.
+  const streamChat = async (message: string, resume = false) => {
     setSending(true)
     shouldAutoScrollRef.current = true
 
@@ -216,14 +239,20 @@ export function FloatingAIChat() {
         credentials: 'include',
         body: JSON.stringify({
           sessionID: sessionId || '',
-          message: userMsg.content,
+          message,
           model: selectedModel,
           context: getContextFromUrl(),
+          resume,
         }),
       })
 
       if (!response.ok) {
-        throw new Error('Failed to send message')
+        // Show why the message was refused, e.g. a used up token budget
+        const body = await response.json().catch(() => null)
+        toast.error(
+          body?.error || t('aiChat.errors.send', 'Failed to send message')
+        )
+        return
       }
 
       const reader = response.body?.getReader()
@@ -321,6 +350,17 @@ export function FloatingAIChat() {
                       lastToolCallIndex = closeIndex + closeTag.length
                     }
                   }
+                } else if (data.approval) {
+                  const approval: AIToolApproval = data.approval
+                  setApprovals((prev) => [
+                    ...prev.filter((a) => a.id !== approval.id),
+                    ...(approval.status === 'pending' ? [approval] : []),
+                  ])
+                } else if (data.contextWindow) {
+                  setContextUsage({
+                    tokens: data.contextTokens,
+                    window: data.contextWindow,
+                  })
                 } else if (data.error) {
                   toast.error(data.error)
                 }
@@ -339,6 +379,28 @@ export function FloatingAIChat() {
     }
   }
 
+  const handleDecision = async (
+    approval: AIToolApproval,
+    approved: boolean
+  ) => {
+    try {
+      await decideAIToolApproval(
+        approval.sessionID,
+        approval.toolCallID,
+        approved
+      )
+      setApprovals((prev) => prev.filter((a) => a.id !== approval.id))
+      // A chat that is still streaming continues by itself, otherwise the
+      // call waited across a reload and the chat has to be resumed
+      if (!sending) {
+        await streamChat('', true)
+      }
+    } catch (error) {
+      console.error('Failed to decide on tool call', error)
+      toast.error(t('aiChat.errors.decide', 'Failed to submit the decision'))
+    }
+  }
+
   const loadSessions = async () => {
     setLoadingSessions(true)
     try {
@@ -355,7 +417,16 @@ export function FloatingAIChat() {
     try {
       const fullSession = await getAIChatSession(session.id)
       setMessages(fullSession.messages || [])
+      setApprovals(fullSession.pendingApprovals || [])
       setSessionId(session.id)
+      setContextUsage(
+        fullSession.contextWindow
+          ? {
+              tokens: fullSession.contextTokens || 0,
+              window: fullSession.contextWindow,
+            }
+          : null
+      )
       setShowHistory(false)
     } catch (error) {
       console.error('Failed to load session details', error)
@@ -371,6 +442,8 @@ export function FloatingAIChat() {
       if (sessionId === id) {
         setSessionId(null)
         setMessages([])
+        setApprovals([])
+        setContextUsage(null)
       }
       toast.success(t('aiChat.sessionDeleted', 'Session deleted'))
     } catch (error) {
@@ -382,6 +455,8 @@ export function FloatingAIChat() {
   const handleNewChat = () => {
     setSessionId(null)
     setMessages([])
+    setApprovals([])
+    setContextUsage(null)
     setShowHistory(false)
   }
 
@@ -608,6 +683,27 @@ export function FloatingAIChat() {
                       </div>
                     ) : (
                       (() => {
+                        if (msg.summary) {
+                          return (
+                            <Collapsible.Root className="bg-muted/30 rounded-2xl rounded-bl-none border border-dashed border-primary/20 overflow-hidden max-w-[85%]">
+                              <Collapsible.Trigger asChild>
+                                <button className="flex items-center justify-between gap-2 w-full px-3 py-2 text-[11px] text-muted-foreground hover:bg-primary/5 transition-colors">
+                                  <span>
+                                    {t(
+                                      'aiChat.summary',
+                                      'Earlier messages were summarized to fit the context window'
+                                    )}
+                                  </span>
+                                  <IconChevronDown className="h-3 w-3 flex-shrink-0" />
+                                </button>
+                              </Collapsible.Trigger>
+                              <Collapsible.Content className="px-3 py-2 text-[11px] text-muted-foreground border-t border-primary/5 whitespace-pre-wrap">
+                                {msg.content}
+                              </Collapsible.Content>
+                            </Collapsible.Root>
+                          )
+                        }
+
                         const content = msg.content
                         const parts = []
                         let remaining = content
@@ -818,6 +914,46 @@ export function FloatingAIChat() {
                   </div>
                 ))}
 
+                {approvals.map((approval) => (
+                  <div
+                    key={approval.id}
+                    className="ml-9 max-w-[85%] rounded-lg border border-amber-300 dark:border-amber-700 bg-amber-50/50 dark:bg-amber-900/10 overflow-hidden"
+                  >
+                    <div className="px-3 py-1.5 text-[11px] font-medium text-amber-800 dark:text-amber-300">
+                      {t(
+                        'aiChat.approval.title',
+                        'Approve {{tool}} on {{cluster}}?',
+                        {
+                          tool: approval.toolName,
+                          cluster: approval.clusterName,
+                        }
+                      )}
+                    </div>
+                    <pre className="px-3 py-2 text-[10px] font-mono whitespace-pre-wrap border-t border-amber-200 dark:border-amber-800">
+                      {approval.preview}
+                    </pre>
+                    <div className="flex justify-end gap-2 px-3 py-2 border-t border-amber-200 dark:border-amber-800">
+                      <Button
+                        size="sm"
+                        variant="outline"
+                        className="h-7 text-xs"
+                        onClick={() => handleDecision(approval, false)}
+                      >
+                        <IconX className="h-3 w-3" />
+                        {t('aiChat.approval.reject', 'Reject')}
+                      </Button>
+                      <Button
+                        size="sm"
+                        className="h-7 text-xs"
+                        onClick={() => handleDecision(approval, true)}
+                      >
+                        <IconCheck className="h-3 w-3" />
+                        {t('aiChat.approval.approve', 'Approve')}
+                      </Button>
+                    </div>
+                  </div>
+                ))}
+
                 {sending && (
                   <div className="flex gap-2 justify-start">
                     <div className="h-7 w-7 rounded-full bg-primary/10 flex items-center justify-center flex-shrink-0">
@@ -874,7 +1010,31 @@ export function FloatingAIChat() {
               </Button>
             </div>
             <div className="text-[10px] text-muted-foreground mt-2 flex justify-between items-center px-1">
-              <span>Kubernetes Assistant</span>
+              {contextUsage ? (
+                <span
+                  title={t(
+                    'aiChat.contextUsageHint',
+                    'Estimated tokens of the conversation sent to the model. Older messages are summarized when it gets full.'
+                  )}
+                >
+                  {t(
+                    'aiChat.contextUsage',
+                    'Context: {{used}} / {{total}} ({{percent}}%)',
+                    {
+                      used: formatTokens(contextUsage.tokens),
+                      total: formatTokens(contextUsage.window),
+                      percent: Math.min(
+                        100,
+                        Math.round(
+                          (contextUsage.tokens / contextUsage.window) * 100
+                        )
+                      ),
+                    }
+                  )}
+                </span>
+              ) : (
+                <span>Kubernetes Assistant</span>
+              )}
               <div className="flex gap-2">
                 <span
                   className="hover:text-primary cursor-pointer transition-colors"
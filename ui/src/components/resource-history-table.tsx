@@ -29,7 +29,9 @@ export function ResourceHistoryTable<T extends ResourceType>({
   currentResource,
 }: ResourceHistoryTableProps<T>) {
   const { t } = useTranslation()
-  const [currentPage, setCurrentPage] = useState(1)
+  // Cursors of the pages from the first to the current one, the history is
+  // paged by the cursor the previous page returned
+  const [cursors, setCursors] = useState<number[]>([0])
   const [pageSize] = useState(10)
   const [selectedHistory, setSelectedHistory] =
     useState<ResourceHistory | null>(null)
@@ -47,12 +49,16 @@ export function ResourceHistoryTable<T extends ResourceType>({
     resourceType,
     namespace ?? '_all',
     name,
-    currentPage,
+    cursors[cursors.length - 1],
     pageSize
   )
 
   const history = historyResponse?.data || []
-  // const pagination = historyResponse?.pagination
+  const pagination = historyResponse?.pagination
+  const totalPages = Math.max(
+    Math.ceil((pagination?.total ?? 0) / pageSize),
+    cursors.length
+  )
 
   // Convert current resource to YAML
   const currentYaml = useMemo(() => {
@@ -264,14 +270,36 @@ export function ResourceHistoryTable<T extends ResourceType>({
             columns={historyColumns}
             emptyMessage={t('resourceHistory.noHistoryFound')}
             getRowId={(history) => history.id.toString()}
-            pagination={{
-              enabled: true,
-              pageSize,
-              showPageInfo: true,
-              currentPage,
-              onPageChange: setCurrentPage,
-            }}
           />
+          {(cursors.length > 1 || pagination?.hasNextPage) && (
+            <div className="flex items-center justify-end gap-2 pt-4 text-sm text-muted-foreground">
+              <span>
+                {t('resourceHistory.pageOf', 'Page {{page}} of {{total}}', {
+                  page: cursors.length,
+                  total: totalPages,
+                })}
+              </span>
+              <Button
+                variant="outline"
+                size="sm"
+                disabled={cursors.length === 1}
+                onClick={() => setCursors((prev) => prev.slice(0, -1))}
+              >
+                {t('resourceHistory.previousPage', 'Previous')}
+              </Button>
+              <Button
+                variant="outline"
+                size="sm"
+                disabled={!pagination?.hasNextPage}
+                onClick={() =>
+                  pagination &&
+                  setCursors((prev) => [...prev, pagination.nextCursor])
+                }
+              >
+                {t('resourceHistory.nextPage', 'Next')}
+              </Button>
+            </div>
+          )}
         </CardContent>
       </Card>
 
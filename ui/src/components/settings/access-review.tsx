@@ -0,0 +1,205 @@
+import { useState } from 'react'
+import {
+  IconCheck,
+  IconSearch,
+  IconUserQuestion,
+  IconX,
+} from '@tabler/icons-react'
+import { useMutation } from '@tanstack/react-query'
+import { useTranslation } from 'react-i18next'
+import { toast } from 'sonner'
+
+import { AccessReviewRequest } from '@/types/api'
+import { reviewAccess, whoCan } from '@/lib/api'
+import { Badge } from '@/components/ui/badge'
+import { Button } from '@/components/ui/button'
+import { Card, CardContent, CardHeader, CardTitle } from '@/components/ui/card'
+import { Input } from '@/components/ui/input'
+import { Label } from '@/components/ui/label'
+
+const splitList = (value: string) =>
+  value
+    .split(',')
+    .map((v) => v.trim())
+    .filter(Boolean)
+
+export function AccessReview() {
+  const { t } = useTranslation()
+  const [form, setForm] = useState({
+    user: '',
+    groups: '',
+    cluster: '',
+    namespace: '',
+    resource: '',
+    verb: 'get',
+    name: '',
+  })
+
+  const request = (): AccessReviewRequest => ({
+    user: form.user.trim() || undefined,
+    groups: splitList(form.groups),
+    cluster: form.cluster.trim(),
+    namespace: form.namespace.trim() || undefined,
+    resource: form.resource.trim(),
+    verb: form.verb.trim(),
+    name: form.name.trim() || undefined,
+  })
+
+  const onError = (error: Error) =>
+    toast.error(error.message || t('accessReview.error', 'Check failed'))
+  const reviewMutation = useMutation({ mutationFn: reviewAccess, onError })
+  const whoCanMutation = useMutation({ mutationFn: whoCan, onError })
+
+  const field = (key: keyof typeof form, label: string, placeholder = '') => (
+    <div className="space-y-2">
+      <Label htmlFor={`access-${key}`}>{label}</Label>
+      <Input
+        id={`access-${key}`}
+        value={form[key]}
+        placeholder={placeholder}
+        onChange={(e) => setForm((prev) => ({ ...prev, [key]: e.target.value }))}
+      />
+    </div>
+  )
+
+  const canCheck =
+    !!form.cluster.trim() && !!form.resource.trim() && !!form.verb.trim()
+  const result = reviewMutation.data
+  const subjects = whoCanMutation.data
+
+  return (
+    <Card>
+      <CardHeader>
+        <CardTitle className="flex items-center gap-2">
+          <IconUserQuestion className="h-5 w-5" />
+          {t('accessReview.title', 'Access Review')}
+        </CardTitle>
+        <p className="text-sm text-muted-foreground mt-1">
+          {t(
+            'accessReview.description',
+            'Check why a user or group can or cannot perform an action, or list who can'
+          )}
+        </p>
+      </CardHeader>
+      <CardContent className="space-y-4">
+        <div className="grid grid-cols-2 gap-4 md:grid-cols-4">
+          {field('user', t('accessReview.user', 'User'), 'alice')}
+          {field('groups', t('accessReview.groups', 'Groups'), 'dev, ops')}
+          {field('cluster', t('accessReview.cluster', 'Cluster'), 'prod')}
+          {field(
+            'namespace',
+            t('accessReview.namespace', 'Namespace'),
+            t('accessReview.allNamespaces', 'All namespaces')
+          )}
+          {field(
+            'resource',
+            t('accessReview.resource', 'Resource'),
+            'pods or pods/exec'
+          )}
+          {field('verb', t('accessReview.verb', 'Verb'), 'get')}
+          {field('name', t('accessReview.name', 'Object Name'), 'web-0')}
+        </div>
+        <div className="flex gap-2">
+          <Button
+            disabled={
+              !canCheck ||
+              (!form.user.trim() && !form.groups.trim()) ||
+              reviewMutation.isPending
+            }
+            onClick={() => reviewMutation.mutate(request())}
+            className="gap-2"
+          >
+            <IconSearch className="h-4 w-4" />
+            {t('accessReview.check', 'Check Access')}
+          </Button>
+          <Button
+            variant="outline"
+            disabled={!canCheck || whoCanMutation.isPending}
+            onClick={() => whoCanMutation.mutate(request())}
+          >
+            {t('accessReview.whoCan', 'Who Can')}
+          </Button>
+        </div>
+
+        {result && (
+          <div className="space-y-3 rounded-md border p-4">
+            <div className="flex items-center gap-2">
+              {result.review.allowed ? (
+                <Badge className="gap-1">
+                  <IconCheck className="h-3 w-3" />
+                  {t('accessReview.allowed', 'Allowed')}
+                </Badge>
+              ) : (
+                <Badge variant="destructive" className="gap-1">
+                  <IconX className="h-3 w-3" />
+                  {t('accessReview.denied', 'Denied')}
+                </Badge>
+              )}
+              <span className="text-sm">{result.review.reason}</span>
+            </div>
+            <div className="text-xs text-muted-foreground">
+              {result.userFound
+                ? t('accessReview.groupsOf', 'Groups: {{groups}}', {
+                    groups: result.groups.join(', ') || '-',
+                  })
+                : t(
+                    'accessReview.unknownUser',
+                    'Not a known user, checked with the given groups only'
+                  )}
+            </div>
+            {result.review.roles.map((role) => (
+              <div key={role.role} className="space-y-1 border-t pt-2">
+                <div className="flex items-center gap-2 text-sm">
+                  <span className="font-medium">{role.role}</span>
+                  {role.effect === 'deny' && (
+                    <Badge variant="destructive">Deny</Badge>
+                  )}
+                  {role.applies && <Badge variant="secondary">Applies</Badge>}
+                  <span className="text-xs text-muted-foreground">
+                    {role.sources
+                      .map((s) => `${s.type}: ${s.subject}`)
+                      .join(', ')}
+                  </span>
+                </div>
+                <p className="text-xs text-muted-foreground">{role.reason}</p>
+                <ul className="text-xs space-y-0.5">
+                  {role.fields.map((f) => (
+                    <li
+                      key={f.field}
+                      className={f.matched ? '' : 'text-destructive'}
+                    >
+                      <span className="font-mono">{f.field}</span>:{' '}
+                      {f.explanation}
+                    </li>
+                  ))}
+                </ul>
+              </div>
+            ))}
+          </div>
+        )}
+
+        {subjects && (
+          <div className="space-y-2 rounded-md border p-4">
+            {subjects.length === 0 && (
+              <p className="text-sm text-muted-foreground">
+                {t('accessReview.nobody', 'Nobody can perform this action')}
+              </p>
+            )}
+            {subjects.map((s) => (
+              <div
+                key={`${s.type}:${s.name}`}
+                className="flex items-center gap-2 text-sm"
+              >
+                <Badge variant="outline">{s.type}</Badge>
+                <span className="font-medium">{s.name}</span>
+                <span className="text-xs text-muted-foreground">
+                  {s.roles.join(', ')}
+                </span>
+              </div>
+            ))}
+          </div>
+        )}
+      </CardContent>
+    </Card>
+  )
+}
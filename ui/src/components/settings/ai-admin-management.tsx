@@ -24,6 +24,7 @@ import {
   SelectValue,
 } from '@/components/ui/select'
 import { Switch } from '@/components/ui/switch'
+import { AIUsageManagement } from '@/components/settings/ai-usage-management'
 
 export function AIAdminManagement() {
   const [loading, setLoading] = useState(true)
@@ -220,9 +221,7 @@ export function AIAdminManagement() {
                   <Label>Provider Type</Label>
                   <Select
                     value={profile.provider}
-                    onValueChange={(
-                      v: 'gemini' | 'openai' | 'azure' | 'custom'
-                    ) => {
+                    onValueChange={(v: AIProviderProfile['provider']) => {
                       const newProfiles = profiles.map((p) =>
                         p.id === profile.id ? { ...p, provider: v } : p
                       )
@@ -235,7 +234,11 @@ export function AIAdminManagement() {
                     <SelectContent>
                       <SelectItem value="gemini">Gemini</SelectItem>
                       <SelectItem value="openai">OpenAI</SelectItem>
-                      <SelectItem value="azure">Azure</SelectItem>
+                      <SelectItem value="anthropic">Anthropic</SelectItem>
+                      <SelectItem value="azure">Azure OpenAI</SelectItem>
+                      <SelectItem value="ollama">
+                        Self-hosted (Ollama, vLLM, llama.cpp)
+                      </SelectItem>
                       <SelectItem value="custom">Custom</SelectItem>
                     </SelectContent>
                   </Select>
@@ -254,8 +257,29 @@ export function AIAdminManagement() {
                     }}
                   />
                 </div>
+                {profile.provider === 'azure' && (
+                  <div className="space-y-2">
+                    <Label>API Version</Label>
+                    <Input
+                      value={profile.apiVersion || ''}
+                      placeholder="2024-10-21"
+                      onChange={(e) => {
+                        const newProfiles = profiles.map((p) =>
+                          p.id === profile.id
+                            ? { ...p, apiVersion: e.target.value }
+                            : p
+                        )
+                        setProfiles(newProfiles)
+                      }}
+                    />
+                  </div>
+                )}
                 <div className="space-y-2">
-                  <Label>Default Model</Label>
+                  <Label>
+                    {profile.provider === 'azure'
+                      ? 'Deployment Name'
+                      : 'Default Model'}
+                  </Label>
                   <Input
                     value={profile.defaultModel}
                     onChange={(e) => {
@@ -268,6 +292,26 @@ export function AIAdminManagement() {
                     }}
                   />
                 </div>
+                <div className="space-y-2">
+                  <Label>Context Window (tokens)</Label>
+                  <Input
+                    type="number"
+                    min={0}
+                    value={profile.contextWindow || ''}
+                    placeholder="Detected from the model"
+                    onChange={(e) => {
+                      const newProfiles = profiles.map((p) =>
+                        p.id === profile.id
+                          ? {
+                              ...p,
+                              contextWindow: parseInt(e.target.value) || 0,
+                            }
+                          : p
+                      )
+                      setProfiles(newProfiles)
+                    }}
+                  />
+                </div>
                 <div className="space-y-2">
                   <Label>System API Key</Label>
                   <Input
@@ -285,7 +329,11 @@ export function AIAdminManagement() {
                   />
                 </div>
                 <div className="space-y-2 col-span-2">
-                  <Label>Allowed Models (Comma separated, empty for any)</Label>
+                  <Label>
+                    {profile.provider === 'ollama'
+                      ? 'Allowed Models (Comma separated, empty for all models of the server)'
+                      : 'Allowed Models (Comma separated, empty for any)'}
+                  </Label>
                   <Input
                     value={profile.allowedModels?.join(', ') || ''}
                     placeholder="e.g. gpt-4, gpt-3.5-turbo"
@@ -387,6 +435,8 @@ export function AIAdminManagement() {
           ))}
         </CardContent>
       </Card>
+
+      <AIUsageManagement profiles={profiles} />
     </div>
   )
 }
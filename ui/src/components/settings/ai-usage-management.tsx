@@ -0,0 +1,468 @@
+import { useCallback, useEffect, useState } from 'react'
+import { IconChartBar, IconPlus, IconTrash } from '@tabler/icons-react'
+import { toast } from 'sonner'
+
+import {
+  AIBudget,
+  AIModelPrice,
+  AIProviderProfile,
+  AIUsageGroup,
+  AIUsageReport,
+} from '@/types/ai'
+import {
+  createAIBudget,
+  createAIModelPrice,
+  deleteAIBudget,
+  deleteAIModelPrice,
+  fetchAIBudgets,
+  fetchAIModelPrices,
+  fetchAIUsageReport,
+  updateAIBudget,
+  updateAIModelPrice,
+} from '@/lib/api'
+import { Button } from '@/components/ui/button'
+import { Card, CardContent, CardHeader, CardTitle } from '@/components/ui/card'
+import { Input } from '@/components/ui/input'
+import { Label } from '@/components/ui/label'
+import {
+  Select,
+  SelectContent,
+  SelectItem,
+  SelectTrigger,
+  SelectValue,
+} from '@/components/ui/select'
+import {
+  Table,
+  TableBody,
+  TableCell,
+  TableFooter,
+  TableHead,
+  TableHeader,
+  TableRow,
+} from '@/components/ui/table'
+
+const formatCost = (cost: number) =>
+  cost.toLocaleString(undefined, {
+    minimumFractionDigits: 2,
+    maximumFractionDigits: 4,
+  })
+
+export function AIUsageManagement({
+  profiles,
+}: {
+  profiles: AIProviderProfile[]
+}) {
+  const [groupBy, setGroupBy] = useState<AIUsageGroup>('user')
+  const [from, setFrom] = useState('')
+  const [to, setTo] = useState('')
+  const [report, setReport] = useState<AIUsageReport | null>(null)
+  const [prices, setPrices] = useState<AIModelPrice[]>([])
+  const [budgets, setBudgets] = useState<AIBudget[]>([])
+
+  const loadReport = useCallback(async () => {
+    try {
+      setReport(await fetchAIUsageReport({ from, to, groupBy }))
+    } catch (err) {
+      console.error(err)
+      toast.error('Failed to load AI usage report')
+    }
+  }, [from, to, groupBy])
+
+  const loadLimits = useCallback(async () => {
+    try {
+      const [p, b] = await Promise.all([fetchAIModelPrices(), fetchAIBudgets()])
+      setPrices(p)
+      setBudgets(b)
+    } catch (err) {
+      console.error(err)
+      toast.error('Failed to load AI prices and budgets')
+    }
+  }, [])
+
+  useEffect(() => {
+    loadReport()
+  }, [loadReport])
+
+  useEffect(() => {
+    loadLimits()
+  }, [loadLimits])
+
+  const handleCreatePrice = async () => {
+    try {
+      await createAIModelPrice({
+        model: `new-model-${prices.length + 1}`,
+        promptPrice: 0,
+        completionPrice: 0,
+      })
+      loadLimits()
+      toast.success('Model price created')
+    } catch (err) {
+      toast.error((err as Error).message || 'Failed to create model price')
+    }
+  }
+
+  const handleUpdatePrice = async (price: AIModelPrice) => {
+    try {
+      await updateAIModelPrice(price.id, price)
+      toast.success('Model price updated')
+    } catch (err) {
+      toast.error((err as Error).message || 'Failed to update model price')
+    }
+  }
+
+  const handleDeletePrice = async (id: number) => {
+    try {
+      await deleteAIModelPrice(id)
+      setPrices((prev) => prev.filter((p) => p.id !== id))
+      toast.success('Model price deleted')
+    } catch {
+      toast.error('Failed to delete model price')
+    }
+  }
+
+  const handleCreateBudget = async () => {
+    try {
+      await createAIBudget({
+        scope: 'user',
+        scopeId: 0,
+        period: 'day',
+        tokenLimit: 100000,
+      })
+      loadLimits()
+      toast.success('Budget created')
+    } catch (err) {
+      toast.error((err as Error).message || 'Failed to create budget')
+    }
+  }
+
+  const handleUpdateBudget = async (budget: AIBudget) => {
+    try {
+      await updateAIBudget(budget.id, budget)
+      toast.success('Budget updated')
+    } catch (err) {
+      toast.error((err as Error).message || 'Failed to update budget')
+    }
+  }
+
+  const handleDeleteBudget = async (id: number) => {
+    try {
+      await deleteAIBudget(id)
+      setBudgets((prev) => prev.filter((b) => b.id !== id))
+      toast.success('Budget deleted')
+    } catch {
+      toast.error('Failed to delete budget')
+    }
+  }
+
+  const setPrice = (id: number, change: Partial<AIModelPrice>) =>
+    setPrices((prev) =>
+      prev.map((p) => (p.id === id ? { ...p, ...change } : p))
+    )
+
+  const setBudget = (id: number, change: Partial<AIBudget>) =>
+    setBudgets((prev) =>
+      prev.map((b) => (b.id === id ? { ...b, ...change } : b))
+    )
+
+  return (
+    <>
+      <Card>
+        <CardHeader>
+          <CardTitle className="flex items-center gap-2">
+            <IconChartBar className="h-5 w-5" />
+            AI Usage
+          </CardTitle>
+        </CardHeader>
+        <CardContent className="space-y-4">
+          <div className="grid grid-cols-3 gap-4">
+            <div className="space-y-2">
+              <Label>From</Label>
+              <Input
+                type="date"
+                value={from || report?.from || ''}
+                onChange={(e) => setFrom(e.target.value)}
+              />
+            </div>
+            <div className="space-y-2">
+              <Label>To</Label>
+              <Input
+                type="date"
+                value={to || report?.to || ''}
+                onChange={(e) => setTo(e.target.value)}
+              />
+            </div>
+            <div className="space-y-2">
+              <Label>Group By</Label>
+              <Select
+                value={groupBy}
+                onValueChange={(v: AIUsageGroup) => setGroupBy(v)}
+              >
+                <SelectTrigger>
+                  <SelectValue />
+                </SelectTrigger>
+                <SelectContent>
+                  <SelectItem value="user">User</SelectItem>
+                  <SelectItem value="profile">Profile</SelectItem>
+                  <SelectItem value="model">Model</SelectItem>
+                  <SelectItem value="session">Chat Session</SelectItem>
+                  <SelectItem value="day">Day</SelectItem>
+                </SelectContent>
+              </Select>
+            </div>
+          </div>
+
+          <Table>
+            <TableHeader>
+              <TableRow>
+                <TableHead>Name</TableHead>
+                <TableHead className="text-right">Requests</TableHead>
+                <TableHead className="text-right">Prompt Tokens</TableHead>
+                <TableHead className="text-right">Completion Tokens</TableHead>
+                <TableHead className="text-right">Total Tokens</TableHead>
+                <TableHead className="text-right">Cost</TableHead>
+              </TableRow>
+            </TableHeader>
+            <TableBody>
+              {report?.rows.length ? (
+                report.rows.map((row) => (
+                  <TableRow key={row.key}>
+                    <TableCell>{row.label || '-'}</TableCell>
+                    <TableCell className="text-right">
+                      {row.requests.toLocaleString()}
+                    </TableCell>
+                    <TableCell className="text-right">
+                      {row.promptTokens.toLocaleString()}
+                    </TableCell>
+                    <TableCell className="text-right">
+                      {row.completionTokens.toLocaleString()}
+                    </TableCell>
+                    <TableCell className="text-right">
+                      {row.totalTokens.toLocaleString()}
+                    </TableCell>
+                    <TableCell className="text-right">
+                      {formatCost(row.cost)}
+                    </TableCell>
+                  </TableRow>
+                ))
+              ) : (
+                <TableRow>
+                  <TableCell
+                    colSpan={6}
+                    className="text-center text-muted-foreground"
+                  >
+                    No AI usage in this period
+                  </TableCell>
+                </TableRow>
+              )}
+            </TableBody>
+            {report && report.rows.length > 0 && (
+              <TableFooter>
+                <TableRow>
+                  <TableCell>Total</TableCell>
+                  <TableCell className="text-right">
+                    {report.total.requests.toLocaleString()}
+                  </TableCell>
+                  <TableCell className="text-right">
+                    {report.total.promptTokens.toLocaleString()}
+                  </TableCell>
+                  <TableCell className="text-right">
+                    {report.total.completionTokens.toLocaleString()}
+                  </TableCell>
+                  <TableCell className="text-right">
+                    {report.total.totalTokens.toLocaleString()}
+                  </TableCell>
+                  <TableCell className="text-right">
+                    {formatCost(report.total.cost)}
+                  </TableCell>
+                </TableRow>
+              </TableFooter>
+            )}
+          </Table>
+        </CardContent>
+      </Card>
+
+      <Card>
+        <CardHeader className="flex flex-row items-center justify-between">
+          <div>
+            <CardTitle>Model Prices</CardTitle>
+            <p className="text-sm text-muted-foreground">
+              Prices per million tokens, used to estimate the cost of new
+              usage.
+            </p>
+          </div>
+          <Button size="sm" onClick={handleCreatePrice}>
+            <IconPlus className="mr-2 h-4 w-4" /> Add Price
+          </Button>
+        </CardHeader>
+        <CardContent className="space-y-4">
+          {prices.map((price) => (
+            <div key={price.id} className="flex items-end gap-4">
+              <div className="space-y-2 flex-1">
+                <Label>Model</Label>
+                <Input
+                  value={price.model}
+                  onChange={(e) =>
+                    setPrice(price.id, { model: e.target.value })
+                  }
+                />
+              </div>
+              <div className="space-y-2 w-40">
+                <Label>Prompt Price</Label>
+                <Input
+                  type="number"
+                  min={0}
+                  step="0.01"
+                  value={price.promptPrice}
+                  onChange={(e) =>
+                    setPrice(price.id, {
+                      promptPrice: parseFloat(e.target.value) || 0,
+                    })
+                  }
+                />
+              </div>
+              <div className="space-y-2 w-40">
+                <Label>Completion Price</Label>
+                <Input
+                  type="number"
+                  min={0}
+                  step="0.01"
+                  value={price.completionPrice}
+                  onChange={(e) =>
+                    setPrice(price.id, {
+                      completionPrice: parseFloat(e.target.value) || 0,
+                    })
+                  }
+                />
+              </div>
+              <Button
+                variant="destructive"
+                size="sm"
+                onClick={() => handleDeletePrice(price.id)}
+              >
+                <IconTrash className="h-4 w-4" />
+              </Button>
+              <Button size="sm" onClick={() => handleUpdatePrice(price)}>
+                Update
+              </Button>
+            </div>
+          ))}
+        </CardContent>
+      </Card>
+
+      <Card>
+        <CardHeader className="flex flex-row items-center justify-between">
+          <div>
+            <CardTitle>Token Budgets</CardTitle>
+            <p className="text-sm text-muted-foreground">
+              Daily and monthly token limits. A user budget for user ID 0
+              applies to all users without a budget of their own, a profile
+              budget counts the usage of all users of the profile.
+            </p>
+          </div>
+          <Button size="sm" onClick={handleCreateBudget}>
+            <IconPlus className="mr-2 h-4 w-4" /> Add Budget
+          </Button>
+        </CardHeader>
+        <CardContent className="space-y-4">
+          {budgets.map((budget) => (
+            <div key={budget.id} className="flex items-end gap-4">
+              <div className="space-y-2 w-36">
+                <Label>Scope</Label>
+                <Select
+                  value={budget.scope}
+                  onValueChange={(v: AIBudget['scope']) =>
+                    setBudget(budget.id, {
+                      scope: v,
+                      scopeId: v === 'profile' ? profiles[0]?.id || 0 : 0,
+                    })
+                  }
+                >
+                  <SelectTrigger>
+                    <SelectValue />
+                  </SelectTrigger>
+                  <SelectContent>
+                    <SelectItem value="user">User</SelectItem>
+                    <SelectItem value="profile">Profile</SelectItem>
+                  </SelectContent>
+                </Select>
+              </div>
+              <div className="space-y-2 flex-1">
+                <Label>{budget.scope === 'user' ? 'User ID' : 'Profile'}</Label>
+                {budget.scope === 'user' ? (
+                  <Input
+                    type="number"
+                    min={0}
+                    value={budget.scopeId}
+                    onChange={(e) =>
+                      setBudget(budget.id, {
+                        scopeId: parseInt(e.target.value) || 0,
+                      })
+                    }
+                  />
+                ) : (
+                  <Select
+                    value={String(budget.scopeId)}
+                    onValueChange={(v) =>
+                      setBudget(budget.id, { scopeId: parseInt(v) })
+                    }
+                  >
+                    <SelectTrigger>
+                      <SelectValue />
+                    </SelectTrigger>
+                    <SelectContent>
+                      {profiles.map((p) => (
+                        <SelectItem key={p.id} value={String(p.id)}>
+                          {p.name}
+                        </SelectItem>
+                      ))}
+                    </SelectContent>
+                  </Select>
+                )}
+              </div>
+              <div className="space-y-2 w-32">
+                <Label>Period</Label>
+                <Select
+                  value={budget.period}
+                  onValueChange={(v: AIBudget['period']) =>
+                    setBudget(budget.id, { period: v })
+                  }
+                >
+                  <SelectTrigger>
+                    <SelectValue />
+                  </SelectTrigger>
+                  <SelectContent>
+                    <SelectItem value="day">Day</SelectItem>
+                    <SelectItem value="month">Month</SelectItem>
+                  </SelectContent>
+                </Select>
+              </div>
+              <div className="space-y-2 w-40">
+                <Label>Token Limit</Label>
+                <Input
+                  type="number"
+                  min={1}
+                  value={budget.tokenLimit}
+                  onChange={(e) =>
+                    setBudget(budget.id, {
+                      tokenLimit: parseInt(e.target.value) || 0,
+                    })
+                  }
+                />
+              </div>
+              <Button
+                variant="destructive"
+                size="sm"
+                onClick={() => handleDeleteBudget(budget.id)}
+              >
+                <IconTrash className="h-4 w-4" />
+              </Button>
+              <Button size="sm" onClick={() => handleUpdateBudget(budget)}>
+                Update
+              </Button>
+            </div>
+          ))}
+        </CardContent>
+      </Card>
+    </>
+  )
+}
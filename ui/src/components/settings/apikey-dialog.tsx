@@ -1,6 +1,7 @@
 import { useEffect, useState } from 'react'
 import { useTranslation } from 'react-i18next'
 
+import { APIKeyCreateRequest } from '@/lib/api'
 import { Button } from '@/components/ui/button'
 import {
   Dialog,
@@ -12,14 +13,24 @@ import {
 } from '@/components/ui/dialog'
 import { Input } from '@/components/ui/input'
 import { Label } from '@/components/ui/label'
+import { Switch } from '@/components/ui/switch'
 
 interface APIKeyDialogProps {
   open: boolean
   onOpenChange: (open: boolean) => void
-  onSubmit: (data: { name: string; expiresAt?: string }) => void
+  onSubmit: (data: APIKeyCreateRequest) => void
   isLoading?: boolean
 }
 
+const scopeFields = ['clusters', 'namespaces', 'resources', 'verbs'] as const
+type ScopeField = (typeof scopeFields)[number]
+
+const splitList = (value: string) =>
+  value
+    .split(',')
+    .map((v) => v.trim())
+    .filter(Boolean)
+
 export function APIKeyDialog({
   open,
   onOpenChange,
@@ -29,6 +40,13 @@ export function APIKeyDialog({
   const { t } = useTranslation()
   const [name, setName] = useState('')
   const [expiresAt, setExpiresAt] = useState('')
+  const [scope, setScope] = useState<Record<ScopeField, string>>({
+    clusters: '',
+    namespaces: '',
+    resources: '',
+    verbs: '',
+  })
+  const [readOnly, setReadOnly] = useState(false)
   const [error, setError] = useState('')
 
   useEffect(() => {
@@ -39,6 +57,8 @@ export function APIKeyDialog({
     } else {
       setName('')
       setExpiresAt('')
+      setScope({ clusters: '', namespaces: '', resources: '', verbs: '' })
+      setReadOnly(false)
       setError('')
     }
   }, [open])
@@ -51,7 +71,17 @@ export function APIKeyDialog({
       return
     }
 
-    onSubmit({ name: name.trim(), expiresAt: expiresAt || undefined })
+    onSubmit({
+      name: name.trim(),
+      expiresAt: expiresAt || undefined,
+      scope: {
+        clusters: splitList(scope.clusters),
+        namespaces: splitList(scope.namespaces),
+        resources: splitList(scope.resources),
+        verbs: readOnly ? [] : splitList(scope.verbs),
+      },
+      readOnly,
+    })
   }
 
   return (
@@ -116,6 +146,48 @@ export function APIKeyDialog({
                 )}
               </p>
             </div>
+
+            <div className="space-y-2">
+              <Label>
+                {t('apikeyManagement.dialog.scope', 'Scope (Optional)')}
+              </Label>
+              <p className="text-xs text-muted-foreground">
+                {t(
+                  'apikeyManagement.dialog.scopeHint',
+                  'Comma-separated values in the same format as roles. Empty fields are not restricted. The token never has more access than your own roles.'
+                )}
+              </p>
+              {scopeFields
+                .filter((field) => !(readOnly && field === 'verbs'))
+                .map((field) => (
+                  <Input
+                    key={field}
+                    aria-label={field}
+                    placeholder={t(
+                      `apikeyManagement.dialog.scopePlaceholder.${field}`,
+                      field
+                    )}
+                    value={scope[field]}
+                    onChange={(e) =>
+                      setScope((prev) => ({
+                        ...prev,
+                        [field]: e.target.value,
+                      }))
+                    }
+                  />
+                ))}
+            </div>
+
+            <div className="flex items-center justify-between">
+              <Label htmlFor="readOnly">
+                {t('apikeyManagement.dialog.readOnly', 'Read-only')}
+              </Label>
+              <Switch
+                id="readOnly"
+                checked={readOnly}
+                onCheckedChange={setReadOnly}
+              />
+            </div>
           </div>
 
           <DialogFooter>
@@ -12,8 +12,13 @@ import { ColumnDef } from '@tanstack/react-table'
 import { useTranslation } from 'react-i18next'
 import { toast } from 'sonner'
 
-import { PersonalAccessToken } from '@/types/api'
-import { createAPIKey, deleteAPIKey, useAPIKeyList } from '@/lib/api'
+import { PersonalAccessToken, TokenScope } from '@/types/api'
+import {
+  APIKeyCreateRequest,
+  createAPIKey,
+  deleteAPIKey,
+  useAPIKeyList,
+} from '@/lib/api'
 import { Badge } from '@/components/ui/badge'
 import { Button } from '@/components/ui/button'
 import { Card, CardContent, CardHeader, CardTitle } from '@/components/ui/card'
@@ -24,6 +29,13 @@ import { Action, ActionTable } from '../action-table'
 import { APIKeyDialog } from './apikey-dialog'
 import UserRoleAssignment from './user-role-assignment'
 
+function formatScope(scope?: TokenScope): string[] {
+  if (!scope) return []
+  return (['clusters', 'namespaces', 'resources', 'verbs'] as const)
+    .filter((field) => scope[field]?.length)
+    .map((field) => `${field}: ${scope[field].join(', ')}`)
+}
+
 export function APIKeyManagement() {
   const { t } = useTranslation()
   const queryClient = useQueryClient()
@@ -133,6 +145,27 @@ export function APIKeyManagement() {
           </span>
         ),
       },
+      {
+        id: 'scope',
+        header: t('apikeyManagement.table.scope', 'Scope'),
+        cell: ({ row: { original: apiKey } }) => {
+          const scope = formatScope(apiKey.scope)
+          if (scope.length === 0) {
+            return (
+              <Badge variant="secondary">
+                {t('apikeyManagement.fullAccess', 'Full access')}
+              </Badge>
+            )
+          }
+          return (
+            <div className="flex flex-col text-xs text-muted-foreground">
+              {scope.map((line) => (
+                <span key={line}>{line}</span>
+              ))}
+            </div>
+          )
+        },
+      },
       {
         id: 'roles',
         header: t('apikeyManagement.table.roles', 'Roles'),
@@ -210,7 +243,7 @@ export function APIKeyManagement() {
   })
 
   const handleCreate = useCallback(
-    (data: { name: string; expiresAt?: string }) => {
+    (data: APIKeyCreateRequest) => {
       createMutation.mutate(data)
     },
     [createMutation]
@@ -37,6 +37,9 @@ export function AuditLog() {
     pageIndex: 0,
     pageSize: 20,
   })
+  // Cursors of the pages reached so far, by page index. The first page has
+  // none, and every loaded page adds the cursor of the next one.
+  const [cursors, setCursors] = useState<number[]>([0])
   const [operatorId, setOperatorId] = useState<number | undefined>(undefined)
   const [searchQuery, setSearchQuery] = useState('')
   const [operationFilter, setOperationFilter] = useState('')
@@ -54,7 +57,7 @@ export function AuditLog() {
     isLoading,
     error,
   } = useAuditLogs(
-    pagination.pageIndex + 1,
+    cursors[pagination.pageIndex] ?? 0,
     pagination.pageSize,
     operatorId,
     searchQuery,
@@ -62,6 +65,20 @@ export function AuditLog() {
     showCluster ? clusterFilter || undefined : undefined
   )
 
+  useEffect(() => {
+    const pageIndex = pagination.pageIndex
+    setCursors((prev) => {
+      const next = prev.slice(0, pageIndex + 1)
+      if (auditData?.hasMore) {
+        next.push(auditData.nextCursor)
+      }
+      return next.length === prev.length &&
+        next.every((c, i) => c === prev[i])
+        ? prev
+        : next
+    })
+  }, [auditData, pagination.pageIndex])
+
   useEffect(() => {
     if (!showCluster && clusterFilter) {
       setClusterFilter('')
@@ -243,6 +260,7 @@ export function AuditLog() {
     [getOperationTypeLabel, showCluster, t]
   )
 
+  const totalPages = Math.ceil((auditData?.total ?? 0) / pagination.pageSize)
   const table = useReactTable({
     data: auditData?.data ?? [],
     columns,
@@ -250,7 +268,11 @@ export function AuditLog() {
     state: { pagination },
     onPaginationChange: setPagination,
     manualPagination: true,
-    pageCount: Math.ceil((auditData?.total ?? 0) / pagination.pageSize) || 0,
+    // Pages are reached one after another through their cursors, so the
+    // next page is only available once its cursor is known
+    pageCount: cursors[pagination.pageIndex + 1]
+      ? Math.max(totalPages, pagination.pageIndex + 2)
+      : pagination.pageIndex + 1,
   })
 
   const emptyState = (() => {
@@ -60,6 +60,7 @@ export function ClusterDialog({
     isDefault: false,
     inCluster: false,
     skipSystemSync: false,
+    impersonateUsers: false,
   })
 
   useEffect(() => {
@@ -73,6 +74,7 @@ export function ClusterDialog({
         isDefault: cluster.isDefault,
         inCluster: cluster.inCluster,
         skipSystemSync: cluster.skipSystemSync || false,
+        impersonateUsers: cluster.impersonateUsers || false,
       })
     }
   }, [cluster, open])
@@ -115,6 +117,7 @@ export function ClusterDialog({
       isDefault: false,
       inCluster: false,
       skipSystemSync: false,
+      impersonateUsers: false,
     })
   }
 
@@ -349,6 +352,31 @@ export function ClusterDialog({
                   }
                 />
               </div>
+
+              {/* Impersonate Users */}
+              <div className="flex items-center justify-between">
+                <div className="space-y-1">
+                  <Label htmlFor="cluster-impersonate">
+                    {t(
+                      'clusterManagement.form.impersonateUsers.label',
+                      'Impersonate Users'
+                    )}
+                  </Label>
+                  <p className="text-xs text-muted-foreground">
+                    {t(
+                      'clusterManagement.form.impersonateUsers.help',
+                      'Send requests as the signed-in user and their groups, so cluster RBAC applies and audit logs show the user. The cluster credential needs the impersonate permission.'
+                    )}
+                  </p>
+                </div>
+                <Switch
+                  id="cluster-impersonate"
+                  checked={formData.impersonateUsers}
+                  onCheckedChange={(checked) =>
+                    handleChange('impersonateUsers', checked)
+                  }
+                />
+              </div>
             </div>
           )}
 
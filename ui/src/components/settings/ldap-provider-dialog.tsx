@@ -0,0 +1,268 @@
+import { useEffect, useState } from 'react'
+import { IconEdit, IconServer } from '@tabler/icons-react'
+import { useTranslation } from 'react-i18next'
+
+import { LDAPProvider } from '@/types/api'
+import { LDAPProviderRequest } from '@/lib/api'
+import { Button } from '@/components/ui/button'
+import {
+  Dialog,
+  DialogContent,
+  DialogFooter,
+  DialogHeader,
+  DialogTitle,
+} from '@/components/ui/dialog'
+import { Input } from '@/components/ui/input'
+import { Label } from '@/components/ui/label'
+import { Separator } from '@/components/ui/separator'
+import { Switch } from '@/components/ui/switch'
+
+interface LDAPProviderDialogProps {
+  open: boolean
+  onOpenChange: (open: boolean) => void
+  provider?: LDAPProvider | null
+  onSubmit: (providerData: LDAPProviderRequest) => void
+}
+
+const emptyForm: LDAPProviderRequest = {
+  name: '',
+  url: '',
+  startTLS: false,
+  insecureSkipVerify: false,
+  bindDN: '',
+  bindPassword: '',
+  userSearchBase: '',
+  userFilter: '',
+  usernameAttribute: '',
+  nameAttribute: '',
+  emailAttribute: '',
+  groupSearchBase: '',
+  groupFilter: '',
+  groupNameAttribute: '',
+  enabled: true,
+}
+
+type TextField = Exclude<
+  keyof LDAPProviderRequest,
+  'startTLS' | 'insecureSkipVerify' | 'enabled'
+>
+
+export function LDAPProviderDialog({
+  open,
+  onOpenChange,
+  provider,
+  onSubmit,
+}: LDAPProviderDialogProps) {
+  const { t } = useTranslation()
+  const isEditMode = !!provider
+
+  const [formData, setFormData] = useState<LDAPProviderRequest>(emptyForm)
+
+  useEffect(() => {
+    if (open) {
+      if (provider) {
+        // eslint-disable-next-line @typescript-eslint/no-unused-vars
+        const { id, createdAt, updatedAt, ...fields } = provider
+        setFormData({ ...emptyForm, ...fields, bindPassword: '' })
+      } else {
+        setFormData(emptyForm)
+      }
+    }
+  }, [open, provider])
+
+  const handleSubmit = (e: React.FormEvent) => {
+    e.preventDefault()
+    onSubmit(formData)
+  }
+
+  const renderInput = (
+    field: TextField,
+    label: string,
+    placeholder: string,
+    required = false
+  ) => (
+    <div className="space-y-2">
+      <Label htmlFor={field}>
+        {label}
+        {required ? ' *' : ''}
+      </Label>
+      <Input
+        id={field}
+        type={field === 'bindPassword' ? 'password' : 'text'}
+        value={formData[field] || ''}
+        onChange={(e) =>
+          setFormData((prev) => ({ ...prev, [field]: e.target.value }))
+        }
+        placeholder={placeholder}
+        required={required}
+      />
+    </div>
+  )
+
+  const renderSwitch = (
+    field: 'startTLS' | 'insecureSkipVerify' | 'enabled',
+    label: string
+  ) => (
+    <div className="flex items-center space-x-2">
+      <Switch
+        id={field}
+        checked={formData[field]}
+        onCheckedChange={(checked) =>
+          setFormData((prev) => ({ ...prev, [field]: checked }))
+        }
+      />
+      <Label htmlFor={field}>{label}</Label>
+    </div>
+  )
+
+  return (
+    <Dialog open={open} onOpenChange={onOpenChange}>
+      <DialogContent className="!max-w-4xl max-h-[90vh] overflow-y-auto sm:!max-w-4xl">
+        <DialogHeader>
+          <DialogTitle className="flex items-center gap-2">
+            {isEditMode ? (
+              <IconEdit className="h-5 w-5" />
+            ) : (
+              <IconServer className="h-5 w-5" />
+            )}
+            {isEditMode
+              ? t('ldapManagement.dialog.editTitle', 'Edit LDAP Provider')
+              : t('ldapManagement.dialog.createTitle', 'Add LDAP Provider')}
+          </DialogTitle>
+        </DialogHeader>
+        <form onSubmit={handleSubmit} className="space-y-6">
+          <div className="space-y-4">
+            <h3 className="text-lg font-medium">
+              {t('ldapManagement.dialog.section.server', 'Server')}
+            </h3>
+            <div className="grid grid-cols-2 gap-4">
+              {renderInput(
+                'name',
+                t('ldapManagement.dialog.name', 'Name'),
+                'corp-ad',
+                true
+              )}
+              {renderInput(
+                'url',
+                t('ldapManagement.dialog.url', 'URL'),
+                'ldaps://ldap.example.org:636',
+                true
+              )}
+              {renderInput(
+                'bindDN',
+                t('ldapManagement.dialog.bindDN', 'Bind DN'),
+                'cn=reader,dc=example,dc=org'
+              )}
+              {renderInput(
+                'bindPassword',
+                t('ldapManagement.dialog.bindPassword', 'Bind Password'),
+                isEditMode
+                  ? t(
+                      'ldapManagement.dialog.bindPasswordPlaceholder',
+                      'Leave empty to keep current password'
+                    )
+                  : ''
+              )}
+            </div>
+            <div className="flex gap-6">
+              {renderSwitch(
+                'startTLS',
+                t('ldapManagement.dialog.startTLS', 'StartTLS')
+              )}
+              {renderSwitch(
+                'insecureSkipVerify',
+                t(
+                  'ldapManagement.dialog.insecureSkipVerify',
+                  'Skip TLS verification'
+                )
+              )}
+            </div>
+          </div>
+          <Separator />
+          <div className="space-y-4">
+            <h3 className="text-lg font-medium">
+              {t('ldapManagement.dialog.section.users', 'User Search')}
+            </h3>
+            <div className="grid grid-cols-2 gap-4">
+              {renderInput(
+                'userSearchBase',
+                t('ldapManagement.dialog.userSearchBase', 'Search Base'),
+                'ou=people,dc=example,dc=org',
+                true
+              )}
+              {renderInput(
+                'userFilter',
+                t('ldapManagement.dialog.userFilter', 'User Filter'),
+                '(uid={username})'
+              )}
+              {renderInput(
+                'usernameAttribute',
+                t('ldapManagement.dialog.usernameAttribute', 'Username'),
+                'uid'
+              )}
+              {renderInput(
+                'nameAttribute',
+                t('ldapManagement.dialog.nameAttribute', 'Display Name'),
+                'cn'
+              )}
+              {renderInput(
+                'emailAttribute',
+                t('ldapManagement.dialog.emailAttribute', 'Email'),
+                'mail'
+              )}
+            </div>
+          </div>
+          <Separator />
+          <div className="space-y-4">
+            <h3 className="text-lg font-medium">
+              {t('ldapManagement.dialog.section.groups', 'Group Search')}
+            </h3>
+            <p className="text-sm text-muted-foreground">
+              {t(
+                'ldapManagement.dialog.groupsHint',
+                'Without a group search base, groups are read from the ' +
+                  'memberOf attribute of the user.'
+              )}
+            </p>
+            <div className="grid grid-cols-2 gap-4">
+              {renderInput(
+                'groupSearchBase',
+                t('ldapManagement.dialog.groupSearchBase', 'Search Base'),
+                'ou=groups,dc=example,dc=org'
+              )}
+              {renderInput(
+                'groupFilter',
+                t('ldapManagement.dialog.groupFilter', 'Group Filter'),
+                '(member={dn})'
+              )}
+              {renderInput(
+                'groupNameAttribute',
+                t('ldapManagement.dialog.groupNameAttribute', 'Group Name'),
+                'cn'
+              )}
+            </div>
+          </div>
+          <Separator />
+          {renderSwitch(
+            'enabled',
+            t('ldapManagement.dialog.enabled', 'Enabled')
+          )}
+          <DialogFooter>
+            <Button
+              type="button"
+              variant="outline"
+              onClick={() => onOpenChange(false)}
+            >
+              {t('common.cancel', 'Cancel')}
+            </Button>
+            <Button type="submit">
+              {isEditMode
+                ? t('common.update', 'Update')
+                : t('common.create', 'Create')}
+            </Button>
+          </DialogFooter>
+        </form>
+      </DialogContent>
+    </Dialog>
+  )
+}
@@ -0,0 +1,242 @@
+import { useMemo, useState } from 'react'
+import { IconEdit, IconPlus, IconServer, IconTrash } from '@tabler/icons-react'
+import { useMutation, useQueryClient } from '@tanstack/react-query'
+import { ColumnDef } from '@tanstack/react-table'
+import { useTranslation } from 'react-i18next'
+import { toast } from 'sonner'
+
+import { LDAPProvider } from '@/types/api'
+import {
+  createLDAPProvider,
+  deleteLDAPProvider,
+  LDAPProviderRequest,
+  updateLDAPProvider,
+  useLDAPProviderList,
+} from '@/lib/api'
+import { Badge } from '@/components/ui/badge'
+import { Button } from '@/components/ui/button'
+import { Card, CardContent, CardHeader, CardTitle } from '@/components/ui/card'
+import { DeleteConfirmationDialog } from '@/components/delete-confirmation-dialog'
+
+import { Action, ActionTable } from '../action-table'
+import { LDAPProviderDialog } from './ldap-provider-dialog'
+
+export function LDAPProviderManagement() {
+  const { t } = useTranslation()
+  const queryClient = useQueryClient()
+
+  const { data: providers = [], isLoading, error } = useLDAPProviderList()
+
+  const [showProviderDialog, setShowProviderDialog] = useState(false)
+  const [editingProvider, setEditingProvider] = useState<LDAPProvider | null>(
+    null
+  )
+  const [deletingProvider, setDeletingProvider] =
+    useState<LDAPProvider | null>(null)
+
+  const columns = useMemo<ColumnDef<LDAPProvider>[]>(
+    () => [
+      {
+        id: 'name',
+        header: t('common.name', 'Name'),
+        cell: ({ row: { original: provider } }) => (
+          <div>
+            <span className="font-medium">{provider.name}</span>
+            <div className="text-sm text-muted-foreground">
+              {provider.url}
+              {provider.startTLS && ' (StartTLS)'}
+            </div>
+          </div>
+        ),
+      },
+      {
+        id: 'userSearchBase',
+        header: t('ldapManagement.table.userSearchBase', 'User Search Base'),
+        cell: ({ row: { original: provider } }) => (
+          <code className="text-sm bg-muted px-2 py-1 rounded">
+            {provider.userSearchBase}
+          </code>
+        ),
+      },
+      {
+        id: 'status',
+        header: t('common.status', 'Status'),
+        cell: ({ row: { original: provider } }) =>
+          provider.enabled ? (
+            <Badge variant="default">{t('common.enabled', 'Enabled')}</Badge>
+          ) : (
+            <Badge variant="secondary">
+              {t('common.disabled', 'Disabled')}
+            </Badge>
+          ),
+      },
+    ],
+    [t]
+  )
+
+  const actions = useMemo<Action<LDAPProvider>[]>(
+    () => [
+      {
+        label: (
+          <>
+            <IconEdit className="h-4 w-4" />
+            {t('common.edit', 'Edit')}
+          </>
+        ),
+        onClick: (provider) => {
+          setEditingProvider(provider)
+          setShowProviderDialog(true)
+        },
+      },
+      {
+        label: (
+          <div className="inline-flex items-center gap-2 text-destructive">
+            <IconTrash className="h-4 w-4" />
+            {t('common.delete', 'Delete')}
+          </div>
+        ),
+        onClick: (provider) => {
+          setDeletingProvider(provider)
+        },
+      },
+    ],
+    [t]
+  )
+
+  const onSaved = (message: string) => {
+    queryClient.invalidateQueries({ queryKey: ['ldap-provider-list'] })
+    toast.success(message)
+    setShowProviderDialog(false)
+    setEditingProvider(null)
+  }
+
+  const createMutation = useMutation({
+    mutationFn: createLDAPProvider,
+    onSuccess: () =>
+      onSaved(t('ldapManagement.messages.created', 'LDAP provider created')),
+    onError: (error: Error) => {
+      toast.error(error.message)
+    },
+  })
+
+  const updateMutation = useMutation({
+    mutationFn: ({ id, data }: { id: number; data: LDAPProviderRequest }) =>
+      updateLDAPProvider(id, data),
+    onSuccess: () =>
+      onSaved(t('ldapManagement.messages.updated', 'LDAP provider updated')),
+    onError: (error: Error) => {
+      toast.error(error.message)
+    },
+  })
+
+  const deleteMutation = useMutation({
+    mutationFn: deleteLDAPProvider,
+    onSuccess: () => {
+      queryClient.invalidateQueries({ queryKey: ['ldap-provider-list'] })
+      toast.success(
+        t('ldapManagement.messages.deleted', 'LDAP provider deleted')
+      )
+      setDeletingProvider(null)
+    },
+    onError: (error: Error) => {
+      toast.error(error.message)
+    },
+  })
+
+  const handleSubmitProvider = (providerData: LDAPProviderRequest) => {
+    if (editingProvider) {
+      updateMutation.mutate({ id: editingProvider.id, data: providerData })
+    } else {
+      createMutation.mutate(providerData)
+    }
+  }
+
+  if (isLoading) {
+    return (
+      <div className="flex items-center justify-center py-8">
+        <div className="text-muted-foreground">
+          {t('common.loading', 'Loading...')}
+        </div>
+      </div>
+    )
+  }
+
+  if (error) {
+    return (
+      <div className="flex items-center justify-center py-8">
+        <div className="text-destructive">
+          {t(
+            'ldapManagement.errors.loadFailed',
+            'Failed to load LDAP providers'
+          )}
+        </div>
+      </div>
+    )
+  }
+
+  return (
+    <div className="space-y-6">
+      <Card>
+        <CardHeader>
+          <div className="flex items-center justify-between">
+            <CardTitle className="flex items-center gap-2">
+              <IconServer className="h-5 w-5" />
+              {t('ldapManagement.title', 'LDAP / Active Directory')}
+            </CardTitle>
+            <Button
+              onClick={() => {
+                setEditingProvider(null)
+                setShowProviderDialog(true)
+              }}
+              className="gap-2"
+            >
+              <IconPlus className="h-4 w-4" />
+              {t('ldapManagement.actions.add', 'Add Provider')}
+            </Button>
+          </div>
+        </CardHeader>
+        <CardContent>
+          <ActionTable actions={actions} data={providers} columns={columns} />
+
+          {providers.length === 0 && (
+            <div className="text-center py-8 text-muted-foreground">
+              <IconServer className="h-12 w-12 mx-auto mb-4 opacity-50" />
+              <p>
+                {t(
+                  'ldapManagement.empty.title',
+                  'No LDAP providers configured'
+                )}
+              </p>
+            </div>
+          )}
+        </CardContent>
+      </Card>
+
+      <LDAPProviderDialog
+        open={showProviderDialog}
+        onOpenChange={(open) => {
+          setShowProviderDialog(open)
+          if (!open) {
+            setEditingProvider(null)
+          }
+        }}
+        provider={editingProvider}
+        onSubmit={handleSubmitProvider}
+      />
+
+      <DeleteConfirmationDialog
+        open={!!deletingProvider}
+        onOpenChange={() => setDeletingProvider(null)}
+        onConfirm={() =>
+          deletingProvider && deleteMutation.mutate(deletingProvider.id)
+        }
+        resourceName={deletingProvider?.name || ''}
+        resourceType="LDAP provider"
+        additionalNote={t(
+          'ldapManagement.deleteConfirmation',
+          'Users will no longer be able to login using this provider.'
+        )}
+      />
+    </div>
+  )
+}
@@ -14,6 +14,13 @@ import {
 } from '@/components/ui/dialog'
 import { Input } from '@/components/ui/input'
 import { Label } from '@/components/ui/label'
+import {
+  Select,
+  SelectContent,
+  SelectItem,
+  SelectTrigger,
+  SelectValue,
+} from '@/components/ui/select'
 import { Textarea } from '@/components/ui/textarea'
 
 import { Separator } from '../ui/separator'
@@ -41,6 +48,9 @@ export function RBACDialog({
     namespaces: ['*'],
     resources: ['*'],
     verbs: ['*'],
+    resourceNames: [],
+    labelSelector: '',
+    effect: 'allow',
   })
 
   useEffect(() => {
@@ -53,7 +63,12 @@ export function RBACDialog({
     setForm((prev) => ({ ...(prev || {}), [field]: value }))
 
   const setArrayField = (
-    field: 'clusters' | 'namespaces' | 'resources' | 'verbs',
+    field:
+      | 'clusters'
+      | 'namespaces'
+      | 'resources'
+      | 'verbs'
+      | 'resourceNames',
     items: string[]
   ) => {
     setForm((prev) => ({ ...(prev || {}), [field]: items }))
@@ -65,12 +80,14 @@ export function RBACDialog({
     onChange,
     placeholder,
     suggestions,
+    optional,
   }: {
     label: string
     items: string[]
     onChange: (items: string[]) => void
     placeholder?: string
     suggestions?: string[]
+    optional?: boolean
   }) {
     const [input, setInput] = useState('')
     const [focused, setFocused] = useState(false)
@@ -120,7 +137,7 @@ export function RBACDialog({
                 // Delay hiding suggestions to allow suggestion click to register
                 setTimeout(() => setFocused(false), 150)
               }}
-              required={items.length === 0}
+              required={!optional && items.length === 0}
               onKeyDown={(e) => {
                 if (e.key === 'Enter') {
                   e.preventDefault()
@@ -240,7 +257,14 @@ export function RBACDialog({
                 label={t('rbac.form.resources.label', 'Resources')}
                 items={form.resources || ['*']}
                 onChange={(items) => setArrayField('resources', items)}
-                placeholder="* or pods,deployments"
+                placeholder="* or pods,deployments,pods/exec"
+                suggestions={[
+                  'pods/exec',
+                  'pods/log',
+                  'pods/portforward',
+                  'pods/files',
+                  'nodes/drain',
+                ]}
               />
 
               <ListEditor
@@ -259,6 +283,63 @@ export function RBACDialog({
               />
             </div>
           </div>
+          <Separator />
+          <div className="space-y-4">
+            <h3 className="text-lg font-medium">
+              {t('rbac.form.objects.label', 'Objects')}
+            </h3>
+            <p className="text-sm text-muted-foreground">
+              {t(
+                'rbac.form.objects.description',
+                'Limit the role to objects by name or labels. Deny roles override allow roles.'
+              )}
+            </p>
+            <div className="grid grid-cols-2 gap-4">
+              <div className="space-y-2">
+                <Label>{t('rbac.form.effect.label', 'Effect')}</Label>
+                <Select
+                  value={form.effect || 'allow'}
+                  onValueChange={(v: 'allow' | 'deny') =>
+                    setForm((prev) => ({ ...(prev || {}), effect: v }))
+                  }
+                >
+                  <SelectTrigger>
+                    <SelectValue />
+                  </SelectTrigger>
+                  <SelectContent>
+                    <SelectItem value="allow">
+                      {t('rbac.form.effect.allow', 'Allow')}
+                    </SelectItem>
+                    <SelectItem value="deny">
+                      {t('rbac.form.effect.deny', 'Deny')}
+                    </SelectItem>
+                  </SelectContent>
+                </Select>
+              </div>
+
+              <div className="space-y-2">
+                <Label htmlFor="role-label-selector">
+                  {t('rbac.form.labelSelector.label', 'Label Selector')}
+                </Label>
+                <Input
+                  id="role-label-selector"
+                  value={form.labelSelector || ''}
+                  onChange={(e) =>
+                    handleChange('labelSelector', e.target.value)
+                  }
+                  placeholder="team=payments,tier!=critical"
+                />
+              </div>
+
+              <ListEditor
+                label={t('rbac.form.resourceNames.label', 'Resource Names')}
+                items={form.resourceNames || []}
+                onChange={(items) => setArrayField('resourceNames', items)}
+                placeholder="web-.* or object name"
+                optional
+              />
+            </div>
+          </div>
           <DialogFooter>
             <Button
               type="button"
@@ -25,6 +25,7 @@ import { DeleteConfirmationDialog } from '@/components/delete-confirmation-dialo
 
 import { Action, ActionTable } from '../action-table'
 import { Badge } from '../ui/badge'
+import { AccessReview } from './access-review'
 import { RBACAssignmentDialog } from './rbac-assignment-dialog'
 import { RBACDialog } from './rbac-dialog'
 
@@ -50,6 +51,9 @@ export function RBACManagement() {
             <div className="flex items-center">
               <span className="font-medium">{r.name}</span>{' '}
               {r.isSystem && <Badge variant="secondary">System</Badge>}
+              {r.effect === 'deny' && (
+                <Badge variant="destructive">Deny</Badge>
+              )}
             </div>
             {r.description && (
               <div className="text-sm text-muted-foreground">
@@ -386,6 +390,8 @@ export function RBACManagement() {
         </CardContent>
       </Card>
 
+      <AccessReview />
+
       <RBACDialog
         open={showDialog}
         onOpenChange={(open) => {
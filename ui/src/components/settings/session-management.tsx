@@ -0,0 +1,162 @@
+import { useMemo, useState } from 'react'
+import { IconDevices, IconLogout } from '@tabler/icons-react'
+import { useMutation, useQueryClient } from '@tanstack/react-query'
+import { ColumnDef } from '@tanstack/react-table'
+import { useTranslation } from 'react-i18next'
+import { toast } from 'sonner'
+
+import { UserSession } from '@/types/api'
+import { revokeSession, useSessionList } from '@/lib/api'
+import { Badge } from '@/components/ui/badge'
+import { Card, CardContent, CardHeader, CardTitle } from '@/components/ui/card'
+import { DeleteConfirmationDialog } from '@/components/delete-confirmation-dialog'
+
+import { Action, ActionTable } from '../action-table'
+
+export function SessionManagement() {
+  const { t } = useTranslation()
+  const queryClient = useQueryClient()
+
+  const { data: sessions = [], error } = useSessionList()
+  const [revoking, setRevoking] = useState<UserSession | null>(null)
+
+  const columns = useMemo<ColumnDef<UserSession>[]>(
+    () => [
+      {
+        id: 'device',
+        header: t('sessionManagement.table.device', 'Device'),
+        cell: ({ row: { original: session } }) => (
+          <div className="flex flex-col">
+            <span className="font-medium flex items-center gap-2">
+              {session.device}
+              {session.current && (
+                <Badge variant="default">
+                  {t('sessionManagement.current', 'This session')}
+                </Badge>
+              )}
+            </span>
+            <span className="text-xs text-muted-foreground">
+              {session.provider}
+            </span>
+          </div>
+        ),
+      },
+      {
+        id: 'ip',
+        header: t('sessionManagement.table.ip', 'IP Address'),
+        cell: ({ row: { original: session } }) => (
+          <span className="text-sm font-mono">{session.ip || '-'}</span>
+        ),
+      },
+      {
+        id: 'lastSeenAt',
+        header: t('sessionManagement.table.lastSeen', 'Last Seen'),
+        cell: ({ row: { original: session } }) => (
+          <span className="text-sm">
+            {new Date(session.lastSeenAt).toLocaleString()}
+          </span>
+        ),
+      },
+      {
+        id: 'createdAt',
+        header: t('sessionManagement.table.signedIn', 'Signed In'),
+        cell: ({ row: { original: session } }) => (
+          <span className="text-sm text-muted-foreground">
+            {new Date(session.createdAt).toLocaleString()}
+          </span>
+        ),
+      },
+      {
+        id: 'expiresAt',
+        header: t('sessionManagement.table.expiresAt', 'Expires At'),
+        cell: ({ row: { original: session } }) => (
+          <span className="text-sm text-muted-foreground">
+            {new Date(session.expiresAt).toLocaleString()}
+          </span>
+        ),
+      },
+    ],
+    [t]
+  )
+
+  const actions = useMemo<Action<UserSession>[]>(
+    () => [
+      {
+        label: (
+          <div className="inline-flex items-center gap-2 text-destructive">
+            <IconLogout className="h-4 w-4" />
+            {t('sessionManagement.actions.revoke', 'Sign Out')}
+          </div>
+        ),
+        onClick: (session) => setRevoking(session),
+      },
+    ],
+    [t]
+  )
+
+  const revokeMutation = useMutation({
+    mutationFn: revokeSession,
+    onSuccess: () => {
+      setRevoking(null)
+      if (revoking?.current) {
+        window.location.reload()
+        return
+      }
+      queryClient.invalidateQueries({ queryKey: ['session-list'] })
+      toast.success(
+        t('sessionManagement.messages.revoked', 'Session signed out')
+      )
+    },
+    onError: () => {
+      toast.error(
+        t(
+          'sessionManagement.messages.revokeError',
+          'Failed to sign out session. Please try again.'
+        )
+      )
+    },
+  })
+
+  if (error) {
+    return (
+      <Card>
+        <CardContent className="flex items-center justify-center py-8">
+          <p className="text-destructive">
+            {t('sessionManagement.errors.loadFailed', 'Failed to load sessions')}
+          </p>
+        </CardContent>
+      </Card>
+    )
+  }
+
+  return (
+    <>
+      <Card>
+        <CardHeader>
+          <CardTitle className="flex items-center gap-2">
+            <IconDevices className="h-5 w-5" />
+            {t('sessionManagement.title', 'Sessions')}
+          </CardTitle>
+          <p className="text-sm text-muted-foreground mt-1">
+            {t(
+              'sessionManagement.description',
+              'Devices that are signed in to your account'
+            )}
+          </p>
+        </CardHeader>
+        <CardContent>
+          <ActionTable columns={columns} data={sessions} actions={actions} />
+        </CardContent>
+      </Card>
+
+      <DeleteConfirmationDialog
+        open={!!revoking}
+        onOpenChange={(open: boolean) => !open && setRevoking(null)}
+        onConfirm={() => revoking && revokeMutation.mutate(revoking.id)}
+        resourceName={revoking?.device || ''}
+        resourceType="Session"
+        isDeleting={revokeMutation.isPending}
+      />
+    </>
+  )
+}
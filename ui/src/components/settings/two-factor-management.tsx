@@ -0,0 +1,273 @@
+import { useState } from 'react'
+import { IconShieldLock } from '@tabler/icons-react'
+import { useMutation, useQueryClient } from '@tanstack/react-query'
+import { useTranslation } from 'react-i18next'
+import { toast } from 'sonner'
+
+import { TwoFactorSetup } from '@/types/api'
+import {
+  disableTwoFactor,
+  enableTwoFactor,
+  regenerateRecoveryCodes,
+  setupTwoFactor,
+  useTwoFactorStatus,
+} from '@/lib/api'
+import { Badge } from '@/components/ui/badge'
+import { Button } from '@/components/ui/button'
+import { Card, CardContent, CardHeader, CardTitle } from '@/components/ui/card'
+import { Input } from '@/components/ui/input'
+import { Label } from '@/components/ui/label'
+
+// RecoveryCodeList shows recovery codes once, right after they are generated
+export function RecoveryCodeList({ codes }: { codes: string[] }) {
+  const { t } = useTranslation()
+
+  return (
+    <div className="space-y-2">
+      <p className="text-sm text-muted-foreground">
+        {t(
+          'twoFactor.recoveryCodesHint',
+          'Save these recovery codes somewhere safe. Each one signs you in ' +
+            'once if you lose your authenticator app. They are not shown again.'
+        )}
+      </p>
+      <div className="grid grid-cols-2 gap-2 rounded-md border p-3 font-mono">
+        {codes.map((code) => (
+          <span key={code} className="text-sm">
+            {code}
+          </span>
+        ))}
+      </div>
+    </div>
+  )
+}
+
+// TwoFactorSecret shows a new TOTP secret to add to an authenticator app
+export function TwoFactorSecret({ setup }: { setup: TwoFactorSetup }) {
+  const { t } = useTranslation()
+
+  return (
+    <div className="space-y-2">
+      <p className="text-sm text-muted-foreground">
+        {t(
+          'twoFactor.setupHint',
+          'Open the link on a device with an authenticator app, or enter ' +
+            'the key in the app manually.'
+        )}
+      </p>
+      <a
+        href={setup.uri}
+        className="text-sm text-primary underline underline-offset-4"
+      >
+        {t('twoFactor.openInApp', 'Add to authenticator app')}
+      </a>
+      <div className="rounded-md border p-3 font-mono text-sm break-all">
+        {setup.secret}
+      </div>
+    </div>
+  )
+}
+
+export function TwoFactorManagement() {
+  const { t } = useTranslation()
+  const queryClient = useQueryClient()
+
+  const { data: status, error } = useTwoFactorStatus()
+  const [setup, setSetup] = useState<TwoFactorSetup | null>(null)
+  const [recoveryCodes, setRecoveryCodes] = useState<string[]>([])
+  const [code, setCode] = useState('')
+
+  const onError = (err: Error) => {
+    toast.error(err.message)
+  }
+
+  const setupMutation = useMutation({
+    mutationFn: setupTwoFactor,
+    onSuccess: (data) => {
+      setSetup(data)
+      setRecoveryCodes([])
+      setCode('')
+    },
+    onError,
+  })
+
+  const enableMutation = useMutation({
+    mutationFn: enableTwoFactor,
+    onSuccess: (data) => {
+      setSetup(null)
+      setRecoveryCodes(data.recoveryCodes)
+      setCode('')
+      queryClient.invalidateQueries({ queryKey: ['two-factor-status'] })
+      toast.success(
+        t('twoFactor.messages.enabled', 'Two-factor authentication enabled')
+      )
+    },
+    onError,
+  })
+
+  const regenerateMutation = useMutation({
+    mutationFn: regenerateRecoveryCodes,
+    onSuccess: (data) => {
+      setRecoveryCodes(data.recoveryCodes)
+      setCode('')
+      queryClient.invalidateQueries({ queryKey: ['two-factor-status'] })
+    },
+    onError,
+  })
+
+  const disableMutation = useMutation({
+    mutationFn: disableTwoFactor,
+    onSuccess: () => {
+      setRecoveryCodes([])
+      setCode('')
+      queryClient.invalidateQueries({ queryKey: ['two-factor-status'] })
+      toast.success(
+        t('twoFactor.messages.disabled', 'Two-factor authentication disabled')
+      )
+    },
+    onError,
+  })
+
+  if (error) {
+    return (
+      <Card>
+        <CardContent className="flex items-center justify-center py-8">
+          <p className="text-destructive">
+            {t(
+              'twoFactor.errors.loadFailed',
+              'Failed to load two-factor settings'
+            )}
+          </p>
+        </CardContent>
+      </Card>
+    )
+  }
+
+  const codeInput = (
+    <div className="space-y-2">
+      <Label htmlFor="two-factor-code">
+        {t('twoFactor.code', 'Authentication code')}
+      </Label>
+      <Input
+        id="two-factor-code"
+        value={code}
+        onChange={(e) => setCode(e.target.value)}
+        autoComplete="one-time-code"
+        placeholder="123456"
+        className="max-w-xs"
+      />
+    </div>
+  )
+
+  const renderContent = () => {
+    if (!status) {
+      return null
+    }
+    if (!status.available) {
+      return (
+        <p className="text-sm text-muted-foreground">
+          {t(
+            'twoFactor.unavailable',
+            'Two-factor authentication is available for password logins. ' +
+              'Accounts of identity providers use the provider sign-in.'
+          )}
+        </p>
+      )
+    }
+    if (!status.enabled && !setup) {
+      return (
+        <div className="space-y-4">
+          <p className="text-sm text-muted-foreground">
+            {t(
+              'twoFactor.disabledHint',
+              'Require a code from an authenticator app when you sign in ' +
+                'with your password.'
+            )}
+          </p>
+          <Button
+            onClick={() => setupMutation.mutate()}
+            disabled={setupMutation.isPending}
+          >
+            {t('twoFactor.actions.setup', 'Set Up')}
+          </Button>
+        </div>
+      )
+    }
+    if (setup) {
+      return (
+        <div className="space-y-4">
+          <TwoFactorSecret setup={setup} />
+          {codeInput}
+          <div className="flex gap-2">
+            <Button
+              onClick={() => enableMutation.mutate(code)}
+              disabled={!code || enableMutation.isPending}
+            >
+              {t('twoFactor.actions.enable', 'Enable')}
+            </Button>
+            <Button variant="outline" onClick={() => setSetup(null)}>
+              {t('common.cancel', 'Cancel')}
+            </Button>
+          </div>
+        </div>
+      )
+    }
+    return (
+      <div className="space-y-4">
+        <p className="text-sm text-muted-foreground">
+          {t(
+            'twoFactor.recoveryCodesRemaining',
+            '{{count}} recovery codes remaining',
+            { count: status.recoveryCodesRemaining }
+          )}
+        </p>
+        {recoveryCodes.length > 0 && (
+          <RecoveryCodeList codes={recoveryCodes} />
+        )}
+        {codeInput}
+        <div className="flex gap-2">
+          <Button
+            variant="outline"
+            onClick={() => regenerateMutation.mutate(code)}
+            disabled={!code || regenerateMutation.isPending}
+          >
+            {t('twoFactor.actions.regenerate', 'New Recovery Codes')}
+          </Button>
+          {!status.required && (
+            <Button
+              variant="destructive"
+              onClick={() => disableMutation.mutate(code)}
+              disabled={!code || disableMutation.isPending}
+            >
+              {t('twoFactor.actions.disable', 'Disable')}
+            </Button>
+          )}
+        </div>
+      </div>
+    )
+  }
+
+  return (
+    <Card>
+      <CardHeader>
+        <CardTitle className="flex items-center gap-2">
+          <IconShieldLock className="h-5 w-5" />
+          {t('twoFactor.title', 'Two-Factor Authentication')}
+          {status?.enabled && (
+            <Badge variant="default">
+              {t('twoFactor.enabled', 'Enabled')}
+            </Badge>
+          )}
+          {status?.required && (
+            <Badge variant="secondary">
+              {t('twoFactor.required', 'Required')}
+            </Badge>
+          )}
+        </CardTitle>
+      </CardHeader>
+      <CardContent className="space-y-4">
+        {renderContent()}
+      </CardContent>
+    </Card>
+  )
+}
@@ -4,9 +4,11 @@ import {
   IconEdit,
   IconLock,
   IconLockOpen,
+  IconLogout,
   IconPlus,
   IconSearch,
   IconShieldCheck,
+  IconShieldOff,
   IconTrash,
   IconUser,
 } from '@tabler/icons-react'
@@ -26,10 +28,14 @@ import {
   createPasswordUser,
   deleteUser,
   resetUserPassword,
+  resetUserTwoFactor,
+  revokeUserSessions,
   setUserEnabled,
   toggleUserAIChat,
+  updateSecuritySettings,
   updateUser,
   useRoleList,
+  useSecuritySettings,
   useUserList,
 } from '@/lib/api'
 import { formatDate } from '@/lib/utils'
@@ -75,6 +81,7 @@ export function UserManagement() {
   const [searchQuery, setSearchQuery] = useState('')
   const [roleFilter, setRoleFilter] = useState('')
   const { data: roles = [] } = useRoleList()
+  const { data: securitySettings } = useSecuritySettings()
 
   const sortParams = useMemo(() => {
     if (sorting.length === 0) {
@@ -117,6 +124,58 @@ export function UserManagement() {
     [queryClient, t]
   )
 
+  const handleRevokeSessions = useCallback(
+    async (u: UserItem) => {
+      try {
+        await revokeUserSessions(u.id)
+        toast.success(
+          t('userManagement.messages.sessionsRevoked', 'User signed out')
+        )
+      } catch (err: unknown) {
+        const message =
+          err instanceof Error ? err.message : 'Failed to revoke sessions'
+        toast.error(message)
+      }
+    },
+    [t]
+  )
+
+  const handleResetTwoFactor = useCallback(
+    async (u: UserItem) => {
+      try {
+        await resetUserTwoFactor(u.id)
+        toast.success(
+          t(
+            'userManagement.messages.twoFactorReset',
+            'Two-factor authentication reset'
+          )
+        )
+      } catch (err: unknown) {
+        const message =
+          err instanceof Error ? err.message : 'Failed to reset two-factor'
+        toast.error(message)
+      }
+    },
+    [t]
+  )
+
+  const handleRequireAdminTwoFactor = useCallback(
+    async (enabled: boolean) => {
+      try {
+        await updateSecuritySettings({ requireAdminTwoFactor: enabled })
+        queryClient.invalidateQueries({ queryKey: ['security-settings'] })
+        toast.success(
+          t('userManagement.messages.securityUpdated', 'Settings updated')
+        )
+      } catch (err: unknown) {
+        const message =
+          err instanceof Error ? err.message : 'Failed to update settings'
+        toast.error(message)
+      }
+    },
+    [queryClient, t]
+  )
+
   const handleToggleAIChat = useCallback(
     async (u: UserItem, enabled: boolean) => {
       try {
@@ -177,6 +236,24 @@ export function UserManagement() {
         ),
         onClick: (item) => handleResetPassword(item),
       },
+      {
+        label: (
+          <>
+            <IconLogout className="h-4 w-4" />
+            {t('userManagement.actions.revokeSessions', 'Sign Out Everywhere')}
+          </>
+        ),
+        onClick: (item) => handleRevokeSessions(item),
+      },
+      {
+        label: (
+          <>
+            <IconShieldOff className="h-4 w-4" />
+            {t('userManagement.actions.resetTwoFactor', 'Reset Two-Factor')}
+          </>
+        ),
+        onClick: (item) => handleResetTwoFactor(item),
+      },
       {
         label: (
           <>
@@ -189,7 +266,7 @@ export function UserManagement() {
         },
       },
     ]
-  }, [handleToggleEnable, t])
+  }, [handleToggleEnable, handleRevokeSessions, handleResetTwoFactor, t])
 
   const [editingUser, setEditingUser] = useState<UserItem | null>(null)
   const [deletingUser, setDeletingUser] = useState<UserItem | null>(null)
@@ -282,8 +359,15 @@ export function UserManagement() {
         header: t('userManagement.table.provider', 'Provider'),
         accessorFn: (row) => row.provider || '-',
         enableSorting: false,
-        cell: ({ getValue }) => (
-          <div className="code">{String(getValue() || '-')}</div>
+        cell: ({ row: { original: user }, getValue }) => (
+          <div className="flex items-center gap-2">
+            <div className="code">{String(getValue() || '-')}</div>
+            {user.externalId && (
+              <Badge variant="outline" title={user.externalId}>
+                SCIM
+              </Badge>
+            )}
+          </div>
         ),
       },
       {
@@ -527,6 +611,16 @@ export function UserManagement() {
                 <IconUser className="h-5 w-5" />
                 {t('userManagement.title', 'User Management')}
               </CardTitle>
+              <label className="mt-2 flex items-center gap-2 text-sm">
+                <Switch
+                  checked={securitySettings?.requireAdminTwoFactor ?? false}
+                  onCheckedChange={handleRequireAdminTwoFactor}
+                />
+                {t(
+                  'userManagement.requireAdminTwoFactor',
+                  'Require two-factor authentication for admins'
+                )}
+              </label>
             </div>
             <div className="flex items-center gap-3">
               <Select
@@ -26,13 +26,46 @@ interface AuthContextType {
   config: { is_ai_chat_enabled: boolean; sidebar_preference?: string } | null
   isLoading: boolean
   providers: string[]
+  ldapProviders: string[]
   login: (provider?: string) => Promise<void>
-  loginWithPassword: (username: string, password: string) => Promise<void>
+  loginWithPassword: (
+    username: string,
+    password: string,
+    code?: string
+  ) => Promise<string[]>
+  loginWithLDAP: (
+    provider: string,
+    username: string,
+    password: string
+  ) => Promise<void>
   logout: () => Promise<void>
   checkAuth: () => Promise<void>
   refreshToken: () => Promise<void>
 }
 
+// LoginError is a failed password login. Logins that need a two-factor code,
+// or the setup of two-factor authentication, carry what the next step needs.
+export class LoginError extends Error {
+  twoFactorRequired?: boolean
+  twoFactorSetupRequired?: boolean
+  secret?: string
+  uri?: string
+
+  constructor(data: {
+    error?: string
+    twoFactorRequired?: boolean
+    twoFactorSetupRequired?: boolean
+    secret?: string
+    uri?: string
+  }) {
+    super(data.error || 'Password login failed')
+    this.twoFactorRequired = data.twoFactorRequired
+    this.twoFactorSetupRequired = data.twoFactorSetupRequired
+    this.secret = data.secret
+    this.uri = data.uri
+  }
+}
+
 const AuthContext = createContext<AuthContextType | undefined>(undefined)
 
 export function useAuth() {
@@ -55,6 +88,7 @@ export function AuthProvider({ children }: AuthProviderProps) {
   } | null>(null)
   const [isLoading, setIsLoading] = useState(true)
   const [providers, setProviders] = useState<string[]>([])
+  const [ldapProviders, setLDAPProviders] = useState<string[]>([])
 
   const loadProviders = async () => {
     try {
@@ -62,6 +96,7 @@ export function AuthProvider({ children }: AuthProviderProps) {
       if (response.ok) {
         const data = await response.json()
         setProviders(data.providers || [])
+        setLDAPProviders(data.ldapProviders || [])
       }
     } catch (error) {
       console.error('Failed to load OAuth providers:', error)
@@ -119,14 +154,52 @@ export function AuthProvider({ children }: AuthProviderProps) {
     }
   }
 
-  const loginWithPassword = async (username: string, password: string) => {
+  // loginWithPassword returns the recovery codes of a user who set up
+  // two-factor authentication during the login. The caller shows them and
+  // then calls checkAuth.
+  const loginWithPassword = async (
+    username: string,
+    password: string,
+    code?: string
+  ) => {
     try {
       const response = await fetch(withSubPath('/api/auth/login/password'), {
         method: 'POST',
         headers: {
           'Content-Type': 'application/json',
         },
-        body: JSON.stringify({ username, password }),
+        body: JSON.stringify({ username, password, code }),
+        credentials: 'include',
+      })
+
+      if (response.status === 200) {
+        const data = await response.json()
+        return (data.recoveryCodes as string[]) || []
+      }
+      if (response.ok) {
+        await checkAuth()
+        return []
+      }
+      const errorData = await response.json()
+      throw new LoginError(errorData)
+    } catch (error) {
+      console.error('Password login failed:', error)
+      throw error
+    }
+  }
+
+  const loginWithLDAP = async (
+    provider: string,
+    username: string,
+    password: string
+  ) => {
+    try {
+      const response = await fetch(withSubPath('/api/auth/login/ldap'), {
+        method: 'POST',
+        headers: {
+          'Content-Type': 'application/json',
+        },
+        body: JSON.stringify({ provider, username, password }),
         credentials: 'include',
       })
 
@@ -134,10 +207,10 @@ export function AuthProvider({ children }: AuthProviderProps) {
         await checkAuth()
       } else {
         const errorData = await response.json()
-        throw new Error(errorData.error || 'Password login failed')
+        throw new Error(errorData.error || 'LDAP login failed')
       }
     } catch (error) {
-      console.error('Password login failed:', error)
+      console.error('LDAP login failed:', error)
       throw error
     }
   }
@@ -215,8 +288,10 @@ export function AuthProvider({ children }: AuthProviderProps) {
     config,
     isLoading,
     providers,
+    ldapProviders,
     login,
     loginWithPassword,
+    loginWithLDAP,
     logout,
     checkAuth,
     refreshToken,
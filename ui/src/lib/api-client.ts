@@ -38,10 +38,12 @@ class ApiClient {
     return this.refreshPromise
   }
 
-  private async makeRequest<T>(
+  // fetchWithAuth sends a request with the cluster header, refreshing the
+  // token once on 401, and throws the error of non-2xx responses
+  private async fetchWithAuth(
     url: string,
     options: RequestInit = {}
-  ): Promise<T> {
+  ): Promise<Response> {
     const fullUrl = withSubPath(this.baseUrl + url)
 
     const headers: Record<string, string> = {
@@ -65,29 +67,37 @@ class ApiClient {
       ...options,
     }
 
-    try {
-      let response = await fetch(fullUrl, defaultOptions)
-
-      // Handle authentication errors with automatic retry
-      if (response.status === 401) {
-        try {
-          // Try to refresh the token
-          await this.refreshToken()
-          // Retry the original request
-          response = await fetch(fullUrl, defaultOptions)
-        } catch (refreshError) {
-          console.error('Token refresh failed:', refreshError)
-          window.location.href = withSubPath('/login')
-          throw new Error('Authentication failed')
-        }
+    let response = await fetch(fullUrl, defaultOptions)
+
+    // Handle authentication errors with automatic retry
+    if (response.status === 401) {
+      try {
+        // Try to refresh the token
+        await this.refreshToken()
+        // Retry the original request
+        response = await fetch(fullUrl, defaultOptions)
+      } catch (refreshError) {
+        console.error('Token refresh failed:', refreshError)
+        window.location.href = withSubPath('/login')
+        throw new Error('Authentication failed')
       }
+    }
 
-      if (!response.ok) {
-        const errorData = await response.json().catch(() => ({}))
-        throw new Error(
-          errorData.error || `HTTP error! status: ${response.status}`
-        )
-      }
+    if (!response.ok) {
+      const errorData = await response.json().catch(() => ({}))
+      throw new Error(
+        errorData.error || `HTTP error! status: ${response.status}`
+      )
+    }
+    return response
+  }
+
+  private async makeRequest<T>(
+    url: string,
+    options: RequestInit = {}
+  ): Promise<T> {
+    try {
+      const response = await this.fetchWithAuth(url, options)
 
       const contentType = response.headers.get('content-type')
       if (contentType && contentType.includes('application/json')) {
@@ -122,6 +132,20 @@ class ApiClient {
     })
   }
 
+  // postStream posts data and returns the response unread, for endpoints
+  // that stream server-sent events
+  async postStream(
+    url: string,
+    data?: unknown,
+    options?: RequestInit
+  ): Promise<Response> {
+    return this.fetchWithAuth(url, {
+      ...options,
+      method: 'POST',
+      body: data ? JSON.stringify(data) : undefined,
+    })
+  }
+
   async put<T>(url: string, data?: unknown, options?: RequestInit): Promise<T> {
     const isFormData = data instanceof FormData
     return this.makeRequest<T>(url, {
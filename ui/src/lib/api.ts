@@ -5,20 +5,28 @@ import { useQuery } from '@tanstack/react-query'
 import { Pod } from 'kubernetes-types/core/v1'
 
 import {
+  AIBudget,
   AIChatSession,
+  AIModelPrice,
   AIModelsResponse,
   AIProviderProfile,
   AISettings,
+  AIToolApproval,
+  AIUsageGroup,
+  AIUsageReport,
   ChatRequest,
   ChatResponse,
 } from '@/types/ai'
 // Resource Analysis API
 import {
+  AccessReviewRequest,
+  AccessReviewResult,
   AuditLogResponse,
   Cluster,
   FetchUserListResponse,
   GitlabHost,
   ImageTagInfo,
+  LDAPProvider,
   OAuthProvider,
   OverviewData,
   PersonalAccessToken,
@@ -32,9 +40,15 @@ import {
   ResourceTypeMap,
   ResourceUsageHistory,
   Role,
+  SecuritySettings,
+  SubjectAccess,
+  TokenScope,
+  TwoFactorSetup,
+  TwoFactorStatus,
   UserAWSConfig,
   UserGitlabConfig,
   UserItem,
+  UserSession,
 } from '@/types/api'
 
 import { API_BASE_URL, apiClient } from './api-client'
@@ -169,6 +183,28 @@ export const scaleDeployment = async (
 }
 
 // Node operation APIs
+export interface DrainProgressEvent {
+  status:
+    | 'skipped'
+    | 'evicting'
+    | 'retrying'
+    | 'evicted'
+    | 'deleted'
+    | 'failed'
+  pod?: string
+  namespace?: string
+  message?: string
+}
+
+export interface DrainResult {
+  node: string
+  evicted: number
+  failed: number
+}
+
+// drainNode cordons a node and evicts its pods. The server streams the
+// progress of each pod as server-sent events, which are passed to onProgress;
+// an error event rejects the returned promise.
 export const drainNode = async (
   nodeName: string,
   options: {
@@ -176,16 +212,60 @@ export const drainNode = async (
     gracePeriod: number
     deleteLocalData: boolean
     ignoreDaemonsets: boolean
-  }
-): Promise<{ message: string; node: string; options: unknown }> => {
+  },
+  onProgress?: (event: DrainProgressEvent) => void
+): Promise<DrainResult> => {
   const endpoint = `/nodes/_all/${nodeName}/drain`
-  const response = await apiClient.post<{
-    message: string
-    node: string
-    options: unknown
-  }>(endpoint, options)
+  const response = await apiClient.postStream(endpoint, options)
+  const reader = response.body?.getReader()
+  if (!reader) {
+    throw new Error('Drain progress is not available')
+  }
 
-  return response
+  const decoder = new TextDecoder()
+  let buffer = ''
+  while (true) {
+    const { done, value } = await reader.read()
+    if (done) break
+
+    buffer += decoder.decode(value, { stream: true })
+    const messages = buffer.split('\n\n')
+    buffer = messages.pop() || '' // Keep the last incomplete message
+
+    for (const message of messages) {
+      let event = 'message'
+      let data = ''
+      for (const line of message.split('\n')) {
+        if (line.startsWith('event:')) {
+          event = line.slice('event:'.length).trim()
+        } else if (line.startsWith('data:')) {
+          data += line.slice('data:'.length).trim()
+        }
+      }
+      // Keep-alive comments carry no data
+      if (!data) continue
+
+      const payload = JSON.parse(data)
+      switch (event) {
+        case 'progress':
+          onProgress?.(payload as DrainProgressEvent)
+          break
+        case 'error': {
+          await reader.cancel()
+          const pods: string[] = payload.pods || []
+          throw new Error(
+            pods.length > 0
+              ? `${payload.error}: ${pods.join(', ')}`
+              : payload.error
+          )
+        }
+        case 'done':
+          await reader.cancel()
+          return payload as DrainResult
+      }
+    }
+  }
+  throw new Error('Drain ended before it completed')
 }
 
 export const cordonNode = async (
@@ -1569,6 +1649,54 @@ export const fetchOAuthProvider = async (
   ).then((response) => response.provider)
 }
 
+// LDAP Provider Management
+export type LDAPProviderRequest = Omit<
+  LDAPProvider,
+  'id' | 'createdAt' | 'updatedAt'
+>
+
+export const fetchLDAPProviderList = (): Promise<LDAPProvider[]> => {
+  return fetchAPI<{ providers: LDAPProvider[] }>('/admin/ldap-providers/').then(
+    (response) => response.providers
+  )
+}
+
+export const useLDAPProviderList = (options?: { staleTime?: number }) => {
+  return useQuery({
+    queryKey: ['ldap-provider-list'],
+    queryFn: fetchLDAPProviderList,
+    staleTime: options?.staleTime || 30000,
+  })
+}
+
+export const createLDAPProvider = async (
+  providerData: LDAPProviderRequest
+): Promise<{ provider: LDAPProvider }> => {
+  return await apiClient.post<{ provider: LDAPProvider }>(
+    '/admin/ldap-providers/',
+    providerData
+  )
+}
+
+// Update LDAP provider, an empty bind password keeps the current one
+export const updateLDAPProvider = async (
+  id: number,
+  providerData: LDAPProviderRequest
+): Promise<{ provider: LDAPProvider }> => {
+  return await apiClient.put<{ provider: LDAPProvider }>(
+    `/admin/ldap-providers/${id}`,
+    providerData
+  )
+}
+
+export const deleteLDAPProvider = async (
+  id: number
+): Promise<{ success: boolean; message: string }> => {
+  return await apiClient.delete<{ success: boolean; message: string }>(
+    `/admin/ldap-providers/${id}`
+  )
+}
+
 // RBAC API
 export const fetchRoleList = async (): Promise<Role[]> => {
   return fetchAPI<{ roles: Role[] }>(`/admin/roles/`).then((resp) => resp.roles)
@@ -1628,6 +1756,16 @@ export const assignRole = async (
   return await apiClient.post(`/admin/roles/${id}/assign`, data)
 }
 
+export const reviewAccess = async (data: AccessReviewRequest) => {
+  return await apiClient.post<AccessReviewResult>(`/admin/access/review`, data)
+}
+
+export const whoCan = async (data: AccessReviewRequest) => {
+  return await apiClient
+    .post<{ subjects: SubjectAccess[] }>(`/admin/access/who-can`, data)
+    .then((resp) => resp.subjects)
+}
+
 export const unassignRole = async (
   id: number,
   subjectType: 'user' | 'group',
@@ -1701,6 +1839,33 @@ export const toggleUserAIChat = async (id: number, enabled: boolean) => {
   })
 }
 
+export const revokeUserSessions = async (id: number) => {
+  return apiClient.delete<{ success: boolean; revoked: number }>(
+    `/admin/users/${id}/sessions`
+  )
+}
+
+export const resetUserTwoFactor = async (id: number) => {
+  return apiClient.delete<{ success: boolean }>(
+    `/admin/users/${id}/two-factor`
+  )
+}
+
+export const fetchSecuritySettings = async (): Promise<SecuritySettings> => {
+  return fetchAPI<SecuritySettings>('/admin/security/')
+}
+
+export const useSecuritySettings = () => {
+  return useQuery({
+    queryKey: ['security-settings'],
+    queryFn: fetchSecuritySettings,
+  })
+}
+
+export const updateSecuritySettings = async (settings: SecuritySettings) => {
+  return apiClient.put<SecuritySettings>('/admin/security/', settings)
+}
+
 export const useUserList = (
   page = 1,
   size = 20,
@@ -1716,8 +1881,10 @@ export const useUserList = (
   })
 }
 
+// Audit logs are paged by cursor: pass the nextCursor of a page to get the
+// page after it, and no cursor for the first page
 export const fetchAuditLogs = async (
-  page = 1,
+  cursor = 0,
   size = 20,
   operatorId?: number,
   search?: string,
@@ -1728,9 +1895,11 @@ export const fetchAuditLogs = async (
   namespace?: string
 ): Promise<AuditLogResponse> => {
   const params = new URLSearchParams({
-    page: String(page),
     size: String(size),
   })
+  if (cursor) {
+    params.set('cursor', String(cursor))
+  }
   if (operatorId) {
     params.set('operatorId', String(operatorId))
   }
@@ -1756,7 +1925,7 @@ export const fetchAuditLogs = async (
 }
 
 export const useAuditLogs = (
-  page = 1,
+  cursor = 0,
   size = 20,
   operatorId?: number,
   search?: string,
@@ -1769,7 +1938,7 @@ export const useAuditLogs = (
   return useQuery<AuditLogResponse, Error>({
     queryKey: [
       'audit-logs',
-      page,
+      cursor,
       size,
       operatorId,
       search,
@@ -1781,7 +1950,7 @@ export const useAuditLogs = (
     ],
     queryFn: () =>
       fetchAuditLogs(
-        page,
+        cursor,
         size,
         operatorId,
         search,
@@ -1800,10 +1969,13 @@ export const fetchResourceHistory = (
   resourceType: string,
   namespace: string,
   name: string,
-  page: number = 1,
+  cursor: number = 0,
   pageSize: number = 10
 ): Promise<ResourceHistoryResponse> => {
-  const endpoint = `/${resourceType}/${namespace}/${name}/history?page=${page}&pageSize=${pageSize}`
+  let endpoint = `/${resourceType}/${namespace}/${name}/history?pageSize=${pageSize}`
+  if (cursor) {
+    endpoint += `&cursor=${cursor}`
+  }
   return fetchAPI<ResourceHistoryResponse>(endpoint)
 }
 
@@ -1811,7 +1983,7 @@ export const useResourceHistory = (
   resourceType: string,
   namespace: string,
   name: string,
-  page: number = 1,
+  cursor: number = 0,
   pageSize: number = 10,
   options?: { enabled?: boolean; staleTime?: number }
 ) => {
@@ -1821,11 +1993,11 @@ export const useResourceHistory = (
       resourceType,
       namespace,
       name,
-      page,
+      cursor,
       pageSize,
     ],
     queryFn: () =>
-      fetchResourceHistory(resourceType, namespace, name, page, pageSize),
+      fetchResourceHistory(resourceType, namespace, name, cursor, pageSize),
     enabled: options?.enabled ?? true,
     staleTime: options?.staleTime || 30000, // 30 seconds cache
   })
@@ -1835,6 +2007,8 @@ export const useResourceHistory = (
 export interface APIKeyCreateRequest {
   name: string
   expiresAt?: string // YYYY-MM-DD
+  scope?: Partial<TokenScope>
+  readOnly?: boolean
 }
 
 export const fetchAPIKeyList = async (): Promise<PersonalAccessToken[]> => {
@@ -1866,6 +2040,65 @@ export const deleteAPIKey = async (
   return await apiClient.delete<{ message: string }>(`/settings/api-keys/${id}`)
 }
 
+// Login sessions
+export const fetchSessionList = async (): Promise<UserSession[]> => {
+  return fetchAPI<UserSession[]>('/settings/sessions/')
+}
+
+export const useSessionList = (options?: { staleTime?: number }) => {
+  return useQuery({
+    queryKey: ['session-list'],
+    queryFn: fetchSessionList,
+    staleTime: options?.staleTime || 30000,
+  })
+}
+
+export const revokeSession = async (
+  id: number
+): Promise<{ message: string }> => {
+  return await apiClient.delete<{ message: string }>(`/settings/sessions/${id}`)
+}
+
+// Two-factor authentication
+export const fetchTwoFactorStatus = async (): Promise<TwoFactorStatus> => {
+  return fetchAPI<TwoFactorStatus>('/settings/two-factor/')
+}
+
+export const useTwoFactorStatus = () => {
+  return useQuery({
+    queryKey: ['two-factor-status'],
+    queryFn: fetchTwoFactorStatus,
+  })
+}
+
+export const setupTwoFactor = async (): Promise<TwoFactorSetup> => {
+  return apiClient.post<TwoFactorSetup>('/settings/two-factor/setup')
+}
+
+export const enableTwoFactor = async (
+  code: string
+): Promise<{ recoveryCodes: string[] }> => {
+  return apiClient.post<{ recoveryCodes: string[] }>(
+    '/settings/two-factor/enable',
+    { code }
+  )
+}
+
+export const regenerateRecoveryCodes = async (
+  code: string
+): Promise<{ recoveryCodes: string[] }> => {
+  return apiClient.post<{ recoveryCodes: string[] }>(
+    '/settings/two-factor/recovery-codes',
+    { code }
+  )
+}
+
+export const disableTwoFactor = async (code: string) => {
+  return apiClient.post<{ success: boolean }>('/settings/two-factor/disable', {
+    code,
+  })
+}
+
 export const usePodFiles = (
   namespace: string,
   podName: string,
@@ -1982,6 +2215,57 @@ export const updateAIGovernance = (data: {
   return apiClient.post('/admin/ai/governance', data)
 }
 
+export const fetchAIModelPrices = (): Promise<AIModelPrice[]> => {
+  return fetchAPI<AIModelPrice[]>('/admin/ai/prices')
+}
+
+export const createAIModelPrice = (
+  data: Partial<AIModelPrice>
+): Promise<AIModelPrice> => {
+  return apiClient.post<AIModelPrice>('/admin/ai/prices', data)
+}
+
+export const updateAIModelPrice = (
+  id: number,
+  data: Partial<AIModelPrice>
+): Promise<AIModelPrice> => {
+  return apiClient.put<AIModelPrice>(`/admin/ai/prices/${id}`, data)
+}
+
+export const deleteAIModelPrice = (id: number): Promise<{ status: string }> => {
+  return apiClient.delete<{ status: string }>(`/admin/ai/prices/${id}`)
+}
+
+export const fetchAIBudgets = (): Promise<AIBudget[]> => {
+  return fetchAPI<AIBudget[]>('/admin/ai/budgets')
+}
+
+export const createAIBudget = (data: Partial<AIBudget>): Promise<AIBudget> => {
+  return apiClient.post<AIBudget>('/admin/ai/budgets', data)
+}
+
+export const updateAIBudget = (
+  id: number,
+  data: Partial<AIBudget>
+): Promise<AIBudget> => {
+  return apiClient.put<AIBudget>(`/admin/ai/budgets/${id}`, data)
+}
+
+export const deleteAIBudget = (id: number): Promise<{ status: string }> => {
+  return apiClient.delete<{ status: string }>(`/admin/ai/budgets/${id}`)
+}
+
+export const fetchAIUsageReport = (params: {
+  from?: string
+  to?: string
+  groupBy: AIUsageGroup
+}): Promise<AIUsageReport> => {
+  const query = new URLSearchParams({ groupBy: params.groupBy })
+  if (params.from) query.set('from', params.from)
+  if (params.to) query.set('to', params.to)
+  return fetchAPI<AIUsageReport>(`/admin/ai/usage?${query.toString()}`)
+}
+
 export const getAIConfig = async (): Promise<AISettings> => {
   return fetchAPI<AISettings>('/ai/config')
 }
@@ -2012,6 +2296,17 @@ export const deleteAIChatSession = async (id: string): Promise<void> => {
   return apiClient.delete(`/ai/sessions/${id}`)
 }
 
+export const decideAIToolApproval = async (
+  sessionId: string,
+  toolCallId: string,
+  approved: boolean
+): Promise<AIToolApproval> => {
+  return apiClient.post<AIToolApproval>(
+    `/ai/sessions/${sessionId}/tool-calls/${encodeURIComponent(toolCallId)}/approval`,
+    { approved }
+  )
+}
+
 export const sendAIChatMessage = async (
   data: ChatRequest,
   clusterName?: string
@@ -1,9 +1,10 @@
 import { FormEvent, useState } from 'react'
 import Logo from '@/assets/logo.png'
-import { useAuth } from '@/contexts/auth-context'
+import { LoginError, useAuth } from '@/contexts/auth-context'
 import { useTranslation } from 'react-i18next'
 import { Navigate, useSearchParams } from 'react-router-dom'
 
+import { TwoFactorSetup } from '@/types/api'
 import { withSubPath } from '@/lib/subpath'
 import { Alert, AlertDescription } from '@/components/ui/alert'
 import { Button } from '@/components/ui/button'
@@ -16,17 +17,56 @@ import {
 } from '@/components/ui/card'
 import { Input } from '@/components/ui/input'
 import { Label } from '@/components/ui/label'
+import {
+  Select,
+  SelectContent,
+  SelectItem,
+  SelectTrigger,
+  SelectValue,
+} from '@/components/ui/select'
 import { Footer } from '@/components/footer'
 import { LanguageToggle } from '@/components/language-toggle'
+import {
+  RecoveryCodeList,
+  TwoFactorSecret,
+} from '@/components/settings/two-factor-management'
 
 export function LoginPage() {
   const { t } = useTranslation()
-  const { user, login, loginWithPassword, providers, isLoading } = useAuth()
+  const {
+    user,
+    login,
+    loginWithPassword,
+    loginWithLDAP,
+    checkAuth,
+    providers,
+    ldapProviders,
+    isLoading,
+  } = useAuth()
   const [searchParams] = useSearchParams()
   const [loginLoading, setLoginLoading] = useState<string | null>(null)
   const [username, setUsername] = useState('')
   const [password, setPassword] = useState('')
   const [passwordError, setPasswordError] = useState<string | null>(null)
+  const [codeRequired, setCodeRequired] = useState(false)
+  const [code, setCode] = useState('')
+  const [twoFactorSetup, setTwoFactorSetup] = useState<TwoFactorSetup | null>(
+    null
+  )
+  const [recoveryCodes, setRecoveryCodes] = useState<string[]>([])
+  const [passwordProvider, setPasswordProvider] = useState('')
+
+  // Local and LDAP accounts both log in with the username and password form
+  const passwordProviders = [
+    ...(providers.includes('password') ? ['password'] : []),
+    ...ldapProviders,
+  ]
+  const activePasswordProvider = passwordProviders.includes(passwordProvider)
+    ? passwordProvider
+    : passwordProviders[0]
+  const oauthProviders = providers.filter(
+    (p) => p !== 'password' && !ldapProviders.includes(p)
+  )
 
   const error = searchParams.get('error')
 
@@ -49,8 +89,27 @@ export function LoginPage() {
     setLoginLoading('password')
     setPasswordError(null)
     try {
-      await loginWithPassword(username, password)
+      if (activePasswordProvider !== 'password') {
+        await loginWithLDAP(activePasswordProvider, username, password)
+        return
+      }
+      const codes = await loginWithPassword(username, password, code)
+      if (codes.length > 0) {
+        setRecoveryCodes(codes)
+      }
     } catch (err) {
+      if (err instanceof LoginError && !code) {
+        if (err.twoFactorSetupRequired && err.secret && err.uri) {
+          setTwoFactorSetup({ secret: err.secret, uri: err.uri })
+          setCodeRequired(true)
+          return
+        }
+        if (err.twoFactorRequired) {
+          setCodeRequired(true)
+          return
+        }
+      }
+      setCode('')
       if (err instanceof Error) {
         setPasswordError(err.message || t('login.errors.invalidCredentials'))
       } else {
@@ -205,54 +264,117 @@ export function LoginPage() {
                 </div>
               ) : (
                 <div className="space-y-4">
-                  {providers.includes('password') && (
-                    <form onSubmit={handlePasswordLogin} className="space-y-4">
-                      <div className="space-y-2">
-                        <Label htmlFor="username">{t('login.username')}</Label>
-                        <Input
-                          id="username"
-                          type="text"
-                          placeholder={t('login.enterUsername')}
-                          value={username}
-                          onChange={(e) => setUsername(e.target.value)}
-                          required
-                        />
-                      </div>
-                      <div className="space-y-2">
-                        <Label htmlFor="password">{t('login.password')}</Label>
-                        <Input
-                          id="password"
-                          type="password"
-                          placeholder={t('login.enterPassword')}
-                          value={password}
-                          onChange={(e) => setPassword(e.target.value)}
-                          required
-                        />
-                      </div>
-                      {passwordError && (
-                        <Alert variant="destructive">
-                          <AlertDescription>{passwordError}</AlertDescription>
-                        </Alert>
-                      )}
-                      <Button
-                        type="submit"
-                        disabled={loginLoading !== null}
-                        className="w-full"
+                  {recoveryCodes.length > 0 && (
+                    <div className="space-y-4">
+                      <RecoveryCodeList codes={recoveryCodes} />
+                      <Button onClick={() => checkAuth()} className="w-full">
+                        {t('login.continue')}
+                      </Button>
+                    </div>
+                  )}
+
+                  {passwordProviders.length > 0 &&
+                    recoveryCodes.length === 0 && (
+                      <form
+                        onSubmit={handlePasswordLogin}
+                        className="space-y-4"
                       >
-                        {loginLoading === 'password' ? (
-                          <div className="flex items-center space-x-2">
-                            <div className="animate-spin rounded-full h-4 w-4 border-b-2"></div>
-                            <span>{t('login.signingIn')}</span>
+                        {passwordProviders.length > 1 && !codeRequired && (
+                          <div className="space-y-2">
+                            <Label htmlFor="account">
+                              {t('login.account')}
+                            </Label>
+                            <Select
+                              value={activePasswordProvider}
+                              onValueChange={setPasswordProvider}
+                            >
+                              <SelectTrigger id="account" className="w-full">
+                                <SelectValue />
+                              </SelectTrigger>
+                              <SelectContent>
+                                {passwordProviders.map((provider) => (
+                                  <SelectItem key={provider} value={provider}>
+                                    {provider === 'password'
+                                      ? t('login.localAccount')
+                                      : provider}
+                                  </SelectItem>
+                                ))}
+                              </SelectContent>
+                            </Select>
                           </div>
-                        ) : (
-                          t('login.signInWithPassword')
                         )}
-                      </Button>
-                    </form>
-                  )}
+                        <div className="space-y-2">
+                          <Label htmlFor="username">
+                            {t('login.username')}
+                          </Label>
+                          <Input
+                            id="username"
+                            type="text"
+                            placeholder={t('login.enterUsername')}
+                            value={username}
+                            onChange={(e) => setUsername(e.target.value)}
+                            required
+                          />
+                        </div>
+                        <div className="space-y-2">
+                          <Label htmlFor="password">
+                            {t('login.password')}
+                          </Label>
+                          <Input
+                            id="password"
+                            type="password"
+                            placeholder={t('login.enterPassword')}
+                            value={password}
+                            onChange={(e) => setPassword(e.target.value)}
+                            required
+                          />
+                        </div>
+                        {twoFactorSetup && (
+                          <TwoFactorSecret setup={twoFactorSetup} />
+                        )}
+                        {codeRequired && (
+                          <div className="space-y-2">
+                            <Label htmlFor="code">
+                              {twoFactorSetup
+                                ? t('login.twoFactorSetupCode')
+                                : t('login.twoFactorCode')}
+                            </Label>
+                            <Input
+                              id="code"
+                              type="text"
+                              autoComplete="one-time-code"
+                              placeholder={t('login.enterTwoFactorCode')}
+                              value={code}
+                              onChange={(e) => setCode(e.target.value)}
+                              autoFocus
+                              required
+                            />
+                          </div>
+                        )}
+                        {passwordError && (
+                          <Alert variant="destructive">
+                            <AlertDescription>{passwordError}</AlertDescription>
+                          </Alert>
+                        )}
+                        <Button
+                          type="submit"
+                          disabled={loginLoading !== null}
+                          className="w-full"
+                        >
+                          {loginLoading === 'password' ? (
+                            <div className="flex items-center space-x-2">
+                              <div className="animate-spin rounded-full h-4 w-4 border-b-2"></div>
+                              <span>{t('login.signingIn')}</span>
+                            </div>
+                          ) : (
+                            t('login.signInWithPassword')
+                          )}
+                        </Button>
+                      </form>
+                    )}
 
-                  {providers.filter((p) => p !== 'password').length > 0 &&
-                    providers.includes('password') && (
+                  {oauthProviders.length > 0 &&
+                    passwordProviders.length > 0 && (
                       <div className="relative">
                         <div className="absolute inset-0 flex items-center">
                           <span className="w-full border-t" />
@@ -265,34 +387,32 @@ export function LoginPage() {
                       </div>
                     )}
 
-                  {providers
-                    .filter((p) => p !== 'password')
-                    .map((provider) => (
-                      <Button
-                        key={provider}
-                        onClick={() => handleLogin(provider)}
-                        disabled={loginLoading !== null}
-                        className="w-full h-10"
-                        variant="outline"
-                      >
-                        {loginLoading === provider ? (
-                          <div className="flex items-center space-x-2">
-                            <div className="animate-spin rounded-full h-4 w-4 border-b-2"></div>
-                            <span>{t('login.signingIn')}</span>
-                          </div>
-                        ) : (
-                          <div className="flex items-center space-x-2">
-                            <span>
-                              {t('login.signInWith', {
-                                provider:
-                                  provider.charAt(0).toUpperCase() +
-                                  provider.slice(1),
-                              })}
-                            </span>
-                          </div>
-                        )}
-                      </Button>
-                    ))}
+                  {oauthProviders.map((provider) => (
+                    <Button
+                      key={provider}
+                      onClick={() => handleLogin(provider)}
+                      disabled={loginLoading !== null}
+                      className="w-full h-10"
+                      variant="outline"
+                    >
+                      {loginLoading === provider ? (
+                        <div className="flex items-center space-x-2">
+                          <div className="animate-spin rounded-full h-4 w-4 border-b-2"></div>
+                          <span>{t('login.signingIn')}</span>
+                        </div>
+                      ) : (
+                        <div className="flex items-center space-x-2">
+                          <span>
+                            {t('login.signInWith', {
+                              provider:
+                                provider.charAt(0).toUpperCase() +
+                                provider.slice(1),
+                            })}
+                          </span>
+                        </div>
+                      )}
+                    </Button>
+                  ))}
                 </div>
               )}
             </CardContent>
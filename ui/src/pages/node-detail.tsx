@@ -68,6 +68,7 @@ export function NodeDetail(props: { name: string }) {
 
   // Node operation states
   const [isDrainPopoverOpen, setIsDrainPopoverOpen] = useState(false)
+  const [isDraining, setIsDraining] = useState(false)
   const [isCordonPopoverOpen, setIsCordonPopoverOpen] = useState(false)
   const [isTaintPopoverOpen, setIsTaintPopoverOpen] = useState(false)
 
@@ -126,14 +127,41 @@ export function NodeDetail(props: { name: string }) {
 
   // Node operation handlers
   const handleDrain = async () => {
+    setIsDraining(true)
+    setIsDrainPopoverOpen(false)
+    const toastId = toast.loading(`Draining node ${name}...`)
+    const failures: string[] = []
     try {
-      await drainNode(name, drainOptions)
-      toast.success(`Node ${name} drained successfully`)
-      setIsDrainPopoverOpen(false)
-      handleRefresh()
+      const result = await drainNode(name, drainOptions, (event) => {
+        const pod = `${event.namespace}/${event.pod}`
+        if (event.status === 'failed') {
+          failures.push(`${pod}: ${event.message}`)
+        }
+        toast.loading(`Draining node ${name}...`, {
+          id: toastId,
+          description: `${pod} ${event.status}`,
+        })
+      })
+      if (result.failed > 0) {
+        toast.error(
+          `Node ${name} drained, but ${result.failed} pods were not evicted`,
+          { id: toastId, description: failures.join('\n') }
+        )
+      } else {
+        toast.success(`Node ${name} drained successfully`, {
+          id: toastId,
+          description: `${result.evicted} pods evicted`,
+        })
+      }
     } catch (error) {
       console.error('Failed to drain node:', error)
-      toast.error(translateError(error, t))
+      toast.error(translateError(error, t), {
+        id: toastId,
+        description: undefined,
+      })
+    } finally {
+      setIsDraining(false)
+      handleRefresh()
     }
   }
 
@@ -252,8 +280,12 @@ export function NodeDetail(props: { name: string }) {
             onOpenChange={setIsDrainPopoverOpen}
           >
             <PopoverTrigger asChild>
-              <Button variant="outline" size="sm">
-                <IconDroplet className="w-4 h-4" />
+              <Button variant="outline" size="sm" disabled={isDraining}>
+                {isDraining ? (
+                  <IconLoader className="w-4 h-4 animate-spin" />
+                ) : (
+                  <IconDroplet className="w-4 h-4" />
+                )}
                 Drain
               </Button>
             </PopoverTrigger>
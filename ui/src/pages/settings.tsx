@@ -11,9 +11,12 @@ import { AuditLog } from '@/components/settings/audit-log'
 import { AWSConfigManagement } from '@/components/settings/aws-config-management'
 import { ClusterManagement } from '@/components/settings/cluster-management'
 import { GitlabConfigManagement } from '@/components/settings/gitlab-config-management'
+import { LDAPProviderManagement } from '@/components/settings/ldap-provider-management'
 import { OAuthProviderManagement } from '@/components/settings/oauth-provider-management'
 import { RBACManagement } from '@/components/settings/rbac-management'
+import { SessionManagement } from '@/components/settings/session-management'
 import { TemplateManagement } from '@/components/settings/template-management'
+import { TwoFactorManagement } from '@/components/settings/two-factor-management'
 import { UserManagement } from '@/components/settings/user-management'
 
 export function SettingsPage() {
@@ -37,6 +40,12 @@ export function SettingsPage() {
         content: <OAuthProviderManagement />,
         adminOnly: true,
       },
+      {
+        value: 'ldap',
+        label: t('settings.tabs.ldap', 'LDAP'),
+        content: <LDAPProviderManagement />,
+        adminOnly: true,
+      },
       {
         value: 'rbac',
         label: t('settings.tabs.rbac', 'RBAC'),
@@ -67,6 +76,18 @@ export function SettingsPage() {
         content: <APIKeyManagement />,
         adminOnly: false,
       },
+      {
+        value: 'sessions',
+        label: t('settings.tabs.sessions', 'Sessions'),
+        content: <SessionManagement />,
+        adminOnly: false,
+      },
+      {
+        value: 'two-factor',
+        label: t('settings.tabs.twoFactor', 'Two-Factor'),
+        content: <TwoFactorManagement />,
+        adminOnly: false,
+      },
       {
         value: 'ai',
         label: t('settings.tabs.ai', 'AI Assistant'),
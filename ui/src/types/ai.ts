@@ -1,8 +1,10 @@
 export interface AIProviderProfile {
   id: number
   name: string
-  provider: 'gemini' | 'openai' | 'azure' | 'custom'
+  provider: 'gemini' | 'openai' | 'anthropic' | 'azure' | 'ollama' | 'custom'
   baseUrl: string
+  apiVersion?: string
+  contextWindow?: number
   defaultModel: string
   apiKey?: string
   isSystem: boolean
@@ -35,9 +37,12 @@ export interface AIChatSession {
   id: string
   userID: number
   title: string
+  contextTokens?: number
+  contextWindow?: number
   createdAt: string
   updatedAt: string
   messages?: AIChatMessage[]
+  pendingApprovals?: AIToolApproval[]
 }
 
 export interface AIChatMessage {
@@ -47,13 +52,70 @@ export interface AIChatMessage {
   content: string
   toolCalls?: string // JSON string
   toolID?: string
+  summary?: boolean
+  summarized?: boolean
+  promptTokens?: number
+  completionTokens?: number
   createdAt: string
 }
 
+// A call of a tool changing resources, which waits for the user to
+// approve it
+export interface AIToolApproval {
+  id: number
+  sessionID: string
+  messageID: number
+  toolCallID: string
+  toolName: string
+  arguments: string
+  preview: string
+  clusterName: string
+  status: 'pending' | 'approved' | 'rejected' | 'expired'
+  approverID?: number
+  decidedAt?: string
+  createdAt: string
+}
+
+export interface AIModelPrice {
+  id: number
+  model: string
+  promptPrice: number // Per million tokens
+  completionPrice: number // Per million tokens
+}
+
+export interface AIBudget {
+  id: number
+  scope: 'user' | 'profile'
+  scopeId: number // 0 for the default of all users
+  period: 'day' | 'month'
+  tokenLimit: number
+}
+
+export type AIUsageGroup = 'user' | 'profile' | 'model' | 'session' | 'day'
+
+export interface AIUsageSummary {
+  key: string
+  label: string
+  requests: number
+  promptTokens: number
+  completionTokens: number
+  totalTokens: number
+  cost: number
+}
+
+export interface AIUsageReport {
+  from: string
+  to: string
+  groupBy: AIUsageGroup
+  rows: AIUsageSummary[]
+  total: AIUsageSummary
+}
+
 export interface ChatRequest {
   sessionID: string
   message: string
   model?: string
+  resume?: boolean // Continue after a tool call was approved or rejected
   context?: {
     route: string
     kind?: string
@@ -430,6 +430,7 @@ export interface Cluster {
   prometheusURL?: string
   error?: string
   skipSystemSync?: boolean
+  impersonateUsers?: boolean
 }
 
 export interface OAuthProvider {
@@ -447,6 +448,27 @@ export interface OAuthProvider {
   updatedAt: string
 }
 
+export interface LDAPProvider {
+  id: number
+  name: string
+  url: string
+  startTLS: boolean
+  insecureSkipVerify: boolean
+  bindDN?: string
+  bindPassword?: string
+  userSearchBase: string
+  userFilter?: string
+  usernameAttribute?: string
+  nameAttribute?: string
+  emailAttribute?: string
+  groupSearchBase?: string
+  groupFilter?: string
+  groupNameAttribute?: string
+  enabled: boolean
+  createdAt: string
+  updatedAt: string
+}
+
 export interface RoleAssignment {
   id: number
   roleId: number
@@ -465,11 +487,61 @@ export interface Role {
   namespaces: string[]
   resources: string[]
   verbs: string[]
+  resourceNames?: string[]
+  labelSelector?: string
+  effect?: 'allow' | 'deny'
   assignments?: RoleAssignment[]
   createdAt: string
   updatedAt: string
 }
 
+export interface AccessReviewRequest {
+  user?: string
+  groups?: string[]
+  cluster: string
+  namespace?: string
+  resource: string
+  verb: string
+  name?: string
+  labels?: Record<string, string>
+}
+
+export interface AccessFieldMatch {
+  field: string
+  value: string
+  entries: string[]
+  matched: boolean
+  entry?: string
+  explanation: string
+}
+
+export interface AccessRoleReview {
+  role: string
+  effect: 'allow' | 'deny'
+  sources: { type: 'user' | 'group'; subject: string }[]
+  applies: boolean
+  reason: string
+  fields: AccessFieldMatch[]
+}
+
+export interface AccessReviewResult {
+  review: {
+    allowed: boolean
+    reason: string
+    decidedBy: string[]
+    roles: AccessRoleReview[]
+  }
+  user: string
+  userFound: boolean
+  groups: string[]
+}
+
+export interface SubjectAccess {
+  type: 'user' | 'group'
+  name: string
+  roles: string[]
+}
+
 export interface UserItem {
   id: number
   username: string
@@ -480,6 +552,7 @@ export interface UserItem {
   avatar_url?: string
   name?: string
   email?: string
+  externalId?: string
   roles?: Role[]
   config?: UserConfig
 }
@@ -491,6 +564,15 @@ export interface FetchUserListResponse {
   size: number
 }
 
+// TokenScope limits a token to part of its owner's access. Empty lists do
+// not restrict.
+export interface TokenScope {
+  clusters: string[]
+  namespaces: string[]
+  resources: string[]
+  verbs: string[]
+}
+
 export interface PersonalAccessToken {
   id: number
   userId: number
@@ -499,6 +581,7 @@ export interface PersonalAccessToken {
   expiresAt?: string
   lastUsedAt?: string
   lastUsedIP?: string
+  scope?: TokenScope
   createdAt: string
   updatedAt: string
   roles?: Role[]
@@ -508,6 +591,38 @@ export interface PersonalAccessToken {
 // Alias for backward compatibility during migration
 export type APIKey = PersonalAccessToken
 
+// UserSession is a browser login session
+export interface UserSession {
+  id: number
+  userId: number
+  provider: string
+  device: string
+  userAgent: string
+  ip: string
+  lastSeenAt: string
+  expiresAt: string
+  current: boolean
+  createdAt: string
+}
+
+// TwoFactorStatus is the two-factor authentication state of the current user
+export interface TwoFactorStatus {
+  available: boolean
+  enabled: boolean
+  required: boolean
+  recoveryCodesRemaining: number
+}
+
+// TwoFactorSetup is a new TOTP secret to add to an authenticator app
+export interface TwoFactorSetup {
+  secret: string
+  uri: string
+}
+
+export interface SecuritySettings {
+  requireAdminTwoFactor: boolean
+}
+
 // Resource History types
 export interface ResourceHistory {
   id: number
@@ -532,20 +647,19 @@ export interface ResourceHistory {
 export interface ResourceHistoryResponse {
   data: ResourceHistory[]
   pagination: {
-    page: number
     pageSize: number
     total: number
-    totalPages: number
+    nextCursor: number // Cursor of the next page, 0 on the last page
     hasNextPage: boolean
-    hasPrevPage: boolean
   }
 }
 
 export interface AuditLogResponse {
   data: ResourceHistory[]
   total: number
-  page: number
   size: number
+  nextCursor: number // Cursor of the next page, 0 on the last page
+  hasMore: boolean
 }
 
 export interface GitlabHost {